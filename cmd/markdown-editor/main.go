@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"markdown-editor/internal/cli"
 	"markdown-editor/internal/editor"
 	"os"
 
@@ -13,6 +14,10 @@ func main() {
 	log.SetOutput(os.Stderr)
 	log.SetPrefix("markdown-editor: ")
 
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	a := app.NewWithID("com.github.sokolawesome.markdown-editor")
 	w := a.NewWindow("Markdown Editor")
 	w.Resize(fyne.NewSize(1200, 800))
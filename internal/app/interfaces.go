@@ -1,6 +1,11 @@
 package app
 
 import (
+	"markdown-editor/internal/diagram"
+	"markdown-editor/internal/frontmatter"
+	"markdown-editor/internal/index"
+	"time"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/widget"
 )
@@ -9,18 +14,63 @@ type EditorComponent interface {
 	View() fyne.CanvasObject
 	Content() string
 	SetContent(text string)
+	ReplaceRange(start, end int, text string)
 	SetOnChanged(fn func(string))
+	InsertAtCursor(text string)
+	SelectedText() string
+	CursorLine() int
+	SetOnPaste(fn func() bool)
 }
 
 type PreviewComponent interface {
 	View() fyne.CanvasObject
 	Update(text string)
+	SetBaseDir(dir string)
+	SetOnTaskToggled(fn func(line int, done bool))
+	SetDiagramRenderer(renderer diagram.Renderer)
+}
+
+type PresentationComponent interface {
+	Show(start int)
+	Close()
 }
 
 type FiletreeComponent interface {
 	View() fyne.CanvasObject
 	SetDirectory(dir fyne.ListableURI)
+	SetFilter(filter func(fyne.URI) bool)
 	Refresh()
 	GetFiles() []fyne.URI
 	SelectFile(id widget.ListItemID)
 }
+
+type TagComponent interface {
+	View() fyne.CanvasObject
+	SetTags(tags []index.TagCount)
+	SelectedTags() []string
+	ClearSelection()
+}
+
+type PropertiesComponent interface {
+	View() fyne.CanvasObject
+	SetDocument(doc *frontmatter.Document)
+}
+
+type CalendarComponent interface {
+	View() fyne.CanvasObject
+	SetMonth(t time.Time)
+	Month() time.Time
+	SetMarkedDays(days map[int]bool)
+}
+
+type TasksComponent interface {
+	View() fyne.CanvasObject
+	SetRoot(root string)
+	SetTasks(items []index.TaskItem)
+}
+
+type AttachmentsComponent interface {
+	View() fyne.CanvasObject
+	SetRoot(root string)
+	SetAttachments(attachments []index.Attachment)
+}
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"markdown-editor/internal/config"
+	"markdown-editor/internal/diagram"
+	"markdown-editor/internal/export"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var exporters = map[string]func(content string, opts export.Options) ([]byte, error){
+	"html":   export.HTML,
+	"pdf":    export.PDF,
+	"docx":   export.DOCX,
+	"odt":    export.ODT,
+	"slides": export.Slides,
+}
+
+var formatExtensions = map[string]string{
+	"slides": ".slides.html",
+}
+
+const formatEPUB = "epub"
+
+func runExport(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("export", "<note.md | folder>", stderr)
+	format := fs.String("format", "", "output format: html, pdf, docx, odt, slides or epub (default: from -o, else html for a note and epub for a folder)")
+	output := fs.String("o", "", "output file, or - for standard output (default: the input path with the format's extension)")
+	title := fs.String("title", "", "document title (default: frontmatter title or first heading)")
+	theme := fs.String("theme", string(export.ThemeLight), "HTML, slides and EPUB theme: light or dark")
+	toc := fs.Bool("toc", false, "include a table of contents in HTML output")
+	pageSize := fs.String("page-size", string(export.PageA4), "PDF, DOCX and ODT page size: A4, A5, Letter or Legal")
+	margin := fs.Float64("margin", export.DefaultMargin, "PDF, DOCX and ODT page margin in millimetres")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("%w: expected exactly one note or folder", ErrCLIUsage)
+	}
+	if *margin <= 0 {
+		return fmt.Errorf("%w: margin must be positive", ErrCLIUsage)
+	}
+
+	input := fs.Arg(0)
+	info, err := os.Stat(input)
+	if err != nil {
+		return err
+	}
+	*format = strings.ToLower(*format)
+	if *format == "" {
+		ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(*output)), ".")
+		_, known := exporters[ext]
+		switch {
+		case info.IsDir():
+			*format = formatEPUB
+		case known && *output != "-":
+			*format = ext
+		default:
+			*format = "html"
+		}
+	}
+	render, ok := exporters[*format]
+	if !ok && *format != formatEPUB {
+		return fmt.Errorf("%w: unknown format %q", ErrCLIUsage, *format)
+	}
+	if info.IsDir() != (*format == formatEPUB) {
+		return fmt.Errorf("%w: the epub format takes a folder, other formats take a note", ErrCLIUsage)
+	}
+
+	opts := export.Options{
+		Title:    *title,
+		BaseDir:  filepath.Dir(input),
+		Theme:    export.Theme(*theme),
+		TOC:      *toc,
+		PageSize: export.PageSize(*pageSize),
+		Margin:   *margin,
+	}
+	if cfg, err := config.Read(); err == nil {
+		opts.Diagrams = diagram.NewCommandRenderer(cfg.DiagramCommands, diagram.DefaultTimeout)
+		if cfg.DefaultFolder != "" {
+			opts.Exclude = []string{cfg.TemplatesDir()}
+		}
+	} else {
+		fmt.Fprintf(stderr, "markdown-editor export: diagrams disabled: %v\n", err)
+	}
+
+	var data []byte
+	if *format == formatEPUB {
+		data, err = export.EPUB(input, opts)
+		if errors.Is(err, export.ErrEPUBMissingImages) {
+			fmt.Fprintf(stderr, "markdown-editor export: %v\n", err)
+			err = nil
+		}
+	} else {
+		var content []byte
+		if content, err = os.ReadFile(input); err == nil {
+			data, err = render(string(content), opts)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if *output == "-" {
+		_, err = stdout.Write(data)
+		return err
+	}
+	if *output == "" {
+		base := filepath.Clean(input)
+		if !info.IsDir() {
+			base = strings.TrimSuffix(base, filepath.Ext(base))
+		}
+		ext, ok := formatExtensions[*format]
+		if !ok {
+			ext = "." + *format
+		}
+		*output = base + ext
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Exported %s to %s\n", input, *output)
+	return nil
+}
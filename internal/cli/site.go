@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"markdown-editor/internal/config"
+	"markdown-editor/internal/diagram"
+	"markdown-editor/internal/export"
+	"path/filepath"
+)
+
+func runBuildSite(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("build-site", "[workspace]", stderr)
+	output := fs.String("o", "", "output folder (default: the workspace path with a -site suffix)")
+	title := fs.String("title", "", "site title (default: the workspace folder name)")
+	theme := fs.String("theme", string(export.ThemeLight), "site theme: light or dark")
+	force := fs.Bool("force", false, "render every page even if its source is unchanged")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("%w: expected at most one workspace folder", ErrCLIUsage)
+	}
+
+	opts := export.Options{
+		Title: *title,
+		Theme: export.Theme(*theme),
+	}
+	cfg, cfgErr := config.Read()
+	if cfgErr == nil {
+		opts.Diagrams = diagram.NewCommandRenderer(cfg.DiagramCommands, diagram.DefaultTimeout)
+		if cfg.DefaultFolder != "" {
+			opts.Exclude = []string{cfg.TemplatesDir()}
+		}
+	} else {
+		fmt.Fprintf(stderr, "markdown-editor build-site: diagrams disabled: %v\n", cfgErr)
+	}
+
+	workspace := fs.Arg(0)
+	if workspace == "" {
+		if cfgErr != nil || cfg.DefaultFolder == "" {
+			fs.Usage()
+			return fmt.Errorf("%w: no workspace given and none is configured", ErrCLIUsage)
+		}
+		workspace = cfg.DefaultFolder
+	}
+	if *output == "" {
+		*output = filepath.Clean(workspace) + "-site"
+	}
+
+	result, err := export.BuildSite(workspace, *output, opts, *force)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Built %s into %s: %d pages, %d rendered, %d files copied, %d removed\n",
+		workspace, *output, result.Pages, result.Rendered, result.Copied, result.Removed)
+	return nil
+}
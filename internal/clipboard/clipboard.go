@@ -0,0 +1,229 @@
+package clipboard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const commandTimeout = 3 * time.Second
+
+var (
+	ErrClipboardUnavailable = errors.New("clipboard: no clipboard tool found (install wl-clipboard or xclip)")
+	ErrClipboardNoImage     = errors.New("clipboard: no image on the clipboard")
+	ErrClipboardNoHTML      = errors.New("clipboard: no HTML on the clipboard")
+	ErrClipboardRead        = errors.New("clipboard: failed to read clipboard")
+	ErrClipboardWrite       = errors.New("clipboard: failed to write clipboard")
+)
+
+var imageTypes = []struct {
+	mime      string
+	extension string
+}{
+	{"image/png", ".png"},
+	{"image/jpeg", ".jpg"},
+	{"image/gif", ".gif"},
+	{"image/bmp", ".bmp"},
+	{"image/svg+xml", ".svg"},
+}
+
+const htmlType = "text/html"
+
+var textTypes = []string{"text/plain", "text/plain;charset=utf-8", "UTF8_STRING", "STRING", "TEXT"}
+
+type Content struct {
+	Mime string
+	Data []byte
+}
+
+type Image struct {
+	Data      []byte
+	Extension string
+}
+
+type tool struct {
+	name string
+	list []string
+	read func(mime string) []string
+}
+
+var tools = []tool{
+	{
+		name: "wl-paste",
+		list: []string{"--list-types"},
+		read: func(mime string) []string { return []string{"--no-newline", "--type", mime} },
+	},
+	{
+		name: "xclip",
+		list: []string{"-selection", "clipboard", "-t", "TARGETS", "-o"},
+		read: func(mime string) []string { return []string{"-selection", "clipboard", "-t", mime, "-o"} },
+	},
+}
+
+func available() (tool, error) {
+	for _, t := range tools {
+		if t.name == "wl-paste" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			continue
+		}
+		if _, err := exec.LookPath(t.name); err == nil {
+			return t, nil
+		}
+	}
+	return tool{}, ErrClipboardUnavailable
+}
+
+func run(name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v: %s", ErrClipboardRead, name, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+func runWrite(name string, args []string, input []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrClipboardWrite, name, err)
+	}
+	return nil
+}
+
+func Write(contents ...Content) error {
+	if len(contents) == 0 {
+		return nil
+	}
+	err := writeX11(contents)
+	if err == nil {
+		return nil
+	}
+	if _, lookErr := exec.LookPath("copyq"); lookErr != nil {
+		return err
+	}
+	args := []string{"copy"}
+	for _, c := range contents {
+		args = append(args, c.Mime, string(c.Data))
+	}
+	if copyErr := runWrite("copyq", args, nil); copyErr != nil {
+		if errors.Is(err, ErrClipboardUnavailable) {
+			return copyErr
+		}
+		return errors.Join(err, copyErr)
+	}
+	return nil
+}
+
+func Targets() ([]string, error) {
+	t, err := available()
+	if err != nil {
+		return nil, err
+	}
+	out, err := run(t.name, t.list...)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			targets = append(targets, line)
+		}
+	}
+	return targets, nil
+}
+
+func Read(mime string) ([]byte, error) {
+	t, err := available()
+	if err != nil {
+		return nil, err
+	}
+	return run(t.name, t.read(mime)...)
+}
+
+func HasText(targets []string) bool {
+	for _, target := range targets {
+		for _, text := range textTypes {
+			if strings.EqualFold(target, text) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func ReadImage(targets []string) (Image, error) {
+	for _, it := range imageTypes {
+		for _, target := range targets {
+			if !strings.EqualFold(target, it.mime) {
+				continue
+			}
+			data, err := Read(it.mime)
+			if err != nil {
+				return Image{}, err
+			}
+			if len(data) == 0 {
+				return Image{}, ErrClipboardNoImage
+			}
+			return Image{Data: data, Extension: it.extension}, nil
+		}
+	}
+	return Image{}, ErrClipboardNoImage
+}
+
+func HasHTML(targets []string) bool {
+	for _, target := range targets {
+		if mime, _, _ := strings.Cut(target, ";"); strings.EqualFold(strings.TrimSpace(mime), htmlType) {
+			return true
+		}
+	}
+	return false
+}
+
+func ReadHTML(targets []string) (string, error) {
+	if !HasHTML(targets) {
+		return "", ErrClipboardNoHTML
+	}
+	data, err := Read(htmlType)
+	if err != nil {
+		return "", err
+	}
+	text := decodeText(data)
+	if strings.TrimSpace(text) == "" {
+		return "", ErrClipboardNoHTML
+	}
+	return text, nil
+}
+
+func decodeText(data []byte) string {
+	var order func(b []byte) uint16
+	switch {
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+		order = func(b []byte) uint16 { return uint16(b[0]) | uint16(b[1])<<8 }
+	case bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		order = func(b []byte) uint16 { return uint16(b[1]) | uint16(b[0])<<8 }
+	default:
+		return strings.TrimRight(strings.TrimPrefix(string(data), "\ufeff"), "\x00")
+	}
+
+	data = data[2:]
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		units = append(units, order(data[i:i+2]))
+	}
+	return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+}
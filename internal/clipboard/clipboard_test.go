@@ -0,0 +1,141 @@
+package clipboard
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const fakeXclip = `#!/bin/sh
+dir=$(dirname "$0")
+case "$4" in
+TARGETS) cat "$dir/targets" ;;
+*) f="$dir/$(printf %s "$4" | tr '/;=' '___')"; [ -f "$f" ] || exit 1; cat "$f" ;;
+esac
+`
+
+func fakeClipboard(t *testing.T, contents map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	var targets []string
+	for mime, data := range contents {
+		targets = append(targets, mime)
+		name := strings.NewReplacer("/", "_", ";", "_", "=", "_").Replace(mime)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sort.Strings(targets)
+	if err := os.WriteFile(filepath.Join(dir, "targets"), []byte(strings.Join(targets, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "xclip"), []byte(fakeXclip), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestHasText(t *testing.T) {
+	tests := []struct {
+		targets []string
+		want    bool
+	}{
+		{targets: []string{"TARGETS", "UTF8_STRING"}, want: true},
+		{targets: []string{"text/plain;charset=UTF-8"}, want: true},
+		{targets: []string{"image/png", "TARGETS"}},
+		{targets: []string{"text/html"}},
+		{},
+	}
+	for _, tt := range tests {
+		if got := HasText(tt.targets); got != tt.want {
+			t.Errorf("HasText(%v) = %v, want %v", tt.targets, got, tt.want)
+		}
+	}
+}
+
+func TestHasHTML(t *testing.T) {
+	tests := []struct {
+		targets []string
+		want    bool
+	}{
+		{targets: []string{"text/html"}, want: true},
+		{targets: []string{"TEXT/HTML ; charset=utf-8"}, want: true},
+		{targets: []string{"text/htmlx", "application/xhtml+xml"}},
+		{targets: []string{"UTF8_STRING"}},
+	}
+	for _, tt := range tests {
+		if got := HasHTML(tt.targets); got != tt.want {
+			t.Errorf("HasHTML(%v) = %v, want %v", tt.targets, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{name: "utf-8", data: []byte("<b>hé</b>"), want: "<b>hé</b>"},
+		{name: "utf-8 with bom and nul", data: []byte("\ufeff<p>x</p>\x00"), want: "<p>x</p>"},
+		{name: "utf-16 little endian", data: []byte{0xff, 0xfe, '<', 0, 'b', 0, '>', 0, 0xe9, 0, 0, 0}, want: "<b>é"},
+		{name: "utf-16 big endian", data: []byte{0xfe, 0xff, 0, 'h', 0, 'i', 0xd8, 0x3d, 0xde, 0x00}, want: "hi😀"},
+		{name: "odd trailing byte", data: []byte{0xff, 0xfe, 'a', 0, 'b'}, want: "a"},
+		{name: "empty", data: nil, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeText(tt.data); got != tt.want {
+				t.Errorf("decodeText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	fakeClipboard(t, map[string]string{
+		"image/png":                "png-data",
+		"text/html":                "\ufeff<p>hi</p>",
+		"text/plain;charset=utf-8": "hi",
+	})
+
+	targets, err := Targets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"image/png", "text/html", "text/plain;charset=utf-8"}; !reflect.DeepEqual(targets, want) {
+		t.Errorf("Targets() = %v, want %v", targets, want)
+	}
+
+	img, err := ReadImage(targets)
+	if err != nil || string(img.Data) != "png-data" || img.Extension != ".png" {
+		t.Errorf("ReadImage() = %q %q, %v", img.Data, img.Extension, err)
+	}
+	html, err := ReadHTML(targets)
+	if err != nil || html != "<p>hi</p>" {
+		t.Errorf("ReadHTML() = %q, %v", html, err)
+	}
+
+	if _, err := ReadImage([]string{"text/plain"}); !errors.Is(err, ErrClipboardNoImage) {
+		t.Errorf("ReadImage() without an image = %v, want %v", err, ErrClipboardNoImage)
+	}
+	if _, err := ReadHTML([]string{"text/plain"}); !errors.Is(err, ErrClipboardNoHTML) {
+		t.Errorf("ReadHTML() without HTML = %v, want %v", err, ErrClipboardNoHTML)
+	}
+	if _, err := ReadImage([]string{"image/gif"}); !errors.Is(err, ErrClipboardRead) {
+		t.Errorf("ReadImage() of a failing target = %v, want %v", err, ErrClipboardRead)
+	}
+}
+
+func TestReadWithoutTool(t *testing.T) {
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("PATH", t.TempDir())
+	if _, err := Targets(); !errors.Is(err, ErrClipboardUnavailable) {
+		t.Errorf("Targets() = %v, want %v", err, ErrClipboardUnavailable)
+	}
+}
@@ -0,0 +1,559 @@
+package clipboard
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	x11CreateWindow           = 1
+	x11ChangeWindowAttributes = 2
+	x11InternAtom             = 16
+	x11ChangeProperty         = 18
+	x11SetSelectionOwner      = 22
+	x11GetSelectionOwner      = 23
+	x11SendEvent              = 25
+
+	x11Error            = 0
+	x11Reply            = 1
+	x11PropertyNotify   = 28
+	x11SelectionClear   = 29
+	x11SelectionRequest = 30
+	x11SelectionNotify  = 31
+
+	x11AtomAtom    = 4
+	x11AtomInteger = 19
+
+	x11InputOnly          = 2
+	x11EventMaskAttribute = 0x800
+	x11PropertyChangeMask = 0x400000
+	x11PropModeReplace    = 0
+	x11PropModeAppend     = 2
+	x11PropertyDeleted    = 1
+
+	x11CookieAuth   = "MIT-MAGIC-COOKIE-1"
+	x11FamilyLocal  = 256
+	x11FamilyWild   = 65535
+	x11SocketPrefix = "/tmp/.X11-unix/X"
+	x11MaxReply     = 1 << 20
+
+	transferTimeout = 30 * time.Second
+)
+
+var x11Order = binary.LittleEndian
+
+type x11Conn struct {
+	conn       net.Conn
+	nextID     uint32
+	idStep     uint32
+	root       uint32
+	maxRequest int
+	pending    [][]byte
+}
+
+type x11Offer struct {
+	target   uint32
+	dataType uint32
+	data     []byte
+}
+
+type x11Transfer struct {
+	dataType uint32
+	data     []byte
+}
+
+type x11TransferKey struct {
+	requestor uint32
+	property  uint32
+}
+
+type x11Owner struct {
+	c         *x11Conn
+	window    uint32
+	selection uint32
+	timestamp uint32
+	targets   uint32
+	stamp     uint32
+	incr      uint32
+	offers    []x11Offer
+	transfers map[x11TransferKey]*x11Transfer
+}
+
+func writeX11(contents []Content) error {
+	display := os.Getenv("DISPLAY")
+	if display == "" {
+		return ErrClipboardUnavailable
+	}
+	c, err := dialX11(display)
+	if err != nil {
+		return err
+	}
+	owner, err := newX11Owner(c, contents)
+	if err != nil {
+		return errors.Join(err, c.conn.Close())
+	}
+	go owner.serve()
+	return nil
+}
+
+func parseDisplay(display string) (host, number string, err error) {
+	i := strings.LastIndex(display, ":")
+	if i < 0 {
+		return "", "", fmt.Errorf("%w: invalid DISPLAY %q", ErrClipboardWrite, display)
+	}
+	host, number = display[:i], display[i+1:]
+	number, _, _ = strings.Cut(number, ".")
+	if _, err := strconv.ParseUint(number, 10, 16); err != nil {
+		return "", "", fmt.Errorf("%w: invalid DISPLAY %q", ErrClipboardWrite, display)
+	}
+	return host, number, nil
+}
+
+func dialX11(display string) (*x11Conn, error) {
+	host, number, err := parseDisplay(display)
+	if err != nil {
+		return nil, err
+	}
+
+	var conn net.Conn
+	if host == "" || host == "unix" {
+		conn, err = net.DialTimeout("unix", x11SocketPrefix+number, commandTimeout)
+		if err != nil {
+			conn, err = net.DialTimeout("unix", "@"+x11SocketPrefix+number, commandTimeout)
+		}
+	} else {
+		port, _ := strconv.Atoi(number)
+		conn, err = net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(6000+port)), commandTimeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: connecting to X display %s: %v", ErrClipboardWrite, display, err)
+	}
+
+	hostname, _ := os.Hostname()
+	if host != "" && host != "unix" {
+		hostname = host
+	}
+	name, cookie := xauthority(readXauthorityFile(), number, hostname)
+	c, err := newX11Conn(conn, name, cookie)
+	if err != nil {
+		return nil, errors.Join(err, conn.Close())
+	}
+	return c, nil
+}
+
+func readXauthorityFile() []byte {
+	path := os.Getenv("XAUTHORITY")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		path = filepath.Join(home, ".Xauthority")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func xauthority(data []byte, number, hostname string) (name, cookie []byte) {
+	for len(data) >= 2 {
+		family := binary.BigEndian.Uint16(data)
+		data = data[2:]
+		var fields [4][]byte
+		for i := range fields {
+			if len(data) < 2 {
+				return nil, nil
+			}
+			n := int(binary.BigEndian.Uint16(data))
+			if len(data) < 2+n {
+				return nil, nil
+			}
+			fields[i], data = data[2:2+n], data[2+n:]
+		}
+		address, display, authName, authData := fields[0], fields[1], fields[2], fields[3]
+		if string(authName) != x11CookieAuth || (len(display) > 0 && string(display) != number) {
+			continue
+		}
+		if family == x11FamilyWild || ((family == x11FamilyLocal || family == 0) && string(address) == hostname) {
+			return authName, authData
+		}
+	}
+	return nil, nil
+}
+
+func pad4(n int) int {
+	return (n + 3) &^ 3
+}
+
+func newX11Conn(conn net.Conn, authName, authData []byte) (*x11Conn, error) {
+	if err := conn.SetDeadline(time.Now().Add(commandTimeout)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrClipboardWrite, err)
+	}
+
+	setup := make([]byte, 12+pad4(len(authName))+pad4(len(authData)))
+	setup[0] = 'l'
+	x11Order.PutUint16(setup[2:], 11)
+	x11Order.PutUint16(setup[6:], uint16(len(authName)))
+	x11Order.PutUint16(setup[8:], uint16(len(authData)))
+	copy(setup[12:], authName)
+	copy(setup[12+pad4(len(authName)):], authData)
+	if _, err := conn.Write(setup); err != nil {
+		return nil, fmt.Errorf("%w: X11 handshake: %v", ErrClipboardWrite, err)
+	}
+
+	head := make([]byte, 8)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return nil, fmt.Errorf("%w: X11 handshake: %v", ErrClipboardWrite, err)
+	}
+	extra := make([]byte, 4*int(x11Order.Uint16(head[6:])))
+	if _, err := io.ReadFull(conn, extra); err != nil {
+		return nil, fmt.Errorf("%w: X11 handshake: %v", ErrClipboardWrite, err)
+	}
+	if head[0] != 1 {
+		reason := extra[:min(len(extra), int(head[1]))]
+		if head[0] != 0 {
+			reason = extra
+		}
+		return nil, fmt.Errorf("%w: X server refused the connection: %s", ErrClipboardWrite, strings.TrimRight(string(reason), "\x00"))
+	}
+
+	if len(extra) < 32 {
+		return nil, fmt.Errorf("%w: X11 handshake: short setup reply", ErrClipboardWrite)
+	}
+	idBase, idMask := x11Order.Uint32(extra[4:]), x11Order.Uint32(extra[8:])
+	screens := 32 + pad4(int(x11Order.Uint16(extra[16:]))) + 8*int(extra[21])
+	if extra[20] == 0 || idMask == 0 || len(extra) < screens+4 {
+		return nil, fmt.Errorf("%w: X11 handshake: malformed setup reply", ErrClipboardWrite)
+	}
+	step := idMask & -idMask
+	return &x11Conn{
+		conn:       conn,
+		nextID:     idBase | step,
+		idStep:     step,
+		root:       x11Order.Uint32(extra[screens:]),
+		maxRequest: int(x11Order.Uint16(extra[18:])),
+	}, nil
+}
+
+func (c *x11Conn) newID() uint32 {
+	id := c.nextID
+	c.nextID += c.idStep
+	return id
+}
+
+func (c *x11Conn) send(opcode, data byte, body []byte) error {
+	req := make([]byte, 4+pad4(len(body)))
+	req[0], req[1] = opcode, data
+	x11Order.PutUint16(req[2:], uint16(len(req)/4))
+	copy(req[4:], body)
+	_, err := c.conn.Write(req)
+	return err
+}
+
+func (c *x11Conn) readPacket() ([]byte, error) {
+	packet := make([]byte, 32)
+	if _, err := io.ReadFull(c.conn, packet); err != nil {
+		return nil, err
+	}
+	if packet[0] == x11Reply {
+		extra := int(x11Order.Uint32(packet[4:])) * 4
+		if extra > x11MaxReply {
+			return nil, fmt.Errorf("%w: X11 reply too large", ErrClipboardWrite)
+		}
+		if extra > 0 {
+			rest := make([]byte, extra)
+			if _, err := io.ReadFull(c.conn, rest); err != nil {
+				return nil, err
+			}
+			packet = append(packet, rest...)
+		}
+	}
+	return packet, nil
+}
+
+func (c *x11Conn) roundTrip(opcode, data byte, body []byte) ([]byte, error) {
+	if err := c.send(opcode, data, body); err != nil {
+		return nil, err
+	}
+	for {
+		packet, err := c.readPacket()
+		if err != nil {
+			return nil, err
+		}
+		switch packet[0] {
+		case x11Error:
+			return nil, fmt.Errorf("X11 request %d failed with error %d", opcode, packet[1])
+		case x11Reply:
+			return packet, nil
+		}
+		c.pending = append(c.pending, packet)
+	}
+}
+
+func (c *x11Conn) nextEvent() ([]byte, error) {
+	if len(c.pending) > 0 {
+		event := c.pending[0]
+		c.pending = c.pending[1:]
+		return event, nil
+	}
+	for {
+		packet, err := c.readPacket()
+		if err != nil {
+			return nil, err
+		}
+		if packet[0] != x11Error && packet[0] != x11Reply {
+			return packet, nil
+		}
+	}
+}
+
+func (c *x11Conn) internAtom(name string) (uint32, error) {
+	body := make([]byte, 4+len(name))
+	x11Order.PutUint16(body, uint16(len(name)))
+	copy(body[4:], name)
+	reply, err := c.roundTrip(x11InternAtom, 0, body)
+	if err != nil {
+		return 0, err
+	}
+	return x11Order.Uint32(reply[8:]), nil
+}
+
+func (c *x11Conn) maxPropertyData() int {
+	return c.maxRequest*4 - 24
+}
+
+func (c *x11Conn) changeProperty(mode byte, window, property, dataType uint32, format byte, data []byte) error {
+	body := make([]byte, 20+len(data))
+	x11Order.PutUint32(body, window)
+	x11Order.PutUint32(body[4:], property)
+	x11Order.PutUint32(body[8:], dataType)
+	body[12] = format
+	x11Order.PutUint32(body[16:], uint32(len(data)/int(format/8)))
+	copy(body[20:], data)
+	return c.send(x11ChangeProperty, mode, body)
+}
+
+func (c *x11Conn) selectEvents(window, mask uint32) error {
+	body := make([]byte, 12)
+	x11Order.PutUint32(body, window)
+	x11Order.PutUint32(body[4:], x11EventMaskAttribute)
+	x11Order.PutUint32(body[8:], mask)
+	return c.send(x11ChangeWindowAttributes, 0, body)
+}
+
+func x11Values(values ...uint32) []byte {
+	data := make([]byte, 4*len(values))
+	for i, v := range values {
+		x11Order.PutUint32(data[4*i:], v)
+	}
+	return data
+}
+
+func newX11Owner(c *x11Conn, contents []Content) (*x11Owner, error) {
+	o := &x11Owner{c: c, transfers: make(map[x11TransferKey]*x11Transfer)}
+	if err := o.claim(contents); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrClipboardWrite, err)
+	}
+	if err := c.conn.SetDeadline(time.Time{}); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrClipboardWrite, err)
+	}
+	return o, nil
+}
+
+func (o *x11Owner) claim(contents []Content) error {
+	names := []string{"CLIPBOARD", "TARGETS", "TIMESTAMP", "INCR", "UTF8_STRING"}
+	for _, content := range contents {
+		names = append(names, content.Mime)
+		if isText(content.Mime) {
+			names = append(names, textTypes...)
+		}
+	}
+	atoms := make(map[string]uint32, len(names))
+	for _, name := range names {
+		if _, ok := atoms[name]; ok {
+			continue
+		}
+		id, err := o.c.internAtom(name)
+		if err != nil {
+			return err
+		}
+		atoms[name] = id
+	}
+	o.selection, o.targets, o.stamp, o.incr = atoms["CLIPBOARD"], atoms["TARGETS"], atoms["TIMESTAMP"], atoms["INCR"]
+
+	seen := make(map[uint32]bool)
+	offer := func(target, dataType uint32, data []byte) {
+		if !seen[target] {
+			seen[target] = true
+			o.offers = append(o.offers, x11Offer{target: target, dataType: dataType, data: data})
+		}
+	}
+	for _, content := range contents {
+		offer(atoms[content.Mime], atoms[content.Mime], content.Data)
+	}
+	for _, content := range contents {
+		if isText(content.Mime) {
+			for _, alias := range textTypes {
+				offer(atoms[alias], atoms["UTF8_STRING"], content.Data)
+			}
+		}
+	}
+
+	o.window = o.c.newID()
+	window := x11Values(o.window, o.c.root, 0, 1<<16|1, x11InputOnly<<16, 0, x11EventMaskAttribute, x11PropertyChangeMask)
+	if err := o.c.send(x11CreateWindow, 0, window); err != nil {
+		return err
+	}
+	if err := o.c.changeProperty(x11PropModeAppend, o.window, o.stamp, x11AtomInteger, 32, nil); err != nil {
+		return err
+	}
+	for o.timestamp == 0 {
+		packet, err := o.c.readPacket()
+		if err != nil {
+			return err
+		}
+		switch {
+		case packet[0] == x11Error:
+			return fmt.Errorf("X11 request failed with error %d", packet[1])
+		case packet[0]&0x7f == x11PropertyNotify && x11Order.Uint32(packet[4:]) == o.window:
+			o.timestamp = x11Order.Uint32(packet[12:])
+		}
+	}
+
+	if err := o.c.send(x11SetSelectionOwner, 0, x11Values(o.window, o.selection, o.timestamp)); err != nil {
+		return err
+	}
+	reply, err := o.c.roundTrip(x11GetSelectionOwner, 0, x11Values(o.selection))
+	if err != nil {
+		return err
+	}
+	if x11Order.Uint32(reply[8:]) != o.window {
+		return fmt.Errorf("another client kept the clipboard")
+	}
+	return nil
+}
+
+func isText(mime string) bool {
+	base, _, _ := strings.Cut(mime, ";")
+	return strings.EqualFold(strings.TrimSpace(base), "text/plain")
+}
+
+func (o *x11Owner) serve() {
+	defer func() {
+		if err := o.c.conn.Close(); err != nil {
+			fmt.Printf("clipboard: info: problem closing the X11 connection: %v\n", err)
+		}
+	}()
+	cleared := false
+	for !cleared || len(o.transfers) > 0 {
+		event, err := o.c.nextEvent()
+		if err != nil {
+			return
+		}
+		switch event[0] & 0x7f {
+		case x11SelectionClear:
+			if x11Order.Uint32(event[12:]) == o.selection {
+				cleared = true
+				if err := o.c.conn.SetReadDeadline(time.Now().Add(transferTimeout)); err != nil {
+					return
+				}
+			}
+		case x11SelectionRequest:
+			err = o.answer(event)
+		case x11PropertyNotify:
+			err = o.continueTransfer(event)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (o *x11Owner) answer(request []byte) error {
+	when := x11Order.Uint32(request[4:])
+	requestor := x11Order.Uint32(request[12:])
+	selection := x11Order.Uint32(request[16:])
+	target := x11Order.Uint32(request[20:])
+	property := x11Order.Uint32(request[24:])
+	if property == 0 {
+		property = target
+	}
+
+	ok, err := false, error(nil)
+	if selection == o.selection && (when == 0 || when >= o.timestamp) {
+		ok, err = o.convert(requestor, target, property)
+		if err != nil {
+			return err
+		}
+	}
+	if !ok {
+		property = 0
+	}
+
+	notify := make([]byte, 32)
+	notify[0] = x11SelectionNotify
+	x11Order.PutUint32(notify[4:], when)
+	x11Order.PutUint32(notify[8:], requestor)
+	x11Order.PutUint32(notify[12:], selection)
+	x11Order.PutUint32(notify[16:], target)
+	x11Order.PutUint32(notify[20:], property)
+	body := append(x11Values(requestor, 0), notify...)
+	return o.c.send(x11SendEvent, 0, body)
+}
+
+func (o *x11Owner) convert(requestor, target, property uint32) (bool, error) {
+	switch target {
+	case o.targets:
+		list := []uint32{o.targets, o.stamp}
+		for _, offer := range o.offers {
+			list = append(list, offer.target)
+		}
+		return true, o.c.changeProperty(x11PropModeReplace, requestor, property, x11AtomAtom, 32, x11Values(list...))
+	case o.stamp:
+		return true, o.c.changeProperty(x11PropModeReplace, requestor, property, x11AtomInteger, 32, x11Values(o.timestamp))
+	}
+
+	for _, offer := range o.offers {
+		if offer.target != target {
+			continue
+		}
+		if len(offer.data) <= o.c.maxPropertyData() {
+			return true, o.c.changeProperty(x11PropModeReplace, requestor, property, offer.dataType, 8, offer.data)
+		}
+		if err := o.c.selectEvents(requestor, x11PropertyChangeMask); err != nil {
+			return false, err
+		}
+		o.transfers[x11TransferKey{requestor, property}] = &x11Transfer{dataType: offer.dataType, data: offer.data}
+		return true, o.c.changeProperty(x11PropModeReplace, requestor, property, o.incr, 32, x11Values(uint32(len(offer.data))))
+	}
+	return false, nil
+}
+
+func (o *x11Owner) continueTransfer(event []byte) error {
+	key := x11TransferKey{requestor: x11Order.Uint32(event[4:]), property: x11Order.Uint32(event[8:])}
+	transfer, ok := o.transfers[key]
+	if !ok || event[16] != x11PropertyDeleted {
+		return nil
+	}
+
+	chunk := transfer.data[:min(len(transfer.data), o.c.maxPropertyData())]
+	transfer.data = transfer.data[len(chunk):]
+	if err := o.c.changeProperty(x11PropModeReplace, key.requestor, key.property, transfer.dataType, 8, chunk); err != nil {
+		return err
+	}
+	if len(chunk) == 0 {
+		delete(o.transfers, key)
+		return o.c.selectEvents(key.requestor, 0)
+	}
+	return nil
+}
@@ -0,0 +1,451 @@
+package clipboard
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	fakeRoot      = 0x100
+	fakeRequestor = 0x300
+	fakeTime      = 1000
+)
+
+type fakeProperty struct {
+	window   uint32
+	property uint32
+	dataType uint32
+	format   byte
+	data     []byte
+}
+
+type fakeX11 struct {
+	t          *testing.T
+	conn       net.Conn
+	maxRequest uint16
+	refuse     string
+
+	mu     sync.Mutex
+	atoms  map[string]uint32
+	window uint32
+	owner  uint32
+
+	props  chan fakeProperty
+	events chan []byte
+}
+
+func startFakeX11(t *testing.T, maxRequest uint16, refuse string) (*fakeX11, net.Conn) {
+	t.Helper()
+	client, server := net.Pipe()
+	x := &fakeX11{
+		t:          t,
+		conn:       server,
+		maxRequest: maxRequest,
+		refuse:     refuse,
+		atoms:      make(map[string]uint32),
+		props:      make(chan fakeProperty, 64),
+		events:     make(chan []byte, 64),
+	}
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	go x.run()
+	return x, client
+}
+
+func (x *fakeX11) write(packet []byte) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if _, err := x.conn.Write(packet); err != nil {
+		x.t.Logf("fake X server write: %v", err)
+	}
+}
+
+func (x *fakeX11) atom(name string) uint32 {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if id, ok := x.atoms[name]; ok {
+		return id
+	}
+	id := uint32(100 + len(x.atoms))
+	x.atoms[name] = id
+	return id
+}
+
+func (x *fakeX11) names(ids []uint32) []string {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	var names []string
+	for _, id := range ids {
+		for name, atom := range x.atoms {
+			if atom == id {
+				names = append(names, name)
+			}
+		}
+		switch id {
+		case x11AtomAtom:
+			names = append(names, "ATOM")
+		case x11AtomInteger:
+			names = append(names, "INTEGER")
+		}
+	}
+	return names
+}
+
+func (x *fakeX11) run() {
+	setup := make([]byte, 12)
+	if _, err := io.ReadFull(x.conn, setup); err != nil {
+		return
+	}
+	auth := pad4(int(x11Order.Uint16(setup[6:]))) + pad4(int(x11Order.Uint16(setup[8:])))
+	if _, err := io.ReadFull(x.conn, make([]byte, auth)); err != nil {
+		return
+	}
+
+	if x.refuse != "" {
+		reason := make([]byte, pad4(len(x.refuse)))
+		copy(reason, x.refuse)
+		head := []byte{0, byte(len(x.refuse)), 11, 0, 0, 0, 0, 0}
+		x11Order.PutUint16(head[6:], uint16(len(reason)/4))
+		x.write(append(head, reason...))
+		return
+	}
+
+	extra := make([]byte, 32+4+40)
+	x11Order.PutUint32(extra[4:], 0x200000)
+	x11Order.PutUint32(extra[8:], 0x1fffff)
+	x11Order.PutUint16(extra[16:], 4)
+	x11Order.PutUint16(extra[18:], x.maxRequest)
+	extra[20] = 1
+	copy(extra[32:], "fake")
+	x11Order.PutUint32(extra[36:], fakeRoot)
+	head := []byte{1, 0, 11, 0, 0, 0, 0, 0}
+	x11Order.PutUint16(head[6:], uint16(len(extra)/4))
+	x.write(append(head, extra...))
+
+	for {
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(x.conn, header); err != nil {
+			return
+		}
+		body := make([]byte, 4*int(x11Order.Uint16(header[2:]))-4)
+		if _, err := io.ReadFull(x.conn, body); err != nil {
+			return
+		}
+		x.handle(header[0], header[1], body)
+	}
+}
+
+func (x *fakeX11) handle(opcode, data byte, body []byte) {
+	reply := make([]byte, 32)
+	reply[0] = x11Reply
+	switch opcode {
+	case x11InternAtom:
+		n := x11Order.Uint16(body)
+		x11Order.PutUint32(reply[8:], x.atom(string(body[4:4+n])))
+		x.write(reply)
+	case x11CreateWindow:
+		x.mu.Lock()
+		x.window = x11Order.Uint32(body)
+		x.mu.Unlock()
+		if parent, class := x11Order.Uint32(body[4:]), x11Order.Uint16(body[18:]); parent != fakeRoot || class != x11InputOnly {
+			x.t.Errorf("window parent = %#x, class = %d, want an input-only child of the root window", parent, class)
+		}
+		if mask, events := x11Order.Uint32(body[24:]), x11Order.Uint32(body[28:]); mask != x11EventMaskAttribute || events != x11PropertyChangeMask {
+			x.t.Errorf("window attributes = %#x: %#x, want property change events", mask, events)
+		}
+	case x11ChangeProperty:
+		format := body[12]
+		n := int(x11Order.Uint32(body[16:])) * int(format) / 8
+		prop := fakeProperty{
+			window:   x11Order.Uint32(body),
+			property: x11Order.Uint32(body[4:]),
+			dataType: x11Order.Uint32(body[8:]),
+			format:   format,
+			data:     append([]byte(nil), body[20:20+n]...),
+		}
+		x.mu.Lock()
+		own := prop.window == x.window
+		x.mu.Unlock()
+		if own && data == x11PropModeAppend {
+			event := make([]byte, 32)
+			event[0] = x11PropertyNotify
+			x11Order.PutUint32(event[4:], prop.window)
+			x11Order.PutUint32(event[8:], prop.property)
+			x11Order.PutUint32(event[12:], fakeTime)
+			x.write(event)
+			return
+		}
+		x.props <- prop
+	case x11SetSelectionOwner:
+		x.mu.Lock()
+		x.owner = x11Order.Uint32(body)
+		x.mu.Unlock()
+	case x11GetSelectionOwner:
+		x.mu.Lock()
+		x11Order.PutUint32(reply[8:], x.owner)
+		x.mu.Unlock()
+		x.write(reply)
+	case x11SendEvent:
+		x.events <- append([]byte(nil), body[8:40]...)
+	}
+}
+
+func (x *fakeX11) request(target, property string) (fakeProperty, uint32) {
+	x.t.Helper()
+	event := make([]byte, 32)
+	event[0] = x11SelectionRequest
+	x11Order.PutUint32(event[4:], fakeTime+1)
+	x11Order.PutUint32(event[12:], fakeRequestor)
+	x11Order.PutUint32(event[16:], x.atom("CLIPBOARD"))
+	x11Order.PutUint32(event[20:], x.atom(target))
+	if property != "" {
+		x11Order.PutUint32(event[24:], x.atom(property))
+	}
+	x.write(event)
+
+	notify := x.nextEvent()
+	if notify[0] != x11SelectionNotify || x11Order.Uint32(notify[8:]) != fakeRequestor {
+		x.t.Fatalf("sent event %v, want a SelectionNotify to the requestor", notify[:4])
+	}
+	got := x11Order.Uint32(notify[20:])
+	if got == 0 {
+		return fakeProperty{}, 0
+	}
+	return x.nextProperty(), got
+}
+
+func (x *fakeX11) nextEvent() []byte {
+	x.t.Helper()
+	select {
+	case event := <-x.events:
+		return event
+	case <-time.After(2 * time.Second):
+		x.t.Fatal("timed out waiting for the clipboard owner to send an event")
+		return nil
+	}
+}
+
+func (x *fakeX11) nextProperty() fakeProperty {
+	x.t.Helper()
+	select {
+	case prop := <-x.props:
+		return prop
+	case <-time.After(2 * time.Second):
+		x.t.Fatal("timed out waiting for the clipboard owner to set a property")
+		return fakeProperty{}
+	}
+}
+
+func claimFake(t *testing.T, maxRequest uint16, contents ...Content) (*fakeX11, chan struct{}) {
+	t.Helper()
+	x, client := startFakeX11(t, maxRequest, "")
+	c, err := newX11Conn(client, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner, err := newX11Owner(c, contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner.timestamp != fakeTime {
+		t.Errorf("selection timestamp = %d, want %d", owner.timestamp, fakeTime)
+	}
+	done := make(chan struct{})
+	go func() {
+		owner.serve()
+		close(done)
+	}()
+	return x, done
+}
+
+func uint32s(data []byte) []uint32 {
+	values := make([]uint32, len(data)/4)
+	for i := range values {
+		values[i] = x11Order.Uint32(data[4*i:])
+	}
+	return values
+}
+
+func TestX11Owner(t *testing.T) {
+	html := []byte("<p><strong>Hi</strong></p>")
+	text := []byte("Hi")
+	x, done := claimFake(t, 0xffff,
+		Content{Mime: "text/html", Data: html},
+		Content{Mime: "text/plain;charset=utf-8", Data: text},
+	)
+
+	prop, property := x.request("TARGETS", "XSEL_DATA")
+	if property != x.atom("XSEL_DATA") || prop.window != fakeRequestor || prop.dataType != x11AtomAtom || prop.format != 32 {
+		t.Fatalf("TARGETS answered with %+v on %d", prop, property)
+	}
+	wantTargets := []string{"TARGETS", "TIMESTAMP", "text/html", "text/plain;charset=utf-8", "text/plain", "UTF8_STRING", "STRING", "TEXT"}
+	if got := x.names(uint32s(prop.data)); !reflect.DeepEqual(got, wantTargets) {
+		t.Errorf("TARGETS = %v, want %v", got, wantTargets)
+	}
+
+	tests := []struct {
+		name     string
+		target   string
+		property string
+		want     []byte
+		wantType string
+	}{
+		{name: "html", target: "text/html", property: "XSEL_DATA", want: html, wantType: "text/html"},
+		{name: "plain text", target: "text/plain;charset=utf-8", property: "XSEL_DATA", want: text, wantType: "text/plain;charset=utf-8"},
+		{name: "utf8 alias", target: "UTF8_STRING", property: "XSEL_DATA", want: text, wantType: "UTF8_STRING"},
+		{name: "string alias", target: "STRING", property: "XSEL_DATA", want: text, wantType: "UTF8_STRING"},
+		{name: "missing property uses the target", target: "text/html", want: html, wantType: "text/html"},
+		{name: "unknown target is refused", target: "image/png", property: "XSEL_DATA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prop, property := x.request(tt.target, tt.property)
+			if tt.want == nil {
+				if property != 0 {
+					t.Errorf("request for %s answered on %d, want a refusal", tt.target, property)
+				}
+				return
+			}
+			wantProperty := tt.property
+			if wantProperty == "" {
+				wantProperty = tt.target
+			}
+			if property != x.atom(wantProperty) || prop.property != property {
+				t.Errorf("answered on %d with property %d, want %s", property, prop.property, wantProperty)
+			}
+			if prop.dataType != x.atom(tt.wantType) || prop.format != 8 || !bytes.Equal(prop.data, tt.want) {
+				t.Errorf("property = %q of type %v, want %q of type %s", prop.data, x.names([]uint32{prop.dataType}), tt.want, tt.wantType)
+			}
+		})
+	}
+
+	clear := make([]byte, 32)
+	clear[0] = x11SelectionClear
+	x11Order.PutUint32(clear[12:], x.atom("CLIPBOARD"))
+	x.write(clear)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("owner kept serving after losing the selection")
+	}
+}
+
+func TestX11OwnerIncrementalTransfer(t *testing.T) {
+	html := []byte(strings.Repeat("<p>long note</p>", 40))
+	x, _ := claimFake(t, 64, Content{Mime: "text/html", Data: html})
+	limit := 64*4 - 24
+
+	prop, property := x.request("text/html", "XSEL_DATA")
+	if prop.dataType != x.atom("INCR") || prop.format != 32 || uint32s(prop.data)[0] != uint32(len(html)) {
+		t.Fatalf("large transfer started with %+v, want INCR with the total size", prop)
+	}
+
+	var received []byte
+	for range len(html)/limit + 2 {
+		deleted := make([]byte, 32)
+		deleted[0] = x11PropertyNotify
+		x11Order.PutUint32(deleted[4:], fakeRequestor)
+		x11Order.PutUint32(deleted[8:], property)
+		deleted[16] = x11PropertyDeleted
+		x.write(deleted)
+
+		chunk := x.nextProperty()
+		if chunk.dataType != x.atom("text/html") || len(chunk.data) > limit {
+			t.Fatalf("chunk of %d bytes with type %v", len(chunk.data), x.names([]uint32{chunk.dataType}))
+		}
+		if len(chunk.data) == 0 {
+			break
+		}
+		received = append(received, chunk.data...)
+	}
+	if !bytes.Equal(received, html) {
+		t.Errorf("received %d bytes, want the %d byte note", len(received), len(html))
+	}
+}
+
+func TestX11Refused(t *testing.T) {
+	_, client := startFakeX11(t, 0xffff, "No protocol specified")
+	_, err := newX11Conn(client, nil, nil)
+	if !errors.Is(err, ErrClipboardWrite) || !strings.Contains(err.Error(), "No protocol specified") {
+		t.Errorf("newX11Conn() error = %v, want the server's reason", err)
+	}
+}
+
+func TestParseDisplay(t *testing.T) {
+	tests := []struct {
+		display    string
+		wantHost   string
+		wantNumber string
+		wantErr    bool
+	}{
+		{display: ":0", wantNumber: "0"},
+		{display: ":1.0", wantNumber: "1"},
+		{display: "unix:2", wantHost: "unix", wantNumber: "2"},
+		{display: "localhost:10.0", wantHost: "localhost", wantNumber: "10"},
+		{display: "wayland-0", wantErr: true},
+		{display: ":x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.display, func(t *testing.T) {
+			host, number, err := parseDisplay(tt.display)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDisplay(%q) error = %v, want error %v", tt.display, err, tt.wantErr)
+			}
+			if host != tt.wantHost || number != tt.wantNumber {
+				t.Errorf("parseDisplay(%q) = %q, %q, want %q, %q", tt.display, host, number, tt.wantHost, tt.wantNumber)
+			}
+		})
+	}
+}
+
+func xauthEntry(family uint16, fields ...string) []byte {
+	entry := binary.BigEndian.AppendUint16(nil, family)
+	for _, field := range fields {
+		entry = binary.BigEndian.AppendUint16(entry, uint16(len(field)))
+		entry = append(entry, field...)
+	}
+	return entry
+}
+
+func TestXauthority(t *testing.T) {
+	var file []byte
+	file = append(file, xauthEntry(x11FamilyLocal, "other", "0", x11CookieAuth, "wrong-host")...)
+	file = append(file, xauthEntry(x11FamilyLocal, "box", "1", x11CookieAuth, "wrong-display")...)
+	file = append(file, xauthEntry(x11FamilyLocal, "box", "0", "XDM-AUTHORIZATION-1", "wrong-kind")...)
+	file = append(file, xauthEntry(x11FamilyLocal, "box", "0", x11CookieAuth, "cookie")...)
+	file = append(file, xauthEntry(x11FamilyWild, "", "", x11CookieAuth, "wild")...)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		number   string
+		hostname string
+		want     string
+	}{
+		{name: "matching host and display", data: file, number: "0", hostname: "box", want: "cookie"},
+		{name: "wildcard entry", data: file, number: "5", hostname: "elsewhere", want: "wild"},
+		{name: "no file", number: "0", hostname: "box"},
+		{name: "truncated file", data: file[:20], number: "0", hostname: "box"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, cookie := xauthority(tt.data, tt.number, tt.hostname)
+			if string(cookie) != tt.want {
+				t.Errorf("cookie = %q, want %q", cookie, tt.want)
+			}
+			if tt.want != "" && string(name) != x11CookieAuth {
+				t.Errorf("auth name = %q, want %s", name, x11CookieAuth)
+			}
+		})
+	}
+}
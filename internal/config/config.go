@@ -7,6 +7,8 @@ import (
 	"markdown-editor/internal/app"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/dialog"
@@ -23,8 +25,92 @@ var (
 	ErrNoFolderSelected      = errors.New("no folder selected by the user")
 )
 
+const (
+	defaultTemplatesFolder    = "templates"
+	defaultJournalPathPattern = "journal/YYYY/MM/YYYY-MM-DD.md"
+	defaultAttachmentsFolder  = "assets/" + AttachmentsNoteVariable
+
+	AttachmentsNoteVariable = "{note}"
+)
+
+var defaultDiagramCommands = map[string]string{
+	"dot":      "dot -Tpng",
+	"graphviz": "dot -Tpng",
+	"mermaid":  "mmdc --quiet --input {input} --output {output}.png",
+}
+
 type Config struct {
-	DefaultFolder string `json:"default_folder"`
+	DefaultFolder      string            `json:"default_folder"`
+	TemplatesFolder    string            `json:"templates_folder,omitempty"`
+	DefaultTemplate    string            `json:"default_template,omitempty"`
+	JournalPathPattern string            `json:"journal_path_pattern,omitempty"`
+	JournalTemplate    string            `json:"journal_template,omitempty"`
+	AttachmentsFolder  string            `json:"attachments_folder,omitempty"`
+	DiagramCommands    map[string]string `json:"diagram_commands,omitempty"`
+}
+
+func (c *Config) TemplatesDir() string {
+	return c.workspacePath(c.TemplatesFolder)
+}
+
+func (c *Config) AttachmentsDir(notePath string) string {
+	note := strings.TrimSuffix(filepath.Base(notePath), filepath.Ext(notePath))
+	dir := filepath.FromSlash(strings.ReplaceAll(c.AttachmentsFolder, AttachmentsNoteVariable, note))
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(filepath.Dir(notePath), dir)
+}
+
+func (c *Config) InAttachmentsFolder(path string) bool {
+	folder := filepath.ToSlash(filepath.Clean(filepath.FromSlash(c.AttachmentsFolder)))
+	if folder == "." || folder == ".." || strings.HasPrefix(folder, "../") {
+		return true
+	}
+
+	parts := strings.Split(folder, AttachmentsNoteVariable)
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	pattern := strings.Join(parts, "[^/]+") + "(/.*)?$"
+	dir := filepath.Dir(path)
+	if filepath.IsAbs(filepath.FromSlash(folder)) {
+		pattern = "^" + pattern
+	} else {
+		pattern = "(^|/)" + pattern
+		if rel, err := filepath.Rel(c.DefaultFolder, dir); c.DefaultFolder != "" && err == nil {
+			dir = rel
+		}
+	}
+	matched, err := regexp.MatchString(pattern, filepath.ToSlash(dir))
+	return err == nil && matched
+}
+
+func (c *Config) workspacePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.DefaultFolder, path)
+}
+
+func (c *Config) applyDefaults() {
+	if c.TemplatesFolder == "" {
+		c.TemplatesFolder = defaultTemplatesFolder
+	}
+	if c.JournalPathPattern == "" {
+		c.JournalPathPattern = defaultJournalPathPattern
+	}
+	if c.AttachmentsFolder == "" {
+		c.AttachmentsFolder = defaultAttachmentsFolder
+	}
+	if c.DiagramCommands == nil {
+		c.DiagramCommands = make(map[string]string, len(defaultDiagramCommands))
+	}
+	for lang, command := range defaultDiagramCommands {
+		if _, ok := c.DiagramCommands[lang]; !ok {
+			c.DiagramCommands[lang] = command
+		}
+	}
 }
 
 func getConfigPath() (string, error) {
@@ -66,6 +152,7 @@ func LoadConfig(window fyne.Window) (*Config, error) {
 		return nil, wrappedErr
 	}
 
+	config.applyDefaults()
 	return &config, nil
 }
 
@@ -116,5 +203,28 @@ func createDefaultConfig(window fyne.Window) (*Config, error) {
 		return nil, wrappedErr
 	}
 	app.ShowSuccessNotification("Configuration Saved", fmt.Sprintf("Workspace configured to: %s", config.DefaultFolder))
+	config.applyDefaults()
+	return &config, nil
+}
+
+func Read() (*Config, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	config := Config{}
+	file, err := os.ReadFile(configPath)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("%w: %v", ErrConfigReadFailed, err)
+	default:
+		if err := json.Unmarshal(file, &config); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrConfigParseFailed, err)
+		}
+	}
+
+	config.applyDefaults()
 	return &config, nil
 }
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestInAttachmentsFolder(t *testing.T) {
+	root := filepath.FromSlash("/work")
+	tests := []struct {
+		name   string
+		folder string
+		path   string
+		want   bool
+	}{
+		{"per note folder", "assets/{note}", "notes/assets/meeting/pic.png", true},
+		{"per note nested", "assets/{note}", "assets/meeting/raw/pic.png", true},
+		{"outside pattern", "assets/{note}", "out/pic.png", false},
+		{"missing note part", "assets/{note}", "assets/pic.png", false},
+		{"fixed folder", "attachments", "attachments/pic.png", true},
+		{"fixed folder elsewhere", "attachments", "docs/pic.png", false},
+		{"beside notes", ".", "docs/pic.png", true},
+		{"absolute folder", "/media/{note}", "/media/a/pic.png", true},
+		{"absolute folder elsewhere", "/media/{note}", "assets/a/pic.png", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{DefaultFolder: root, AttachmentsFolder: tt.folder}
+			path := filepath.FromSlash(tt.path)
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(root, path)
+			}
+			if got := c.InAttachmentsFolder(path); got != tt.want {
+				t.Errorf("InAttachmentsFolder(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
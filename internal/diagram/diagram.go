@@ -0,0 +1,194 @@
+package diagram
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTimeout    = 15 * time.Second
+	InputPlaceholder  = "{input}"
+	OutputPlaceholder = "{output}"
+
+	maxCacheEntries = 64
+)
+
+var (
+	ErrDiagramUnsupported = errors.New("diagram: no renderer configured for language")
+	ErrDiagramCommand     = errors.New("diagram: invalid renderer command")
+	ErrDiagramRender      = errors.New("diagram: renderer failed")
+	ErrDiagramTimeout     = errors.New("diagram: renderer timed out")
+	ErrDiagramEmpty       = errors.New("diagram: renderer produced no output")
+)
+
+type Renderer interface {
+	Supports(lang string) bool
+	Render(ctx context.Context, lang string, source []byte) ([]byte, error)
+}
+
+type CommandRenderer struct {
+	commands map[string]string
+	timeout  time.Duration
+
+	mu    sync.Mutex
+	cache map[string][]byte
+	order []string
+}
+
+func NewCommandRenderer(commands map[string]string, timeout time.Duration) *CommandRenderer {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	r := &CommandRenderer{
+		commands: make(map[string]string, len(commands)),
+		timeout:  timeout,
+		cache:    make(map[string][]byte),
+	}
+	for lang, command := range commands {
+		if strings.TrimSpace(command) != "" {
+			r.commands[strings.ToLower(lang)] = command
+		}
+	}
+	return r
+}
+
+func (r *CommandRenderer) Supports(lang string) bool {
+	_, ok := r.commands[strings.ToLower(lang)]
+	return ok
+}
+
+func (r *CommandRenderer) Render(ctx context.Context, lang string, source []byte) ([]byte, error) {
+	command, ok := r.commands[strings.ToLower(lang)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrDiagramUnsupported, lang)
+	}
+
+	key := Hash(command, source)
+	r.mu.Lock()
+	data, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok {
+		return data, nil
+	}
+
+	data, err := r.run(ctx, command, source)
+	if err != nil {
+		return nil, err
+	}
+	r.store(key, data)
+	return data, nil
+}
+
+func (r *CommandRenderer) store(key string, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.cache[key]; !ok {
+		r.order = append(r.order, key)
+	}
+	r.cache[key] = data
+	for len(r.order) > maxCacheEntries {
+		delete(r.cache, r.order[0])
+		r.order = r.order[1:]
+	}
+}
+
+func (r *CommandRenderer) run(ctx context.Context, command string, source []byte) ([]byte, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%w: empty command", ErrDiagramCommand)
+	}
+
+	usesInput := strings.Contains(command, InputPlaceholder)
+	usesOutput := strings.Contains(command, OutputPlaceholder)
+	var dir string
+	if usesInput || usesOutput {
+		var err error
+		if dir, err = os.MkdirTemp("", "markdown-editor-diagram-"); err != nil {
+			return nil, fmt.Errorf("%w: creating work directory: %v", ErrDiagramRender, err)
+		}
+		defer os.RemoveAll(dir)
+	}
+	input := filepath.Join(dir, "input")
+	output := filepath.Join(dir, "output")
+	for i, arg := range args {
+		arg = strings.ReplaceAll(arg, InputPlaceholder, input)
+		args[i] = strings.ReplaceAll(arg, OutputPlaceholder, output)
+	}
+	if usesInput {
+		if err := os.WriteFile(input, source, 0600); err != nil {
+			return nil, fmt.Errorf("%w: writing input: %v", ErrDiagramRender, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	if !usesInput {
+		cmd.Stdin = bytes.NewReader(source)
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w: %s after %s", ErrDiagramTimeout, args[0], r.timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s: %v: %s", ErrDiagramRender, args[0], err, msg)
+		}
+		return nil, fmt.Errorf("%w: %s: %v", ErrDiagramRender, args[0], err)
+	}
+
+	data := stdout.Bytes()
+	if usesOutput {
+		if data, err = readOutput(output); err != nil {
+			return nil, fmt.Errorf("%w: reading output: %v", ErrDiagramRender, err)
+		}
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrDiagramEmpty, args[0])
+	}
+	return data, nil
+}
+
+func readOutput(output string) ([]byte, error) {
+	if data, err := os.ReadFile(output); err == nil {
+		return data, nil
+	}
+	matches, err := filepath.Glob(output + "*")
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, os.ErrNotExist
+	}
+	return os.ReadFile(matches[0])
+}
+
+func Hash(command string, source []byte) string {
+	h := sha256.New()
+	h.Write([]byte(command))
+	h.Write([]byte{0})
+	h.Write(source)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func Extension(data []byte) string {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		return ".svg"
+	}
+	return ".png"
+}
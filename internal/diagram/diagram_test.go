@@ -0,0 +1,139 @@
+package diagram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "render.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func countCalls(t *testing.T, log string) int {
+	t.Helper()
+	data, err := os.ReadFile(log)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Count(string(data), "\n")
+}
+
+func TestCommandRendererCache(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "calls")
+	tests := []struct {
+		name    string
+		command string
+	}{
+		{"stdin to stdout", writeScript(t, "echo call >> "+log+"\ncat\n")},
+		{"input and output files", writeScript(t, "echo call >> "+log+"\ncp \"$1\" \"$2.svg\"\n") + " {input} {output}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(log)
+			r := NewCommandRenderer(map[string]string{"Mermaid": tt.command}, time.Second)
+
+			first, err := r.Render(context.Background(), "mermaid", []byte("<svg>a</svg>"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(first) != "<svg>a</svg>" {
+				t.Errorf("Render() = %q, want the command output", first)
+			}
+
+			cached, err := r.Render(context.Background(), "MERMAID", []byte("<svg>a</svg>"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(cached, first) || countCalls(t, log) != 1 {
+				t.Errorf("same content ran the command %d times, want 1", countCalls(t, log))
+			}
+
+			changed, err := r.Render(context.Background(), "mermaid", []byte("<svg>b</svg>"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(changed) != "<svg>b</svg>" || countCalls(t, log) != 2 {
+				t.Errorf("changed content = %q after %d runs, want a fresh render", changed, countCalls(t, log))
+			}
+		})
+	}
+}
+
+func TestCommandRendererErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		timeout time.Duration
+		lang    string
+		wantErr error
+		wantMsg string
+	}{
+		{"timeout", "sleep 5", 100 * time.Millisecond, "dot", ErrDiagramTimeout, "sleep"},
+		{"failing command", writeScript(t, "echo syntax error >&2\nexit 3\n"), time.Second, "dot", ErrDiagramRender, "syntax error"},
+		{"missing command", "markdown-editor-no-such-renderer", time.Second, "dot", ErrDiagramRender, "no-such-renderer"},
+		{"empty output", writeScript(t, "cat > /dev/null\n"), time.Second, "dot", ErrDiagramEmpty, ""},
+		{"unsupported language", "cat", time.Second, "plantuml", ErrDiagramUnsupported, "plantuml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewCommandRenderer(map[string]string{"dot": tt.command}, tt.timeout)
+			start := time.Now()
+			data, err := r.Render(context.Background(), tt.lang, []byte("digraph { a -> b }"))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Render() = %q, %v, want %v", data, err, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("error %q does not mention %q", err, tt.wantMsg)
+			}
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("Render() took %s", elapsed)
+			}
+			if _, cached := r.cache[Hash(tt.command, []byte("digraph { a -> b }"))]; cached {
+				t.Error("failed render was cached")
+			}
+		})
+	}
+}
+
+func TestStubRenderer(t *testing.T) {
+	s := NewStubRenderer("mermaid", "dot")
+	if !s.Supports("Mermaid") || s.Supports("plantuml") {
+		t.Error("Supports() does not follow Languages")
+	}
+
+	first, err := s.Render(context.Background(), "dot", []byte("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := s.Render(context.Background(), "dot", []byte("a"))
+	other, _ := s.Render(context.Background(), "dot", []byte("b"))
+	if !bytes.Equal(first, again) || bytes.Equal(first, other) {
+		t.Error("stub output should depend only on the source")
+	}
+	if Extension(first) != ".png" {
+		t.Errorf("Extension() = %q, want .png", Extension(first))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.Render(ctx, "dot", []byte("a")); !errors.Is(err, ErrDiagramRender) {
+		t.Errorf("cancelled Render() error = %v, want %v", err, ErrDiagramRender)
+	}
+	if s.Calls() != 4 {
+		t.Errorf("Calls() = %d, want 4", s.Calls())
+	}
+}
@@ -0,0 +1,68 @@
+package diagram
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	stubWidth  = 160
+	stubHeight = 90
+)
+
+type StubRenderer struct {
+	Languages []string
+	Err       error
+
+	mu    sync.Mutex
+	calls int
+}
+
+func NewStubRenderer(languages ...string) *StubRenderer {
+	return &StubRenderer{Languages: languages}
+}
+
+func (s *StubRenderer) Supports(lang string) bool {
+	return slices.Contains(s.Languages, strings.ToLower(lang))
+}
+
+func (s *StubRenderer) Render(ctx context.Context, lang string, source []byte) ([]byte, error) {
+	s.mu.Lock()
+	s.calls++
+	s.mu.Unlock()
+
+	if !s.Supports(lang) {
+		return nil, fmt.Errorf("%w: %s", ErrDiagramUnsupported, lang)
+	}
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiagramRender, err)
+	}
+
+	sum := sha256.Sum256(source)
+	img := image.NewRGBA(image.Rect(0, 0, stubWidth, stubHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: sum[0], G: sum[1], B: sum[2], A: 0xff}), image.Point{}, draw.Src)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiagramRender, err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *StubRenderer) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
@@ -0,0 +1,67 @@
+package editor
+
+import (
+	"errors"
+	"markdown-editor/internal/app"
+	"markdown-editor/internal/clipboard"
+	"markdown-editor/internal/export"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+)
+
+const (
+	mimeHTML = "text/html"
+	mimeText = "text/plain;charset=utf-8"
+)
+
+func (e *Editor) copySource() (string, export.Options) {
+	content := e.editComponent.SelectedText()
+	if content == "" {
+		content = e.editComponent.Content()
+	}
+	opts := export.Options{Diagrams: e.diagrams}
+	if e.currentFile != nil {
+		opts.BaseDir = filepath.Dir(e.currentFile.Path())
+	}
+	return content, opts
+}
+
+func (e *Editor) copyAsHTML() {
+	content, opts := e.copySource()
+	fragment, err := export.HTMLFragment(content, opts)
+	if err != nil {
+		app.ShowErrorNotification("Error Copying", "Could not render the note as HTML.", err)
+		return
+	}
+	text, err := export.Text(content, opts)
+	if err != nil {
+		app.ShowErrorNotification("Error Copying", "Could not render the note as plain text.", err)
+		return
+	}
+
+	err = clipboard.Write(
+		clipboard.Content{Mime: mimeHTML, Data: fragment},
+		clipboard.Content{Mime: mimeText, Data: text},
+	)
+	switch {
+	case errors.Is(err, clipboard.ErrClipboardUnavailable):
+		fyne.CurrentApp().Clipboard().SetContent(string(text))
+		app.ShowInfoNotification("Copied as Plain Text", "Copying HTML needs an X11 display or CopyQ; the note was copied as plain text instead.")
+	case err != nil:
+		app.ShowErrorNotification("Error Copying", "Could not place the HTML on the clipboard.", err)
+	default:
+		app.ShowSuccessNotification("Copied as HTML", "The note is on the clipboard as HTML and plain text.")
+	}
+}
+
+func (e *Editor) copyAsPlainText() {
+	content, opts := e.copySource()
+	text, err := export.Text(content, opts)
+	if err != nil {
+		app.ShowErrorNotification("Error Copying", "Could not render the note as plain text.", err)
+		return
+	}
+	fyne.CurrentApp().Clipboard().SetContent(string(text))
+	app.ShowSuccessNotification("Copied as Plain Text", "The rendered note is on the clipboard.")
+}
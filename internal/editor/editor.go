@@ -6,14 +6,28 @@ import (
 	"log"
 	"markdown-editor/internal/app"
 	"markdown-editor/internal/config"
+	"markdown-editor/internal/diagram"
 	"markdown-editor/internal/fileservice"
+	"markdown-editor/internal/frontmatter"
+	"markdown-editor/internal/imaging"
+	"markdown-editor/internal/index"
+	"markdown-editor/internal/journal"
+	"markdown-editor/internal/tasks"
+	"markdown-editor/internal/templates"
+	"markdown-editor/internal/ui/attachmentscomponent"
+	"markdown-editor/internal/ui/calendarcomponent"
 	"markdown-editor/internal/ui/editorcomponent"
 	"markdown-editor/internal/ui/filetreecomponent"
 	"markdown-editor/internal/ui/previewcomponent"
+	"markdown-editor/internal/ui/propertiescomponent"
+	"markdown-editor/internal/ui/tagcomponent"
+	"markdown-editor/internal/ui/taskscomponent"
 
+	"net/url"
 	"path/filepath"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
@@ -32,28 +46,52 @@ var (
 	ErrEditorCheckFileExistence = errors.New("could not check for existing file during save")
 	ErrEditorListDirectory      = errors.New("failed to list directory contents")
 	ErrEditorFilenameInvalid    = errors.New("generated filename is invalid or empty")
+	ErrEditorInvalidTag         = errors.New("invalid tag name")
+	ErrEditorNoJournal          = errors.New("daily notes are not configured")
+	ErrEditorNoNoteOpen         = errors.New("no note is open")
+	ErrEditorInvalidMargin      = errors.New("margin must be a positive number")
+	ErrEditorInvalidCopies      = errors.New("copies must be a whole number from 1 to 99")
+	ErrEditorNoImportSource     = errors.New("no export selected to import")
 )
 
 const newFileBasePrefix = "note-"
 const newFileExtension = ".md"
 const editorFilenameDefault = "untitled"
+const pastedImagePrefix = "pasted-"
 
 type Editor struct {
 	currentFile       fyne.URI
 	editComponent     app.EditorComponent
 	previewComponent  app.PreviewComponent
 	filetreeComponent app.FiletreeComponent
+	tagComponent      app.TagComponent
+	propertiesPanel   app.PropertiesComponent
+	calendar          app.CalendarComponent
+	tasksComponent    app.TasksComponent
+	attachments       app.AttachmentsComponent
+	presentation      app.PresentationComponent
+	sidebar           *container.AppTabs
 	window            fyne.Window
 	config            *config.Config
 	currentDir        fyne.ListableURI
 	editorMode        bool
 	fs                *fileservice.Service
+	index             *index.Index
+	templates         *templates.Service
+	journal           *journal.Journal
+	diagrams          diagram.Renderer
+	savedContent      string
+	crlf              bool
+	dirty             bool
+	baseTitle         string
 }
 
 func NewEditor(w fyne.Window) *Editor {
 	e := &Editor{
 		window:     w,
 		editorMode: true,
+		fs:         fileservice.New(),
+		baseTitle:  w.Title(),
 	}
 
 	e.editComponent = editorcomponent.NewEditComponent()
@@ -64,24 +102,171 @@ func NewEditor(w fyne.Window) *Editor {
 		e.newFile,
 		e.saveFile,
 	)
+	e.tagComponent = tagcomponent.NewTagComponent(e.filterByTags, e.renameTag)
+	e.propertiesPanel = propertiescomponent.NewPropertiesComponent(e.applyProperties, e.refreshProperties)
+	propertiesTab := container.NewTabItem("Properties", e.propertiesPanel.View())
+	e.calendar = calendarcomponent.NewCalendarComponent(e.openDailyNote, e.markJournalDays)
+	e.tasksComponent = taskscomponent.NewTasksComponent(e.setTaskDone, e.openTask)
+	e.attachments = attachmentscomponent.NewAttachmentsComponent(e.openAttachment, e.refreshAttachments, e.cleanupAttachments)
+	attachmentsTab := container.NewTabItem("Attachments", e.attachments.View())
+	e.sidebar = container.NewAppTabs(
+		container.NewTabItem("Files", e.filetreeComponent.View()),
+		container.NewTabItem("Tags", e.tagComponent.View()),
+		propertiesTab,
+		container.NewTabItem("Tasks", e.tasksComponent.View()),
+		container.NewTabItem("Journal", container.NewVBox(
+			widget.NewButton("Today's Note", e.openTodaysNote),
+			container.NewGridWithColumns(2,
+				widget.NewButton("Previous Day", func() { e.openAdjacentDailyNote(-1) }),
+				widget.NewButton("Next Day", func() { e.openAdjacentDailyNote(1) }),
+			),
+			e.calendar.View(),
+		)),
+		attachmentsTab,
+	)
+	e.sidebar.OnSelected = func(tab *container.TabItem) {
+		switch tab {
+		case propertiesTab:
+			e.refreshProperties()
+		case attachmentsTab:
+			e.refreshAttachments()
+		}
+	}
 
 	w.SetContent(container.NewVBox(
 		widget.NewLabel("Initializing..."),
 		widget.NewProgressBarInfinite(),
 	))
 
+	w.SetMainMenu(e.mainMenu())
+	w.SetOnDropped(e.dropFiles)
+
 	shortcut := &desktop.CustomShortcut{KeyName: fyne.KeyM, Modifier: fyne.KeyModifierControl}
 	w.Canvas().AddShortcut(shortcut, func(_ fyne.Shortcut) {
 		e.toggleMode()
 	})
 
+	plainPaste := &desktop.CustomShortcut{KeyName: fyne.KeyV, Modifier: fyne.KeyModifierControl | fyne.KeyModifierShift}
+	w.Canvas().AddShortcut(plainPaste, func(_ fyne.Shortcut) {
+		e.pasteAsPlainText()
+	})
+
+	printShortcut := &desktop.CustomShortcut{KeyName: fyne.KeyP, Modifier: fyne.KeyModifierControl}
+	w.Canvas().AddShortcut(printShortcut, func(_ fyne.Shortcut) {
+		e.printNote()
+	})
+
 	go e.initialize()
 	e.editComponent.SetOnChanged(e.updatePreview)
+	e.editComponent.SetOnPaste(e.paste)
+	e.previewComponent.SetOnTaskToggled(e.togglePreviewTask)
 	return e
 }
 
+func (e *Editor) mainMenu() *fyne.MainMenu {
+	return fyne.NewMainMenu(
+		fyne.NewMenu("File",
+			fyne.NewMenuItem("New File", e.newFile),
+			fyne.NewMenuItem("New from Template...", e.newFromTemplate),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Save", e.saveFile),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Import Notes...", e.importNotes),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Export as HTML...", e.exportHTML),
+			fyne.NewMenuItem("Export as PDF...", e.exportPDF),
+			fyne.NewMenuItem("Export as DOCX...", e.exportDOCX),
+			fyne.NewMenuItem("Export as ODT...", e.exportODT),
+			fyne.NewMenuItem("Export Slides as HTML...", e.exportSlides),
+			fyne.NewMenuItem("Export Folder as EPUB...", e.exportEPUB),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Print...", e.printNote),
+		),
+		fyne.NewMenu("Edit",
+			fyne.NewMenuItem("Copy as HTML", e.copyAsHTML),
+			fyne.NewMenuItem("Copy as Plain Text", e.copyAsPlainText),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Paste as Plain Text", e.pasteAsPlainText),
+		),
+		fyne.NewMenu("Journal",
+			fyne.NewMenuItem("Today's Note", e.openTodaysNote),
+			fyne.NewMenuItem("Previous Day", func() { e.openAdjacentDailyNote(-1) }),
+			fyne.NewMenuItem("Next Day", func() { e.openAdjacentDailyNote(1) }),
+		),
+		fyne.NewMenu("View",
+			fyne.NewMenuItem("Toggle Preview", e.toggleMode),
+			fyne.NewMenuItem("Start Presentation", e.startPresentation),
+		),
+	)
+}
+
 func (e *Editor) updatePreview(text string) {
 	e.previewComponent.Update(text)
+	e.setDirty(e.currentFile != nil && text != e.savedContent)
+}
+
+func (e *Editor) markSaved(content string) {
+	e.savedContent = content
+	e.setDirty(false)
+}
+
+func (e *Editor) setSavedContent(content string) {
+	e.crlf = strings.Contains(content, "\r\n")
+	e.editComponent.SetContent(content)
+	e.markSaved(e.editComponent.Content())
+}
+
+func (e *Editor) fileContent(content string) string {
+	if e.crlf {
+		return strings.ReplaceAll(content, "\n", "\r\n")
+	}
+	return content
+}
+
+func (e *Editor) setDirty(dirty bool) {
+	e.dirty = dirty
+	title := e.baseTitle
+	if e.currentFile != nil {
+		title = e.currentFile.Name() + " - " + e.baseTitle
+		if dirty {
+			title = "*" + title
+		}
+	}
+	e.window.SetTitle(title)
+}
+
+func (e *Editor) replaceContent(updated string) {
+	current, next := []rune(e.editComponent.Content()), []rune(updated)
+	prefix := 0
+	for prefix < len(current) && prefix < len(next) && current[prefix] == next[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(current)-prefix && suffix < len(next)-prefix && current[len(current)-1-suffix] == next[len(next)-1-suffix] {
+		suffix++
+	}
+	e.editComponent.ReplaceRange(prefix, len(current)-suffix, string(next[prefix:len(next)-suffix]))
+}
+
+func (e *Editor) togglePreviewTask(line int, done bool) {
+	content := e.editComponent.Content()
+	lines := strings.Split(content, "\n")
+	if line < 0 || line >= len(lines) {
+		app.ShowErrorNotification("Error Updating Task", "The task is no longer part of the document.", fmt.Errorf("%w: line %d", tasks.ErrTaskNotFound, line+1))
+		return
+	}
+
+	updated, err := tasks.SetLineDone(lines[line], done)
+	if err != nil {
+		app.ShowErrorNotification("Error Updating Task", "The task line changed before it could be toggled.", err)
+		e.previewComponent.Update(content)
+		return
+	}
+	start := utf8.RuneCountInString(strings.Join(lines[:line], "\n"))
+	if line > 0 {
+		start++
+	}
+	e.editComponent.ReplaceRange(start, start+utf8.RuneCountInString(lines[line]), updated)
 }
 
 func (e *Editor) initialize() {
@@ -105,10 +290,28 @@ func (e *Editor) initialize() {
 
 	e.config = cfg
 	e.currentDir = currentDir
+	e.templates = templates.New(cfg.TemplatesDir(), e.fs)
+	e.journal, err = journal.New(cfg.DefaultFolder, cfg.JournalPathPattern, e.fs)
+	if err != nil {
+		app.ShowErrorNotification("Journal Error", "The configured journal path pattern is invalid; daily notes are disabled.", err)
+	}
 	e.filetreeComponent.SetDirectory(e.currentDir)
+	e.diagrams = diagram.NewCommandRenderer(cfg.DiagramCommands, diagram.DefaultTimeout)
+	e.previewComponent.SetDiagramRenderer(e.diagrams)
+
+	e.index = index.New(cfg.DefaultFolder, e.fs)
+	e.index.Exclude(cfg.TemplatesDir())
+	e.index.SetAttachmentFilter(cfg.InAttachmentsFolder)
+	if err := e.index.Build(); err != nil {
+		app.ShowErrorNotification("Index Error", "Some notes could not be indexed.", err)
+	}
+	e.tasksComponent.SetRoot(cfg.DefaultFolder)
+	e.attachments.SetRoot(cfg.DefaultFolder)
+	e.refreshIndexViews()
+	e.markJournalDays(e.calendar.Month())
 
 	e.window.Canvas().SetContent(container.NewHSplit(
-		e.filetreeComponent.View(),
+		e.sidebar,
 		e.editComponent.View(),
 	))
 	e.filetreeComponent.Refresh()
@@ -119,13 +322,13 @@ func (e *Editor) toggleMode() {
 	e.editorMode = !e.editorMode
 	if e.editorMode {
 		e.window.Canvas().SetContent(container.NewHSplit(
-			e.filetreeComponent.View(),
+			e.sidebar,
 			e.editComponent.View(),
 		))
 	} else {
 		e.previewComponent.Update(e.editComponent.Content())
 		e.window.Canvas().SetContent(container.NewHSplit(
-			e.filetreeComponent.View(),
+			e.sidebar,
 			e.previewComponent.View(),
 		))
 	}
@@ -154,8 +357,10 @@ func (e *Editor) loadFile(uri fyne.URI) {
 	}
 
 	e.currentFile = uri
-	e.editComponent.SetContent(string(content))
-	e.previewComponent.Update(string(content))
+	e.previewComponent.SetBaseDir(filepath.Dir(uri.Path()))
+	e.setSavedContent(string(content))
+	e.previewComponent.Update(e.editComponent.Content())
+	e.refreshProperties()
 	log.Printf("File loaded: %s", uri.Path())
 }
 
@@ -171,25 +376,44 @@ func (e *Editor) newFile() {
 		return
 	}
 
+	fileTitle := strings.TrimSuffix(baseName, newFileExtension)
+	fileTitle = strings.TrimPrefix(fileTitle, newFileBasePrefix)
+	header := "# " + fileTitle + "\n"
+
+	if e.config.DefaultTemplate != "" {
+		content, err := e.expandTemplate(e.config.DefaultTemplate, templates.Variables{Title: fileTitle})
+		if err != nil {
+			app.ShowErrorNotification("Template Error", fmt.Sprintf("Could not apply default template '%s', using a plain header.", e.config.DefaultTemplate), err)
+		} else {
+			header = content
+		}
+	}
+
+	e.createWorkspaceNote(baseName, header)
+}
+
+func (e *Editor) createWorkspaceNote(baseName, content string) {
 	newURI, err := storage.Child(e.currentDir, baseName)
 	if err != nil {
 		wrappedErr := fmt.Errorf("%w: for '%s' in '%s': %v", ErrEditorCreateFileURI, baseName, e.currentDir.Path(), err)
 		app.ShowErrorNotification("Error Creating File", "Could not prepare the new file location.", wrappedErr)
 		return
 	}
+	e.createNote(newURI, content)
+}
 
-	fileTitle := strings.TrimSuffix(baseName, newFileExtension)
-	fileTitle = strings.TrimPrefix(fileTitle, newFileBasePrefix)
-	header := "# " + fileTitle + "\n"
-
-	if err := e.fs.WriteFile(newURI, []byte(header)); err != nil {
+func (e *Editor) createNote(newURI fyne.URI, content string) {
+	if err := e.fs.WriteFile(newURI, []byte(content)); err != nil {
 		app.ShowErrorNotification("Error Creating File", "Failed to write initial content to the new file.", fmt.Errorf("writing new file content: %w", err))
 		return
 	}
 
+	e.reindexFile(newURI, []byte(content))
 	e.currentFile = newURI
-	e.editComponent.SetContent(header)
-	e.previewComponent.Update(header)
+	e.previewComponent.SetBaseDir(filepath.Dir(newURI.Path()))
+	e.setSavedContent(content)
+	e.previewComponent.Update(e.editComponent.Content())
+	e.refreshProperties()
 	e.editorMode = false
 	e.toggleMode()
 	e.filetreeComponent.Refresh()
@@ -204,6 +428,108 @@ func (e *Editor) newFile() {
 	app.ShowSuccessNotification("File Created", fmt.Sprintf("New file '%s' created.", newURI.Name()))
 }
 
+func (e *Editor) newFromTemplate() {
+	if e.currentDir == nil || e.templates == nil {
+		app.ShowErrorNotification("Error Creating File", ErrEditorNoWorkspace.Error(), ErrEditorNoWorkspace)
+		return
+	}
+
+	list, err := e.templates.List()
+	if err != nil {
+		app.ShowErrorNotification("Template Error", "Could not list note templates.", err)
+		return
+	}
+	if len(list) == 0 {
+		app.ShowInfoNotification("No Templates", fmt.Sprintf("Add markdown files to '%s' to use them as templates.", e.templates.Dir()))
+		return
+	}
+
+	names := make([]string, 0, len(list))
+	for _, t := range list {
+		names = append(names, t.Name)
+	}
+	picker := widget.NewSelect(names, nil)
+	picker.SetSelectedIndex(0)
+	titleEntry := widget.NewEntry()
+	titleEntry.SetPlaceHolder("Note title")
+
+	dialog.ShowForm("New from Template", "Create", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Template", picker),
+			widget.NewFormItem("Title", titleEntry),
+		},
+		func(ok bool) {
+			if ok {
+				e.createFromTemplate(picker.Selected, strings.TrimSpace(titleEntry.Text))
+			}
+		}, e.window)
+}
+
+func (e *Editor) createFromTemplate(name, title string) {
+	raw, err := e.templates.Load(name)
+	if err != nil {
+		app.ShowErrorNotification("Template Error", fmt.Sprintf("Could not load template '%s'.", name), err)
+		return
+	}
+	fields, err := templates.Fields(raw)
+	if err != nil {
+		app.ShowErrorNotification("Template Error", fmt.Sprintf("Template '%s' is invalid.", name), err)
+		return
+	}
+
+	create := func(values map[string]string) {
+		baseName, err := e.fs.GenerateUniqueFilename(e.currentDir, newFileBasePrefix, newFileExtension)
+		if err != nil {
+			app.ShowErrorNotification("Error Creating File", "Could not generate a unique name for the new file.", fmt.Errorf("generating filename for template note: %w", err))
+			return
+		}
+		noteTitle := title
+		if noteTitle == "" {
+			noteTitle = strings.TrimPrefix(strings.TrimSuffix(baseName, newFileExtension), newFileBasePrefix)
+		}
+		content, err := templates.Expand(raw, templates.Variables{Title: noteTitle, Fields: values})
+		if err != nil {
+			app.ShowErrorNotification("Template Error", fmt.Sprintf("Could not expand template '%s'.", name), err)
+			return
+		}
+		e.createWorkspaceNote(baseName, content)
+	}
+
+	if len(fields) == 0 {
+		create(nil)
+		return
+	}
+
+	entries := make(map[string]*widget.Entry, len(fields))
+	items := make([]*widget.FormItem, 0, len(fields))
+	for _, field := range fields {
+		entry := widget.NewEntry()
+		entries[field] = entry
+		items = append(items, widget.NewFormItem(field, entry))
+	}
+	dialog.ShowForm("Template Fields", "Create", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		values := make(map[string]string, len(entries))
+		for field, entry := range entries {
+			values[field] = entry.Text
+		}
+		create(values)
+	}, e.window)
+}
+
+func (e *Editor) expandTemplate(name string, vars templates.Variables) (string, error) {
+	if e.templates == nil {
+		return "", ErrEditorNoWorkspace
+	}
+	raw, err := e.templates.Load(name)
+	if err != nil {
+		return "", err
+	}
+	return templates.Expand(raw, vars)
+}
+
 func (e *Editor) deleteFile(fileToDelete fyne.URI, index int) {
 	dialog.ShowConfirm("Delete File", fmt.Sprintf("Are you sure you want to delete '%s'?", fileToDelete.Name()),
 		func(ok bool) {
@@ -215,11 +541,14 @@ func (e *Editor) deleteFile(fileToDelete fyne.URI, index int) {
 				}
 
 				app.ShowInfoNotification("File Deleted", fmt.Sprintf("File '%s' deleted.", fileToDelete.Name()))
+				e.removeFromIndex(fileToDelete)
 
 				if e.currentFile != nil && e.currentFile.String() == fileToDelete.String() {
 					e.currentFile = nil
+					e.markSaved("")
 					e.editComponent.SetContent("")
 					e.previewComponent.Update("")
+					e.refreshProperties()
 				}
 
 				e.filetreeComponent.Refresh()
@@ -236,8 +565,10 @@ func (e *Editor) deleteFile(fileToDelete fyne.URI, index int) {
 					e.loadFile(files[newIndex])
 				} else {
 					e.currentFile = nil
+					e.markSaved("")
 					e.editComponent.SetContent("")
 					e.previewComponent.Update("")
+					e.refreshProperties()
 				}
 			}
 		}, e.window)
@@ -273,8 +604,14 @@ func (e *Editor) saveFile() {
 	}
 	desiredNewFilename := newFilenameComponent + ".md"
 
-	if desiredNewFilename != originalFilename {
-		newURI, err := storage.Child(e.currentDir, desiredNewFilename)
+	if desiredNewFilename != originalFilename && !e.isJournalNote(e.currentFile) {
+		parentDir, err := storage.Parent(e.currentFile)
+		if err != nil {
+			userMsg := fmt.Sprintf("Could not determine the folder of '%s'.", originalFilename)
+			app.ShowErrorNotification("Error Saving File", userMsg, fmt.Errorf("%w: parent of '%s': %v", ErrEditorCreateFileURI, e.currentFile.Path(), err))
+			return
+		}
+		newURI, err := storage.Child(parentDir, desiredNewFilename)
 		if err != nil {
 			userMsg := fmt.Sprintf("Could not determine path for new filename '%s'.", desiredNewFilename)
 			wrappedErr := fmt.Errorf("%w: creating child URI for '%s': %v", ErrEditorCreateFileURI, desiredNewFilename, err)
@@ -297,17 +634,21 @@ func (e *Editor) saveFile() {
 			app.ShowErrorNotification("Error Saving File", userMsg, fmt.Errorf("renaming file for save: %w", err))
 			return
 		}
+		e.removeFromIndex(e.currentFile)
 		e.currentFile = newURI
 		log.Printf("File renamed to: %s", newURI.Path())
 		app.ShowInfoNotification("File Renamed", fmt.Sprintf("File renamed to '%s'.", desiredNewFilename))
 	}
 
-	if err := e.fs.WriteFile(e.currentFile, []byte(content)); err != nil {
+	data := []byte(e.fileContent(content))
+	if err := e.fs.WriteFile(e.currentFile, data); err != nil {
 		userMsg := fmt.Sprintf("Failed to write content to '%s'.", e.currentFile.Name())
 		app.ShowErrorNotification("Error Saving File", userMsg, fmt.Errorf("writing file content for save: %w", err))
 		return
 	}
 
+	e.markSaved(content)
+	e.reindexFile(e.currentFile, data)
 	e.filetreeComponent.Refresh()
 	if e.currentFile != nil {
 		files := e.filetreeComponent.GetFiles()
@@ -320,3 +661,416 @@ func (e *Editor) saveFile() {
 	}
 	app.ShowSuccessNotification("File Saved", fmt.Sprintf("File '%s' saved successfully!", e.currentFile.Name()))
 }
+
+func (e *Editor) reindexFile(uri fyne.URI, content []byte) {
+	if e.index == nil {
+		return
+	}
+	e.index.Update(uri.Path(), content)
+	e.refreshIndexViews()
+}
+
+func (e *Editor) removeFromIndex(uri fyne.URI) {
+	if e.index == nil {
+		return
+	}
+	e.index.Remove(uri.Path())
+	e.refreshIndexViews()
+}
+
+func (e *Editor) refreshIndexViews() {
+	e.tagComponent.SetTags(e.index.Tags())
+	e.tasksComponent.SetTasks(e.index.Tasks())
+}
+
+func (e *Editor) filterByTags(tags []string) {
+	if len(tags) == 0 || e.index == nil {
+		e.filetreeComponent.SetFilter(nil)
+	} else {
+		e.filetreeComponent.SetFilter(func(uri fyne.URI) bool {
+			return e.index.HasTags(uri.Path(), tags...)
+		})
+	}
+	if e.currentDir != nil {
+		e.filetreeComponent.Refresh()
+	}
+}
+
+func (e *Editor) renameTag(tag string) {
+	entry := widget.NewEntry()
+	entry.SetText(tag)
+	dialog.ShowForm("Rename Tag", "Rename", "Cancel",
+		[]*widget.FormItem{widget.NewFormItem("New name", entry)},
+		func(ok bool) {
+			if ok {
+				e.applyTagRename(tag, entry.Text)
+			}
+		}, e.window)
+}
+
+func (e *Editor) applyTagRename(oldTag, newTag string) {
+	if e.index == nil {
+		app.ShowErrorNotification("Error Renaming Tag", ErrEditorNoWorkspace.Error(), ErrEditorNoWorkspace)
+		return
+	}
+
+	newTag = index.NormalizeTag(newTag)
+	if !index.IsValidTag(newTag) {
+		userMsg := fmt.Sprintf("'%s' is not a valid tag name.", newTag)
+		app.ShowErrorNotification("Error Renaming Tag", userMsg, fmt.Errorf("%w: '%s'", ErrEditorInvalidTag, newTag))
+		return
+	}
+
+	renamed := 0
+	var errs []error
+	for _, path := range e.index.NotesWithTagTree(oldTag) {
+		uri := storage.NewFileURI(path)
+		content, err := e.fs.ReadFile(uri)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		updated, changed := index.RenameTag(string(content), oldTag, newTag)
+		if !changed {
+			continue
+		}
+		if err := e.fs.WriteFile(uri, []byte(updated)); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		e.index.Update(path, []byte(updated))
+		renamed++
+	}
+
+	if e.currentFile != nil {
+		if updated, changed := index.RenameTag(e.editComponent.Content(), oldTag, newTag); changed {
+			wasDirty := e.dirty
+			e.replaceContent(updated)
+			if !wasDirty {
+				e.markSaved(updated)
+			}
+		}
+	}
+
+	e.refreshIndexViews()
+	e.filetreeComponent.Refresh()
+
+	if err := errors.Join(errs...); err != nil {
+		app.ShowErrorNotification("Error Renaming Tag", "Some notes could not be updated.", fmt.Errorf("renaming tag '%s': %w", oldTag, err))
+		return
+	}
+	app.ShowSuccessNotification("Tag Renamed", fmt.Sprintf("Renamed #%s to #%s in %d notes.", oldTag, newTag, renamed))
+}
+
+func (e *Editor) refreshProperties() {
+	if e.currentFile == nil {
+		e.propertiesPanel.SetDocument(nil)
+		return
+	}
+
+	doc, err := frontmatter.Parse(e.editComponent.Content())
+	if err != nil {
+		app.ShowErrorNotification("Properties Error", "Could not parse the note frontmatter.", err)
+		e.propertiesPanel.SetDocument(nil)
+		return
+	}
+	e.propertiesPanel.SetDocument(doc)
+}
+
+func (e *Editor) applyProperties(props []frontmatter.Property) {
+	if e.currentFile == nil {
+		app.ShowErrorNotification("Error Updating Properties", ErrEditorNoFileToSave.Error(), ErrEditorNoFileToSave)
+		return
+	}
+
+	content := e.editComponent.Content()
+	updated, err := frontmatter.Render(content, props)
+	if err != nil {
+		app.ShowErrorNotification("Error Updating Properties", "Could not write the properties back into the note.", err)
+		return
+	}
+	if updated != content {
+		e.replaceContent(updated)
+	}
+	e.refreshProperties()
+}
+
+func (e *Editor) isJournalNote(uri fyne.URI) bool {
+	if e.journal == nil || uri == nil {
+		return false
+	}
+	_, ok := e.journal.DateForPath(uri.Path())
+	return ok
+}
+
+func (e *Editor) openTodaysNote() {
+	e.openDailyNote(time.Now())
+}
+
+func (e *Editor) openAdjacentDailyNote(offset int) {
+	date := time.Now().AddDate(0, 0, offset)
+	if e.journal != nil && e.currentFile != nil {
+		if adjacent, ok := e.journal.Adjacent(e.currentFile.Path(), offset); ok {
+			date = adjacent
+		}
+	}
+	e.openDailyNote(date)
+}
+
+func (e *Editor) openDailyNote(date time.Time) {
+	if e.journal == nil {
+		app.ShowErrorNotification("Journal Error", ErrEditorNoJournal.Error(), ErrEditorNoJournal)
+		return
+	}
+
+	date = journal.Day(date)
+	path := e.journal.Path(date)
+	uri := storage.NewFileURI(path)
+
+	exists, err := e.fs.FileExists(uri)
+	if err != nil {
+		app.ShowErrorNotification("Journal Error", "Could not check for an existing daily note.", err)
+		return
+	}
+	if exists {
+		e.loadFile(uri)
+		e.showJournalMonth(date)
+		return
+	}
+
+	if err := e.fs.CreateDirectoryAll(filepath.Dir(path)); err != nil {
+		app.ShowErrorNotification("Journal Error", "Could not create the journal folder.", err)
+		return
+	}
+
+	title := date.Format(journal.DateLayout)
+	content := "# " + title + "\n"
+	if e.config.JournalTemplate != "" {
+		expanded, err := e.expandTemplate(e.config.JournalTemplate, templates.Variables{Title: title, Now: date})
+		if err != nil {
+			app.ShowErrorNotification("Template Error", fmt.Sprintf("Could not apply journal template '%s', using a plain header.", e.config.JournalTemplate), err)
+		} else {
+			content = expanded
+		}
+	}
+
+	e.createNote(uri, content)
+	e.showJournalMonth(date)
+}
+
+func (e *Editor) showJournalMonth(date time.Time) {
+	month := e.calendar.Month()
+	if month.Year() == date.Year() && month.Month() == date.Month() {
+		e.markJournalDays(month)
+		return
+	}
+	e.calendar.SetMonth(date)
+}
+
+func (e *Editor) markJournalDays(month time.Time) {
+	if e.journal == nil {
+		return
+	}
+	e.calendar.SetMarkedDays(e.journal.DaysWithNotes(month))
+}
+
+func (e *Editor) openTask(item index.TaskItem) {
+	e.loadFile(storage.NewFileURI(item.Path))
+}
+
+func (e *Editor) setTaskDone(item index.TaskItem, done bool) {
+	uri := storage.NewFileURI(item.Path)
+	content, err := e.fs.ReadFile(uri)
+	if err != nil {
+		app.ShowErrorNotification("Error Updating Task", fmt.Sprintf("Could not read '%s'.", uri.Name()), err)
+		e.refreshIndexViews()
+		return
+	}
+
+	updated, err := tasks.SetDone(string(content), item.Task, done)
+	if err != nil {
+		app.ShowErrorNotification("Error Updating Task", "The task changed on disk; the task list has been refreshed.", err)
+		e.index.Update(item.Path, content)
+		e.refreshIndexViews()
+		return
+	}
+	if err := e.fs.WriteFile(uri, []byte(updated)); err != nil {
+		app.ShowErrorNotification("Error Updating Task", fmt.Sprintf("Could not write '%s'.", uri.Name()), err)
+		e.refreshIndexViews()
+		return
+	}
+
+	if e.currentFile != nil && e.currentFile.Path() == item.Path {
+		if buffer, err := tasks.SetDone(e.editComponent.Content(), item.Task, done); err == nil {
+			wasDirty := e.dirty
+			e.replaceContent(buffer)
+			if !wasDirty {
+				e.markSaved(buffer)
+			}
+		}
+	}
+	e.reindexFile(uri, []byte(updated))
+}
+
+func (e *Editor) dropFiles(_ fyne.Position, uris []fyne.URI) {
+	if e.currentFile == nil {
+		app.ShowErrorNotification("Error Adding Images", "Open a note before dropping images onto the window.", ErrEditorNoNoteOpen)
+		return
+	}
+
+	var links []string
+	skipped := 0
+	for _, uri := range uris {
+		if !imaging.IsSupported(uri.Path()) {
+			skipped++
+			continue
+		}
+		data, err := e.fs.ReadFile(uri)
+		if err != nil {
+			app.ShowErrorNotification("Error Adding Image", fmt.Sprintf("Could not read '%s'.", uri.Name()), err)
+			continue
+		}
+
+		ext := strings.ToLower(filepath.Ext(uri.Name()))
+		prefix := e.fs.SanitizeFilenameComponent(strings.TrimSuffix(uri.Name(), filepath.Ext(uri.Name())))
+		if prefix != "" {
+			prefix += "-"
+		}
+		link, err := e.saveAttachment(data, prefix, ext)
+		if err != nil {
+			app.ShowErrorNotification("Error Adding Image", fmt.Sprintf("Could not copy '%s' into the attachments folder.", uri.Name()), err)
+			continue
+		}
+		links = append(links, link)
+	}
+
+	if len(links) > 0 {
+		e.editComponent.InsertAtCursor(strings.Join(links, "\n"))
+	}
+	if skipped > 0 {
+		app.ShowInfoNotification("Files Skipped", fmt.Sprintf("%d dropped file(s) are not supported images.", skipped))
+	}
+}
+
+func (e *Editor) saveAttachment(data []byte, prefix, ext string) (string, error) {
+	notePath := e.currentFile.Path()
+	dir := e.config.AttachmentsDir(notePath)
+	if err := e.fs.CreateDirectoryAll(dir); err != nil {
+		return "", err
+	}
+	dirURI, err := storage.ListerForURI(storage.NewFileURI(dir))
+	if err != nil {
+		return "", fmt.Errorf("%w: for '%s': %v", ErrEditorCreateFileURI, dir, err)
+	}
+
+	name, err := e.fs.GenerateUniqueFilename(dirURI, prefix, ext)
+	if err != nil {
+		return "", err
+	}
+	target := filepath.Join(dir, name)
+	if err := e.fs.WriteFile(storage.NewFileURI(target), data); err != nil {
+		return "", err
+	}
+	log.Printf("Attachment saved: %s", target)
+
+	rel, err := filepath.Rel(filepath.Dir(notePath), target)
+	if err != nil {
+		rel = target
+	}
+	alt := strings.TrimSuffix(strings.TrimSuffix(name, ext), "-")
+	return fmt.Sprintf("![%s](%s)", alt, (&url.URL{Path: filepath.ToSlash(rel)}).EscapedPath()), nil
+}
+
+func (e *Editor) refreshAttachments() {
+	if e.index == nil {
+		return
+	}
+	attachments, err := e.index.Attachments()
+	if err != nil {
+		app.ShowErrorNotification("Attachments Error", "Could not list workspace attachments.", err)
+		return
+	}
+	e.attachments.SetAttachments(attachments)
+}
+
+func (e *Editor) openAttachment(attachment index.Attachment) {
+	if !attachment.Referenced() {
+		app.ShowInfoNotification("Unreferenced Attachment", fmt.Sprintf("'%s' is not linked from any note.", filepath.Base(attachment.Path)))
+		return
+	}
+	e.loadFile(storage.NewFileURI(attachment.Notes[0]))
+}
+
+func (e *Editor) orphanedAttachments() ([]index.Attachment, error) {
+	attachments, err := e.index.Attachments()
+	if err != nil {
+		return nil, err
+	}
+
+	inUse := make(map[string]bool)
+	if e.currentFile != nil {
+		links, err := e.index.ResolveLinks(e.currentFile.Path(), e.editComponent.Content())
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			inUse[link] = true
+		}
+	}
+
+	var orphans []index.Attachment
+	for _, attachment := range attachments {
+		if attachment.Orphaned() && !inUse[attachment.Path] {
+			orphans = append(orphans, attachment)
+		}
+	}
+	return orphans, nil
+}
+
+func (e *Editor) cleanupAttachments() {
+	if e.index == nil {
+		return
+	}
+	orphans, err := e.orphanedAttachments()
+	if err != nil {
+		app.ShowErrorNotification("Attachments Error", "Could not check the workspace for unreferenced attachments.", err)
+		return
+	}
+	if len(orphans) == 0 {
+		app.ShowInfoNotification("Nothing to Clean Up", "Every attachment is referenced by a note.")
+		e.refreshAttachments()
+		return
+	}
+
+	message := fmt.Sprintf("Move %d unreferenced attachment(s) to the trash?", len(orphans))
+	dialog.ShowConfirm("Clean Up Attachments", message, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		orphans, err := e.orphanedAttachments()
+		if err != nil {
+			app.ShowErrorNotification("Attachments Error", "Could not check the workspace for unreferenced attachments.", err)
+			return
+		}
+		var failed []error
+		for _, attachment := range orphans {
+			if err := e.fs.MoveToTrash(storage.NewFileURI(attachment.Path)); err != nil {
+				failed = append(failed, err)
+				continue
+			}
+			log.Printf("Attachment moved to trash: %s", attachment.Path)
+		}
+
+		switch {
+		case len(failed) > 0:
+			userMsg := fmt.Sprintf("%d of %d attachment(s) could not be moved to the trash.", len(failed), len(orphans))
+			app.ShowErrorNotification("Clean Up Failed", userMsg, errors.Join(failed...))
+		case len(orphans) == 0:
+			app.ShowInfoNotification("Nothing to Clean Up", "Every attachment is referenced by a note.")
+		default:
+			app.ShowSuccessNotification("Attachments Cleaned Up", fmt.Sprintf("Moved %d attachment(s) to the trash.", len(orphans)))
+		}
+		e.refreshAttachments()
+	}, e.window)
+}
@@ -0,0 +1,234 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"markdown-editor/internal/app"
+	"markdown-editor/internal/export"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+func (e *Editor) exportOptions() export.Options {
+	return export.Options{
+		BaseDir:  filepath.Dir(e.currentFile.Path()),
+		Diagrams: e.diagrams,
+	}
+}
+
+func (e *Editor) exportHTML() {
+	if e.currentFile == nil {
+		app.ShowErrorNotification("Error Exporting", "Open a note before exporting it.", ErrEditorNoNoteOpen)
+		return
+	}
+
+	themeSelect := exportThemeSelect()
+	tocCheck := widget.NewCheck("Include table of contents", nil)
+
+	dialog.ShowForm("Export as HTML", "Export", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Theme", themeSelect),
+			widget.NewFormItem("", tocCheck),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			opts := e.exportOptions()
+			opts.Theme = export.Theme(themeSelect.Selected)
+			opts.TOC = tocCheck.Checked
+			data, err := export.HTML(e.editComponent.Content(), opts)
+			if err != nil {
+				app.ShowErrorNotification("Error Exporting", "Could not render the note as HTML.", err)
+				return
+			}
+			e.saveNoteExport(data, ".html")
+		}, e.window)
+}
+
+func (e *Editor) exportSlides() {
+	if e.currentFile == nil {
+		app.ShowErrorNotification("Error Exporting", "Open a note before exporting it.", ErrEditorNoNoteOpen)
+		return
+	}
+
+	themeSelect := exportThemeSelect()
+	dialog.ShowForm("Export Slides as HTML", "Export", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Theme", themeSelect),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			opts := e.exportOptions()
+			opts.Theme = export.Theme(themeSelect.Selected)
+			data, err := export.Slides(e.editComponent.Content(), opts)
+			if err != nil {
+				app.ShowErrorNotification("Error Exporting", "Could not render the note as slides.", err)
+				return
+			}
+			e.saveNoteExport(data, ".slides.html")
+		}, e.window)
+}
+
+func exportThemeSelect() *widget.Select {
+	themes := make([]string, 0, len(export.Themes()))
+	for _, t := range export.Themes() {
+		themes = append(themes, string(t))
+	}
+	themeSelect := widget.NewSelect(themes, nil)
+	themeSelect.SetSelected(string(export.ThemeLight))
+	if fyne.CurrentApp().Settings().ThemeVariant() == theme.VariantDark {
+		themeSelect.SetSelected(string(export.ThemeDark))
+	}
+	return themeSelect
+}
+
+func (e *Editor) exportPDF() {
+	e.exportPaged("PDF", ".pdf", export.PDF)
+}
+
+func (e *Editor) exportDOCX() {
+	e.exportPaged("Word document", ".docx", export.DOCX)
+}
+
+func (e *Editor) exportODT() {
+	e.exportPaged("OpenDocument text", ".odt", export.ODT)
+}
+
+func (e *Editor) exportPaged(format, ext string, render func(content string, opts export.Options) ([]byte, error)) {
+	if e.currentFile == nil {
+		app.ShowErrorNotification("Error Exporting", "Open a note before exporting it.", ErrEditorNoNoteOpen)
+		return
+	}
+
+	setup := newPageSetup()
+	dialog.ShowForm("Export as "+format, "Export", "Cancel", setup.items(),
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			opts := e.exportOptions()
+			setup.apply(&opts)
+			data, err := render(e.editComponent.Content(), opts)
+			if err != nil {
+				app.ShowErrorNotification("Error Exporting", fmt.Sprintf("Could not render the note as %s.", format), err)
+				return
+			}
+			e.saveNoteExport(data, ext)
+		}, e.window)
+}
+
+type pageSetup struct {
+	size   *widget.Select
+	margin *widget.Entry
+}
+
+func newPageSetup() *pageSetup {
+	sizes := make([]string, 0, len(export.PageSizes()))
+	for _, s := range export.PageSizes() {
+		sizes = append(sizes, string(s))
+	}
+	p := &pageSetup{
+		size:   widget.NewSelect(sizes, nil),
+		margin: widget.NewEntry(),
+	}
+	p.size.SetSelected(string(export.PageA4))
+	p.margin.SetText(strconv.FormatFloat(export.DefaultMargin, 'f', -1, 64))
+	p.margin.Validator = func(text string) error {
+		if margin, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err != nil || margin <= 0 {
+			return ErrEditorInvalidMargin
+		}
+		return nil
+	}
+	return p
+}
+
+func (p *pageSetup) items() []*widget.FormItem {
+	return []*widget.FormItem{
+		widget.NewFormItem("Page size", p.size),
+		widget.NewFormItem("Margin (mm)", p.margin),
+	}
+}
+
+func (p *pageSetup) apply(opts *export.Options) {
+	opts.PageSize = export.PageSize(p.size.Selected)
+	opts.Margin, _ = strconv.ParseFloat(strings.TrimSpace(p.margin.Text), 64)
+}
+
+func (e *Editor) exportEPUB() {
+	open := dialog.NewFolderOpen(func(dir fyne.ListableURI, err error) {
+		if err != nil {
+			app.ShowErrorNotification("Error Exporting", "Could not open the selected folder.", err)
+			return
+		}
+		if dir == nil {
+			return
+		}
+
+		opts := export.Options{Diagrams: e.diagrams}
+		if e.config != nil {
+			opts.Exclude = []string{e.config.TemplatesDir()}
+		}
+		data, err := export.EPUB(dir.Path(), opts)
+		if errors.Is(err, export.ErrEPUBMissingImages) {
+			app.ShowErrorNotification("Missing Images", "Some images could not be found and were left out of the EPUB.", err)
+		} else if err != nil {
+			app.ShowErrorNotification("Error Exporting", fmt.Sprintf("Could not bundle '%s' as an EPUB.", dir.Name()), err)
+			return
+		}
+		e.saveExport(data, dir.Name()+".epub", dir)
+	}, e.window)
+	if e.config != nil {
+		if root, err := storage.ListerForURI(storage.NewFileURI(e.config.DefaultFolder)); err == nil {
+			open.SetLocation(root)
+		}
+	}
+	open.Show()
+}
+
+func (e *Editor) saveNoteExport(data []byte, ext string) {
+	name := e.currentFile.Name()
+	var location fyne.URI
+	if parent, err := storage.Parent(e.currentFile); err == nil {
+		location = parent
+	}
+	e.saveExport(data, strings.TrimSuffix(name, filepath.Ext(name))+ext, location)
+}
+
+func (e *Editor) saveExport(data []byte, fileName string, location fyne.URI) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			app.ShowErrorNotification("Error Exporting", "Could not open the export destination.", err)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if _, err := writer.Write(data); err != nil {
+			app.ShowErrorNotification("Error Exporting", fmt.Sprintf("Could not write '%s'.", writer.URI().Name()), fmt.Errorf("writing export: %w", err))
+			return
+		}
+		log.Printf("Exported to: %s", writer.URI().Path())
+		app.ShowSuccessNotification("Export Complete", fmt.Sprintf("Exported to '%s'.", writer.URI().Name()))
+	}, e.window)
+
+	save.SetFileName(fileName)
+	if location != nil {
+		if dir, err := storage.ListerForURI(location); err == nil {
+			save.SetLocation(dir)
+		}
+	}
+	save.Show()
+}
@@ -0,0 +1,176 @@
+package editor
+
+import (
+	"fmt"
+	"log"
+	"markdown-editor/internal/app"
+	"markdown-editor/internal/importer"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+const importFolderSuffix = " Import"
+
+var importExtensions = map[importer.Source]string{
+	importer.SourceEvernote: ".enex",
+	importer.SourceJoplin:   ".jex",
+	importer.SourceNotion:   ".zip",
+}
+
+type importForm struct {
+	editor     *Editor
+	source     *widget.Select
+	from       *widget.Entry
+	folder     *widget.Entry
+	fileButton *widget.Button
+}
+
+func (e *Editor) importNotes() {
+	if e.config == nil {
+		app.ShowErrorNotification("Error Importing", ErrEditorNoWorkspace.Error(), ErrEditorNoWorkspace)
+		return
+	}
+
+	sources := importer.Sources()
+	names := make([]string, 0, len(sources))
+	for _, source := range sources {
+		names = append(names, string(source))
+	}
+
+	f := &importForm{
+		editor: e,
+		from:   widget.NewEntry(),
+		folder: widget.NewEntry(),
+	}
+	f.from.SetPlaceHolder("Export file or folder")
+	f.fileButton = widget.NewButton("File...", f.chooseFile)
+	folderButton := widget.NewButton("Folder...", f.chooseFolder)
+	f.source = widget.NewSelect(names, func(name string) {
+		f.folder.SetText(e.fs.SanitizeFilenameComponent(name + importFolderSuffix))
+		if _, ok := importExtensions[importer.Source(name)]; ok {
+			f.fileButton.Show()
+		} else {
+			f.fileButton.Hide()
+		}
+	})
+	f.source.SetSelected(names[0])
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Import from", f.source),
+		widget.NewFormItem("Export", container.NewBorder(nil, nil, nil, container.NewHBox(f.fileButton, folderButton), f.from)),
+		widget.NewFormItem("Into folder", f.folder),
+	}
+	d := dialog.NewForm("Import Notes", "Import", "Cancel", items, func(ok bool) {
+		if ok {
+			f.submit()
+		}
+	}, e.window)
+	d.Resize(fyne.NewSize(560, 0))
+	d.Show()
+}
+
+func (f *importForm) location() fyne.ListableURI {
+	dir, err := storage.ListerForURI(storage.NewFileURI(f.editor.config.DefaultFolder))
+	if err != nil {
+		return nil
+	}
+	return dir
+}
+
+func (f *importForm) chooseFile() {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			app.ShowErrorNotification("Error Importing", "Could not open the selected file.", err)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		reader.Close()
+		f.from.SetText(reader.URI().Path())
+	}, f.editor.window)
+	if ext, ok := importExtensions[importer.Source(f.source.Selected)]; ok {
+		open.SetFilter(storage.NewExtensionFileFilter([]string{ext}))
+	}
+	if dir := f.location(); dir != nil {
+		open.SetLocation(dir)
+	}
+	open.Show()
+}
+
+func (f *importForm) chooseFolder() {
+	open := dialog.NewFolderOpen(func(dir fyne.ListableURI, err error) {
+		if err != nil {
+			app.ShowErrorNotification("Error Importing", "Could not open the selected folder.", err)
+			return
+		}
+		if dir == nil {
+			return
+		}
+		f.from.SetText(dir.Path())
+	}, f.editor.window)
+	if dir := f.location(); dir != nil {
+		open.SetLocation(dir)
+	}
+	open.Show()
+}
+
+func (f *importForm) targetDir() string {
+	parts := []string{f.editor.config.DefaultFolder}
+	for _, part := range strings.FieldsFunc(f.folder.Text, func(r rune) bool { return r == '/' || r == filepath.Separator }) {
+		if part = f.editor.fs.SanitizeFilenameComponent(part); part != "" && part != "." && part != ".." {
+			parts = append(parts, part)
+		}
+	}
+	return filepath.Join(parts...)
+}
+
+func (f *importForm) submit() {
+	e := f.editor
+	source := importer.Source(f.source.Selected)
+	from := strings.TrimSpace(f.from.Text)
+	if from == "" {
+		app.ShowErrorNotification("Error Importing", "Choose an export file or folder to import.", ErrEditorNoImportSource)
+		return
+	}
+	target := f.targetDir()
+
+	progress := dialog.NewCustomWithoutButtons("Importing from "+string(source), container.NewVBox(
+		widget.NewLabel(fmt.Sprintf("Importing '%s'...", filepath.Base(from))),
+		widget.NewProgressBarInfinite(),
+	), e.window)
+	progress.Show()
+
+	service := importer.New(e.fs, e.config.AttachmentsDir)
+	go func() {
+		report, err := service.Import(source, from, target)
+		fyne.Do(func() {
+			progress.Hide()
+			if err != nil {
+				app.ShowErrorNotification("Error Importing", fmt.Sprintf("Could not import '%s' from %s.", filepath.Base(from), source), err)
+				return
+			}
+			e.finishImport(report)
+		})
+	}()
+}
+
+func (e *Editor) finishImport(report *importer.Report) {
+	log.Printf("Imported %d notes from %s into %s", len(report.Notes), report.From, report.Target)
+	if err := e.index.Build(); err != nil {
+		app.ShowErrorNotification("Error Importing", "The notes were imported, but the workspace index could not be rebuilt.", err)
+	}
+	e.refreshIndexViews()
+	e.markJournalDays(e.calendar.Month())
+	e.filetreeComponent.Refresh()
+	if !e.dirty && report.Path != "" {
+		e.loadFile(storage.NewFileURI(report.Path))
+	}
+	app.ShowSuccessNotification("Import Complete", report.Summary())
+}
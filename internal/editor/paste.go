@@ -0,0 +1,90 @@
+package editor
+
+import (
+	"errors"
+	"markdown-editor/internal/app"
+	"markdown-editor/internal/clipboard"
+	"markdown-editor/internal/htmltomd"
+	"strings"
+
+	"fyne.io/fyne/v2"
+)
+
+type pastedContent struct {
+	image    clipboard.Image
+	markdown string
+	title    string
+	message  string
+	err      error
+}
+
+func (e *Editor) paste() bool {
+	note := e.currentFile
+	go func() {
+		content := readPaste(note != nil)
+		fyne.Do(func() {
+			e.applyPaste(note, content)
+		})
+	}()
+	return true
+}
+
+func readPaste(images bool) pastedContent {
+	targets, err := clipboard.Targets()
+	if err != nil {
+		return pastedContent{}
+	}
+
+	var failed pastedContent
+	if images && !clipboard.HasText(targets) {
+		img, err := clipboard.ReadImage(targets)
+		switch {
+		case err == nil:
+			return pastedContent{image: img}
+		case !errors.Is(err, clipboard.ErrClipboardNoImage):
+			failed = pastedContent{title: "Error Pasting Image", message: "Could not read the image from the clipboard.", err: err}
+		}
+	}
+
+	source, err := clipboard.ReadHTML(targets)
+	if err != nil {
+		if !errors.Is(err, clipboard.ErrClipboardNoHTML) {
+			return pastedContent{title: "Error Pasting", message: "Could not read the formatted text from the clipboard.", err: err}
+		}
+		return failed
+	}
+	converted, err := htmltomd.Convert(source)
+	if err != nil {
+		return pastedContent{title: "Error Pasting", message: "Could not convert the formatted text to markdown; pasting it as plain text instead.", err: err}
+	}
+	return pastedContent{markdown: converted}
+}
+
+func (e *Editor) applyPaste(note fyne.URI, content pastedContent) {
+	if (note == nil) != (e.currentFile == nil) || (note != nil && note.String() != e.currentFile.String()) {
+		return
+	}
+	if content.err != nil {
+		app.ShowErrorNotification(content.title, content.message, content.err)
+	}
+
+	switch {
+	case len(content.image.Data) > 0:
+		link, err := e.saveAttachment(content.image.Data, pastedImagePrefix, content.image.Extension)
+		if err != nil {
+			app.ShowErrorNotification("Error Pasting Image", "Could not save the pasted image.", err)
+			return
+		}
+		e.editComponent.InsertAtCursor(link)
+	case strings.TrimSpace(content.markdown) != "":
+		e.editComponent.InsertAtCursor(content.markdown)
+	default:
+		e.pasteAsPlainText()
+	}
+}
+
+func (e *Editor) pasteAsPlainText() {
+	if text := fyne.CurrentApp().Clipboard().Content(); text != "" {
+		e.editComponent.InsertAtCursor(text)
+	}
+}
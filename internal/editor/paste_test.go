@@ -0,0 +1,173 @@
+package editor
+
+import (
+	"markdown-editor/internal/config"
+	"markdown-editor/internal/fileservice"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/test"
+)
+
+const fakeXclip = `#!/bin/sh
+dir=$(dirname "$0")
+case "$4" in
+TARGETS) cat "$dir/targets" ;;
+*) f="$dir/$(printf %s "$4" | tr '/;=' '___')"; [ -f "$f" ] || exit 1; cat "$f" ;;
+esac
+`
+
+func fakeClipboard(t *testing.T, contents map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	var targets []string
+	for mime, data := range contents {
+		targets = append(targets, mime)
+		name := strings.NewReplacer("/", "_", ";", "_", "=", "_").Replace(mime)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sort.Strings(targets)
+	if err := os.WriteFile(filepath.Join(dir, "targets"), []byte(strings.Join(targets, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "xclip"), []byte(fakeXclip), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestReadPaste(t *testing.T) {
+	tests := []struct {
+		name         string
+		clipboard    map[string]string
+		images       bool
+		wantImage    string
+		wantMarkdown string
+		wantErr      bool
+	}{
+		{
+			name:      "image without text",
+			clipboard: map[string]string{"image/png": "png-data", "TARGETS": ""},
+			images:    true,
+			wantImage: "png-data",
+		},
+		{
+			name:         "image alongside html prefers the text",
+			clipboard:    map[string]string{"image/png": "png-data", "text/html": "<p><b>hi</b></p>", "UTF8_STRING": "hi"},
+			images:       true,
+			wantMarkdown: "**hi**",
+		},
+		{
+			name:         "image without an open note",
+			clipboard:    map[string]string{"image/png": "png-data", "text/html": "<p>x</p>"},
+			wantMarkdown: "x",
+		},
+		{
+			name:      "plain text only",
+			clipboard: map[string]string{"UTF8_STRING": "hi"},
+			images:    true,
+		},
+		{
+			name:      "unreadable html",
+			clipboard: map[string]string{"UTF8_STRING": "hi", "text/html;charset=utf-16": ""},
+			images:    true,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClipboard(t, tt.clipboard)
+			got := readPaste(tt.images)
+			if string(got.image.Data) != tt.wantImage || got.markdown != tt.wantMarkdown || (got.err != nil) != tt.wantErr {
+				t.Errorf("readPaste() = image %q, markdown %q, error %v", got.image.Data, got.markdown, got.err)
+			}
+			if got.err != nil && (got.title == "" || got.message == "") {
+				t.Errorf("error %v has no notification text", got.err)
+			}
+		})
+	}
+}
+
+func TestReadPasteWithoutClipboardTool(t *testing.T) {
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("PATH", t.TempDir())
+	if got := readPaste(true); got.err != nil || got.markdown != "" || got.image.Data != nil {
+		t.Errorf("readPaste() = %+v, want nothing so the plain text paste runs", got)
+	}
+}
+
+func TestSaveAttachment(t *testing.T) {
+	test.NewTempApp(t)
+
+	dir := t.TempDir()
+	outside := t.TempDir()
+	tests := []struct {
+		name     string
+		folder   string
+		wantDir  string
+		wantLink string
+	}{
+		{
+			name:     "relative folder with the note name",
+			folder:   "assets/{note}",
+			wantDir:  filepath.Join(dir, "assets", "my note"),
+			wantLink: `^!\[pasted-[0-9_-]+\]\(assets/my%20note/pasted-[0-9_-]+\.png\)$`,
+		},
+		{
+			name:     "absolute folder",
+			folder:   outside,
+			wantDir:  outside,
+			wantLink: `^!\[pasted-[0-9_-]+\]\((\.\./)+.+/pasted-[0-9_-]+\.png\)$`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Editor{
+				fs:          fileservice.New(),
+				config:      &config.Config{AttachmentsFolder: tt.folder},
+				currentFile: storage.NewFileURI(filepath.Join(dir, "my note.md")),
+			}
+			first, err := e.saveAttachment([]byte("one"), pastedImagePrefix, ".png")
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, err := e.saveAttachment([]byte("two"), pastedImagePrefix, ".png")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if first == second {
+				t.Errorf("both images were linked as %s", first)
+			}
+			for _, link := range []string{first, second} {
+				if !regexp.MustCompile(tt.wantLink).MatchString(link) {
+					t.Errorf("link = %s, want a match for %s", link, tt.wantLink)
+				}
+			}
+
+			entries, err := os.ReadDir(tt.wantDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var saved []string
+			for _, entry := range entries {
+				data, err := os.ReadFile(filepath.Join(tt.wantDir, entry.Name()))
+				if err != nil {
+					t.Fatal(err)
+				}
+				saved = append(saved, string(data))
+			}
+			sort.Strings(saved)
+			if strings.Join(saved, ",") != "one,two" {
+				t.Errorf("saved files = %v, want both images", saved)
+			}
+		})
+	}
+}
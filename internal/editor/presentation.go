@@ -0,0 +1,41 @@
+package editor
+
+import (
+	"markdown-editor/internal/app"
+	"markdown-editor/internal/slides"
+	"markdown-editor/internal/ui/presentationcomponent"
+	"path/filepath"
+)
+
+func (e *Editor) startPresentation() {
+	deck := slides.Split(e.editComponent.Content())
+	if len(deck) == 0 {
+		app.ShowErrorNotification("Error Presenting", "The note has no slides to show.", slides.ErrNoSlides)
+		return
+	}
+
+	start := 0
+	line := e.editComponent.CursorLine()
+	for i, slide := range deck {
+		if slide.Line <= line {
+			start = i
+		}
+	}
+
+	baseDir := ""
+	if e.currentFile != nil {
+		baseDir = filepath.Dir(e.currentFile.Path())
+	}
+	if e.presentation != nil {
+		e.presentation.Close()
+	}
+	presentation := presentationcomponent.NewPresentationComponent(deck, baseDir, e.diagrams)
+	presentation.OnClosed = func() {
+		if e.presentation == presentation {
+			e.presentation = nil
+		}
+		e.window.RequestFocus()
+	}
+	e.presentation = presentation
+	presentation.Show(start)
+}
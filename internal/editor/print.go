@@ -0,0 +1,232 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"markdown-editor/internal/app"
+	"markdown-editor/internal/export"
+	"markdown-editor/internal/printer"
+	"markdown-editor/internal/ui/previewcomponent"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	previewDPI        = 60
+	previewPageWidth  = 420
+	defaultPrinterTag = " (default)"
+)
+
+type printPreview struct {
+	editor     *Editor
+	content    string
+	setup      *pageSetup
+	printers   *widget.Select
+	copies     *widget.Entry
+	status     *widget.Label
+	pages      *fyne.Container
+	generation int
+}
+
+func (e *Editor) printNote() {
+	if e.currentFile == nil {
+		app.ShowErrorNotification("Error Printing", "Open a note before printing it.", ErrEditorNoNoteOpen)
+		return
+	}
+
+	p := &printPreview{
+		editor:  e,
+		content: e.editComponent.Content(),
+		setup:   newPageSetup(),
+		copies:  widget.NewEntry(),
+		status:  widget.NewLabel(""),
+		pages:   container.NewVBox(),
+	}
+	p.copies.SetText("1")
+	p.copies.Validator = func(text string) error {
+		if copies, err := strconv.Atoi(strings.TrimSpace(text)); err != nil || copies < 1 || copies > 99 {
+			return ErrEditorInvalidCopies
+		}
+		return nil
+	}
+	p.status.Wrapping = fyne.TextWrapWord
+
+	items := p.setup.items()
+	confirm := "Save PDF..."
+	if printer.Available() {
+		confirm = "Print"
+		p.printers = p.destinationSelect()
+		items = append([]*widget.FormItem{widget.NewFormItem("Printer", p.printers)}, items...)
+		items = append(items, widget.NewFormItem("Copies", p.copies))
+	}
+	p.setup.size.OnChanged = func(string) { p.render() }
+	p.setup.margin.OnChanged = func(string) { p.render() }
+
+	content := container.NewBorder(
+		container.NewVBox(widget.NewForm(items...), p.status, widget.NewSeparator()),
+		nil, nil, nil,
+		container.NewVScroll(container.NewCenter(p.pages)),
+	)
+	d := dialog.NewCustomConfirm("Print "+e.currentFile.Name(), confirm, "Cancel", content, func(ok bool) {
+		if ok {
+			p.submit()
+		}
+	}, e.window)
+	d.Resize(fyne.NewSize(previewPageWidth+160, 720))
+	d.Show()
+	p.render()
+}
+
+func (p *printPreview) destinationSelect() *widget.Select {
+	printers := widget.NewSelect(nil, nil)
+	printers.PlaceHolder = "Loading printers..."
+	printers.Disable()
+
+	go func() {
+		destinations, err := printer.Destinations()
+		fyne.Do(func() {
+			p.showDestinations(printers, destinations, err)
+		})
+	}()
+	return printers
+}
+
+func (p *printPreview) showDestinations(printers *widget.Select, destinations []printer.Destination, err error) {
+	if err != nil {
+		app.ShowErrorNotification("Error Printing", "Could not list the available printers; the default printer will be used.", err)
+	}
+	names := make([]string, 0, len(destinations))
+	selected := ""
+	for _, d := range destinations {
+		name := d.Name
+		if d.Default {
+			name += defaultPrinterTag
+			selected = name
+		}
+		names = append(names, name)
+	}
+	printers.PlaceHolder = "System default"
+	printers.SetOptions(names)
+	printers.Enable()
+	if selected == "" && len(names) > 0 {
+		selected = names[0]
+	}
+	if selected != "" {
+		printers.SetSelected(selected)
+	}
+}
+
+func (p *printPreview) options() (export.Options, bool) {
+	if p.setup.margin.Validate() != nil {
+		return export.Options{}, false
+	}
+	opts := p.editor.exportOptions()
+	p.setup.apply(&opts)
+	return opts, true
+}
+
+func (p *printPreview) render() {
+	opts, ok := p.options()
+	if !ok {
+		return
+	}
+	p.generation++
+	generation := p.generation
+	p.status.SetText("Rendering preview...")
+
+	go func() {
+		data, err := export.PDF(p.content, opts)
+		var pages []image.Image
+		if err == nil {
+			pages, err = printer.Preview(data, previewDPI)
+		}
+		fyne.Do(func() {
+			if generation != p.generation {
+				return
+			}
+			p.showPages(data, pages, err)
+		})
+	}()
+}
+
+func (p *printPreview) showPages(data []byte, pages []image.Image, err error) {
+	p.pages.RemoveAll()
+	switch {
+	case errors.Is(err, printer.ErrPreviewUnavailable):
+		p.status.SetText(fmt.Sprintf("%s. Page previews need pdftoppm from poppler-utils; the note is shown unpaginated.", pageCount(printer.PageCount(data))))
+		preview := previewcomponent.NewPreviewComponent()
+		preview.SetBaseDir(filepath.Dir(p.editor.currentFile.Path()))
+		preview.SetDiagramRenderer(p.editor.diagrams)
+		preview.Update(p.content)
+		p.pages.Add(container.NewGridWrap(fyne.NewSize(previewPageWidth, previewPageWidth*1.4), preview.View()))
+	case err != nil:
+		p.status.SetText("Could not render the preview: " + err.Error())
+	default:
+		p.status.SetText(pageCount(len(pages)) + ".")
+		for i, page := range pages {
+			bounds := page.Bounds()
+			img := canvas.NewImageFromImage(page)
+			img.FillMode = canvas.ImageFillContain
+			img.SetMinSize(fyne.NewSize(previewPageWidth, previewPageWidth*float32(bounds.Dy())/float32(bounds.Dx())))
+			p.pages.Add(img)
+			p.pages.Add(widget.NewLabelWithStyle(fmt.Sprintf("Page %d", i+1), fyne.TextAlignCenter, fyne.TextStyle{Italic: true}))
+		}
+	}
+	if !printer.Available() {
+		p.status.SetText(p.status.Text + " Printing needs lp from CUPS; save the PDF and print it from another app.")
+	}
+}
+
+func pageCount(n int) string {
+	if n == 1 {
+		return "1 page"
+	}
+	return fmt.Sprintf("%d pages", n)
+}
+
+func (p *printPreview) submit() {
+	opts, ok := p.options()
+	if !ok {
+		app.ShowErrorNotification("Error Printing", "Check the page setup.", ErrEditorInvalidMargin)
+		return
+	}
+	data, err := export.PDF(p.content, opts)
+	if err != nil {
+		app.ShowErrorNotification("Error Printing", "Could not render the note as PDF.", err)
+		return
+	}
+	if !printer.Available() {
+		p.editor.saveNoteExport(data, ".pdf")
+		return
+	}
+	if err := p.copies.Validate(); err != nil {
+		app.ShowErrorNotification("Error Printing", "Check the number of copies.", err)
+		return
+	}
+
+	copies, _ := strconv.Atoi(strings.TrimSpace(p.copies.Text))
+	job := printer.Job{
+		Printer: strings.TrimSuffix(p.printers.Selected, defaultPrinterTag),
+		Title:   p.editor.currentFile.Name(),
+		Copies:  copies,
+		Media:   string(opts.PageSize),
+	}
+	request, err := printer.Print(data, job)
+	if err != nil {
+		app.ShowErrorNotification("Error Printing", fmt.Sprintf("Could not print '%s'.", job.Title), err)
+		return
+	}
+	message := fmt.Sprintf("'%s' was sent to the print queue.", job.Title)
+	if request != "" {
+		message = fmt.Sprintf("'%s' was sent to the print queue as %s.", job.Title, request)
+	}
+	app.ShowSuccessNotification("Printing", message)
+}
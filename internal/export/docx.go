@@ -0,0 +1,456 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	extast "github.com/yuin/goldmark/extension/ast"
+)
+
+const (
+	docxEMUPerPixel = 9525
+	docxIndentTwips = 720
+	docxMonoFont    = "Consolas"
+	docxBodyFont    = "Calibri"
+	docxBulletChars = "•◦▪"
+)
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Default Extension="png" ContentType="image/png"/>
+<Default Extension="jpg" ContentType="image/jpeg"/>
+<Default Extension="gif" ContentType="image/gif"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>
+<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>
+</Types>
+`
+
+const docxPackageRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+</Relationships>
+`
+
+const docxCore = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+<dc:title>%s</dc:title>
+<dc:creator>markdown-editor</dc:creator>
+<dcterms:created xsi:type="dcterms:W3CDTF">%s</dcterms:created>
+<dcterms:modified xsi:type="dcterms:W3CDTF">%s</dcterms:modified>
+</cp:coreProperties>
+`
+
+const docxStylesHead = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults>
+<w:rPrDefault><w:rPr><w:rFonts w:ascii="` + docxBodyFont + `" w:hAnsi="` + docxBodyFont + `" w:eastAsia="` + docxBodyFont + `" w:cs="` + docxBodyFont + `"/><w:sz w:val="22"/><w:szCs w:val="22"/><w:lang w:val="en-US"/></w:rPr></w:rPrDefault>
+<w:pPrDefault><w:pPr><w:spacing w:after="160" w:line="276" w:lineRule="auto"/></w:pPr></w:pPrDefault>
+</w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>
+`
+
+const docxStylesTail = `<w:style w:type="paragraph" w:styleId="Code"><w:name w:val="Code"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:shd w:val="clear" w:color="auto" w:fill="%s"/><w:spacing w:after="0" w:line="240" w:lineRule="auto"/></w:pPr><w:rPr><w:rFonts w:ascii="` + docxMonoFont + `" w:hAnsi="` + docxMonoFont + `" w:cs="` + docxMonoFont + `"/><w:sz w:val="19"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:pBdr><w:left w:val="single" w:sz="18" w:space="8" w:color="%s"/></w:pBdr></w:pPr><w:rPr><w:color w:val="%s"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="ListParagraph"><w:name w:val="List Paragraph"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:spacing w:after="40"/><w:contextualSpacing/></w:pPr></w:style>
+<w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:rPr><w:color w:val="%s"/><w:u w:val="single"/></w:rPr></w:style>
+<w:style w:type="character" w:styleId="CodeChar"><w:name w:val="Code Char"/><w:rPr><w:rFonts w:ascii="` + docxMonoFont + `" w:hAnsi="` + docxMonoFont + `" w:cs="` + docxMonoFont + `"/><w:shd w:val="clear" w:color="auto" w:fill="%s"/></w:rPr></w:style>
+<w:style w:type="table" w:styleId="TableGrid"><w:name w:val="Table Grid"/><w:tblPr><w:tblBorders><w:top w:val="single" w:sz="4" w:color="%[6]s"/><w:left w:val="single" w:sz="4" w:color="%[6]s"/><w:bottom w:val="single" w:sz="4" w:color="%[6]s"/><w:right w:val="single" w:sz="4" w:color="%[6]s"/><w:insideH w:val="single" w:sz="4" w:color="%[6]s"/><w:insideV w:val="single" w:sz="4" w:color="%[6]s"/></w:tblBorders><w:tblCellMar><w:left w:w="100" w:type="dxa"/><w:right w:w="100" w:type="dxa"/></w:tblCellMar></w:tblPr></w:style>
+</w:styles>
+`
+
+type docxWriter struct {
+	flow      *flowDocument
+	palette   palette
+	page      string
+	body      strings.Builder
+	rels      []string
+	links     map[string]string
+	lists     []*flowList
+	numbered  map[int]bool
+	shapes    int
+	bookmarks int
+}
+
+var docxPageSizes = map[PageSize][2]int{
+	PageA4:     {11906, 16838},
+	PageA5:     {8391, 11906},
+	PageLetter: {12240, 15840},
+	PageLegal:  {12240, 20160},
+}
+
+func DOCX(content string, opts Options) ([]byte, error) {
+	pageSize, err := checkPageSize(opts.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	margin := opts.Margin
+	if margin <= 0 {
+		margin = DefaultMargin
+	}
+	size, marginTwips := docxPageSizes[pageSize], int(margin*1440/25.4)
+
+	w := &docxWriter{
+		flow:     buildFlow(content, opts),
+		palette:  palettes[ThemeLight],
+		links:    make(map[string]string),
+		numbered: make(map[int]bool),
+		page: fmt.Sprintf(`<w:sectPr><w:pgSz w:w="%d" w:h="%d"/><w:pgMar w:top="%d" w:right="%d" w:bottom="%d" w:left="%d" w:header="709" w:footer="709" w:gutter="0"/></w:sectPr>`,
+			size[0], size[1], marginTwips, marginTwips, marginTwips, marginTwips),
+	}
+	for _, img := range w.flow.images {
+		w.rels = append(w.rels, fmt.Sprintf(`<Relationship Id="rIdImage%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="media/image%d%s"/>`, img.id, img.id, img.ext))
+	}
+	for _, block := range w.flow.blocks {
+		w.block(block)
+	}
+
+	now := time.Now().UTC().Format("2006-01-02T15:04:05Z")
+	files := []zipEntry{
+		{"[Content_Types].xml", []byte(docxContentTypes)},
+		{"_rels/.rels", []byte(docxPackageRels)},
+		{"docProps/core.xml", []byte(fmt.Sprintf(docxCore, xmlText(w.flow.title), now, now))},
+		{"word/document.xml", []byte(w.document())},
+		{"word/styles.xml", []byte(w.styles())},
+		{"word/numbering.xml", []byte(w.numbering())},
+		{"word/_rels/document.xml.rels", []byte(w.relationships())},
+	}
+	for _, img := range w.flow.images {
+		files = append(files, zipEntry{fmt.Sprintf("word/media/image%d%s", img.id, img.ext), img.data})
+	}
+	return writeZip(files, false)
+}
+
+func (w *docxWriter) document() string {
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture">
+<w:body>
+` + w.body.String() + w.page + `
+</w:body>
+</w:document>
+`
+}
+
+func (w *docxWriter) styles() string {
+	var b strings.Builder
+	b.WriteString(docxStylesHead)
+	for level, size := range headingSizes {
+		fmt.Fprintf(&b, `<w:style w:type="paragraph" w:styleId="Heading%d"><w:name w:val="heading %d"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="120"/><w:outlineLvl w:val="%d"/></w:pPr><w:rPr><w:b/><w:sz w:val="%d"/></w:rPr></w:style>`+"\n",
+			level+1, level+1, level, int(size*2*flowFontSize/pdfBodySize))
+	}
+	p := w.palette
+	fmt.Fprintf(&b, docxStylesTail, hexColor(p.surface), hexColor(p.border), hexColor(p.muted), hexColor(p.link), hexColor(p.surface), hexColor(p.border))
+	return b.String()
+}
+
+func (w *docxWriter) numbering() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+`)
+	bullets := []rune(docxBulletChars)
+	for abstract, ordered := range []bool{false, true} {
+		fmt.Fprintf(&b, `<w:abstractNum w:abstractNumId="%d"><w:multiLevelType w:val="hybridMultilevel"/>`, abstract)
+		for level := 0; level < 9; level++ {
+			format, text := "bullet", string(bullets[level%len(bullets)])
+			if ordered {
+				format, text = "decimal", fmt.Sprintf("%%%d.", level+1)
+			}
+			fmt.Fprintf(&b, `<w:lvl w:ilvl="%d"><w:start w:val="1"/><w:numFmt w:val="%s"/><w:lvlText w:val="%s"/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="%d" w:hanging="360"/></w:pPr></w:lvl>`,
+				level, format, text, (level+1)*docxIndentTwips)
+		}
+		b.WriteString("</w:abstractNum>\n")
+	}
+	for _, list := range w.lists {
+		abstract := 0
+		if list.ordered {
+			abstract = 1
+		}
+		fmt.Fprintf(&b, `<w:num w:numId="%d"><w:abstractNumId w:val="%d"/><w:lvlOverride w:ilvl="%d"><w:startOverride w:val="%d"/></w:lvlOverride></w:num>`+"\n",
+			list.id, abstract, list.level, max(list.start, 1))
+	}
+	b.WriteString("</w:numbering>\n")
+	return b.String()
+}
+
+func (w *docxWriter) relationships() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rIdStyles" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+<Relationship Id="rIdNumbering" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>
+`)
+	for _, rel := range w.rels {
+		b.WriteString(rel + "\n")
+	}
+	b.WriteString("</Relationships>\n")
+	return b.String()
+}
+
+func (w *docxWriter) block(block *flowBlock) {
+	switch block.kind {
+	case flowHeading:
+		w.paragraph(fmt.Sprintf(`<w:pStyle w:val="Heading%d"/>`, min(block.level, len(headingSizes))), block.anchor, block.runs)
+	case flowRule:
+		w.paragraph(fmt.Sprintf(`<w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="%s"/></w:pBdr>`, hexColor(w.palette.border)), "", nil)
+	case flowCode:
+		for i, line := range block.lines {
+			before, after := 0, 0
+			if i == 0 {
+				before = 120
+			}
+			if i == len(block.lines)-1 {
+				after = 160
+			}
+			props := fmt.Sprintf(`<w:pStyle w:val="Code"/><w:spacing w:before="%d" w:after="%d"/>`, before, after) + w.indent(block, 0)
+			w.paragraph(props, "", line)
+		}
+	case flowTable:
+		w.table(block)
+	default:
+		props := ""
+		switch {
+		case block.list != nil && block.item:
+			if !w.numbered[block.list.id] {
+				w.numbered[block.list.id] = true
+				w.lists = append(w.lists, block.list)
+			}
+			props = fmt.Sprintf(`<w:pStyle w:val="ListParagraph"/><w:numPr><w:ilvl w:val="%d"/><w:numId w:val="%d"/></w:numPr>`, block.list.level, block.list.id)
+		case block.list != nil:
+			props = `<w:pStyle w:val="ListParagraph"/>` + w.indent(block, 0)
+		case block.quote > 0:
+			props = `<w:pStyle w:val="Quote"/>` + w.indent(block, -1)
+		}
+		if block.centered {
+			props += `<w:jc w:val="center"/>`
+		}
+		w.paragraph(props, block.anchor, block.runs)
+	}
+}
+
+func (w *docxWriter) indent(block *flowBlock, offset int) string {
+	level := block.quote + offset + listLevel(block)
+	if level <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(`<w:ind w:left="%d"/>`, level*docxIndentTwips)
+}
+
+func (w *docxWriter) paragraph(props, anchor string, runs []flowRun) {
+	w.body.WriteString("<w:p>")
+	if props != "" {
+		w.body.WriteString("<w:pPr>" + props + "</w:pPr>")
+	}
+	if anchor != "" {
+		w.bookmarks++
+		fmt.Fprintf(&w.body, `<w:bookmarkStart w:id="%d" w:name="%s"/><w:bookmarkEnd w:id="%d"/>`, w.bookmarks, bookmarkName(anchor), w.bookmarks)
+	}
+	w.runs(runs)
+	w.body.WriteString("</w:p>\n")
+}
+
+func (w *docxWriter) runs(runs []flowRun) {
+	for i := 0; i < len(runs); {
+		link := runs[i].link
+		j := i + 1
+		for j < len(runs) && runs[j].link == link {
+			j++
+		}
+		if link == "" {
+			for _, r := range runs[i:j] {
+				w.run(r)
+			}
+		} else {
+			if name, ok := strings.CutPrefix(link, "#"); ok {
+				fmt.Fprintf(&w.body, `<w:hyperlink w:anchor="%s">`, bookmarkName(name))
+			} else {
+				fmt.Fprintf(&w.body, `<w:hyperlink r:id="%s">`, w.linkID(link))
+			}
+			for _, r := range runs[i:j] {
+				w.run(r)
+			}
+			w.body.WriteString("</w:hyperlink>")
+		}
+		i = j
+	}
+}
+
+func (w *docxWriter) linkID(target string) string {
+	if id, ok := w.links[target]; ok {
+		return id
+	}
+	id := fmt.Sprintf("rIdLink%d", len(w.links)+1)
+	w.links[target] = id
+	w.rels = append(w.rels, fmt.Sprintf(`<Relationship Id="%s" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="%s" TargetMode="External"/>`, id, xmlText(target)))
+	return id
+}
+
+func (w *docxWriter) run(r flowRun) {
+	switch {
+	case r.lineBreak:
+		w.body.WriteString("<w:r><w:br/></w:r>")
+		return
+	case r.image != nil:
+		w.drawing(r.image, r.text)
+		return
+	}
+
+	var props strings.Builder
+	if r.link != "" {
+		props.WriteString(`<w:rStyle w:val="Hyperlink"/>`)
+	} else if r.code {
+		props.WriteString(`<w:rStyle w:val="CodeChar"/>`)
+	}
+	if r.code {
+		props.WriteString(`<w:rFonts w:ascii="` + docxMonoFont + `" w:hAnsi="` + docxMonoFont + `" w:cs="` + docxMonoFont + `"/>`)
+	}
+	if r.bold {
+		props.WriteString("<w:b/>")
+	}
+	if r.italic {
+		props.WriteString("<w:i/>")
+	}
+	if r.strike {
+		props.WriteString("<w:strike/>")
+	}
+	if r.color != "" {
+		fmt.Fprintf(&props, `<w:color w:val="%s"/>`, hexColor(r.color))
+	}
+	if r.super {
+		props.WriteString(`<w:vertAlign w:val="superscript"/>`)
+	}
+
+	w.body.WriteString("<w:r>")
+	if props.Len() > 0 {
+		w.body.WriteString("<w:rPr>" + props.String() + "</w:rPr>")
+	}
+	for i, part := range strings.Split(r.text, "\t") {
+		if i > 0 {
+			w.body.WriteString("<w:tab/>")
+		}
+		if part != "" {
+			fmt.Fprintf(&w.body, `<w:t xml:space="preserve">%s</w:t>`, xmlText(part))
+		}
+	}
+	w.body.WriteString("</w:r>")
+}
+
+func (w *docxWriter) drawing(img *flowImage, alt string) {
+	w.shapes++
+	cx, cy := img.width*docxEMUPerPixel, img.height*docxEMUPerPixel
+	fmt.Fprintf(&w.body, `<w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0"><wp:extent cx="%d" cy="%d"/><wp:docPr id="%d" name="Picture %d" descr="%s"/>`+
+		`<a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture"><pic:pic>`+
+		`<pic:nvPicPr><pic:cNvPr id="%d" name="image%d%s"/><pic:cNvPicPr/></pic:nvPicPr>`+
+		`<pic:blipFill><a:blip r:embed="rIdImage%d"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>`+
+		`<pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr>`+
+		`</pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r>`,
+		cx, cy, w.shapes, w.shapes, xmlText(alt), w.shapes, img.id, img.ext, img.id, cx, cy)
+}
+
+func (w *docxWriter) table(block *flowBlock) {
+	w.body.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="0" w:type="auto"/>`)
+	if level := block.quote + listLevel(block); level > 0 {
+		fmt.Fprintf(&w.body, `<w:tblInd w:w="%d" w:type="dxa"/>`, level*docxIndentTwips)
+	}
+	w.body.WriteString(`</w:tblPr><w:tblGrid>`)
+	for range block.aligns {
+		w.body.WriteString(`<w:gridCol/>`)
+	}
+	w.body.WriteString("</w:tblGrid>\n")
+	for i, row := range block.rows {
+		w.body.WriteString("<w:tr>")
+		if i == 0 {
+			w.body.WriteString(`<w:trPr><w:tblHeader/></w:trPr>`)
+		}
+		for col, cell := range row {
+			w.body.WriteString("<w:tc>")
+			if i == 0 {
+				fmt.Fprintf(&w.body, `<w:tcPr><w:shd w:val="clear" w:color="auto" w:fill="%s"/></w:tcPr>`, hexColor(w.palette.surface))
+			}
+			props := `<w:spacing w:after="0"/>`
+			switch block.aligns[col] {
+			case extast.AlignCenter:
+				props += `<w:jc w:val="center"/>`
+			case extast.AlignRight:
+				props += `<w:jc w:val="right"/>`
+			}
+			w.paragraph(props, "", cell)
+			w.body.WriteString("</w:tc>")
+		}
+		w.body.WriteString("</w:tr>\n")
+	}
+	w.body.WriteString("</w:tbl>\n")
+	w.paragraph(`<w:spacing w:after="0"/>`, "", nil)
+}
+
+func listLevel(block *flowBlock) int {
+	if block.list == nil {
+		return 0
+	}
+	return block.list.level + 1
+}
+
+func bookmarkName(anchor string) string {
+	var b strings.Builder
+	b.WriteString("_")
+	for _, r := range anchor {
+		if r < 0x80 && (r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	name := b.String()
+	if len(name) > 40 {
+		name = name[:40]
+	}
+	return name
+}
+
+func hexColor(color string) string {
+	return strings.ToUpper(strings.TrimPrefix(color, "#"))
+}
+
+func xmlText(s string) string {
+	return html.EscapeString(strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, s))
+}
+
+type zipEntry struct {
+	name string
+	data []byte
+}
+
+func writeZip(files []zipEntry, storeFirst bool) ([]byte, error) {
+	var out bytes.Buffer
+	zw := zip.NewWriter(&out)
+	for i, f := range files {
+		header := &zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: time.Now()}
+		if storeFirst && i == 0 {
+			header.Method = zip.Store
+		}
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrExportRender, err)
+		}
+		if _, err := fw.Write(f.data); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrExportRender, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExportRender, err)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,294 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const officeNote = `# Report
+
+1. first
+2. second
+   - nested
+3. third
+
+Then:
+
+5. five
+6. six
+
+| Left | Center | Right |
+| :-- | :-: | --: |
+| a | b | c |
+
+![chart](chart.png)
+
+See [Go](https://go.dev), [Go again](https://go.dev), [mail](mailto:a@b.c) and [the top](#report). Tom & Jerry <3
+`
+
+type xmlElement struct {
+	name  string
+	attrs map[string]string
+}
+
+func unzipParts(t *testing.T, data []byte) ([]*zip.File, map[string][]byte) {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := make(map[string][]byte, len(zr.File))
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name], err = io.ReadAll(rc)
+		if closeErr := rc.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return zr.File, parts
+}
+
+func parseXML(t *testing.T, name string, data []byte) []xmlElement {
+	t.Helper()
+	var elements []xmlElement
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return elements
+		}
+		if err != nil {
+			t.Fatalf("%s is not well-formed: %v", name, err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if tok.Name.Space != "" && !strings.Contains(tok.Name.Space, ":") {
+				t.Errorf("%s: element %s:%s uses an undeclared namespace prefix", name, tok.Name.Space, tok.Name.Local)
+			}
+			attrs := make(map[string]string, len(tok.Attr))
+			for _, attr := range tok.Attr {
+				attrs[attr.Name.Local] = attr.Value
+			}
+			elements = append(elements, xmlElement{name: tok.Name.Local, attrs: attrs})
+		case xml.EndElement:
+			elements = append(elements, xmlElement{name: "/" + tok.Name.Local})
+		}
+	}
+}
+
+func parseXMLParts(t *testing.T, parts map[string][]byte) map[string][]xmlElement {
+	t.Helper()
+	parsed := make(map[string][]xmlElement)
+	for name, data := range parts {
+		if ext := path.Ext(name); ext == ".xml" || ext == ".rels" {
+			parsed[name] = parseXML(t, name, data)
+		}
+	}
+	return parsed
+}
+
+func elementsNamed(elements []xmlElement, name string) []xmlElement {
+	var matches []xmlElement
+	for _, e := range elements {
+		if e.name == name {
+			matches = append(matches, e)
+		}
+	}
+	return matches
+}
+
+func officeNoteDir(t *testing.T) (string, []byte) {
+	t.Helper()
+	chart := pngData(t)
+	return writeNotes(t, map[string]string{"chart.png": string(chart)}), chart
+}
+
+func TestDOCX(t *testing.T) {
+	dir, chart := officeNoteDir(t)
+	data, err := DOCX(officeNote, Options{BaseDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, parts := unzipParts(t, data)
+	xmlParts := parseXMLParts(t, parts)
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "docProps/core.xml", "word/document.xml", "word/styles.xml", "word/numbering.xml", "word/_rels/document.xml.rels"} {
+		if _, ok := xmlParts[name]; !ok {
+			t.Errorf("package has no %s", name)
+		}
+	}
+	document := xmlParts["word/document.xml"]
+
+	t.Run("content types", func(t *testing.T) {
+		types := make(map[string]bool)
+		for _, e := range xmlParts["[Content_Types].xml"] {
+			switch e.name {
+			case "Default":
+				types[e.attrs["Extension"]] = true
+			case "Override":
+				types[e.attrs["PartName"]] = true
+			}
+		}
+		for name := range parts {
+			if name != "[Content_Types].xml" && !types["/"+name] && !types[strings.TrimPrefix(path.Ext(name), ".")] {
+				t.Errorf("%s has no content type", name)
+			}
+		}
+	})
+
+	rels := make(map[string]xmlElement)
+	for _, e := range elementsNamed(xmlParts["word/_rels/document.xml.rels"], "Relationship") {
+		rels[e.attrs["Id"]] = e
+	}
+
+	t.Run("list numbering", func(t *testing.T) {
+		type num struct{ abstract, level, start string }
+		nums := make(map[string]num)
+		var current string
+		for _, e := range xmlParts["word/numbering.xml"] {
+			switch e.name {
+			case "num":
+				current = e.attrs["numId"]
+			case "abstractNumId":
+				nums[current] = num{abstract: e.attrs["val"]}
+			case "lvlOverride":
+				n := nums[current]
+				n.level = e.attrs["ilvl"]
+				nums[current] = n
+			case "startOverride":
+				n := nums[current]
+				n.start = e.attrs["val"]
+				nums[current] = n
+			}
+		}
+
+		type item struct {
+			level, start string
+			ordered      bool
+		}
+		var items []item
+		var level string
+		for _, e := range document {
+			switch e.name {
+			case "ilvl":
+				level = e.attrs["val"]
+			case "numId":
+				n, ok := nums[e.attrs["val"]]
+				if !ok {
+					t.Fatalf("paragraph uses numbering %s, which is not defined", e.attrs["val"])
+				}
+				if n.level != level {
+					t.Errorf("numbering %s overrides level %s, paragraph is at level %s", e.attrs["val"], n.level, level)
+				}
+				items = append(items, item{level: level, start: n.start, ordered: n.abstract == "1"})
+			}
+		}
+		want := []item{
+			{level: "0", start: "1", ordered: true},
+			{level: "0", start: "1", ordered: true},
+			{level: "1", start: "1"},
+			{level: "0", start: "1", ordered: true},
+			{level: "0", start: "5", ordered: true},
+			{level: "0", start: "5", ordered: true},
+		}
+		if !reflect.DeepEqual(items, want) {
+			t.Errorf("list items = %+v, want %+v", items, want)
+		}
+	})
+
+	t.Run("table alignment", func(t *testing.T) {
+		var aligns []string
+		inCell := false
+		for _, e := range document {
+			switch e.name {
+			case "tc":
+				inCell = true
+				aligns = append(aligns, "")
+			case "/tc":
+				inCell = false
+			case "jc":
+				if inCell {
+					aligns[len(aligns)-1] = e.attrs["val"]
+				}
+			}
+		}
+		want := []string{"", "center", "right", "", "center", "right"}
+		if !reflect.DeepEqual(aligns, want) {
+			t.Errorf("cell alignment = %q, want %q", aligns, want)
+		}
+	})
+
+	t.Run("image relationships", func(t *testing.T) {
+		blips := elementsNamed(document, "blip")
+		if len(blips) != 1 {
+			t.Fatalf("document has %d images, want 1", len(blips))
+		}
+		rel, ok := rels[blips[0].attrs["embed"]]
+		if !ok {
+			t.Fatalf("image uses relationship %q, which is not defined", blips[0].attrs["embed"])
+		}
+		if !strings.HasSuffix(rel.attrs["Type"], "/relationships/image") {
+			t.Errorf("image relationship type = %s", rel.attrs["Type"])
+		}
+		media, ok := parts[path.Join("word", rel.attrs["Target"])]
+		if !ok {
+			t.Fatalf("image relationship targets %s, which is not in the package", rel.attrs["Target"])
+		}
+		if !bytes.Equal(media, chart) {
+			t.Errorf("%s differs from the source image", rel.attrs["Target"])
+		}
+	})
+
+	t.Run("hyperlink relationships", func(t *testing.T) {
+		bookmarks := make(map[string]bool)
+		for _, e := range elementsNamed(document, "bookmarkStart") {
+			bookmarks[e.attrs["name"]] = true
+		}
+		var targets []string
+		for _, e := range elementsNamed(document, "hyperlink") {
+			if anchor, ok := e.attrs["anchor"]; ok {
+				if !bookmarks[anchor] {
+					t.Errorf("hyperlink points at bookmark %q, which is not defined", anchor)
+				}
+				targets = append(targets, "#"+anchor)
+				continue
+			}
+			rel, ok := rels[e.attrs["id"]]
+			if !ok {
+				t.Fatalf("hyperlink uses relationship %q, which is not defined", e.attrs["id"])
+			}
+			if !strings.HasSuffix(rel.attrs["Type"], "/relationships/hyperlink") || rel.attrs["TargetMode"] != "External" {
+				t.Errorf("hyperlink relationship = %v, want an external hyperlink", rel.attrs)
+			}
+			targets = append(targets, rel.attrs["Target"])
+		}
+		want := []string{"https://go.dev", "https://go.dev", "mailto:a@b.c", "#" + bookmarkName("report")}
+		if !reflect.DeepEqual(targets, want) {
+			t.Errorf("hyperlink targets = %q, want %q", targets, want)
+		}
+
+		var linkRels []string
+		for id, rel := range rels {
+			if strings.HasSuffix(rel.attrs["Type"], "/relationships/hyperlink") {
+				linkRels = append(linkRels, id)
+			}
+		}
+		sort.Strings(linkRels)
+		if want := []string{"rIdLink1", "rIdLink2"}; !reflect.DeepEqual(linkRels, want) {
+			t.Errorf("hyperlink relationships = %q, want %q", linkRels, want)
+		}
+	})
+}
@@ -0,0 +1,758 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"markdown-editor/internal/frontmatter"
+	"markdown-editor/internal/markdown"
+	"markdown-editor/internal/mathtex"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/yuin/goldmark/ast"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+const (
+	SummaryFile = "SUMMARY.md"
+
+	epubMimetype  = "application/epub+zip"
+	epubOPFType   = "application/oebps-package+xml"
+	epubXHTMLType = "application/xhtml+xml"
+	epubRoot      = "OEBPS"
+	epubNavFile   = "nav.xhtml"
+	epubCSSFile   = "style.css"
+	epubTextDir   = "text"
+	epubImageDir  = "images"
+)
+
+var (
+	ErrEPUBNoChapters    = errors.New("export: folder contains no markdown notes")
+	ErrEPUBRead          = errors.New("export: failed to read note")
+	ErrEPUBInvalid       = errors.New("export: invalid EPUB")
+	ErrEPUBMissingImages = errors.New("export: images left out of the EPUB")
+)
+
+type epubEntry struct {
+	title    string
+	path     string
+	children []*epubEntry
+}
+
+type epubChapter struct {
+	id    string
+	path  string
+	file  string
+	title string
+	body  []byte
+}
+
+type epubResource struct {
+	id        string
+	file      string
+	mediaType string
+	data      []byte
+}
+
+type epubBook struct {
+	dir       string
+	opts      Options
+	theme     Theme
+	title     string
+	toc       []*epubEntry
+	chapters  []*epubChapter
+	byPath    map[string]*epubChapter
+	resources []*epubResource
+	bySource  map[string]*epubResource
+	missing   []string
+}
+
+var chapterTemplate = template.Must(template.New("chapter").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="en" xml:lang="en">
+<head>
+<meta charset="utf-8" />
+<title>{{html .Title}}</title>
+<link rel="stylesheet" type="text/css" href="../{{.CSS}}" />
+</head>
+<body>
+<main>
+<section epub:type="chapter">
+{{.Body}}</section>
+</main>
+</body>
+</html>
+`))
+
+var navTemplate = template.Must(template.New("nav").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="en" xml:lang="en">
+<head>
+<meta charset="utf-8" />
+<title>{{html .Title}}</title>
+<link rel="stylesheet" type="text/css" href="{{.CSS}}" />
+</head>
+<body>
+<main>
+<nav epub:type="toc" id="toc">
+<h1>{{html .Title}}</h1>
+{{.List}}</nav>
+</main>
+</body>
+</html>
+`))
+
+var opfTemplate = template.Must(template.New("opf").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="en">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:identifier id="book-id">{{.ID}}</dc:identifier>
+<dc:title>{{html .Title}}</dc:title>
+<dc:language>en</dc:language>
+<dc:creator>markdown-editor</dc:creator>
+<meta property="dcterms:modified">{{.Modified}}</meta>
+</metadata>
+<manifest>
+<item id="nav" href="{{.Nav}}" media-type="application/xhtml+xml" properties="nav" />
+<item id="style" href="{{.CSS}}" media-type="text/css" />
+{{range .Chapters}}<item id="{{.ID}}" href="{{.Href}}" media-type="application/xhtml+xml" />
+{{end}}{{range .Resources}}<item id="{{.ID}}" href="{{.Href}}" media-type="{{.MediaType}}" />
+{{end}}</manifest>
+<spine>
+{{range .Chapters}}<itemref idref="{{.ID}}" />
+{{end}}</spine>
+</package>
+`))
+
+const containerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles>
+<rootfile full-path="` + epubRoot + `/content.opf" media-type="` + epubOPFType + `" />
+</rootfiles>
+</container>
+`
+
+type opfItem struct {
+	ID        string
+	Href      string
+	MediaType string
+}
+
+func EPUB(dir string, opts Options) ([]byte, error) {
+	theme, err := checkTheme(opts.Theme)
+	if err != nil {
+		return nil, err
+	}
+	book := &epubBook{
+		dir:      dir,
+		opts:     opts,
+		theme:    theme,
+		byPath:   make(map[string]*epubChapter),
+		bySource: make(map[string]*epubResource),
+	}
+	if err := book.collect(); err != nil {
+		return nil, err
+	}
+	if len(book.chapters) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrEPUBNoChapters, dir)
+	}
+	for _, ch := range book.chapters {
+		if err := book.render(ch); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := book.write()
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateEPUB(data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExportRender, err)
+	}
+	if len(book.missing) > 0 {
+		return data, fmt.Errorf("%w: %s", ErrEPUBMissingImages, strings.Join(book.missing, ", "))
+	}
+	return data, nil
+}
+
+func (b *epubBook) collect() error {
+	b.title = strings.TrimSpace(b.opts.Title)
+	summary, err := os.ReadFile(filepath.Join(b.dir, SummaryFile))
+	switch {
+	case err == nil:
+		b.toc = b.parseSummary(summary)
+		if b.title == "" {
+			b.title = documentTitle(string(summary), "", nil)
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		if err := b.listNotes(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: %s: %v", ErrEPUBRead, SummaryFile, err)
+	}
+	if b.title == "" || b.title == defaultTitle {
+		b.title = filepath.Base(filepath.Clean(b.dir))
+	}
+	return nil
+}
+
+func (b *epubBook) parseSummary(source []byte) []*epubEntry {
+	body := []byte(frontmatter.Split(string(source)).Body)
+	root := markdown.Parse(body)
+	var entries []*epubEntry
+	for n := root.FirstChild(); n != nil; n = n.NextSibling() {
+		if list, ok := n.(*ast.List); ok {
+			entries = append(entries, b.summaryList(list, body)...)
+		}
+	}
+	return entries
+}
+
+func (b *epubBook) summaryList(list *ast.List, source []byte) []*epubEntry {
+	var entries []*epubEntry
+	for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+		entry := &epubEntry{}
+		for child := item.FirstChild(); child != nil; child = child.NextSibling() {
+			if nested, ok := child.(*ast.List); ok {
+				entry.children = append(entry.children, b.summaryList(nested, source)...)
+				continue
+			}
+			if entry.title == "" {
+				entry.title = markdown.PlainText(child, source)
+				entry.path = b.summaryLink(child, source)
+			}
+		}
+		if entry.path == "" && len(entry.children) == 0 {
+			continue
+		}
+		if entry.path != "" {
+			b.addChapter(entry.path, entry.title)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func (b *epubBook) summaryLink(n ast.Node, source []byte) string {
+	var rel string
+	_ = ast.Walk(n, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		link, ok := node.(*ast.Link)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		if path, ok := markdown.ResolveLink(b.dir, string(link.Destination)); ok && isNote(path) {
+			if r, err := filepath.Rel(b.dir, path); err == nil && !strings.HasPrefix(r, "..") {
+				rel = filepath.ToSlash(r)
+				return ast.WalkStop, nil
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return rel
+}
+
+func (b *epubBook) listNotes() error {
+	var paths []string
+	err := filepath.WalkDir(b.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if (strings.HasPrefix(d.Name(), ".") || b.opts.excludes(p)) && p != b.dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && isNote(p) {
+			rel, err := filepath.Rel(b.dir, p)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrEPUBRead, err)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		ch := b.addChapter(p, "")
+		b.toc = append(b.toc, &epubEntry{path: ch.path})
+	}
+	return nil
+}
+
+func isNote(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".md")
+}
+
+func (b *epubBook) addChapter(rel, title string) *epubChapter {
+	if ch, ok := b.byPath[rel]; ok {
+		return ch
+	}
+	n := len(b.chapters) + 1
+	ch := &epubChapter{
+		id:    fmt.Sprintf("chapter-%03d", n),
+		path:  rel,
+		file:  fmt.Sprintf("%s/chapter-%03d.xhtml", epubTextDir, n),
+		title: strings.TrimSpace(title),
+	}
+	b.chapters = append(b.chapters, ch)
+	b.byPath[rel] = ch
+	return ch
+}
+
+func (b *epubBook) render(ch *epubChapter) error {
+	notePath := filepath.Join(b.dir, filepath.FromSlash(ch.path))
+	content, err := os.ReadFile(notePath)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrEPUBRead, ch.path, err)
+	}
+
+	doc := parse(string(content), Options{Title: ch.title})
+	if doc.title == defaultTitle {
+		doc.title = strings.TrimSuffix(path.Base(ch.path), path.Ext(ch.path))
+	}
+	ch.title = doc.title
+	b.rewriteLinks(doc.root, ch.path, filepath.Dir(notePath))
+
+	body, err := renderNodes(doc, &htmlNodes{
+		palette:  palettes[b.theme],
+		diagrams: b.opts.Diagrams,
+		math:     mathtex.NewRenderer(),
+		embed:    b.embed,
+		xhtml:    true,
+	}, gmhtml.WithXHTML())
+	if err != nil {
+		return err
+	}
+
+	var page bytes.Buffer
+	if err := chapterTemplate.Execute(&page, map[string]any{
+		"Title": ch.title,
+		"CSS":   epubCSSFile,
+		"Body":  string(body),
+	}); err != nil {
+		return fmt.Errorf("%w: %v", ErrExportRender, err)
+	}
+	ch.body = page.Bytes()
+	return nil
+}
+
+func (b *epubBook) rewriteLinks(root ast.Node, from, baseDir string) {
+	var unresolved []ast.Node
+	_ = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Image:
+			if _, ok := markdown.ResolveLink(baseDir, string(node.Destination)); !ok {
+				return ast.WalkContinue, nil
+			}
+			if file, ok := b.image(baseDir, string(node.Destination)); ok {
+				node.Destination = []byte("../" + file)
+			} else {
+				b.missing = append(b.missing, from+": "+string(node.Destination))
+				unresolved = append(unresolved, node)
+			}
+		case *ast.Link:
+			dest := string(node.Destination)
+			target, ok := markdown.ResolveLink(baseDir, dest)
+			if !ok {
+				return ast.WalkContinue, nil
+			}
+			if ch, ok := b.chapterFor(target); ok {
+				fragment := ""
+				if i := strings.Index(dest, "#"); i >= 0 {
+					fragment = dest[i:]
+				}
+				node.Destination = []byte(path.Base(ch.file) + fragment)
+			} else {
+				unresolved = append(unresolved, node)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+
+	unwrapNodes(unresolved)
+}
+
+func unwrapNodes(nodes []ast.Node) {
+	for _, n := range nodes {
+		parent := n.Parent()
+		for child := n.FirstChild(); child != nil; child = n.FirstChild() {
+			parent.InsertBefore(parent, n, child)
+		}
+		parent.RemoveChild(parent, n)
+	}
+}
+
+func (b *epubBook) chapterFor(target string) (*epubChapter, bool) {
+	rel, err := filepath.Rel(b.dir, target)
+	if err != nil {
+		return nil, false
+	}
+	ch, ok := b.byPath[filepath.ToSlash(rel)]
+	return ch, ok
+}
+
+func (b *epubBook) image(baseDir, dest string) (string, bool) {
+	source, ok := markdown.ResolveLink(baseDir, dest)
+	if !ok {
+		return "", false
+	}
+	if res, ok := b.bySource[source]; ok {
+		return res.file, true
+	}
+	mediaType, ok := imageTypes[strings.ToLower(filepath.Ext(source))]
+	if !ok {
+		return "", false
+	}
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return "", false
+	}
+	res := b.addResource(mediaType, filepath.Ext(source), data)
+	b.bySource[source] = res
+	return res.file, true
+}
+
+func (b *epubBook) embed(mediaType string, data []byte) string {
+	ext := ".png"
+	if mediaType == imageTypes[".svg"] {
+		ext = ".svg"
+	}
+	return "../" + b.addResource(mediaType, ext, data).file
+}
+
+func (b *epubBook) addResource(mediaType, ext string, data []byte) *epubResource {
+	n := len(b.resources) + 1
+	res := &epubResource{
+		id:        fmt.Sprintf("image-%03d", n),
+		file:      fmt.Sprintf("%s/image-%03d%s", epubImageDir, n, strings.ToLower(ext)),
+		mediaType: mediaType,
+		data:      data,
+	}
+	b.resources = append(b.resources, res)
+	return res
+}
+
+func (b *epubBook) navList(entries []*epubEntry) string {
+	var s strings.Builder
+	s.WriteString("<ol>\n")
+	for _, entry := range entries {
+		s.WriteString("<li>")
+		title := entry.title
+		if ch, ok := b.byPath[entry.path]; ok && entry.path != "" {
+			if title == "" {
+				title = ch.title
+			}
+			fmt.Fprintf(&s, "<a href=\"%s\">%s</a>", html.EscapeString(ch.file), html.EscapeString(title))
+		} else {
+			fmt.Fprintf(&s, "<span>%s</span>", html.EscapeString(title))
+		}
+		if len(entry.children) > 0 {
+			s.WriteString("\n")
+			s.WriteString(b.navList(entry.children))
+		}
+		s.WriteString("</li>\n")
+	}
+	s.WriteString("</ol>\n")
+	return s.String()
+}
+
+func (b *epubBook) identifier() string {
+	h := sha1.New()
+	h.Write([]byte(b.title))
+	for _, ch := range b.chapters {
+		h.Write([]byte{0})
+		h.Write([]byte(ch.path))
+	}
+	sum := h.Sum(nil)
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+func (b *epubBook) write() ([]byte, error) {
+	var nav bytes.Buffer
+	if err := navTemplate.Execute(&nav, map[string]any{
+		"Title": b.title,
+		"CSS":   epubCSSFile,
+		"List":  b.navList(b.toc),
+	}); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExportRender, err)
+	}
+
+	chapters := make([]opfItem, 0, len(b.chapters))
+	for _, ch := range b.chapters {
+		chapters = append(chapters, opfItem{ID: ch.id, Href: ch.file, MediaType: epubXHTMLType})
+	}
+	resources := make([]opfItem, 0, len(b.resources))
+	for _, res := range b.resources {
+		resources = append(resources, opfItem{ID: res.id, Href: res.file, MediaType: res.mediaType})
+	}
+	var opf bytes.Buffer
+	if err := opfTemplate.Execute(&opf, map[string]any{
+		"ID":        b.identifier(),
+		"Title":     b.title,
+		"Modified":  time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		"Nav":       epubNavFile,
+		"CSS":       epubCSSFile,
+		"Chapters":  chapters,
+		"Resources": resources,
+	}); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExportRender, err)
+	}
+
+	files := []zipEntry{
+		{"mimetype", []byte(epubMimetype)},
+		{"META-INF/container.xml", []byte(containerXML)},
+		{epubRoot + "/content.opf", opf.Bytes()},
+		{epubRoot + "/" + epubNavFile, nav.Bytes()},
+		{epubRoot + "/" + epubCSSFile, []byte(stylesheet(b.theme))},
+	}
+	for _, ch := range b.chapters {
+		files = append(files, zipEntry{epubRoot + "/" + ch.file, ch.body})
+	}
+	for _, res := range b.resources {
+		files = append(files, zipEntry{epubRoot + "/" + res.file, res.data})
+	}
+	return writeZip(files, true)
+}
+
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath  string `xml:"full-path,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type epubPackage struct {
+	Version          string `xml:"version,attr"`
+	UniqueIdentifier string `xml:"unique-identifier,attr"`
+	Metadata         struct {
+		Identifiers []struct {
+			ID    string `xml:"id,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"identifier"`
+		Titles    []string `xml:"title"`
+		Languages []string `xml:"language"`
+		Meta      []struct {
+			Property string `xml:"property,attr"`
+			Value    string `xml:",chardata"`
+		} `xml:"meta"`
+	} `xml:"metadata"`
+	Manifest []struct {
+		ID         string `xml:"id,attr"`
+		Href       string `xml:"href,attr"`
+		MediaType  string `xml:"media-type,attr"`
+		Properties string `xml:"properties,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+func ValidateEPUB(data []byte) error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrEPUBInvalid, fmt.Sprintf(format, args...))
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return invalid("not a zip archive: %v", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		if _, dup := files[f.Name]; dup {
+			return invalid("duplicate entry %s", f.Name)
+		}
+		files[f.Name] = f
+	}
+	if len(zr.File) == 0 || zr.File[0].Name != "mimetype" || zr.File[0].Method != zip.Store {
+		return invalid("mimetype must be the first, uncompressed entry")
+	}
+	if mimetype, err := readZipFile(zr.File[0]); err != nil || string(mimetype) != epubMimetype {
+		return invalid("mimetype must contain %s", epubMimetype)
+	}
+
+	var container epubContainer
+	if err := unmarshalZipFile(files, "META-INF/container.xml", &container); err != nil {
+		return invalid("%v", err)
+	}
+	if len(container.Rootfiles) == 0 || container.Rootfiles[0].MediaType != epubOPFType {
+		return invalid("container.xml has no package document")
+	}
+	opfPath := container.Rootfiles[0].FullPath
+	var pkg epubPackage
+	if err := unmarshalZipFile(files, opfPath, &pkg); err != nil {
+		return invalid("%v", err)
+	}
+
+	if !strings.HasPrefix(pkg.Version, "3.") {
+		return invalid("package version %q is not EPUB 3", pkg.Version)
+	}
+	hasID := false
+	for _, id := range pkg.Metadata.Identifiers {
+		hasID = hasID || (id.ID == pkg.UniqueIdentifier && strings.TrimSpace(id.Value) != "")
+	}
+	if !hasID {
+		return invalid("missing unique identifier %q", pkg.UniqueIdentifier)
+	}
+	if len(pkg.Metadata.Titles) == 0 || len(pkg.Metadata.Languages) == 0 {
+		return invalid("metadata needs a title and a language")
+	}
+	hasModified := false
+	for _, meta := range pkg.Metadata.Meta {
+		hasModified = hasModified || (meta.Property == "dcterms:modified" && strings.TrimSpace(meta.Value) != "")
+	}
+	if !hasModified {
+		return invalid("metadata needs dcterms:modified")
+	}
+
+	baseDir := path.Dir(opfPath)
+	items := make(map[string]string, len(pkg.Manifest))
+	hrefs := make(map[string]bool, len(pkg.Manifest))
+	navs := 0
+	for _, item := range pkg.Manifest {
+		if _, dup := items[item.ID]; dup || item.ID == "" {
+			return invalid("manifest id %q is missing or duplicated", item.ID)
+		}
+		name := path.Join(baseDir, item.Href)
+		if _, ok := files[name]; !ok {
+			return invalid("manifest item %s is missing from the archive", item.Href)
+		}
+		items[item.ID] = item.MediaType
+		hrefs[name] = true
+		if strings.Contains(" "+item.Properties+" ", " nav ") {
+			navs++
+		}
+	}
+	if navs != 1 {
+		return invalid("expected one navigation document, found %d", navs)
+	}
+	if len(pkg.Spine) == 0 {
+		return invalid("spine is empty")
+	}
+	for _, ref := range pkg.Spine {
+		if _, ok := items[ref.IDRef]; !ok {
+			return invalid("spine references unknown item %q", ref.IDRef)
+		}
+	}
+
+	for _, item := range pkg.Manifest {
+		if item.MediaType != epubXHTMLType {
+			continue
+		}
+		name := path.Join(baseDir, item.Href)
+		refs, err := xhtmlReferences(files[name])
+		if err != nil {
+			return invalid("%s: %v", item.Href, err)
+		}
+		for _, ref := range refs {
+			target := path.Join(path.Dir(name), ref)
+			if !hrefs[target] {
+				return invalid("%s references %s, which is not in the manifest", item.Href, ref)
+			}
+		}
+	}
+	return nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func unmarshalZipFile(files map[string]*zip.File, name string, v any) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("%s is missing", name)
+	}
+	data, err := readZipFile(f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
+
+func xhtmlReferences(f *zip.File) ([]string, error) {
+	data, err := readZipFile(f)
+	if err != nil {
+		return nil, err
+	}
+	var refs []string
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+	depth, roots := 0, 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			if roots != 1 {
+				return nil, errors.New("document must have a single html root element")
+			}
+			return refs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 && len(bytes.TrimSpace(t)) > 0 {
+				return nil, errors.New("text outside the root element")
+			}
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if depth == 0 {
+			if start.Name.Local != "html" {
+				return nil, fmt.Errorf("unexpected root element %s", start.Name.Local)
+			}
+			roots++
+		}
+		depth++
+		for _, attr := range start.Attr {
+			if attr.Name.Local != "src" && attr.Name.Local != "href" {
+				continue
+			}
+			ref := attr.Value
+			if i := strings.Index(ref, "#"); i >= 0 {
+				ref = ref[:i]
+			}
+			if ref == "" || strings.Contains(ref, ":") {
+				continue
+			}
+			if unescaped, err := url.PathUnescape(ref); err == nil {
+				ref = unescaped
+			}
+			refs = append(refs, ref)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var navLinkRegex = regexp.MustCompile(`<a href="([^"]+)">([^<]+)</a>`)
+
+func writeNotes(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestEPUB(t *testing.T) {
+	notes := map[string]string{
+		"b.md":         "# Beta\n\nSee [alpha](a.md).\n",
+		"a.md":         "# Alpha\n\n![pic](img/pic.png)\n\n![gone](img/missing.png)\n",
+		"sub/c.md":     "# Gamma\n\n![again](../img/pic.png)\n",
+		"img/pic.png":  "png-data",
+		".hidden/d.md": "# Hidden\n",
+	}
+	tests := []struct {
+		name        string
+		summary     string
+		wantNav     []string
+		wantMissing []string
+	}{
+		{
+			name:        "ordered by filename",
+			wantNav:     []string{"Alpha", "Beta", "Gamma"},
+			wantMissing: []string{"a.md: img/missing.png"},
+		},
+		{
+			name:        "ordered by summary",
+			summary:     "# Book\n\n- [Intro](b.md)\n- [Part](sub/c.md)\n  - [First](a.md)\n",
+			wantNav:     []string{"Intro", "Part", "First"},
+			wantMissing: []string{"a.md: img/missing.png"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := make(map[string]string, len(notes)+1)
+			for name, content := range notes {
+				files[name] = content
+			}
+			if tt.summary != "" {
+				files[SummaryFile] = tt.summary
+			}
+			dir := writeNotes(t, files)
+
+			data, err := EPUB(dir, Options{})
+			if !errors.Is(err, ErrEPUBMissingImages) {
+				t.Fatalf("EPUB() error = %v, want %v", err, ErrEPUBMissingImages)
+			}
+			for _, missing := range tt.wantMissing {
+				if !strings.Contains(err.Error(), missing) {
+					t.Errorf("error %q does not report %q", err, missing)
+				}
+			}
+			if strings.Contains(err.Error(), "pic.png") {
+				t.Errorf("error %q reports an image that exists", err)
+			}
+			if err := ValidateEPUB(data); err != nil {
+				t.Fatalf("ValidateEPUB() = %v", err)
+			}
+
+			zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if first := zr.File[0]; first.Name != "mimetype" || first.Method != zip.Store {
+				t.Errorf("first entry = %s (method %d), want uncompressed mimetype", first.Name, first.Method)
+			}
+			entries := make(map[string][]byte)
+			for _, f := range zr.File {
+				rc, err := f.Open()
+				if err != nil {
+					t.Fatal(err)
+				}
+				entries[f.Name], err = io.ReadAll(rc)
+				rc.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var titles []string
+			for _, match := range navLinkRegex.FindAllStringSubmatch(string(entries[epubRoot+"/"+epubNavFile]), -1) {
+				titles = append(titles, match[2])
+				if _, ok := entries[epubRoot+"/"+match[1]]; !ok {
+					t.Errorf("nav links to %s, which is not in the package", match[1])
+				}
+			}
+			if !reflect.DeepEqual(titles, tt.wantNav) {
+				t.Errorf("nav order = %v, want %v", titles, tt.wantNav)
+			}
+
+			var images []string
+			for name, content := range entries {
+				if strings.HasPrefix(name, epubRoot+"/"+epubImageDir+"/") {
+					images = append(images, name)
+					if string(content) != "png-data" {
+						t.Errorf("%s = %q, want the copied image", name, content)
+					}
+				}
+			}
+			if len(images) != 1 {
+				t.Errorf("package images = %v, want one shared copy of pic.png", images)
+			}
+			for name, content := range entries {
+				if strings.HasSuffix(name, ".xhtml") && bytes.Contains(content, []byte("missing.png")) {
+					t.Errorf("%s still references the missing image", name)
+				}
+			}
+		})
+	}
+}
+
+func TestEPUBWithoutMissingImages(t *testing.T) {
+	dir := writeNotes(t, map[string]string{"note.md": "# Note\n\n![pic](pic.png)\n", "pic.png": "png-data"})
+	data, err := EPUB(dir, Options{})
+	if err != nil {
+		t.Fatalf("EPUB() error = %v", err)
+	}
+	if err := ValidateEPUB(data); err != nil {
+		t.Errorf("ValidateEPUB() = %v", err)
+	}
+}
+
+func TestValidateEPUBRejectsCompressedMimetype(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("mimetype")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(epubMimetype)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateEPUB(buf.Bytes()); !errors.Is(err, ErrEPUBInvalid) {
+		t.Errorf("ValidateEPUB() = %v, want %v", err, ErrEPUBInvalid)
+	}
+}
@@ -0,0 +1,187 @@
+package export
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image/png"
+	"markdown-editor/internal/diagram"
+	"markdown-editor/internal/frontmatter"
+	"markdown-editor/internal/imaging"
+	"markdown-editor/internal/markdown"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+const (
+	defaultTitle = "Untitled"
+	tocMaxLevel  = 3
+)
+
+var (
+	ErrExportRender = errors.New("export: failed to render document")
+	ErrExportTheme  = errors.New("export: unknown theme")
+)
+
+type Theme string
+
+const (
+	ThemeLight Theme = "light"
+	ThemeDark  Theme = "dark"
+)
+
+func Themes() []Theme {
+	return []Theme{ThemeLight, ThemeDark}
+}
+
+type Options struct {
+	Title    string
+	BaseDir  string
+	Theme    Theme
+	TOC      bool
+	Diagrams diagram.Renderer
+	PageSize PageSize
+	Margin   float64
+	Exclude  []string
+}
+
+func (o Options) excludes(path string) bool {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for _, dir := range o.Exclude {
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(dir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+type Heading struct {
+	Level int
+	ID    string
+	Text  string
+}
+
+type document struct {
+	source   []byte
+	root     ast.Node
+	title    string
+	headings []Heading
+}
+
+var imageTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".bmp":  "image/bmp",
+	".webp": "image/webp",
+	".svg":  "image/svg+xml",
+}
+
+func parse(content string, opts Options) *document {
+	return parseWith(content, opts, markdown.Parse)
+}
+
+func parseWith(content string, opts Options, parseMarkdown func(source []byte) ast.Node) *document {
+	body := frontmatter.Split(content).Body
+	doc := &document{source: []byte(body)}
+	doc.root = parseMarkdown(doc.source)
+
+	_ = ast.Walk(doc.root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if h, ok := n.(*ast.Heading); ok && entering {
+			doc.headings = append(doc.headings, Heading{
+				Level: h.Level,
+				ID:    markdown.HeadingID(h),
+				Text:  markdown.PlainText(h, doc.source),
+			})
+		}
+		return ast.WalkContinue, nil
+	})
+
+	doc.title = documentTitle(content, opts.Title, doc.headings)
+	return doc
+}
+
+func documentTitle(content, title string, headings []Heading) string {
+	if title = strings.TrimSpace(title); title != "" {
+		return title
+	}
+	if fm, err := frontmatter.Parse(content); err == nil {
+		if value, ok := fm.Get("title"); ok {
+			if title = strings.TrimSpace(frontmatter.FormatValue(value)); title != "" {
+				return title
+			}
+		}
+	}
+	for _, h := range headings {
+		if h.Level == 1 && h.Text != "" {
+			return h.Text
+		}
+	}
+	return defaultTitle
+}
+
+func checkTheme(theme Theme) (Theme, error) {
+	switch theme {
+	case "":
+		return ThemeLight, nil
+	case ThemeLight, ThemeDark:
+		return theme, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrExportTheme, theme)
+}
+
+func inlineImages(root ast.Node, baseDir string) {
+	_ = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		img, ok := n.(*ast.Image)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		if uri, ok := imageDataURI(baseDir, string(img.Destination)); ok {
+			img.Destination = []byte(uri)
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+func imageDataURI(baseDir, dest string) (string, bool) {
+	path, ok := markdown.ResolveLink(baseDir, dest)
+	if !ok {
+		return "", false
+	}
+	mimeType, ok := imageTypes[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	if mimeType == imageTypes[".bmp"] {
+		img, err := imaging.Decode(path, data)
+		if err != nil {
+			return "", false
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return "", false
+		}
+		mimeType, data = imageTypes[".png"], buf.Bytes()
+	}
+	return dataURI(mimeType, data), true
+}
+
+func dataURI(mimeType string, data []byte) string {
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
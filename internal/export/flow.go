@@ -0,0 +1,434 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"markdown-editor/internal/diagram"
+	"markdown-editor/internal/highlight"
+	"markdown-editor/internal/imaging"
+	"markdown-editor/internal/markdown"
+	"markdown-editor/internal/mathtex"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/util"
+)
+
+const (
+	flowMaxImageWidth = 600
+	flowFontSize      = 11
+)
+
+type flowBlockKind int
+
+const (
+	flowParagraph flowBlockKind = iota
+	flowHeading
+	flowCode
+	flowTable
+	flowRule
+)
+
+type flowRun struct {
+	text      string
+	bold      bool
+	italic    bool
+	code      bool
+	strike    bool
+	super     bool
+	color     string
+	link      string
+	image     *flowImage
+	lineBreak bool
+}
+
+type flowImage struct {
+	id        int
+	ext       string
+	mediaType string
+	data      []byte
+	width     int
+	height    int
+}
+
+type flowList struct {
+	id      int
+	level   int
+	ordered bool
+	start   int
+	parent  *flowList
+}
+
+type flowBlock struct {
+	kind     flowBlockKind
+	level    int
+	anchor   string
+	runs     []flowRun
+	lines    [][]flowRun
+	rows     [][][]flowRun
+	aligns   []extast.Alignment
+	list     *flowList
+	item     bool
+	quote    int
+	centered bool
+}
+
+type flowDocument struct {
+	title  string
+	blocks []*flowBlock
+	images []*flowImage
+}
+
+type flowBuilder struct {
+	doc     *document
+	opts    Options
+	palette palette
+	math    *mathtex.Renderer
+	out     *flowDocument
+	lists   []*flowList
+	listIDs int
+	quote   int
+	images  map[string]*flowImage
+}
+
+func buildFlow(content string, opts Options) *flowDocument {
+	doc := parse(content, opts)
+	b := &flowBuilder{
+		doc:     doc,
+		opts:    opts,
+		palette: palettes[ThemeLight],
+		math:    mathtex.NewRenderer(),
+		out:     &flowDocument{title: doc.title},
+		images:  make(map[string]*flowImage),
+	}
+	b.blocks(doc.root)
+	return b.out
+}
+
+func (b *flowBuilder) add(block *flowBlock) *flowBlock {
+	block.quote = b.quote
+	if n := len(b.lists); n > 0 {
+		block.list = b.lists[n-1]
+	}
+	b.out.blocks = append(b.out.blocks, block)
+	return block
+}
+
+func (b *flowBuilder) blocks(parent ast.Node) {
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		b.block(n)
+	}
+}
+
+func (b *flowBuilder) block(n ast.Node) {
+	switch node := n.(type) {
+	case *ast.Heading:
+		b.add(&flowBlock{kind: flowHeading, level: node.Level, anchor: markdown.HeadingID(node), runs: b.inlines(node, flowRun{})})
+	case *ast.Paragraph, *ast.TextBlock:
+		b.paragraph(n)
+	case *ast.ThematicBreak:
+		b.add(&flowBlock{kind: flowRule})
+	case *ast.CodeBlock, *ast.FencedCodeBlock:
+		b.code(n)
+	case *ast.Blockquote:
+		b.quote++
+		b.blocks(node)
+		b.quote--
+	case *markdown.Callout:
+		b.quote++
+		b.add(&flowBlock{runs: []flowRun{{text: node.DisplayTitle(), bold: true, color: b.palette.callouts[node.Category()]}}})
+		b.blocks(node)
+		b.quote--
+	case *ast.List:
+		b.list(node)
+	case *extast.Table:
+		b.table(node)
+	case *extast.DefinitionList:
+		for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+			if _, ok := child.(*extast.DefinitionTerm); ok {
+				b.add(&flowBlock{runs: b.inlines(child, flowRun{bold: true})})
+				continue
+			}
+			b.quote++
+			b.blocks(child)
+			b.quote--
+		}
+	case *extast.FootnoteList:
+		b.add(&flowBlock{kind: flowRule})
+		for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+			fn, ok := child.(*extast.Footnote)
+			if !ok {
+				continue
+			}
+			runs := []flowRun{{text: fmt.Sprintf("%d. ", fn.Index)}}
+			for para := fn.FirstChild(); para != nil; para = para.NextSibling() {
+				runs = append(runs, b.inlines(para, flowRun{})...)
+			}
+			b.add(&flowBlock{anchor: fmt.Sprintf("fn:%d", fn.Index), runs: runs})
+		}
+	case *markdown.MathBlock:
+		b.add(&flowBlock{centered: true, runs: []flowRun{b.formula(markdown.Formula(node, b.doc.source), true, flowRun{})}})
+	case *ast.HTMLBlock:
+	default:
+		b.blocks(n)
+	}
+}
+
+func (b *flowBuilder) paragraph(n ast.Node) {
+	runs := b.inlines(n, flowRun{})
+	if len(runs) == 0 {
+		return
+	}
+	block := b.add(&flowBlock{runs: runs})
+	if len(runs) == 1 && runs[0].image != nil {
+		block.centered = true
+	}
+}
+
+func (b *flowBuilder) list(list *ast.List) {
+	b.listIDs++
+	l := &flowList{id: b.listIDs, level: len(b.lists), ordered: list.IsOrdered(), start: list.Start}
+	if n := len(b.lists); n > 0 {
+		l.parent = b.lists[n-1]
+	}
+	b.lists = append(b.lists, l)
+	for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+		first := len(b.out.blocks)
+		b.blocks(item)
+		for _, block := range b.out.blocks[first:] {
+			if block.list == l {
+				block.item = true
+				break
+			}
+		}
+	}
+	b.lists = b.lists[:len(b.lists)-1]
+}
+
+func (b *flowBuilder) code(n ast.Node) {
+	code := strings.TrimSuffix(markdown.BlockText(n, b.doc.source), "\n")
+	info := ""
+	if fenced, ok := n.(*ast.FencedCodeBlock); ok && fenced.Info != nil {
+		info = string(fenced.Info.Segment.Value(b.doc.source))
+	}
+	if fields := strings.Fields(info); len(fields) > 0 && b.opts.Diagrams != nil && b.opts.Diagrams.Supports(fields[0]) {
+		if data, err := b.opts.Diagrams.Render(context.Background(), fields[0], []byte(code)); err == nil {
+			if img, ok := b.image("diagram"+diagram.Extension(data), data, 1); ok {
+				b.add(&flowBlock{centered: true, runs: []flowRun{{image: img}}})
+				return
+			}
+		}
+	}
+
+	tokens, err := highlight.Tokenize(highlight.Language(info), code)
+	if err != nil {
+		tokens = []highlight.Token{{Kind: highlight.KindPlain, Text: code}}
+	}
+	lines := [][]flowRun{nil}
+	for _, token := range tokens {
+		for i, part := range strings.Split(token.Text, "\n") {
+			if i > 0 {
+				lines = append(lines, nil)
+			}
+			if part != "" {
+				last := len(lines) - 1
+				lines[last] = append(lines[last], flowRun{
+					text:   part,
+					code:   true,
+					color:  b.palette.tokens[token.Kind],
+					italic: token.Kind == highlight.KindComment,
+					bold:   token.Kind == highlight.KindKeyword || token.Kind == highlight.KindHeading,
+				})
+			}
+		}
+	}
+	b.add(&flowBlock{kind: flowCode, lines: lines})
+}
+
+func (b *flowBuilder) table(t *extast.Table) {
+	block := &flowBlock{kind: flowTable, aligns: t.Alignments}
+	for row := t.FirstChild(); row != nil; row = row.NextSibling() {
+		header := row.Kind() == extast.KindTableHeader
+		cells := make([][]flowRun, len(t.Alignments))
+		col := 0
+		for cell := row.FirstChild(); cell != nil && col < len(cells); cell = cell.NextSibling() {
+			cells[col] = b.inlines(cell, flowRun{bold: header})
+			col++
+		}
+		block.rows = append(block.rows, cells)
+	}
+	b.add(block)
+}
+
+func (b *flowBuilder) inlines(parent ast.Node, style flowRun) []flowRun {
+	var runs []flowRun
+	for child := parent.FirstChild(); child != nil; child = child.NextSibling() {
+		runs = append(runs, b.inline(child, style)...)
+	}
+	return runs
+}
+
+func (b *flowBuilder) inline(n ast.Node, style flowRun) []flowRun {
+	text := func(s string) flowRun {
+		r := style
+		r.text = s
+		return r
+	}
+	switch node := n.(type) {
+	case *ast.Text:
+		runs := []flowRun{text(textValue(node, b.doc.source))}
+		if node.HardLineBreak() {
+			runs = append(runs, flowRun{lineBreak: true})
+		} else if node.SoftLineBreak() {
+			runs = append(runs, text(" "))
+		}
+		return runs
+	case *ast.String:
+		return []flowRun{text(string(node.Value))}
+	case *ast.CodeSpan:
+		r := text(markdown.PlainText(node, b.doc.source))
+		r.code = true
+		return []flowRun{r}
+	case *ast.Emphasis:
+		if node.Level >= 2 {
+			style.bold = true
+		} else {
+			style.italic = true
+		}
+		return b.inlines(node, style)
+	case *extast.Strikethrough:
+		style.strike = true
+		return b.inlines(node, style)
+	case *ast.Link:
+		style.link = string(node.Destination)
+		return b.inlines(node, style)
+	case *ast.AutoLink:
+		r := text(string(node.Label(b.doc.source)))
+		r.link = string(node.URL(b.doc.source))
+		if node.AutoLinkType == ast.AutoLinkEmail && !strings.HasPrefix(r.link, "mailto:") {
+			r.link = "mailto:" + r.link
+		}
+		return []flowRun{r}
+	case *ast.Image:
+		alt := markdown.PlainText(node, b.doc.source)
+		if img, ok := b.localImage(string(node.Destination)); ok {
+			return []flowRun{{image: img, text: alt}}
+		}
+		if alt == "" {
+			alt = string(node.Destination)
+		}
+		r := text("[" + alt + "]")
+		r.italic = true
+		return []flowRun{r}
+	case *markdown.MathInline:
+		return []flowRun{b.formula(markdown.Formula(node, b.doc.source), node.Display, style)}
+	case *extast.TaskCheckBox:
+		if node.IsChecked {
+			return []flowRun{text("☑ ")}
+		}
+		return []flowRun{text("☐ ")}
+	case *extast.FootnoteLink:
+		return []flowRun{{text: fmt.Sprintf("%d", node.Index), super: true, link: fmt.Sprintf("#fn:%d", node.Index)}}
+	case *ast.RawHTML, *extast.FootnoteBacklink:
+		return nil
+	default:
+		return b.inlines(node, style)
+	}
+}
+
+func (b *flowBuilder) formula(formula string, display bool, style flowRun) flowRun {
+	size := float64(flowFontSize * mathOversample * 96 / 72)
+	if display {
+		size *= displayMathScale
+	}
+	rendered, err := b.math.Render(formula, mathtex.Options{Size: size, Color: parseHex(b.palette.foreground), Display: display})
+	if err == nil {
+		var data bytes.Buffer
+		if err := png.Encode(&data, rendered.Image); err == nil {
+			if img, ok := b.image("math.png", data.Bytes(), mathOversample); ok {
+				return flowRun{image: img, text: formula}
+			}
+		}
+	}
+	style.text = "$" + formula + "$"
+	style.code = true
+	style.color = b.palette.callouts["caution"]
+	return style
+}
+
+func (b *flowBuilder) localImage(dest string) (*flowImage, bool) {
+	path, ok := markdown.ResolveLink(b.opts.BaseDir, dest)
+	if !ok {
+		return nil, false
+	}
+	if img, ok := b.images[path]; ok {
+		return img, true
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	img, ok := b.image(path, data, 1)
+	if ok {
+		b.images[path] = img
+	}
+	return img, ok
+}
+
+func (b *flowBuilder) image(name string, data []byte, scale int) (*flowImage, bool) {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == ".jpeg" {
+		ext = ".jpg"
+	}
+	var cfg image.Config
+	native := false
+	switch ext {
+	case ".png", ".jpg", ".gif":
+		c, _, err := image.DecodeConfig(bytes.NewReader(data))
+		cfg, native = c, err == nil
+	}
+	if !native {
+		decoded, decodeErr := imaging.Decode(name, data)
+		if decodeErr != nil {
+			return nil, false
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, decoded); err != nil {
+			return nil, false
+		}
+		data, ext = buf.Bytes(), ".png"
+		cfg = image.Config{Width: decoded.Bounds().Dx(), Height: decoded.Bounds().Dy()}
+	}
+	if cfg.Width == 0 || cfg.Height == 0 {
+		return nil, false
+	}
+
+	width, height := cfg.Width/scale, cfg.Height/scale
+	if width > flowMaxImageWidth {
+		width, height = flowMaxImageWidth, height*flowMaxImageWidth/width
+	}
+	img := &flowImage{
+		id:        len(b.out.images) + 1,
+		ext:       ext,
+		mediaType: imageTypes[ext],
+		data:      data,
+		width:     max(width, 1),
+		height:    max(height, 1),
+	}
+	b.out.images = append(b.out.images, img)
+	return img, true
+}
+
+func textValue(t *ast.Text, source []byte) string {
+	value := t.Segment.Value(source)
+	if t.IsRaw() {
+		return string(value)
+	}
+	return string(util.ResolveEntityNames(util.ResolveNumericReferences(util.UnescapePunctuations(value))))
+}
@@ -0,0 +1,386 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"html/template"
+	"image/color"
+	"image/png"
+	"markdown-editor/internal/diagram"
+	"markdown-editor/internal/highlight"
+	"markdown-editor/internal/markdown"
+	"markdown-editor/internal/mathtex"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+const (
+	htmlFontSize     = 16
+	mathOversample   = 2
+	displayMathScale = 1.2
+)
+
+type palette struct {
+	background string
+	foreground string
+	muted      string
+	border     string
+	surface    string
+	link       string
+	tokens     map[highlight.Kind]string
+	callouts   map[string]string
+}
+
+var palettes = map[Theme]palette{
+	ThemeLight: {
+		background: "#ffffff",
+		foreground: "#1f2328",
+		muted:      "#59636e",
+		border:     "#d1d9e0",
+		surface:    "#f6f8fa",
+		link:       "#0969da",
+		tokens: map[highlight.Kind]string{
+			highlight.KindKeyword:  "#cf222e",
+			highlight.KindType:     "#8250df",
+			highlight.KindFunction: "#8250df",
+			highlight.KindString:   "#0a3069",
+			highlight.KindNumber:   "#0550ae",
+			highlight.KindComment:  "#59636e",
+			highlight.KindInserted: "#116329",
+			highlight.KindDeleted:  "#82071e",
+			highlight.KindHeading:  "#0550ae",
+		},
+		callouts: map[string]string{
+			"note":      "#0969da",
+			"tip":       "#1a7f37",
+			"important": "#8250df",
+			"warning":   "#9a6700",
+			"caution":   "#d1242f",
+		},
+	},
+	ThemeDark: {
+		background: "#0d1117",
+		foreground: "#e6edf3",
+		muted:      "#9198a1",
+		border:     "#3d444d",
+		surface:    "#151b23",
+		link:       "#4493f8",
+		tokens: map[highlight.Kind]string{
+			highlight.KindKeyword:  "#ff7b72",
+			highlight.KindType:     "#d2a8ff",
+			highlight.KindFunction: "#d2a8ff",
+			highlight.KindString:   "#a5d6ff",
+			highlight.KindNumber:   "#79c0ff",
+			highlight.KindComment:  "#9198a1",
+			highlight.KindInserted: "#3fb950",
+			highlight.KindDeleted:  "#ffa198",
+			highlight.KindHeading:  "#79c0ff",
+		},
+		callouts: map[string]string{
+			"note":      "#4493f8",
+			"tip":       "#3fb950",
+			"important": "#ab7df8",
+			"warning":   "#d29922",
+			"caution":   "#f85149",
+		},
+	},
+}
+
+const baseCSS = `*{box-sizing:border-box}
+body{margin:0;background:var(--bg);color:var(--fg);font:16px/1.6 -apple-system,"Segoe UI",Helvetica,Arial,sans-serif}
+main{max-width:860px;margin:0 auto;padding:32px 24px}
+a{color:var(--link);text-decoration:none}
+a:hover{text-decoration:underline}
+h1,h2{border-bottom:1px solid var(--border);padding-bottom:.3em}
+code,pre{font-family:ui-monospace,SFMono-Regular,Menlo,Consolas,monospace;font-size:85%}
+code{background:var(--surface);padding:.2em .4em;border-radius:4px}
+pre{background:var(--surface);padding:16px;border-radius:6px;overflow:auto;line-height:1.45}
+pre code{background:none;padding:0;font-size:100%}
+blockquote{margin:0 0 16px;padding:0 1em;color:var(--muted);border-left:4px solid var(--border)}
+table{border-collapse:collapse;margin-bottom:16px;display:block;overflow:auto}
+th,td{border:1px solid var(--border);padding:6px 13px}
+tr:nth-child(2n){background:var(--surface)}
+img{max-width:100%}
+hr{border:0;border-top:1px solid var(--border)}
+li>input[type=checkbox]{margin-right:.4em}
+.toc{background:var(--surface);border:1px solid var(--border);border-radius:6px;padding:8px 16px;margin-bottom:24px}
+.toc-title{font-weight:600;margin:8px 0}
+.toc ul{padding-left:1.2em;margin:0}
+.callout{margin:0 0 16px;padding:8px 16px;border-left:4px solid var(--accent);border-radius:4px;background:color-mix(in srgb,var(--accent) 10%,transparent)}
+.callout-title{font-weight:600;color:var(--accent);margin:4px 0}
+.math-display,.diagram{display:block;text-align:center;margin:16px 0;overflow:auto}
+.math-error{color:var(--error)}
+.footnotes{font-size:90%;color:var(--muted);border-top:1px solid var(--border);margin-top:32px}
+.footnotes hr{display:none}
+`
+
+var htmlTemplate = template.Must(template.New("html").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="generator" content="markdown-editor">
+<title>{{.Title}}</title>
+<style>
+{{.CSS}}</style>
+</head>
+<body>
+<main>
+{{.TOC}}{{.Body}}</main>
+</body>
+</html>
+`))
+
+type htmlPage struct {
+	Title string
+	CSS   template.CSS
+	TOC   template.HTML
+	Body  template.HTML
+}
+
+func HTML(content string, opts Options) ([]byte, error) {
+	theme, err := checkTheme(opts.Theme)
+	if err != nil {
+		return nil, err
+	}
+	doc := parse(content, opts)
+	body, err := renderBody(doc, opts, theme)
+	if err != nil {
+		return nil, err
+	}
+
+	page := htmlPage{
+		Title: doc.title,
+		CSS:   template.CSS(stylesheet(theme)),
+		Body:  template.HTML(body),
+	}
+	if opts.TOC {
+		page.TOC = template.HTML(tocHTML(doc.headings))
+	}
+
+	var out bytes.Buffer
+	if err := htmlTemplate.Execute(&out, page); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExportRender, err)
+	}
+	return out.Bytes(), nil
+}
+
+func HTMLFragment(content string, opts Options) ([]byte, error) {
+	theme, err := checkTheme(opts.Theme)
+	if err != nil {
+		return nil, err
+	}
+	return renderBody(parse(content, opts), opts, theme)
+}
+
+func renderBody(doc *document, opts Options, theme Theme) ([]byte, error) {
+	inlineImages(doc.root, opts.BaseDir)
+	return renderNodes(doc, &htmlNodes{
+		palette:  palettes[theme],
+		diagrams: opts.Diagrams,
+		math:     mathtex.NewRenderer(),
+		embed:    dataURI,
+	})
+}
+
+func renderNodes(doc *document, nodes *htmlNodes, rendererOpts ...renderer.Option) ([]byte, error) {
+	rendererOpts = append(rendererOpts, renderer.WithNodeRenderers(util.Prioritized(nodes, 100)))
+	md := markdown.New(goldmark.WithRendererOptions(rendererOpts...))
+
+	var body bytes.Buffer
+	if err := md.Renderer().Render(&body, doc.source, doc.root); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExportRender, err)
+	}
+	return body.Bytes(), nil
+}
+
+func stylesheet(theme Theme) string {
+	p := palettes[theme]
+	var b strings.Builder
+	fmt.Fprintf(&b, ":root{color-scheme:%s;--bg:%s;--fg:%s;--muted:%s;--border:%s;--surface:%s;--link:%s;--error:%s}\n",
+		theme, p.background, p.foreground, p.muted, p.border, p.surface, p.link, p.callouts["caution"])
+	b.WriteString(baseCSS)
+	for _, kind := range []highlight.Kind{
+		highlight.KindKeyword, highlight.KindType, highlight.KindFunction, highlight.KindString, highlight.KindNumber,
+		highlight.KindComment, highlight.KindInserted, highlight.KindDeleted, highlight.KindHeading,
+	} {
+		fmt.Fprintf(&b, ".tok-%s{color:%s}\n", kind, p.tokens[kind])
+	}
+	b.WriteString(".tok-comment{font-style:italic}\n.tok-keyword,.tok-heading{font-weight:600}\n")
+	for _, category := range []string{"note", "tip", "important", "warning", "caution"} {
+		fmt.Fprintf(&b, ".callout-%s{--accent:%s}\n", category, p.callouts[category])
+	}
+	return b.String()
+}
+
+func tocHTML(headings []Heading) string {
+	minLevel := 0
+	for _, h := range headings {
+		if h.Level <= tocMaxLevel && (minLevel == 0 || h.Level < minLevel) {
+			minLevel = h.Level
+		}
+	}
+	if minLevel == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("<nav class=\"toc\">\n<p class=\"toc-title\">Contents</p>\n")
+	depth := 0
+	for _, h := range headings {
+		if h.Level > tocMaxLevel {
+			continue
+		}
+		level := min(h.Level-minLevel+1, depth+1)
+		if level > depth {
+			b.WriteString("<ul>\n<li>")
+			depth++
+		} else {
+			b.WriteString("</li>\n")
+			for ; depth > level; depth-- {
+				b.WriteString("</ul>\n</li>\n")
+			}
+			b.WriteString("<li>")
+		}
+		fmt.Fprintf(&b, "<a href=\"#%s\">%s</a>", html.EscapeString(h.ID), html.EscapeString(h.Text))
+	}
+	for ; depth > 0; depth-- {
+		b.WriteString("</li>\n</ul>\n")
+	}
+	b.WriteString("</nav>\n")
+	return b.String()
+}
+
+type htmlNodes struct {
+	palette  palette
+	diagrams diagram.Renderer
+	math     *mathtex.Renderer
+	embed    func(mimeType string, data []byte) string
+	xhtml    bool
+}
+
+func (r *htmlNodes) closeTag() string {
+	if r.xhtml {
+		return " />"
+	}
+	return ">"
+}
+
+func (r *htmlNodes) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindCodeBlock, r.renderCode)
+	reg.Register(ast.KindFencedCodeBlock, r.renderCode)
+	reg.Register(markdown.KindMathBlock, r.renderMath)
+	reg.Register(markdown.KindMathInline, r.renderMath)
+	reg.Register(markdown.KindCallout, r.renderCallout)
+}
+
+func (r *htmlNodes) renderCode(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	code := markdown.BlockText(n, source)
+	info := ""
+	if fenced, ok := n.(*ast.FencedCodeBlock); ok && fenced.Info != nil {
+		info = string(fenced.Info.Segment.Value(source))
+	}
+	if fields := strings.Fields(info); len(fields) > 0 && r.diagrams != nil && r.diagrams.Supports(fields[0]) {
+		if data, err := r.diagrams.Render(context.Background(), fields[0], []byte(code)); err == nil {
+			mimeType := imageTypes[diagram.Extension(data)]
+			fmt.Fprintf(w, "<figure class=\"diagram\"><img src=\"%s\" alt=\"%s diagram\"%s</figure>\n", r.embed(mimeType, data), html.EscapeString(fields[0]), r.closeTag())
+			return ast.WalkSkipChildren, nil
+		}
+	}
+
+	lang := highlight.Language(info)
+	tokens, err := highlight.Tokenize(lang, code)
+	if err != nil {
+		tokens = []highlight.Token{{Kind: highlight.KindPlain, Text: code}}
+	}
+	if lang != "" {
+		fmt.Fprintf(w, "<pre class=\"code\"><code class=\"language-%s\">", html.EscapeString(lang))
+	} else {
+		_, _ = w.WriteString("<pre class=\"code\"><code>")
+	}
+	for _, token := range tokens {
+		text := html.EscapeString(token.Text)
+		if _, ok := r.palette.tokens[token.Kind]; ok {
+			fmt.Fprintf(w, "<span class=\"tok-%s\">%s</span>", token.Kind, text)
+			continue
+		}
+		_, _ = w.WriteString(text)
+	}
+	_, _ = w.WriteString("\n</code></pre>\n")
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *htmlNodes) renderMath(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	formula := markdown.Formula(n, source)
+	display := n.Type() == ast.TypeBlock
+	if inline, ok := n.(*markdown.MathInline); ok {
+		display = inline.Display
+	}
+	img, err := r.mathImage(formula, display)
+	switch {
+	case err != nil:
+		fmt.Fprintf(w, "<code class=\"math-error\" title=\"%s\">$%s$</code>", html.EscapeString(err.Error()), html.EscapeString(formula))
+	case display:
+		fmt.Fprintf(w, "<span class=\"math-display\">%s</span>", img)
+	default:
+		_, _ = w.WriteString(img)
+	}
+	if n.Type() == ast.TypeBlock {
+		_ = w.WriteByte('\n')
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *htmlNodes) mathImage(formula string, display bool) (string, error) {
+	size := float64(htmlFontSize * mathOversample)
+	if display {
+		size *= displayMathScale
+	}
+	rendered, err := r.math.Render(formula, mathtex.Options{Size: size, Color: parseHex(r.palette.foreground), Display: display})
+	if err != nil {
+		return "", err
+	}
+
+	var data bytes.Buffer
+	if err := png.Encode(&data, rendered.Image); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrExportRender, err)
+	}
+	bounds := rendered.Image.Bounds()
+	depth := float64(bounds.Dy()-rendered.Baseline) / mathOversample
+	return fmt.Sprintf("<img class=\"math\" src=\"%s\" alt=\"%s\" width=\"%d\" height=\"%d\" style=\"vertical-align:-%.1fpx\"%s",
+		r.embed("image/png", data.Bytes()), html.EscapeString(formula),
+		bounds.Dx()/mathOversample, bounds.Dy()/mathOversample, depth, r.closeTag()), nil
+}
+
+func (r *htmlNodes) renderCallout(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	callout := n.(*markdown.Callout)
+	if entering {
+		fmt.Fprintf(w, "<div class=\"callout callout-%s\">\n<p class=\"callout-title\">%s</p>\n", callout.Category(), html.EscapeString(callout.DisplayTitle()))
+	} else {
+		_, _ = w.WriteString("</div>\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+func parseHex(hex string) color.Color {
+	var c color.RGBA
+	c.A = 0xff
+	if _, err := fmt.Sscanf(hex, "#%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return color.Black
+	}
+	return c
+}
@@ -0,0 +1,174 @@
+package export
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/png"
+	"regexp"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/bmp"
+)
+
+var imgSrcRegex = regexp.MustCompile(`<img src="([^"]*)"`)
+
+func TestHTMLTOC(t *testing.T) {
+	content := "# Guide\n\n## Install\n\n### Linux\n\n#### Deep\n\n## Use & Enjoy\n"
+	tests := []struct {
+		name    string
+		content string
+		toc     bool
+		want    string
+	}{
+		{
+			name:    "nested headings",
+			content: content,
+			toc:     true,
+			want: "<nav class=\"toc\">\n<p class=\"toc-title\">Contents</p>\n<ul>\n<li><a href=\"#guide\">Guide</a><ul>\n" +
+				"<li><a href=\"#install\">Install</a><ul>\n<li><a href=\"#linux\">Linux</a></li>\n</ul>\n</li>\n" +
+				"<li><a href=\"#use--enjoy\">Use &amp; Enjoy</a></li>\n</ul>\n</li>\n</ul>\n</nav>\n",
+		},
+		{
+			name:    "disabled",
+			content: content,
+		},
+		{
+			name:    "no headings",
+			content: "Just text.\n",
+			toc:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HTML(tt.content, Options{TOC: tt.toc})
+			if err != nil {
+				t.Fatal(err)
+			}
+			toc := ""
+			if start := bytes.Index(got, []byte("<nav")); start >= 0 {
+				end := bytes.Index(got, []byte("</nav>\n"))
+				toc = string(got[start : end+len("</nav>\n")])
+			}
+			if toc != tt.want {
+				t.Errorf("TOC =\n%q\nwant\n%q", toc, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLTheme(t *testing.T) {
+	tests := []struct {
+		theme   Theme
+		want    string
+		wantErr bool
+	}{
+		{theme: "", want: "color-scheme:light"},
+		{theme: ThemeLight, want: "color-scheme:light"},
+		{theme: ThemeDark, want: "color-scheme:dark"},
+		{theme: "neon", wantErr: true},
+		{theme: "Dark", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.theme), func(t *testing.T) {
+			got, err := HTML("# Note\n", Options{Theme: tt.theme})
+			if tt.wantErr {
+				if !errors.Is(err, ErrExportTheme) {
+					t.Errorf("HTML() error = %v, want %v", err, ErrExportTheme)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(got), tt.want) {
+				t.Errorf("HTML() does not contain %q", tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLImages(t *testing.T) {
+	var bmpData bytes.Buffer
+	if err := bmp.Encode(&bmpData, image.NewRGBA(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+	dir := writeNotes(t, map[string]string{
+		"pic.png":         string(pngData(t)),
+		"pic.bmp":         bmpData.String(),
+		"broken.bmp":      "not a bitmap",
+		"icon.svg":        `<svg xmlns="http://www.w3.org/2000/svg" width="4" height="4"/>`,
+		"notes/inner.gif": "GIF89a",
+		"doc.txt":         "text",
+	})
+	tests := []struct {
+		name string
+		dest string
+		want string
+	}{
+		{name: "png", dest: "pic.png", want: "data:image/png;base64,"},
+		{name: "bmp is re-encoded as png", dest: "pic.bmp", want: "data:image/png;base64,"},
+		{name: "svg", dest: "icon.svg", want: "data:image/svg+xml;base64,"},
+		{name: "escaped path in a subdirectory", dest: "notes/inner%2Egif", want: "data:image/gif;base64,R0lGODlh"},
+		{name: "undecodable bmp keeps its path", dest: "broken.bmp", want: "broken.bmp"},
+		{name: "missing file keeps its path", dest: "gone.png", want: "gone.png"},
+		{name: "unknown type keeps its path", dest: "doc.txt", want: "doc.txt"},
+		{name: "remote images are not fetched", dest: "https://example.com/a.png", want: "https://example.com/a.png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HTML("![alt]("+tt.dest+")\n", Options{BaseDir: dir})
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := imgSrcRegex.FindStringSubmatch(string(got))
+			if m == nil {
+				t.Fatalf("HTML() has no image:\n%s", got)
+			}
+			if !strings.HasPrefix(m[1], tt.want) {
+				t.Errorf("src = %.60q, want prefix %q", m[1], tt.want)
+			}
+		})
+	}
+
+	t.Run("bmp decodes", func(t *testing.T) {
+		got, err := HTML("![alt](pic.bmp)\n", Options{BaseDir: dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		src := imgSrcRegex.FindStringSubmatch(string(got))[1]
+		data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(src, "data:image/png;base64,"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size := img.Bounds().Size(); size != image.Pt(3, 2) {
+			t.Errorf("image size = %v, want 3x2", size)
+		}
+	})
+}
+
+func TestHTMLOmitsRawHTML(t *testing.T) {
+	content := "<div onclick=\"x()\">block</div>\n\nText <span style=\"color:red\">inline</span> and <b>bold</b>.\n\n" +
+		"<script>alert(1)</script>\n\n[click](javascript:alert(2))\n"
+	got, err := HTML(content, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(got)
+	for _, unwanted := range []string{"<div onclick", "onclick", "<span", "<b>", "<script>", "alert(1)", "javascript:"} {
+		if strings.Contains(html, unwanted) {
+			t.Errorf("HTML() contains %q", unwanted)
+		}
+	}
+	for _, want := range []string{"<!-- raw HTML omitted -->", "inline", "bold", `<a href="">click</a>`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML() does not contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,443 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	extast "github.com/yuin/goldmark/extension/ast"
+)
+
+const (
+	odtMimetype   = "application/vnd.oasis.opendocument.text"
+	odtIndentCM   = 1.0
+	odtPixelCM    = 2.54 / 96
+	odtMonoFont   = "Liberation Mono"
+	odtBodyFont   = "Liberation Sans"
+	odtNamespaces = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:draw="urn:oasis:names:tc:opendocument:xmlns:drawing:1.0" xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" xmlns:xlink="http://www.w3.org/1999/xlink" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0" xmlns:svg="urn:oasis:names:tc:opendocument:xmlns:svg-compatible:1.0" xmlns:loext="urn:org:documentfoundation:names:experimental:office:xmlns:loext:1.0"`
+)
+
+var odtPageSizes = map[PageSize][2]string{
+	PageA4:     {"21cm", "29.7cm"},
+	PageA5:     {"14.8cm", "21cm"},
+	PageLetter: {"8.5in", "11in"},
+	PageLegal:  {"8.5in", "14in"},
+}
+
+const odtStyles = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-styles ` + odtNamespaces + ` office:version="1.3">
+<office:font-face-decls>
+<style:font-face style:name="` + odtBodyFont + `" svg:font-family="'` + odtBodyFont + `'" style:font-family-generic="swiss"/>
+<style:font-face style:name="` + odtMonoFont + `" svg:font-family="'` + odtMonoFont + `'" style:font-family-generic="modern" style:font-pitch="fixed"/>
+</office:font-face-decls>
+<office:styles>
+<style:default-style style:family="paragraph"><style:paragraph-properties fo:margin-bottom="0.25cm"/><style:text-properties style:font-name="` + odtBodyFont + `" fo:font-size="11pt" fo:language="en" fo:country="US"/></style:default-style>
+<style:style style:name="Standard" style:family="paragraph" style:class="text"/>
+<style:style style:name="Text_20_body" style:display-name="Text body" style:family="paragraph" style:parent-style-name="Standard" style:class="text"/>
+%s<style:style style:name="Preformatted_20_Text" style:display-name="Preformatted Text" style:family="paragraph" style:parent-style-name="Standard" style:class="html"><style:paragraph-properties fo:margin-top="0cm" fo:margin-bottom="0cm" fo:background-color="%s"/><style:text-properties style:font-name="` + odtMonoFont + `" fo:font-size="9.5pt"/></style:style>
+<style:style style:name="Quotations" style:family="paragraph" style:parent-style-name="Standard" style:class="html"><style:paragraph-properties fo:padding-left="0.3cm" fo:border-left="0.1cm solid %s"/><style:text-properties fo:color="%s"/></style:style>
+<style:style style:name="List_20_Paragraph" style:display-name="List Paragraph" style:family="paragraph" style:parent-style-name="Standard" style:class="list"><style:paragraph-properties fo:margin-bottom="0.1cm"/></style:style>
+<style:style style:name="Table_20_Contents" style:display-name="Table Contents" style:family="paragraph" style:parent-style-name="Standard" style:class="extra"><style:paragraph-properties fo:margin-bottom="0cm"/></style:style>
+<style:style style:name="Horizontal_20_Line" style:display-name="Horizontal Line" style:family="paragraph" style:parent-style-name="Standard" style:class="html"><style:paragraph-properties fo:border-bottom="0.05pt solid %s" fo:padding="0cm"/><style:text-properties fo:font-size="6pt"/></style:style>
+<style:style style:name="Source_20_Text" style:display-name="Source Text" style:family="text"><style:text-properties style:font-name="` + odtMonoFont + `" fo:background-color="%s"/></style:style>
+<style:style style:name="Internet_20_link" style:display-name="Internet link" style:family="text"><style:text-properties fo:color="%s" style:text-underline-style="solid" style:text-underline-width="auto" style:text-underline-color="font-color"/></style:style>
+<text:list-style style:name="List_20_Bullet" style:display-name="List Bullet">%s</text:list-style>
+<text:list-style style:name="List_20_Number" style:display-name="List Number">%s</text:list-style>
+</office:styles>
+<office:automatic-styles>
+<style:page-layout style:name="PageLayout"><style:page-layout-properties fo:page-width="%s" fo:page-height="%s" fo:margin-top="%[12]s" fo:margin-bottom="%[12]s" fo:margin-left="%[12]s" fo:margin-right="%[12]s"/></style:page-layout>
+</office:automatic-styles>
+<office:master-styles>
+<style:master-page style:name="Standard" style:page-layout-name="PageLayout"/>
+</office:master-styles>
+</office:document-styles>
+`
+
+const odtMeta = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-meta ` + odtNamespaces + ` office:version="1.3">
+<office:meta>
+<dc:title>%s</dc:title>
+<meta:generator>markdown-editor</meta:generator>
+<dc:date>%s</dc:date>
+</office:meta>
+</office:document-meta>
+`
+
+type odtSpan struct {
+	bold   bool
+	italic bool
+	code   bool
+	strike bool
+	super  bool
+	color  string
+}
+
+type odtIndent struct {
+	parent string
+	margin float64
+}
+
+type odtWriter struct {
+	flow    *flowDocument
+	palette palette
+	body    strings.Builder
+	spans   map[odtSpan]string
+	order   []odtSpan
+	indents map[odtIndent]string
+	indentN []odtIndent
+	lists   []*flowList
+	tables  int
+	frames  int
+}
+
+func ODT(content string, opts Options) ([]byte, error) {
+	pageSize, err := checkPageSize(opts.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	margin := opts.Margin
+	if margin <= 0 {
+		margin = DefaultMargin
+	}
+
+	w := &odtWriter{
+		flow:    buildFlow(content, opts),
+		palette: palettes[ThemeLight],
+		spans:   make(map[odtSpan]string),
+		indents: make(map[odtIndent]string),
+	}
+	for _, block := range w.flow.blocks {
+		w.block(block)
+	}
+	w.closeLists(0)
+
+	size := odtPageSizes[pageSize]
+	files := []zipEntry{
+		{"mimetype", []byte(odtMimetype)},
+		{"META-INF/manifest.xml", []byte(w.manifest())},
+		{"content.xml", []byte(w.content())},
+		{"styles.xml", []byte(w.styles(size, fmt.Sprintf("%.1fmm", margin)))},
+		{"meta.xml", []byte(fmt.Sprintf(odtMeta, xmlText(w.flow.title), time.Now().UTC().Format("2006-01-02T15:04:05")))},
+	}
+	for _, img := range w.flow.images {
+		files = append(files, zipEntry{odtPicture(img), img.data})
+	}
+	return writeZip(files, true)
+}
+
+func odtPicture(img *flowImage) string {
+	return fmt.Sprintf("Pictures/image%d%s", img.id, img.ext)
+}
+
+func (w *odtWriter) manifest() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.3">
+<manifest:file-entry manifest:full-path="/" manifest:version="1.3" manifest:media-type="` + odtMimetype + `"/>
+<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>
+<manifest:file-entry manifest:full-path="styles.xml" manifest:media-type="text/xml"/>
+<manifest:file-entry manifest:full-path="meta.xml" manifest:media-type="text/xml"/>
+`)
+	for _, img := range w.flow.images {
+		fmt.Fprintf(&b, "<manifest:file-entry manifest:full-path=\"%s\" manifest:media-type=\"%s\"/>\n", odtPicture(img), img.mediaType)
+	}
+	b.WriteString("</manifest:manifest>\n")
+	return b.String()
+}
+
+func (w *odtWriter) styles(size [2]string, margin string) string {
+	p := w.palette
+	var headings strings.Builder
+	for level, pt := range headingSizes {
+		fmt.Fprintf(&headings, `<style:style style:name="Heading_20_%d" style:display-name="Heading %d" style:family="paragraph" style:parent-style-name="Standard" style:next-style-name="Text_20_body" style:default-outline-level="%d" style:class="text"><style:paragraph-properties fo:margin-top="0.4cm" fo:margin-bottom="0.2cm" fo:keep-with-next="always"/><style:text-properties fo:font-size="%.1fpt" fo:font-weight="bold"/></style:style>`+"\n",
+			level+1, level+1, level+1, pt*flowFontSize/pdfBodySize)
+	}
+	return fmt.Sprintf(odtStyles, headings.String(), p.surface, p.border, p.muted, p.border, p.surface, p.link,
+		odtListLevels(false), odtListLevels(true), size[0], size[1], margin)
+}
+
+func odtListLevels(ordered bool) string {
+	var b strings.Builder
+	bullets := []rune(docxBulletChars)
+	for level := 1; level <= 10; level++ {
+		indent := fmt.Sprintf(`<style:list-level-properties text:list-level-position-and-space-mode="label-alignment"><style:list-level-label-alignment text:label-followed-by="listtab" fo:text-indent="-0.5cm" fo:margin-left="%.2fcm"/></style:list-level-properties>`, float64(level)*odtIndentCM)
+		if ordered {
+			fmt.Fprintf(&b, `<text:list-level-style-number text:level="%d" style:num-suffix="." style:num-format="1">%s</text:list-level-style-number>`, level, indent)
+		} else {
+			fmt.Fprintf(&b, `<text:list-level-style-bullet text:level="%d" text:bullet-char="%c">%s</text:list-level-style-bullet>`, level, bullets[(level-1)%len(bullets)], indent)
+		}
+	}
+	return b.String()
+}
+
+func (w *odtWriter) content() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content ` + odtNamespaces + ` office:version="1.3">
+<office:automatic-styles>
+<style:style style:name="PCenter" style:family="paragraph" style:parent-style-name="Text_20_body"><style:paragraph-properties fo:text-align="center"/></style:style>
+<style:style style:name="PCellCenter" style:family="paragraph" style:parent-style-name="Table_20_Contents"><style:paragraph-properties fo:text-align="center"/></style:style>
+<style:style style:name="PCellRight" style:family="paragraph" style:parent-style-name="Table_20_Contents"><style:paragraph-properties fo:text-align="end"/></style:style>
+<style:style style:name="Image" style:family="graphic"><style:graphic-properties style:vertical-pos="middle" style:vertical-rel="text" fo:border="none"/></style:style>
+`)
+	fmt.Fprintf(&b, `<style:style style:name="Cell" style:family="table-cell"><style:table-cell-properties fo:padding="0.1cm" fo:border="0.5pt solid %s"/></style:style>
+<style:style style:name="HeaderCell" style:family="table-cell"><style:table-cell-properties fo:padding="0.1cm" fo:border="0.5pt solid %s" fo:background-color="%s"/></style:style>
+`, w.palette.border, w.palette.border, w.palette.surface)
+	for i, indent := range w.indentN {
+		fmt.Fprintf(&b, "<style:style style:name=\"P%d\" style:family=\"paragraph\" style:parent-style-name=\"%s\"><style:paragraph-properties fo:margin-left=\"%.2fcm\"/></style:style>\n", i+1, indent.parent, indent.margin)
+	}
+	for i := 1; i <= w.tables; i++ {
+		fmt.Fprintf(&b, "<style:style style:name=\"Table%d\" style:family=\"table\"><style:table-properties table:align=\"left\" fo:margin-bottom=\"0.25cm\"/></style:style>\n", i)
+	}
+	for _, span := range w.order {
+		b.WriteString(w.spanStyle(span))
+	}
+	b.WriteString("</office:automatic-styles>\n<office:body>\n<office:text>\n")
+	b.WriteString(w.body.String())
+	b.WriteString("</office:text>\n</office:body>\n</office:document-content>\n")
+	return b.String()
+}
+
+func (w *odtWriter) spanStyle(span odtSpan) string {
+	var props strings.Builder
+	if span.bold {
+		props.WriteString(` fo:font-weight="bold"`)
+	}
+	if span.italic {
+		props.WriteString(` fo:font-style="italic"`)
+	}
+	if span.strike {
+		props.WriteString(` style:text-line-through-style="solid" style:text-line-through-type="single"`)
+	}
+	if span.super {
+		props.WriteString(` style:text-position="super 58%"`)
+	}
+	if span.color != "" {
+		props.WriteString(` fo:color="` + span.color + `"`)
+	}
+	parent := ""
+	if span.code {
+		parent = ` style:parent-style-name="Source_20_Text"`
+	}
+	return fmt.Sprintf("<style:style style:name=\"%s\" style:family=\"text\"%s><style:text-properties%s/></style:style>\n", w.spans[span], parent, props.String())
+}
+
+func (w *odtWriter) block(block *flowBlock) {
+	inList := block.list != nil && (block.kind == flowParagraph || block.kind == flowCode)
+	if inList {
+		w.openList(block)
+	} else {
+		w.closeLists(0)
+	}
+	switch block.kind {
+	case flowHeading:
+		level := min(block.level, len(headingSizes))
+		fmt.Fprintf(&w.body, `<text:h text:style-name="Heading_20_%d" text:outline-level="%d">`, level, level)
+		w.bookmark(block.anchor)
+		w.runs(block.runs)
+		w.body.WriteString("</text:h>\n")
+	case flowRule:
+		w.body.WriteString("<text:p text:style-name=\"Horizontal_20_Line\"/>\n")
+	case flowCode:
+		style := w.indented("Preformatted_20_Text", block, inList)
+		for _, line := range block.lines {
+			fmt.Fprintf(&w.body, `<text:p text:style-name="%s">`, style)
+			w.runs(line)
+			w.body.WriteString("</text:p>\n")
+		}
+		fmt.Fprintf(&w.body, "<text:p text:style-name=\"%s\"/>\n", w.indented("Text_20_body", block, inList))
+	case flowTable:
+		w.table(block)
+	default:
+		style := "Text_20_body"
+		switch {
+		case inList:
+			style = "List_20_Paragraph"
+		case block.quote > 0:
+			style = w.indented("Quotations", block, false)
+		case block.centered:
+			style = "PCenter"
+		}
+		w.paragraph(style, block)
+	}
+}
+
+func (w *odtWriter) paragraph(style string, block *flowBlock) {
+	fmt.Fprintf(&w.body, `<text:p text:style-name="%s">`, style)
+	w.bookmark(block.anchor)
+	w.runs(block.runs)
+	w.body.WriteString("</text:p>\n")
+}
+
+func (w *odtWriter) openList(block *flowBlock) {
+	level := block.list.level
+	w.closeLists(level + 1)
+	if len(w.lists) == level+1 && w.lists[level] != block.list {
+		w.closeLists(level)
+	}
+	if len(w.lists) == level+1 {
+		if block.item {
+			w.body.WriteString("</text:list-item>\n<text:list-item>")
+		}
+		return
+	}
+	for len(w.lists) <= level {
+		list := block.list
+		if len(w.lists) < level {
+			list = &flowList{level: len(w.lists)}
+		}
+		style := "List_20_Bullet"
+		if list.ordered {
+			style = "List_20_Number"
+		}
+		fmt.Fprintf(&w.body, `<text:list text:style-name="%s">`, style)
+		if list.ordered && list.start > 1 {
+			fmt.Fprintf(&w.body, `<text:list-item text:start-value="%d">`, list.start)
+		} else {
+			w.body.WriteString("<text:list-item>")
+		}
+		w.lists = append(w.lists, list)
+	}
+}
+
+func (w *odtWriter) closeLists(depth int) {
+	for len(w.lists) > depth {
+		w.body.WriteString("</text:list-item></text:list>\n")
+		w.lists = w.lists[:len(w.lists)-1]
+	}
+}
+
+func (w *odtWriter) indented(parent string, block *flowBlock, inList bool) string {
+	level := block.quote
+	if !inList {
+		level += listLevel(block)
+	}
+	if level == 0 {
+		return parent
+	}
+	key := odtIndent{parent: parent, margin: float64(level) * odtIndentCM}
+	if name, ok := w.indents[key]; ok {
+		return name
+	}
+	w.indentN = append(w.indentN, key)
+	name := fmt.Sprintf("P%d", len(w.indentN))
+	w.indents[key] = name
+	return name
+}
+
+func (w *odtWriter) bookmark(anchor string) {
+	if anchor != "" {
+		fmt.Fprintf(&w.body, `<text:bookmark text:name="%s"/>`, xmlText(anchor))
+	}
+}
+
+func (w *odtWriter) runs(runs []flowRun) {
+	for i := 0; i < len(runs); {
+		link := runs[i].link
+		j := i + 1
+		for j < len(runs) && runs[j].link == link {
+			j++
+		}
+		if link != "" {
+			fmt.Fprintf(&w.body, `<text:a xlink:type="simple" xlink:href="%s" text:style-name="Internet_20_link">`, xmlText(link))
+		}
+		for _, r := range runs[i:j] {
+			w.run(r)
+		}
+		if link != "" {
+			w.body.WriteString("</text:a>")
+		}
+		i = j
+	}
+}
+
+func (w *odtWriter) run(r flowRun) {
+	switch {
+	case r.lineBreak:
+		w.body.WriteString("<text:line-break/>")
+		return
+	case r.image != nil:
+		w.frames++
+		fmt.Fprintf(&w.body, `<draw:frame draw:style-name="Image" draw:name="Image%d" text:anchor-type="as-char" svg:width="%.3fcm" svg:height="%.3fcm"><draw:image xlink:href="%s" xlink:type="simple" xlink:show="embed" xlink:actuate="onLoad"/><svg:desc>%s</svg:desc></draw:frame>`,
+			w.frames, float64(r.image.width)*odtPixelCM, float64(r.image.height)*odtPixelCM, odtPicture(r.image), xmlText(r.text))
+		return
+	}
+
+	span := odtSpan{bold: r.bold, italic: r.italic, code: r.code, strike: r.strike, super: r.super, color: r.color}
+	text := odtText(r.text, r.code)
+	if span == (odtSpan{}) {
+		w.body.WriteString(text)
+		return
+	}
+	name, ok := w.spans[span]
+	if !ok {
+		name = fmt.Sprintf("T%d", len(w.order)+1)
+		w.spans[span] = name
+		w.order = append(w.order, span)
+	}
+	fmt.Fprintf(&w.body, `<text:span text:style-name="%s">%s</text:span>`, name, text)
+}
+
+func odtText(s string, preserve bool) string {
+	var b strings.Builder
+	spaces := 0
+	flush := func() {
+		switch {
+		case spaces == 0:
+			return
+		case spaces == 1 && !preserve:
+			b.WriteByte(' ')
+		default:
+			fmt.Fprintf(&b, `<text:s text:c="%d"/>`, spaces)
+		}
+		spaces = 0
+	}
+	for _, r := range xmlText(s) {
+		switch r {
+		case ' ':
+			spaces++
+			continue
+		case '\t':
+			flush()
+			b.WriteString("<text:tab/>")
+			continue
+		}
+		flush()
+		b.WriteRune(r)
+	}
+	flush()
+	return b.String()
+}
+
+func (w *odtWriter) table(block *flowBlock) {
+	w.tables++
+	fmt.Fprintf(&w.body, `<table:table table:name="Table%d" table:style-name="Table%d"><table:table-column table:number-columns-repeated="%d"/>`, w.tables, w.tables, len(block.aligns))
+	for i, row := range block.rows {
+		if i == 0 {
+			w.body.WriteString("<table:table-header-rows>")
+		}
+		w.body.WriteString("<table:table-row>")
+		for col, cell := range row {
+			cellStyle := "Cell"
+			if i == 0 {
+				cellStyle = "HeaderCell"
+			}
+			style := "Table_20_Contents"
+			switch block.aligns[col] {
+			case extast.AlignCenter:
+				style = "PCellCenter"
+			case extast.AlignRight:
+				style = "PCellRight"
+			}
+			fmt.Fprintf(&w.body, `<table:table-cell table:style-name="%s" office:value-type="string"><text:p text:style-name="%s">`, cellStyle, style)
+			w.runs(cell)
+			w.body.WriteString("</text:p></table:table-cell>")
+		}
+		w.body.WriteString("</table:table-row>")
+		if i == 0 {
+			w.body.WriteString("</table:table-header-rows>")
+		}
+		w.body.WriteString("\n")
+	}
+	w.body.WriteString("</table:table>\n")
+}
@@ -0,0 +1,153 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestODT(t *testing.T) {
+	dir, chart := officeNoteDir(t)
+	data, err := ODT(officeNote, Options{BaseDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, parts := unzipParts(t, data)
+	if first := files[0]; first.Name != "mimetype" || first.Method != zip.Store || string(parts["mimetype"]) != odtMimetype {
+		t.Errorf("first entry = %s (method %d), want an uncompressed mimetype", first.Name, first.Method)
+	}
+	xmlParts := parseXMLParts(t, parts)
+	for _, name := range []string{"META-INF/manifest.xml", "content.xml", "styles.xml", "meta.xml"} {
+		if _, ok := xmlParts[name]; !ok {
+			t.Errorf("package has no %s", name)
+		}
+	}
+	content := xmlParts["content.xml"]
+
+	manifest := make(map[string]string)
+	for _, e := range elementsNamed(xmlParts["META-INF/manifest.xml"], "file-entry") {
+		manifest[e.attrs["full-path"]] = e.attrs["media-type"]
+	}
+	t.Run("manifest", func(t *testing.T) {
+		for name := range parts {
+			if name == "mimetype" || name == "META-INF/manifest.xml" {
+				continue
+			}
+			if _, ok := manifest[name]; !ok {
+				t.Errorf("%s is not in the manifest", name)
+			}
+		}
+		for name := range manifest {
+			if _, ok := parts[name]; !ok && name != "/" {
+				t.Errorf("manifest lists %s, which is not in the package", name)
+			}
+		}
+	})
+
+	t.Run("styles are defined", func(t *testing.T) {
+		defined := make(map[string]bool)
+		for _, part := range []string{"content.xml", "styles.xml"} {
+			for _, e := range elementsNamed(xmlParts[part], "style") {
+				defined[e.attrs["name"]] = true
+			}
+			for _, e := range elementsNamed(xmlParts[part], "list-style") {
+				defined[e.attrs["name"]] = true
+			}
+		}
+		for _, e := range content {
+			if name, ok := e.attrs["style-name"]; ok && !defined[name] {
+				t.Errorf("%s uses style %q, which is not defined", e.name, name)
+			}
+		}
+	})
+
+	t.Run("list numbering", func(t *testing.T) {
+		var items []string
+		var lists []string
+		for _, e := range content {
+			switch e.name {
+			case "list":
+				lists = append(lists, e.attrs["style-name"])
+			case "/list":
+				lists = lists[:len(lists)-1]
+			case "list-item":
+				item := strings.Repeat("  ", len(lists)-1) + lists[len(lists)-1]
+				if start, ok := e.attrs["start-value"]; ok {
+					item += " from " + start
+				}
+				items = append(items, item)
+			}
+		}
+		want := []string{
+			"List_20_Number",
+			"List_20_Number",
+			"  List_20_Bullet",
+			"List_20_Number",
+			"List_20_Number from 5",
+			"List_20_Number",
+		}
+		if !reflect.DeepEqual(items, want) {
+			t.Errorf("list items = %q, want %q", items, want)
+		}
+	})
+
+	t.Run("table alignment", func(t *testing.T) {
+		var aligns []string
+		inCell := false
+		for _, e := range content {
+			switch e.name {
+			case "table-cell":
+				inCell = true
+			case "/table-cell":
+				inCell = false
+			case "p":
+				if inCell {
+					aligns = append(aligns, e.attrs["style-name"])
+				}
+			}
+		}
+		want := []string{"Table_20_Contents", "PCellCenter", "PCellRight", "Table_20_Contents", "PCellCenter", "PCellRight"}
+		if !reflect.DeepEqual(aligns, want) {
+			t.Errorf("cell paragraph styles = %q, want %q", aligns, want)
+		}
+	})
+
+	t.Run("images", func(t *testing.T) {
+		images := elementsNamed(content, "image")
+		if len(images) != 1 {
+			t.Fatalf("document has %d images, want 1", len(images))
+		}
+		href := images[0].attrs["href"]
+		picture, ok := parts[href]
+		if !ok {
+			t.Fatalf("image links to %s, which is not in the package", href)
+		}
+		if !bytes.Equal(picture, chart) {
+			t.Errorf("%s differs from the source image", href)
+		}
+		if manifest[href] != "image/png" {
+			t.Errorf("manifest media type of %s = %q, want image/png", href, manifest[href])
+		}
+	})
+
+	t.Run("hyperlinks", func(t *testing.T) {
+		bookmarks := make(map[string]bool)
+		for _, e := range elementsNamed(content, "bookmark") {
+			bookmarks[e.attrs["name"]] = true
+		}
+		var targets []string
+		for _, e := range elementsNamed(content, "a") {
+			href := e.attrs["href"]
+			if anchor, ok := strings.CutPrefix(href, "#"); ok && !bookmarks[anchor] {
+				t.Errorf("link points at bookmark %q, which is not defined", anchor)
+			}
+			targets = append(targets, href)
+		}
+		want := []string{"https://go.dev", "https://go.dev", "mailto:a@b.c", "#report"}
+		if !reflect.DeepEqual(targets, want) {
+			t.Errorf("link targets = %q, want %q", targets, want)
+		}
+	})
+}
@@ -0,0 +1,926 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"markdown-editor/internal/diagram"
+	"markdown-editor/internal/highlight"
+	"markdown-editor/internal/imaging"
+	"markdown-editor/internal/markdown"
+	"markdown-editor/internal/mathtex"
+	"os"
+	"strings"
+
+	"github.com/go-fonts/dejavu/dejavusans"
+	"github.com/go-fonts/dejavu/dejavusansbold"
+	"github.com/go-fonts/dejavu/dejavusansboldoblique"
+	"github.com/go-fonts/dejavu/dejavusansmono"
+	"github.com/go-fonts/dejavu/dejavusansmonobold"
+	"github.com/go-fonts/dejavu/dejavusansoblique"
+	"github.com/go-pdf/fpdf"
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+)
+
+const (
+	DefaultMargin = 20.0
+
+	fontSans = "sans"
+	fontMono = "mono"
+
+	pdfBodySize    = 10.5
+	pdfCodeSize    = 9
+	pdfSmallSize   = 8
+	pdfLineSpacing = 1.45
+	pdfBlockGap    = 2.5
+	pdfIndent      = 7
+	pdfBarWidth    = 1
+	pdfCodePadding = 2.5
+	pdfCellPadding = 1.5
+	pdfMinColumn   = 15
+	pdfImageDPI    = 96
+	pdfMathScale   = 4
+	ptToMM         = 25.4 / 72
+)
+
+var ErrExportPageSize = errors.New("export: unknown page size")
+
+type PageSize string
+
+const (
+	PageA4     PageSize = "A4"
+	PageA5     PageSize = "A5"
+	PageLetter PageSize = "Letter"
+	PageLegal  PageSize = "Legal"
+)
+
+func PageSizes() []PageSize {
+	return []PageSize{PageA4, PageA5, PageLetter, PageLegal}
+}
+
+var headingSizes = [...]float64{20, 16, 13.5, 12, 11, 10.5}
+
+type pdfStyle struct {
+	bold   bool
+	italic bool
+	mono   bool
+	strike bool
+	size   float64
+	color  string
+}
+
+type pdfRenderer struct {
+	pdf     *fpdf.Fpdf
+	doc     *document
+	opts    Options
+	palette palette
+	math    *mathtex.Renderer
+	anchors map[string]int
+	images  int
+}
+
+func PDF(content string, opts Options) ([]byte, error) {
+	pageSize, err := checkPageSize(opts.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	margin := opts.Margin
+	if margin <= 0 {
+		margin = DefaultMargin
+	}
+
+	doc := parse(content, opts)
+	pdf := fpdf.NewCustom(&fpdf.InitType{UnitStr: "mm", SizeStr: string(pageSize)})
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(true, margin)
+	pdf.SetTitle(doc.title, true)
+	pdf.SetCreator("markdown-editor", true)
+	pdf.AliasNbPages("")
+	for _, f := range []struct {
+		family, style string
+		ttf           []byte
+	}{
+		{fontSans, "", dejavusans.TTF},
+		{fontSans, "B", dejavusansbold.TTF},
+		{fontSans, "I", dejavusansoblique.TTF},
+		{fontSans, "BI", dejavusansboldoblique.TTF},
+		{fontMono, "", dejavusansmono.TTF},
+		{fontMono, "B", dejavusansmonobold.TTF},
+		{fontMono, "I", dejavusansmono.TTF},
+		{fontMono, "BI", dejavusansmonobold.TTF},
+	} {
+		pdf.AddUTF8FontFromBytes(f.family, f.style, f.ttf)
+	}
+
+	r := &pdfRenderer{
+		pdf:     pdf,
+		doc:     doc,
+		opts:    opts,
+		palette: palettes[ThemeLight],
+		math:    mathtex.NewRenderer(),
+		anchors: make(map[string]int),
+	}
+	r.collectAnchors()
+	pdf.SetHeaderFuncMode(r.header, true)
+	pdf.SetFooterFunc(r.footer)
+	pdf.AddPage()
+
+	for n := doc.root.FirstChild(); n != nil; n = n.NextSibling() {
+		r.block(n)
+		if pdf.Err() {
+			break
+		}
+	}
+
+	var out bytes.Buffer
+	if err := pdf.Output(&out); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExportRender, err)
+	}
+	return out.Bytes(), nil
+}
+
+func checkPageSize(size PageSize) (PageSize, error) {
+	if size == "" {
+		return PageA4, nil
+	}
+	for _, known := range PageSizes() {
+		if strings.EqualFold(string(size), string(known)) {
+			return known, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrExportPageSize, size)
+}
+
+func (r *pdfRenderer) collectAnchors() {
+	for _, h := range r.doc.headings {
+		if h.ID != "" {
+			r.anchors[h.ID] = r.pdf.AddLink()
+		}
+	}
+	_ = ast.Walk(r.doc.root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if fn, ok := n.(*extast.Footnote); ok && entering {
+			r.anchors[fmt.Sprintf("fn:%d", fn.Index)] = r.pdf.AddLink()
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+func (r *pdfRenderer) header() {
+	left, top, right, _ := r.pdf.GetMargins()
+	width, _ := r.pdf.GetPageSize()
+	r.setStyle(pdfStyle{size: pdfSmallSize, color: r.palette.muted})
+	y := top / 2
+	r.pdf.SetXY(left, y-2)
+	r.pdf.CellFormat(width-left-right, 4, pdfText(r.doc.title), "", 0, "L", false, 0, "")
+	r.setDraw(r.palette.border)
+	r.pdf.SetLineWidth(0.2)
+	r.pdf.Line(left, y+2.5, width-right, y+2.5)
+	r.pdf.SetXY(left, top)
+}
+
+func (r *pdfRenderer) footer() {
+	_, _, _, bottom := r.pdf.GetMargins()
+	r.setStyle(pdfStyle{size: pdfSmallSize, color: r.palette.muted})
+	r.pdf.SetY(-bottom/2 - 2)
+	r.pdf.CellFormat(0, 4, fmt.Sprintf("Page %d of {nb}", r.pdf.PageNo()), "", 0, "C", false, 0, "")
+}
+
+func (r *pdfRenderer) setStyle(st pdfStyle) {
+	family := fontSans
+	if st.mono {
+		family = fontMono
+	}
+	style := ""
+	if st.bold {
+		style += "B"
+	}
+	if st.italic {
+		style += "I"
+	}
+	if st.strike {
+		style += "S"
+	}
+	size := st.size
+	if size == 0 {
+		size = pdfBodySize
+	}
+	r.pdf.SetFont(family, style, size)
+	color := st.color
+	if color == "" {
+		color = r.palette.foreground
+	}
+	red, green, blue := rgb(color)
+	r.pdf.SetTextColor(red, green, blue)
+}
+
+func (r *pdfRenderer) setDraw(color string) {
+	red, green, blue := rgb(color)
+	r.pdf.SetDrawColor(red, green, blue)
+}
+
+func (r *pdfRenderer) setFill(color string) {
+	red, green, blue := rgb(color)
+	r.pdf.SetFillColor(red, green, blue)
+}
+
+func lineHeight(size float64) float64 {
+	return size * ptToMM * pdfLineSpacing
+}
+
+func (r *pdfRenderer) contentWidth() float64 {
+	width, _ := r.pdf.GetPageSize()
+	left, _, right, _ := r.pdf.GetMargins()
+	return width - left - right
+}
+
+func (r *pdfRenderer) pageBottom() float64 {
+	_, height := r.pdf.GetPageSize()
+	_, _, _, bottom := r.pdf.GetMargins()
+	return height - bottom
+}
+
+func (r *pdfRenderer) ensureSpace(h float64) {
+	if r.pdf.GetY()+h > r.pageBottom() {
+		r.pdf.AddPage()
+	}
+}
+
+func (r *pdfRenderer) gap() {
+	r.pdf.Ln(pdfBlockGap)
+}
+
+func (r *pdfRenderer) startBlock() {
+	left, _, _, _ := r.pdf.GetMargins()
+	r.pdf.SetX(left)
+}
+
+func (r *pdfRenderer) block(n ast.Node) {
+	switch node := n.(type) {
+	case *ast.Heading:
+		r.heading(node)
+	case *ast.Paragraph, *ast.TextBlock:
+		r.paragraph(n, pdfStyle{})
+	case *ast.ThematicBreak:
+		r.rule()
+	case *ast.CodeBlock, *ast.FencedCodeBlock:
+		r.codeBlock(n)
+	case *ast.Blockquote:
+		r.quote(n, r.palette.border, "", r.palette.muted)
+	case *markdown.Callout:
+		accent := r.palette.callouts[node.Category()]
+		r.quote(n, accent, node.DisplayTitle(), "")
+	case *ast.List:
+		r.list(node)
+	case *ast.HTMLBlock:
+		r.plainBlock(markdown.BlockText(n, r.doc.source), pdfStyle{mono: true, size: pdfCodeSize, color: r.palette.muted})
+	case *extast.Table:
+		r.table(node)
+	case *extast.DefinitionList:
+		r.definitionList(node)
+	case *extast.FootnoteList:
+		r.footnotes(node)
+	case *markdown.MathBlock:
+		r.mathBlock(markdown.Formula(node, r.doc.source))
+	default:
+		r.children(n)
+	}
+}
+
+func (r *pdfRenderer) children(n ast.Node) {
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		r.block(child)
+	}
+}
+
+func (r *pdfRenderer) heading(h *ast.Heading) {
+	size := headingSizes[min(max(h.Level, 1), len(headingSizes))-1]
+	lh := lineHeight(size)
+	r.pdf.Ln(lh / 3)
+	r.ensureSpace(lh * 3)
+	r.startBlock()
+	if link, ok := r.anchors[markdown.HeadingID(h)]; ok {
+		r.pdf.SetLink(link, r.pdf.GetY(), -1)
+	}
+	r.inlines(h, pdfStyle{bold: true, size: size}, lh)
+	r.pdf.Ln(lh)
+	if h.Level <= 2 {
+		left, _, _, _ := r.pdf.GetMargins()
+		r.setDraw(r.palette.border)
+		r.pdf.SetLineWidth(0.3)
+		r.pdf.Line(left, r.pdf.GetY(), left+r.contentWidth(), r.pdf.GetY())
+		r.pdf.Ln(1.5)
+	}
+	r.gap()
+}
+
+func (r *pdfRenderer) paragraph(n ast.Node, st pdfStyle) {
+	size := st.size
+	if size == 0 {
+		size = pdfBodySize
+	}
+	lh := lineHeight(size)
+	r.startBlock()
+	r.inlines(n, st, lh)
+	r.pdf.Ln(lh)
+	r.gap()
+}
+
+func (r *pdfRenderer) plainBlock(text string, st pdfStyle) {
+	lh := lineHeight(st.size)
+	r.startBlock()
+	r.setStyle(st)
+	r.pdf.Write(lh, pdfText(text))
+	r.pdf.Ln(lh)
+	r.gap()
+}
+
+func (r *pdfRenderer) rule() {
+	r.ensureSpace(pdfBlockGap * 2)
+	left, _, _, _ := r.pdf.GetMargins()
+	y := r.pdf.GetY() + pdfBlockGap/2
+	r.setDraw(r.palette.border)
+	r.pdf.SetLineWidth(0.4)
+	r.pdf.Line(left, y, left+r.contentWidth(), y)
+	r.pdf.SetY(y + pdfBlockGap)
+}
+
+func (r *pdfRenderer) inlines(parent ast.Node, st pdfStyle, lh float64) {
+	for child := parent.FirstChild(); child != nil; child = child.NextSibling() {
+		r.inline(child, st, lh)
+	}
+}
+
+func (r *pdfRenderer) inline(n ast.Node, st pdfStyle, lh float64) {
+	switch node := n.(type) {
+	case *ast.Text:
+		r.write(textValue(node, r.doc.source), st, lh)
+		if node.HardLineBreak() {
+			r.pdf.Ln(lh)
+		} else if node.SoftLineBreak() {
+			r.write(" ", st, lh)
+		}
+	case *ast.String:
+		r.write(string(node.Value), st, lh)
+	case *ast.CodeSpan:
+		code := st
+		code.mono = true
+		code.size = fontSizeOf(st) * pdfCodeSize / pdfBodySize
+		r.write(markdown.PlainText(node, r.doc.source), code, lh)
+	case *ast.Emphasis:
+		emphasis := st
+		if node.Level >= 2 {
+			emphasis.bold = true
+		} else {
+			emphasis.italic = true
+		}
+		r.inlines(node, emphasis, lh)
+	case *extast.Strikethrough:
+		strike := st
+		strike.strike = true
+		r.inlines(node, strike, lh)
+	case *ast.Link:
+		r.link(string(node.Destination), markdown.PlainText(node, r.doc.source), st, lh)
+	case *ast.AutoLink:
+		dest := string(node.URL(r.doc.source))
+		if node.AutoLinkType == ast.AutoLinkEmail && !strings.HasPrefix(dest, "mailto:") {
+			dest = "mailto:" + dest
+		}
+		r.link(dest, string(node.Label(r.doc.source)), st, lh)
+	case *ast.Image:
+		r.pdf.Ln(lh)
+		r.image(string(node.Destination), markdown.PlainText(node, r.doc.source))
+		r.startBlock()
+	case *markdown.MathInline:
+		formula := markdown.Formula(node, r.doc.source)
+		if node.Display {
+			r.pdf.Ln(lh)
+			r.mathBlock(formula)
+			r.startBlock()
+			return
+		}
+		r.inlineMath(formula, st, lh)
+	case *extast.TaskCheckBox:
+		mark := "☐ "
+		if node.IsChecked {
+			mark = "☑ "
+		}
+		r.write(mark, st, lh)
+	case *extast.FootnoteLink:
+		ref := st
+		ref.size = pdfSmallSize
+		ref.color = r.palette.link
+		text := fmt.Sprintf("[%d]", node.Index)
+		r.setStyle(ref)
+		if link, ok := r.anchors[fmt.Sprintf("fn:%d", node.Index)]; ok {
+			r.pdf.WriteLinkID(lh, text, link)
+		} else {
+			r.pdf.Write(lh, text)
+		}
+	case *ast.RawHTML, *extast.FootnoteBacklink:
+	default:
+		r.inlines(node, st, lh)
+	}
+}
+
+func fontSizeOf(st pdfStyle) float64 {
+	if st.size == 0 {
+		return pdfBodySize
+	}
+	return st.size
+}
+
+func (r *pdfRenderer) write(text string, st pdfStyle, lh float64) {
+	if text == "" {
+		return
+	}
+	r.setStyle(st)
+	r.pdf.Write(lh, pdfText(text))
+}
+
+func (r *pdfRenderer) link(dest, text string, st pdfStyle, lh float64) {
+	if text == "" {
+		text = dest
+	}
+	linkStyle := st
+	linkStyle.color = r.palette.link
+	r.setStyle(linkStyle)
+	if name, ok := strings.CutPrefix(dest, "#"); ok {
+		if link, ok := r.anchors[name]; ok {
+			r.pdf.WriteLinkID(lh, pdfText(text), link)
+			return
+		}
+		r.pdf.Write(lh, pdfText(text))
+		return
+	}
+	r.pdf.WriteLinkString(lh, pdfText(text), dest)
+}
+
+func (r *pdfRenderer) quote(n ast.Node, accent, title, textColor string) {
+	left, _, _, _ := r.pdf.GetMargins()
+	startPage, startY := r.pdf.PageNo(), r.pdf.GetY()
+	r.pdf.SetLeftMargin(left + pdfIndent)
+	if title != "" {
+		r.paragraphText(title, pdfStyle{bold: true, color: accent})
+	}
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		if textColor != "" {
+			if _, ok := child.(*ast.Paragraph); ok {
+				r.paragraph(child, pdfStyle{color: textColor})
+				continue
+			}
+		}
+		r.block(child)
+	}
+	r.pdf.SetLeftMargin(left)
+	r.bar(left+pdfBarWidth, accent, startPage, startY, r.pdf.PageNo(), r.pdf.GetY()-pdfBlockGap)
+}
+
+func (r *pdfRenderer) paragraphText(text string, st pdfStyle) {
+	lh := lineHeight(fontSizeOf(st))
+	r.startBlock()
+	r.write(text, st, lh)
+	r.pdf.Ln(lh)
+	r.pdf.Ln(pdfBlockGap / 2)
+}
+
+func (r *pdfRenderer) bar(x float64, color string, startPage int, startY float64, endPage int, endY float64) {
+	_, top, _, _ := r.pdf.GetMargins()
+	current := r.pdf.PageNo()
+	r.setDraw(color)
+	r.pdf.SetLineWidth(pdfBarWidth)
+	for page := startPage; page <= endPage; page++ {
+		from, to := top, r.pageBottom()
+		if page == startPage {
+			from = startY
+		}
+		if page == endPage {
+			to = endY
+		}
+		if to <= from {
+			continue
+		}
+		r.pdf.SetPage(page)
+		r.pdf.Line(x, from, x, to)
+	}
+	r.pdf.SetPage(current)
+}
+
+func (r *pdfRenderer) list(list *ast.List) {
+	left, _, _, _ := r.pdf.GetMargins()
+	number := list.Start
+	for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+		marker := "•"
+		if list.IsOrdered() {
+			marker = fmt.Sprintf("%d%c", number, list.Marker)
+		}
+		number++
+		if first := item.FirstChild(); first != nil {
+			if _, ok := first.FirstChild().(*extast.TaskCheckBox); ok {
+				marker = ""
+			}
+		}
+
+		lh := lineHeight(pdfBodySize)
+		r.ensureSpace(lh)
+		r.setStyle(pdfStyle{})
+		r.pdf.SetX(left)
+		r.pdf.CellFormat(pdfIndent-1, lh, pdfText(marker), "", 0, "R", false, 0, "")
+		r.pdf.SetLeftMargin(left + pdfIndent)
+		y := r.pdf.GetY()
+		for child := item.FirstChild(); child != nil; child = child.NextSibling() {
+			if child == item.FirstChild() {
+				r.pdf.SetXY(left+pdfIndent, y)
+				r.listItemBlock(child, list.IsTight)
+				continue
+			}
+			r.startBlock()
+			r.listItemBlock(child, list.IsTight)
+		}
+		r.pdf.SetLeftMargin(left)
+	}
+	r.gap()
+}
+
+func (r *pdfRenderer) listItemBlock(n ast.Node, tight bool) {
+	switch n.(type) {
+	case *ast.Paragraph, *ast.TextBlock:
+		lh := lineHeight(pdfBodySize)
+		r.inlines(n, pdfStyle{}, lh)
+		r.pdf.Ln(lh)
+		if !tight {
+			r.gap()
+		}
+	case *ast.List:
+		r.list(n.(*ast.List))
+		r.pdf.Ln(-pdfBlockGap)
+	default:
+		r.block(n)
+	}
+}
+
+func (r *pdfRenderer) definitionList(n *extast.DefinitionList) {
+	left, _, _, _ := r.pdf.GetMargins()
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		switch child.(type) {
+		case *extast.DefinitionTerm:
+			lh := lineHeight(pdfBodySize)
+			r.startBlock()
+			r.inlines(child, pdfStyle{bold: true}, lh)
+			r.pdf.Ln(lh)
+		case *extast.DefinitionDescription:
+			r.pdf.SetLeftMargin(left + pdfIndent)
+			r.children(child)
+			r.pdf.SetLeftMargin(left)
+		}
+	}
+}
+
+func (r *pdfRenderer) footnotes(n *extast.FootnoteList) {
+	r.rule()
+	left, _, _, _ := r.pdf.GetMargins()
+	size := pdfBodySize * 0.9
+	lh := lineHeight(size)
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		fn, ok := child.(*extast.Footnote)
+		if !ok {
+			continue
+		}
+		r.ensureSpace(lh)
+		if link, ok := r.anchors[fmt.Sprintf("fn:%d", fn.Index)]; ok {
+			r.pdf.SetLink(link, r.pdf.GetY(), -1)
+		}
+		r.setStyle(pdfStyle{size: size, color: r.palette.muted})
+		r.pdf.SetX(left)
+		r.pdf.CellFormat(pdfIndent, lh, fmt.Sprintf("%d.", fn.Index), "", 0, "L", false, 0, "")
+		r.pdf.SetLeftMargin(left + pdfIndent)
+		for para := fn.FirstChild(); para != nil; para = para.NextSibling() {
+			if para != fn.FirstChild() {
+				r.startBlock()
+			}
+			r.inlines(para, pdfStyle{size: size, color: r.palette.muted}, lh)
+			r.pdf.Ln(lh)
+		}
+		r.pdf.SetLeftMargin(left)
+	}
+	r.gap()
+}
+
+func (r *pdfRenderer) codeBlock(n ast.Node) {
+	code := markdown.BlockText(n, r.doc.source)
+	info := ""
+	if fenced, ok := n.(*ast.FencedCodeBlock); ok && fenced.Info != nil {
+		info = string(fenced.Info.Segment.Value(r.doc.source))
+	}
+	if fields := strings.Fields(info); len(fields) > 0 && r.opts.Diagrams != nil && r.opts.Diagrams.Supports(fields[0]) {
+		if data, err := r.opts.Diagrams.Render(context.Background(), fields[0], []byte(code)); err == nil {
+			if r.imageData("diagram"+diagram.Extension(data), data, true) {
+				return
+			}
+		}
+	}
+
+	lang := highlight.Language(info)
+	tokens, err := highlight.Tokenize(lang, code)
+	if err != nil {
+		tokens = []highlight.Token{{Kind: highlight.KindPlain, Text: code}}
+	}
+
+	st := pdfStyle{mono: true, size: pdfCodeSize}
+	r.setStyle(st)
+	lh := lineHeight(pdfCodeSize) * 0.9
+	left, _, _, _ := r.pdf.GetMargins()
+	width := r.contentWidth()
+	columns := max(1, int((width-2*pdfCodePadding)/r.pdf.GetStringWidth("m")))
+	lines := wrapTokens(tokens, columns)
+
+	r.setFill(r.palette.surface)
+	r.ensureSpace(lh + 2*pdfCodePadding)
+	r.pdf.Rect(left, r.pdf.GetY(), width, pdfCodePadding, "F")
+	r.pdf.SetY(r.pdf.GetY() + pdfCodePadding)
+	for _, line := range lines {
+		if r.pdf.GetY()+lh > r.pageBottom() {
+			r.pdf.AddPage()
+		}
+		y := r.pdf.GetY()
+		r.setFill(r.palette.surface)
+		r.pdf.Rect(left, y, width, lh, "F")
+		r.pdf.SetXY(left+pdfCodePadding, y)
+		for _, token := range line {
+			tokenStyle := st
+			tokenStyle.color = r.palette.tokens[token.Kind]
+			tokenStyle.italic = token.Kind == highlight.KindComment
+			tokenStyle.bold = token.Kind == highlight.KindKeyword || token.Kind == highlight.KindHeading
+			r.setStyle(tokenStyle)
+			text := pdfText(token.Text)
+			r.pdf.CellFormat(r.pdf.GetStringWidth(text), lh, text, "", 0, "L", false, 0, "")
+		}
+		r.pdf.SetXY(left, y+lh)
+	}
+	r.setFill(r.palette.surface)
+	r.pdf.Rect(left, r.pdf.GetY(), width, pdfCodePadding, "F")
+	r.pdf.SetY(r.pdf.GetY() + pdfCodePadding)
+	r.gap()
+}
+
+func wrapTokens(tokens []highlight.Token, columns int) [][]highlight.Token {
+	lines := [][]highlight.Token{nil}
+	used := 0
+	for _, token := range tokens {
+		parts := strings.Split(strings.ReplaceAll(token.Text, "\t", "    "), "\n")
+		for i, part := range parts {
+			if i > 0 {
+				lines = append(lines, nil)
+				used = 0
+			}
+			runes := []rune(part)
+			for len(runes) > 0 {
+				if used == columns {
+					lines = append(lines, nil)
+					used = 0
+				}
+				take := min(len(runes), columns-used)
+				last := len(lines) - 1
+				lines[last] = append(lines[last], highlight.Token{Kind: token.Kind, Text: string(runes[:take])})
+				used += take
+				runes = runes[take:]
+			}
+		}
+	}
+	return lines
+}
+
+func (r *pdfRenderer) table(t *extast.Table) {
+	columns := len(t.Alignments)
+	if columns == 0 {
+		return
+	}
+	var rows [][]string
+	for row := t.FirstChild(); row != nil; row = row.NextSibling() {
+		cells := make([]string, columns)
+		col := 0
+		for cell := row.FirstChild(); cell != nil && col < columns; cell = cell.NextSibling() {
+			cells[col] = markdown.PlainText(cell, r.doc.source)
+			col++
+		}
+		rows = append(rows, cells)
+	}
+
+	widths := r.columnWidths(rows, columns)
+	left, _, _, _ := r.pdf.GetMargins()
+	lh := lineHeight(pdfBodySize * 0.95)
+	r.setDraw(r.palette.border)
+	r.pdf.SetLineWidth(0.2)
+	for i, cells := range rows {
+		header := i == 0
+		st := pdfStyle{bold: header, size: pdfBodySize * 0.95}
+		r.setStyle(st)
+		wrapped := make([][]string, columns)
+		height := 0.0
+		for col, text := range cells {
+			wrapped[col] = r.pdf.SplitText(pdfText(text), widths[col]-2*pdfCellPadding+2*r.pdf.GetCellMargin())
+			if len(wrapped[col]) == 0 {
+				wrapped[col] = []string{""}
+			}
+			height = max(height, float64(len(wrapped[col]))*lh+2*pdfCellPadding)
+		}
+		r.ensureSpace(height)
+
+		x, y := left, r.pdf.GetY()
+		for col := range cells {
+			style := "D"
+			if header {
+				r.setFill(r.palette.surface)
+				style = "FD"
+			}
+			r.pdf.Rect(x, y, widths[col], height, style)
+			r.setStyle(st)
+			for j, line := range wrapped[col] {
+				r.pdf.SetXY(x+pdfCellPadding, y+pdfCellPadding+float64(j)*lh)
+				r.pdf.CellFormat(widths[col]-2*pdfCellPadding, lh, line, "", 0, tableAlign(t.Alignments[col]), false, 0, "")
+			}
+			x += widths[col]
+		}
+		r.pdf.SetXY(left, y+height)
+	}
+	r.gap()
+}
+
+func (r *pdfRenderer) columnWidths(rows [][]string, columns int) []float64 {
+	natural := make([]float64, columns)
+	for i, cells := range rows {
+		r.setStyle(pdfStyle{bold: i == 0, size: pdfBodySize * 0.95})
+		for col, text := range cells {
+			natural[col] = max(natural[col], r.pdf.GetStringWidth(pdfText(text))+2*pdfCellPadding+1)
+		}
+	}
+	total := 0.0
+	for col := range natural {
+		natural[col] = max(natural[col], pdfMinColumn)
+		total += natural[col]
+	}
+	available := r.contentWidth()
+	if total <= available {
+		return natural
+	}
+	for col := range natural {
+		natural[col] = available * natural[col] / total
+	}
+	return natural
+}
+
+func tableAlign(a extast.Alignment) string {
+	switch a {
+	case extast.AlignCenter:
+		return "C"
+	case extast.AlignRight:
+		return "R"
+	default:
+		return "L"
+	}
+}
+
+func (r *pdfRenderer) image(dest, alt string) {
+	path, ok := markdown.ResolveLink(r.opts.BaseDir, dest)
+	if ok {
+		if data, err := os.ReadFile(path); err == nil && r.imageData(path, data, false) {
+			return
+		}
+	}
+	if alt == "" {
+		alt = dest
+	}
+	r.paragraphText("["+alt+"]", pdfStyle{italic: true, color: r.palette.muted})
+}
+
+func (r *pdfRenderer) imageData(name string, data []byte, centered bool) bool {
+	img, err := imaging.Decode(name, data)
+	if err != nil || img.Bounds().Empty() {
+		return false
+	}
+	_, imageType, err := image.DecodeConfig(bytes.NewReader(data))
+	switch {
+	case err == nil && (imageType == "jpeg" || imageType == "png" || imageType == "gif"):
+	default:
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return false
+		}
+		data, imageType = buf.Bytes(), "png"
+	}
+
+	r.images++
+	id := fmt.Sprintf("image-%d", r.images)
+	info := r.pdf.RegisterImageOptionsReader(id, fpdf.ImageOptions{ImageType: imageType}, bytes.NewReader(data))
+	if r.pdf.Err() || info == nil {
+		r.pdf.ClearError()
+		return false
+	}
+
+	info.SetDpi(pdfImageDPI)
+	r.placeImage(id, info.Width(), info.Height(), centered)
+	return true
+}
+
+func (r *pdfRenderer) placeImage(id string, w, h float64, centered bool) {
+	left, top, _, _ := r.pdf.GetMargins()
+	width := r.contentWidth()
+	if w > width {
+		h, w = h*width/w, width
+	}
+	if maxHeight := r.pageBottom() - top; h > maxHeight {
+		w, h = w*maxHeight/h, maxHeight
+	}
+	r.ensureSpace(h)
+	x := left
+	if centered {
+		x += (width - w) / 2
+	}
+	y := r.pdf.GetY()
+	r.pdf.ImageOptions(id, x, y, w, h, false, fpdf.ImageOptions{}, 0, "")
+	r.pdf.SetXY(left, y+h)
+	r.gap()
+}
+
+func (r *pdfRenderer) renderMath(formula string, display bool) (*mathtex.Formula, string, bool) {
+	size := pdfBodySize * pdfMathScale
+	if display {
+		size *= displayMathScale
+	}
+	rendered, err := r.math.Render(formula, mathtex.Options{Size: size, Color: parseHex(r.palette.foreground), Display: display})
+	if err != nil {
+		return nil, "", false
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, rendered.Image); err != nil {
+		return nil, "", false
+	}
+	r.images++
+	id := fmt.Sprintf("math-%d", r.images)
+	r.pdf.RegisterImageOptionsReader(id, fpdf.ImageOptions{ImageType: "png"}, &buf)
+	if r.pdf.Err() {
+		r.pdf.ClearError()
+		return nil, "", false
+	}
+	return rendered, id, true
+}
+
+func (r *pdfRenderer) mathBlock(formula string) {
+	rendered, id, ok := r.renderMath(formula, true)
+	if !ok {
+		r.plainBlock("$$"+formula+"$$", pdfStyle{mono: true, size: pdfCodeSize, color: r.palette.callouts["caution"]})
+		return
+	}
+	bounds := rendered.Image.Bounds()
+	scale := ptToMM / pdfMathScale
+	r.placeImage(id, float64(bounds.Dx())*scale, float64(bounds.Dy())*scale, true)
+}
+
+func (r *pdfRenderer) inlineMath(formula string, st pdfStyle, lh float64) {
+	rendered, id, ok := r.renderMath(formula, false)
+	if !ok {
+		failed := st
+		failed.mono = true
+		failed.color = r.palette.callouts["caution"]
+		r.write("$"+formula+"$", failed, lh)
+		return
+	}
+
+	bounds := rendered.Image.Bounds()
+	scale := ptToMM / pdfMathScale * fontSizeOf(st) / pdfBodySize
+	w, h := float64(bounds.Dx())*scale, float64(bounds.Dy())*scale
+	width, _ := r.pdf.GetPageSize()
+	_, _, right, _ := r.pdf.GetMargins()
+	if r.pdf.GetX()+w > width-right {
+		r.pdf.Ln(lh)
+	}
+	if r.pdf.GetY()+lh > r.pageBottom() {
+		r.pdf.AddPage()
+	}
+
+	x, y := r.pdf.GetX(), r.pdf.GetY()
+	baseline := y + lh/2 + fontSizeOf(st)*ptToMM*0.35
+	top := baseline - float64(rendered.Baseline)*scale
+	r.pdf.ImageOptions(id, x, top, w, h, false, fpdf.ImageOptions{}, 0, "")
+	r.pdf.SetXY(x+w, y)
+}
+
+func pdfText(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r > 0xffff {
+			return '�'
+		}
+		return r
+	}, text)
+}
+
+func rgb(hex string) (int, int, int) {
+	var red, green, blue int
+	if _, err := fmt.Sscanf(hex, "#%02x%02x%02x", &red, &green, &blue); err != nil {
+		return 0, 0, 0
+	}
+	return red, green, blue
+}
@@ -0,0 +1,56 @@
+package export
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func pngData(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	for x := range 40 {
+		for y := range 30 {
+			img.Set(x, y, color.RGBA{uint8(x * 6), uint8(y * 8), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestPDFImages(t *testing.T) {
+	valid := pngData(t)
+	tests := []struct {
+		name      string
+		file      string
+		data      []byte
+		wantImage bool
+	}{
+		{name: "valid png", file: "pic.png", data: valid, wantImage: true},
+		{name: "png with a jpeg extension", file: "pic.jpg", data: valid, wantImage: true},
+		{name: "truncated png", file: "pic.png", data: valid[:len(valid)/2]},
+		{name: "header only", file: "pic.png", data: valid[:33]},
+		{name: "not an image", file: "pic.gif", data: []byte("gif-data")},
+		{name: "empty file", file: "pic.jpeg", data: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeNotes(t, map[string]string{tt.file: string(tt.data)})
+			data, err := PDF("# Note\n\n![shot]("+tt.file+")\n", Options{BaseDir: dir})
+			if err != nil {
+				t.Fatalf("PDF() error = %v", err)
+			}
+			if !bytes.HasPrefix(data, []byte("%PDF-")) {
+				t.Fatalf("PDF() = %q, want a PDF document", data[:min(len(data), 16)])
+			}
+			if got := bytes.Contains(data, []byte("/Subtype /Image")); got != tt.wantImage {
+				t.Errorf("embedded image = %v, want %v", got, tt.wantImage)
+			}
+		})
+	}
+}
@@ -0,0 +1,932 @@
+package export
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"io/fs"
+	"markdown-editor/internal/index"
+	"markdown-editor/internal/markdown"
+	"markdown-editor/internal/mathtex"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+const (
+	SiteManifestFile    = index.BuildManifestFile
+	SiteSearchIndexFile = "_site/search-index.json"
+
+	siteFormat     = "1"
+	siteHomeFile   = "index.html"
+	siteStyleFile  = "_site/style.css"
+	siteScriptFile = "_site/search.js"
+	siteTagDir     = "_site/tags"
+	siteTagIndex   = siteTagDir + "/index.html"
+	sitePageExt    = ".html"
+)
+
+var (
+	ErrSiteNoNotes  = errors.New("export: workspace contains no markdown notes")
+	ErrSiteRead     = errors.New("export: failed to read workspace")
+	ErrSiteWrite    = errors.New("export: failed to write site")
+	ErrSiteOutput   = errors.New("export: site folder must not contain the workspace")
+	ErrSiteConflict = errors.New("export: two site files map to the same output path")
+)
+
+type SiteResult struct {
+	Pages    int
+	Rendered int
+	Copied   int
+	Removed  int
+}
+
+type sitePage struct {
+	path       string
+	file       string
+	title      string
+	tags       []string
+	content    []byte
+	doc        *document
+	links      []*sitePage
+	searchable string
+}
+
+type siteFolder struct {
+	name    string
+	path    string
+	folders []*siteFolder
+	pages   []*sitePage
+}
+
+type siteManifest struct {
+	Outputs map[string]string `json:"outputs"`
+}
+
+type siteBuilder struct {
+	root     string
+	out      string
+	opts     Options
+	theme    Theme
+	rebuild  bool
+	md       goldmark.Markdown
+	pages    []*sitePage
+	byPath   map[string]*sitePage
+	byName   map[string]*sitePage
+	files    map[string]string
+	names    map[string]string
+	assets   map[string]bool
+	tree     *siteFolder
+	key      string
+	previous siteManifest
+	manifest siteManifest
+	result   SiteResult
+}
+
+var siteTemplate = template.Must(template.New("site").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="generator" content="markdown-editor">
+<title>{{.Title}} · {{.Site}}</title>
+<link rel="stylesheet" href="{{.Stylesheet}}">
+</head>
+<body>
+<nav class="sidebar">
+<a class="site-title" href="{{.Home}}">{{.Site}}</a>
+<input class="site-search" type="search" placeholder="Search" aria-label="Search notes" data-root="{{.Root}}">
+<ul class="search-results" hidden></ul>
+{{.Nav}}<p class="site-tags"><a href="{{.TagIndex}}">All tags</a></p>
+</nav>
+<main>
+{{if .Tags}}<p class="page-tags">{{range .Tags}}<a class="tag" href="{{.URL}}">#{{.Name}}</a> {{end}}</p>
+{{end}}{{.Body}}</main>
+<script src="{{.Script}}"></script>
+</body>
+</html>
+`))
+
+type sitePageData struct {
+	Site       string
+	Title      string
+	Root       string
+	Stylesheet string
+	Script     string
+	Home       string
+	TagIndex   string
+	Nav        template.HTML
+	Tags       []siteTagLink
+	Body       template.HTML
+}
+
+type siteTagLink struct {
+	Name string
+	URL  string
+}
+
+type siteSearchEntry struct {
+	Title    string   `json:"title"`
+	URL      string   `json:"url"`
+	Tags     []string `json:"tags"`
+	Headings []string `json:"headings"`
+	Text     string   `json:"text"`
+}
+
+const siteCSS = `body{display:flex;min-height:100vh}
+main{flex:1;min-width:0}
+.sidebar{flex:0 0 280px;height:100vh;position:sticky;top:0;overflow:auto;padding:24px 16px;background:var(--surface);border-right:1px solid var(--border);font-size:14px}
+.site-title{display:block;margin-bottom:12px;color:var(--fg);font-size:18px;font-weight:600}
+.site-search{width:100%;padding:6px 8px;border:1px solid var(--border);border-radius:6px;background:var(--bg);color:var(--fg);font:inherit}
+.search-results{list-style:none;margin:8px 0;padding:0 0 8px;border-bottom:1px solid var(--border)}
+.nav,.nav ul{list-style:none;margin:0;padding-left:12px}
+.nav{margin-top:12px;padding:0}
+.nav summary{cursor:pointer;color:var(--muted)}
+.nav a[aria-current=page]{font-weight:600}
+.site-tags{margin-top:16px}
+.page-tags{margin:0 0 16px}
+.tag{display:inline-block;margin:0 4px 4px 0;padding:0 8px;border:1px solid var(--border);border-radius:12px;background:var(--surface);font-size:85%}
+.note-path{color:var(--muted);font-size:85%}
+.wikilink-missing{color:var(--muted);border-bottom:1px dashed var(--muted)}
+@media (max-width:760px){body{display:block}.sidebar{position:static;height:auto;border-right:0;border-bottom:1px solid var(--border)}}
+`
+
+const siteScript = `(function () {
+  var input = document.querySelector(".site-search");
+  var results = document.querySelector(".search-results");
+  if (!input || !results) return;
+  var root = input.getAttribute("data-root") || "";
+  var index = null;
+
+  function load() {
+    if (!index) {
+      index = fetch(root + "_site/search-index.json").then(function (r) {
+        if (!r.ok) throw new Error(r.statusText);
+        return r.json();
+      });
+    }
+    return index;
+  }
+
+  function show(items) {
+    results.innerHTML = "";
+    items.forEach(function (item) {
+      var li = document.createElement("li");
+      if (item.url !== undefined) {
+        var a = document.createElement("a");
+        a.href = root + item.url;
+        a.textContent = item.title;
+        li.appendChild(a);
+      } else {
+        li.textContent = item;
+      }
+      results.appendChild(li);
+    });
+    results.hidden = items.length === 0;
+  }
+
+  input.addEventListener("focus", load);
+  input.addEventListener("input", function () {
+    var query = input.value;
+    var terms = query.toLowerCase().split(/\s+/).filter(Boolean);
+    if (terms.length === 0) {
+      show([]);
+      return;
+    }
+    load().then(function (pages) {
+      if (input.value !== query) return;
+      var hits = [];
+      pages.forEach(function (page) {
+        var title = page.title.toLowerCase();
+        var body = (page.tags.join(" ") + " " + page.headings.join(" ") + " " + page.text).toLowerCase();
+        var score = 0;
+        for (var i = 0; i < terms.length; i++) {
+          if (title.indexOf(terms[i]) >= 0) score += 3;
+          else if (body.indexOf(terms[i]) >= 0) score += 1;
+          else return;
+        }
+        hits.push({ page: page, score: score });
+      });
+      hits.sort(function (a, b) { return b.score - a.score; });
+      show(hits.length ? hits.slice(0, 20).map(function (h) { return h.page; }) : ["No results"]);
+    }).catch(function () {
+      show(["Search is unavailable"]);
+    });
+  });
+})();
+`
+
+func BuildSite(root, outDir string, opts Options, rebuild bool) (SiteResult, error) {
+	theme, err := checkTheme(opts.Theme)
+	if err != nil {
+		return SiteResult{}, err
+	}
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return SiteResult{}, fmt.Errorf("%w: %v", ErrSiteRead, err)
+	}
+	out, err := filepath.Abs(outDir)
+	if err != nil {
+		return SiteResult{}, fmt.Errorf("%w: %v", ErrSiteWrite, err)
+	}
+	if rel, err := filepath.Rel(out, root); err == nil && !strings.HasPrefix(rel, "..") {
+		return SiteResult{}, fmt.Errorf("%w: %s", ErrSiteOutput, outDir)
+	}
+	if opts.Title = strings.TrimSpace(opts.Title); opts.Title == "" {
+		opts.Title = filepath.Base(root)
+	}
+
+	b := &siteBuilder{
+		root:     root,
+		out:      out,
+		opts:     opts,
+		theme:    theme,
+		rebuild:  rebuild,
+		md:       markdown.New(goldmark.WithExtensions(markdown.WikiLinks)),
+		byPath:   make(map[string]*sitePage),
+		byName:   make(map[string]*sitePage),
+		files:    make(map[string]string),
+		names:    make(map[string]string),
+		assets:   make(map[string]bool),
+		manifest: siteManifest{Outputs: make(map[string]string)},
+	}
+	if err := b.scan(); err != nil {
+		return SiteResult{}, err
+	}
+	if len(b.pages) == 0 {
+		return SiteResult{}, fmt.Errorf("%w: %s", ErrSiteNoNotes, root)
+	}
+	for _, page := range b.pages {
+		b.resolveLinks(page)
+	}
+	b.key = b.siteKey()
+	b.loadManifest()
+
+	if err := b.writePages(); err != nil {
+		return b.result, err
+	}
+	if err := b.writeGenerated(); err != nil {
+		return b.result, err
+	}
+	if err := b.removeStale(); err != nil {
+		return b.result, err
+	}
+	return b.result, b.saveManifest()
+}
+
+func (b *siteBuilder) scan() error {
+	var notes []string
+	err := filepath.WalkDir(b.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == b.root {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") || p == b.out || b.opts.excludes(p) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(b.root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if isNote(rel) {
+			notes = append(notes, rel)
+		} else {
+			b.files[strings.ToLower(rel)] = rel
+			name := strings.ToLower(path.Base(rel))
+			if other, ok := b.names[name]; !ok || strings.Count(rel, "/") < strings.Count(other, "/") {
+				b.names[name] = rel
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSiteRead, err)
+	}
+
+	sort.Strings(notes)
+	b.tree = &siteFolder{}
+	for _, rel := range notes {
+		content, err := os.ReadFile(filepath.Join(b.root, filepath.FromSlash(rel)))
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrSiteRead, rel, err)
+		}
+		doc := parseWith(string(content), Options{}, func(source []byte) ast.Node {
+			return b.md.Parser().Parse(text.NewReader(source))
+		})
+		if doc.title == defaultTitle {
+			doc.title = strings.TrimSuffix(path.Base(rel), path.Ext(rel))
+		}
+		page := &sitePage{
+			path:       rel,
+			file:       strings.TrimSuffix(rel, path.Ext(rel)) + sitePageExt,
+			title:      doc.title,
+			tags:       index.ParseTags(string(content)),
+			content:    content,
+			doc:        doc,
+			searchable: searchText(doc.root, doc.source),
+		}
+		b.pages = append(b.pages, page)
+		b.byPath[strings.ToLower(strings.TrimSuffix(rel, path.Ext(rel)))] = page
+		name := strings.ToLower(strings.TrimSuffix(path.Base(rel), path.Ext(rel)))
+		if other, ok := b.byName[name]; !ok || strings.Count(rel, "/") < strings.Count(other.path, "/") {
+			b.byName[name] = page
+		}
+		b.tree.add(page)
+	}
+	return nil
+}
+
+func (f *siteFolder) add(page *sitePage) {
+	dir := path.Dir(page.path)
+	if dir == "." {
+		f.pages = append(f.pages, page)
+		return
+	}
+	folder := f
+	for _, name := range strings.Split(dir, "/") {
+		var next *siteFolder
+		for _, sub := range folder.folders {
+			if sub.name == name {
+				next = sub
+				break
+			}
+		}
+		if next == nil {
+			next = &siteFolder{name: name, path: path.Join(folder.path, name)}
+			folder.folders = append(folder.folders, next)
+		}
+		folder = next
+	}
+	folder.pages = append(folder.pages, page)
+}
+
+func (b *siteBuilder) resolveLinks(page *sitePage) {
+	baseDir := filepath.Dir(filepath.Join(b.root, filepath.FromSlash(page.path)))
+	var unresolved []ast.Node
+	var wikiLinks []*markdown.WikiLink
+	_ = ast.Walk(page.doc.root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *markdown.WikiLink:
+			wikiLinks = append(wikiLinks, node)
+		case *ast.Image:
+			dest := string(node.Destination)
+			target, ok := markdown.ResolveLink(baseDir, dest)
+			if !ok {
+				return ast.WalkContinue, nil
+			}
+			if rel, ok := b.asset(target); ok {
+				node.Destination = []byte(siteHref(page.file, rel))
+			} else if uri, ok := imageDataURI(baseDir, dest); ok {
+				node.Destination = []byte(uri)
+			} else {
+				unresolved = append(unresolved, node)
+			}
+		case *ast.Link:
+			dest := string(node.Destination)
+			target, ok := markdown.ResolveLink(baseDir, dest)
+			if !ok {
+				return ast.WalkContinue, nil
+			}
+			if linked, ok := b.pageFor(target); ok {
+				fragment := ""
+				if i := strings.Index(dest, "#"); i >= 0 {
+					fragment = dest[i:]
+				}
+				node.Destination = []byte(siteHref(page.file, linked.file) + fragment)
+			} else if rel, ok := b.asset(target); ok {
+				node.Destination = []byte(siteHref(page.file, rel))
+			} else {
+				unresolved = append(unresolved, node)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	unwrapNodes(unresolved)
+
+	for _, wl := range wikiLinks {
+		b.resolveWikiLink(page, wl)
+	}
+}
+
+func (b *siteBuilder) resolveWikiLink(page *sitePage, wl *markdown.WikiLink) {
+	var replacement ast.Node
+	if target, ok := b.wikiPage(page, wl.Target); ok {
+		href := ""
+		if target != page {
+			href = siteHref(page.file, target.file)
+			page.links = append(page.links, target)
+		}
+		if id := headingFor(target, wl.Fragment); id != "" {
+			href += "#" + id
+		}
+		if href == "" {
+			href = "#"
+		}
+		link := ast.NewLink()
+		link.Destination = []byte(href)
+		replacement = link
+	} else if rel, ok := b.wikiFile(wl.Target); ok {
+		href := siteHref(page.file, rel)
+		if _, image := imageTypes[strings.ToLower(path.Ext(rel))]; image && wl.Embed {
+			link := ast.NewLink()
+			link.Destination = []byte(href)
+			replacement = ast.NewImage(link)
+		} else {
+			link := ast.NewLink()
+			link.Destination = []byte(href)
+			replacement = link
+		}
+	}
+	if replacement == nil {
+		return
+	}
+	replacement.SetAttributeString("class", []byte("wikilink"))
+	for child := wl.FirstChild(); child != nil; child = wl.FirstChild() {
+		replacement.AppendChild(replacement, child)
+	}
+	parent := wl.Parent()
+	parent.ReplaceChild(parent, wl, replacement)
+}
+
+func (b *siteBuilder) wikiPage(from *sitePage, target string) (*sitePage, bool) {
+	target = strings.Trim(filepath.ToSlash(strings.TrimSpace(target)), "/")
+	if target == "" {
+		return from, true
+	}
+	key := strings.ToLower(target)
+	if isNote(key) {
+		key = strings.TrimSuffix(key, path.Ext(key))
+	}
+	if page, ok := b.byPath[path.Clean(path.Join(strings.ToLower(path.Dir(from.path)), key))]; ok {
+		return page, true
+	}
+	if page, ok := b.byPath[key]; ok {
+		return page, true
+	}
+	if !strings.Contains(key, "/") {
+		page, ok := b.byName[key]
+		return page, ok
+	}
+	return nil, false
+}
+
+func (b *siteBuilder) wikiFile(target string) (string, bool) {
+	key := strings.ToLower(strings.Trim(filepath.ToSlash(strings.TrimSpace(target)), "/"))
+	rel, ok := b.files[key]
+	if !ok && !strings.Contains(key, "/") {
+		rel, ok = b.names[key]
+	}
+	if ok {
+		b.assets[rel] = true
+	}
+	return rel, ok
+}
+
+func headingFor(page *sitePage, fragment string) string {
+	if fragment == "" {
+		return ""
+	}
+	for _, h := range page.doc.headings {
+		if strings.EqualFold(h.Text, fragment) || h.ID == fragment {
+			return h.ID
+		}
+	}
+	return ""
+}
+
+func (b *siteBuilder) pageFor(target string) (*sitePage, bool) {
+	rel, ok := b.relative(target)
+	if !ok || !isNote(rel) {
+		return nil, false
+	}
+	page, ok := b.byPath[strings.ToLower(strings.TrimSuffix(rel, path.Ext(rel)))]
+	return page, ok
+}
+
+func (b *siteBuilder) asset(target string) (string, bool) {
+	rel, ok := b.relative(target)
+	if !ok {
+		return "", false
+	}
+	if rel, ok = b.files[strings.ToLower(rel)]; !ok {
+		return "", false
+	}
+	b.assets[rel] = true
+	return rel, true
+}
+
+func (b *siteBuilder) relative(target string) (string, bool) {
+	rel, err := filepath.Rel(b.root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+func (b *siteBuilder) siteKey() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%t\n", siteFormat, b.opts.Title, b.theme, b.opts.Diagrams != nil)
+	for _, page := range b.pages {
+		fmt.Fprintf(h, "page\x00%s\x00%s\n", page.path, page.title)
+	}
+	files := make([]string, 0, len(b.files))
+	for _, rel := range b.files {
+		files = append(files, rel)
+	}
+	sort.Strings(files)
+	for _, rel := range files {
+		fmt.Fprintf(h, "file\x00%s\n", rel)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (b *siteBuilder) pageKey(page *sitePage) string {
+	h := sha256.New()
+	h.Write([]byte(b.key))
+	h.Write(page.content)
+	for _, linked := range page.links {
+		fmt.Fprintf(h, "\x00%s", linked.path)
+		for _, heading := range linked.doc.headings {
+			fmt.Fprintf(h, "\x00%s\x00%s", heading.ID, heading.Text)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (b *siteBuilder) loadManifest() {
+	data, err := os.ReadFile(filepath.Join(b.out, SiteManifestFile))
+	if err != nil || json.Unmarshal(data, &b.previous) != nil || b.previous.Outputs == nil {
+		b.previous = siteManifest{Outputs: make(map[string]string)}
+	}
+}
+
+func (b *siteBuilder) saveManifest() error {
+	data, err := json.MarshalIndent(b.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSiteWrite, err)
+	}
+	if err := os.WriteFile(filepath.Join(b.out, SiteManifestFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("%w: %v", ErrSiteWrite, err)
+	}
+	return nil
+}
+
+func (b *siteBuilder) emit(file, hash string, produce func() ([]byte, error)) (bool, error) {
+	if _, ok := b.manifest.Outputs[file]; ok {
+		return false, fmt.Errorf("%w: %s", ErrSiteConflict, file)
+	}
+	b.manifest.Outputs[file] = hash
+	target := filepath.Join(b.out, filepath.FromSlash(file))
+	if !b.rebuild && b.previous.Outputs[file] == hash {
+		if _, err := os.Stat(target); err == nil {
+			return false, nil
+		}
+	}
+
+	data, err := produce()
+	if err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrSiteWrite, err)
+	}
+	if err := os.WriteFile(target, data, 0644); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrSiteWrite, err)
+	}
+	return true, nil
+}
+
+func (b *siteBuilder) emitData(file string, data []byte) error {
+	sum := sha256.Sum256(data)
+	_, err := b.emit(file, hex.EncodeToString(sum[:]), func() ([]byte, error) {
+		return data, nil
+	})
+	return err
+}
+
+func (b *siteBuilder) writePages() error {
+	b.result.Pages = len(b.pages)
+	for _, page := range b.pages {
+		rendered, err := b.emit(page.file, b.pageKey(page), func() ([]byte, error) {
+			return b.renderPage(page)
+		})
+		if err != nil {
+			return err
+		}
+		if rendered {
+			b.result.Rendered++
+		}
+	}
+
+	assets := make([]string, 0, len(b.assets))
+	for rel := range b.assets {
+		assets = append(assets, rel)
+	}
+	sort.Strings(assets)
+	for _, rel := range assets {
+		data, err := os.ReadFile(filepath.Join(b.root, filepath.FromSlash(rel)))
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrSiteRead, rel, err)
+		}
+		sum := sha256.Sum256(data)
+		copied, err := b.emit(rel, hex.EncodeToString(sum[:]), func() ([]byte, error) {
+			return data, nil
+		})
+		if err != nil {
+			return err
+		}
+		if copied {
+			b.result.Copied++
+		}
+	}
+	return nil
+}
+
+func (b *siteBuilder) renderPage(page *sitePage) ([]byte, error) {
+	body, err := renderNodes(page.doc, &htmlNodes{
+		palette:  palettes[b.theme],
+		diagrams: b.opts.Diagrams,
+		math:     mathtex.NewRenderer(),
+		embed:    dataURI,
+	}, renderer.WithNodeRenderers(util.Prioritized(&siteNodes{}, 100)))
+	if err != nil {
+		return nil, err
+	}
+	return b.layout(page.file, page.title, page, string(body))
+}
+
+func (b *siteBuilder) layout(file, title string, current *sitePage, body string) ([]byte, error) {
+	data := sitePageData{
+		Site:       b.opts.Title,
+		Title:      title,
+		Root:       strings.Repeat("../", strings.Count(file, "/")),
+		Stylesheet: siteHref(file, siteStyleFile),
+		Script:     siteHref(file, siteScriptFile),
+		Home:       siteHref(file, siteHomeFile),
+		TagIndex:   siteHref(file, siteTagIndex),
+		Nav:        template.HTML(b.nav(file, current, false)),
+		Body:       template.HTML(body),
+	}
+	if current != nil {
+		for _, tag := range current.tags {
+			data.Tags = append(data.Tags, siteTagLink{Name: tag, URL: siteHref(file, tagFile(tag))})
+		}
+	}
+
+	var out bytes.Buffer
+	if err := siteTemplate.Execute(&out, data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExportRender, err)
+	}
+	return out.Bytes(), nil
+}
+
+func (b *siteBuilder) nav(file string, current *sitePage, expanded bool) string {
+	var s strings.Builder
+	s.WriteString("<ul class=\"nav\">\n")
+	b.navFolder(&s, b.tree, file, current, expanded)
+	s.WriteString("</ul>\n")
+	return s.String()
+}
+
+func (b *siteBuilder) navFolder(s *strings.Builder, folder *siteFolder, file string, current *sitePage, expanded bool) {
+	for _, sub := range folder.folders {
+		open := ""
+		if expanded || (current != nil && strings.HasPrefix(current.path, sub.path+"/")) {
+			open = " open"
+		}
+		fmt.Fprintf(s, "<li><details%s><summary>%s</summary>\n<ul>\n", open, html.EscapeString(sub.name))
+		b.navFolder(s, sub, file, current, expanded)
+		s.WriteString("</ul>\n</details></li>\n")
+	}
+	for _, page := range folder.pages {
+		attr := ""
+		if page == current {
+			attr = " aria-current=\"page\""
+		}
+		fmt.Fprintf(s, "<li><a href=\"%s\"%s>%s</a></li>\n", html.EscapeString(siteHref(file, page.file)), attr, html.EscapeString(page.title))
+	}
+}
+
+func (b *siteBuilder) writeGenerated() error {
+	if err := b.emitData(siteStyleFile, []byte(stylesheet(b.theme)+siteCSS)); err != nil {
+		return err
+	}
+	if err := b.emitData(siteScriptFile, []byte(siteScript)); err != nil {
+		return err
+	}
+
+	entries := make([]siteSearchEntry, 0, len(b.pages))
+	for _, page := range b.pages {
+		entry := siteSearchEntry{
+			Title:    page.title,
+			URL:      siteHref("", page.file),
+			Tags:     page.tags,
+			Headings: []string{},
+			Text:     page.searchable,
+		}
+		if entry.Tags == nil {
+			entry.Tags = []string{}
+		}
+		for _, h := range page.doc.headings {
+			entry.Headings = append(entry.Headings, h.Text)
+		}
+		entries = append(entries, entry)
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrExportRender, err)
+	}
+	if err := b.emitData(SiteSearchIndexFile, data); err != nil {
+		return err
+	}
+
+	tagged := make(map[string][]*sitePage)
+	for _, page := range b.pages {
+		for _, tag := range page.tags {
+			tagged[tag] = append(tagged[tag], page)
+		}
+	}
+	tags := make([]string, 0, len(tagged))
+	for tag := range tagged {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		file := tagFile(tag)
+		var body strings.Builder
+		fmt.Fprintf(&body, "<h1>#%s</h1>\n", html.EscapeString(tag))
+		b.pageList(&body, file, b.taggedPages(tagged, tag))
+		if err := b.emitLayout(file, "#"+tag, body.String()); err != nil {
+			return err
+		}
+	}
+
+	var body strings.Builder
+	body.WriteString("<h1>Tags</h1>\n")
+	b.tagList(&body, siteTagIndex, tags, tagged)
+	if err := b.emitLayout(siteTagIndex, "Tags", body.String()); err != nil {
+		return err
+	}
+
+	if _, ok := b.byPath["index"]; ok {
+		return nil
+	}
+	body.Reset()
+	fmt.Fprintf(&body, "<h1>%s</h1>\n", html.EscapeString(b.opts.Title))
+	body.WriteString(b.nav(siteHomeFile, nil, true))
+	if len(tags) > 0 {
+		body.WriteString("<h2>Tags</h2>\n")
+		b.tagList(&body, siteHomeFile, tags, tagged)
+	}
+	return b.emitLayout(siteHomeFile, b.opts.Title, body.String())
+}
+
+func (b *siteBuilder) emitLayout(file, title, body string) error {
+	data, err := b.layout(file, title, nil, body)
+	if err != nil {
+		return err
+	}
+	return b.emitData(file, data)
+}
+
+func (b *siteBuilder) taggedPages(tagged map[string][]*sitePage, tag string) []*sitePage {
+	seen := make(map[*sitePage]bool)
+	var pages []*sitePage
+	for other, list := range tagged {
+		if other != tag && !strings.HasPrefix(other, tag+"/") {
+			continue
+		}
+		for _, page := range list {
+			if !seen[page] {
+				seen[page] = true
+				pages = append(pages, page)
+			}
+		}
+	}
+	sort.Slice(pages, func(i, j int) bool {
+		return pages[i].path < pages[j].path
+	})
+	return pages
+}
+
+func (b *siteBuilder) pageList(s *strings.Builder, file string, pages []*sitePage) {
+	s.WriteString("<ul>\n")
+	for _, page := range pages {
+		fmt.Fprintf(s, "<li><a href=\"%s\">%s</a> <span class=\"note-path\">%s</span></li>\n",
+			html.EscapeString(siteHref(file, page.file)), html.EscapeString(page.title), html.EscapeString(page.path))
+	}
+	s.WriteString("</ul>\n")
+}
+
+func (b *siteBuilder) tagList(s *strings.Builder, file string, tags []string, tagged map[string][]*sitePage) {
+	s.WriteString("<p>")
+	for _, tag := range tags {
+		fmt.Fprintf(s, "<a class=\"tag\" href=\"%s\">#%s (%d)</a> ",
+			html.EscapeString(siteHref(file, tagFile(tag))), html.EscapeString(tag), len(b.taggedPages(tagged, tag)))
+	}
+	s.WriteString("</p>\n")
+}
+
+func (b *siteBuilder) removeStale() error {
+	var stale []string
+	for file := range b.previous.Outputs {
+		if _, ok := b.manifest.Outputs[file]; !ok {
+			stale = append(stale, file)
+		}
+	}
+	sort.Strings(stale)
+	for _, file := range stale {
+		target := filepath.Join(b.out, filepath.FromSlash(file))
+		if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%w: %v", ErrSiteWrite, err)
+		}
+		b.result.Removed++
+		for dir := filepath.Dir(target); dir != b.out && strings.HasPrefix(dir, b.out); dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func tagFile(tag string) string {
+	return siteTagDir + "/" + tag + sitePageExt
+}
+
+func siteHref(from, to string) string {
+	rel, err := filepath.Rel(filepath.Dir(filepath.FromSlash(from)), filepath.FromSlash(to))
+	if err != nil {
+		rel = to
+	}
+	return (&url.URL{Path: filepath.ToSlash(rel)}).String()
+}
+
+func searchText(root ast.Node, source []byte) string {
+	var b strings.Builder
+	_ = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Text:
+			b.WriteString(textValue(node, source))
+			if node.SoftLineBreak() || node.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(node.Value)
+		case *ast.CodeBlock, *ast.FencedCodeBlock:
+			b.WriteString(markdown.BlockText(n, source))
+			return ast.WalkSkipChildren, nil
+		case *markdown.MathBlock, *markdown.MathInline, *ast.HTMLBlock, *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		}
+		if n.Type() == ast.TypeBlock {
+			b.WriteByte(' ')
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+type siteNodes struct{}
+
+func (r *siteNodes) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(markdown.KindWikiLink, r.renderWikiLink)
+}
+
+func (r *siteNodes) renderWikiLink(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		_, _ = w.WriteString("<span class=\"wikilink-missing\">")
+	} else {
+		_, _ = w.WriteString("</span>")
+	}
+	return ast.WalkContinue, nil
+}
@@ -0,0 +1,290 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var (
+	siteLinkRegex     = regexp.MustCompile(`<a href="([^"]*)"( class="wikilink")?>`)
+	sitePageListRegex = regexp.MustCompile(`<li><a href="([^"]*)">[^<]*</a> <span class="note-path">`)
+)
+
+func siteNotes() map[string]string {
+	return map[string]string{
+		"a.md":        "---\ntags: [go]\n---\n# Alpha\n\n## Setup Steps\n\nText #project/web\n",
+		"b.md":        "# Beta\n\nSee [[a]], [[A#Setup Steps|setup]], [[docs/c]], [[c]], [[Missing page]], [[#Local]], ![[pic.png]], [[report.pdf]] and [link](a.md#setup-steps).\n\n## Local\n\n#project\n",
+		"docs/a.md":   "# Docs Alpha\n",
+		"docs/c.md":   "# Gamma\n\nBack to [[b]], [[a]] and [[../a]].\n",
+		"img/pic.png": "png-data",
+		"report.pdf":  "pdf-data",
+		"unused.txt":  "text",
+	}
+}
+
+func readSiteFile(t *testing.T, out, file string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func siteLinks(t *testing.T, out, file string, regex *regexp.Regexp) []string {
+	t.Helper()
+	_, body, _ := strings.Cut(readSiteFile(t, out, file), "<main>")
+	var links []string
+	for _, m := range regex.FindAllStringSubmatch(body, -1) {
+		if regex == siteLinkRegex && m[2] == "" {
+			continue
+		}
+		links = append(links, m[1])
+	}
+	return links
+}
+
+func buildSite(t *testing.T, root, out string, rebuild bool) SiteResult {
+	t.Helper()
+	result, err := BuildSite(root, out, Options{}, rebuild)
+	if err != nil {
+		t.Fatalf("BuildSite() error = %v", err)
+	}
+	return result
+}
+
+func writeFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(rel)), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildSiteWikiLinks(t *testing.T) {
+	root := writeNotes(t, siteNotes())
+	out := t.TempDir()
+	buildSite(t, root, out, false)
+
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{
+			file: "b.html",
+			want: []string{"a.html", "a.html#setup-steps", "docs/c.html", "docs/c.html", "#local", "report.pdf"},
+		},
+		{
+			file: "docs/c.html",
+			want: []string{"../b.html", "a.html", "../a.html"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			if got := siteLinks(t, out, tt.file, siteLinkRegex); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("wiki links = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	page := readSiteFile(t, out, "b.html")
+	for _, want := range []string{
+		`<span class="wikilink-missing">Missing page</span>`,
+		`<img src="img/pic.png" alt="pic.png" class="wikilink">`,
+		`<a href="a.html#setup-steps">link</a>`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("b.html does not contain %q", want)
+		}
+	}
+	for file, want := range map[string]string{"img/pic.png": "png-data", "report.pdf": "pdf-data"} {
+		if got := readSiteFile(t, out, file); got != want {
+			t.Errorf("%s = %q, want %q", file, got, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(out, "unused.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("unused.txt was copied, want only linked files")
+	}
+}
+
+func TestBuildSiteTags(t *testing.T) {
+	root := writeNotes(t, siteNotes())
+	out := t.TempDir()
+	buildSite(t, root, out, false)
+
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{file: "_site/tags/go.html", want: []string{"../../a.html"}},
+		{file: "_site/tags/project.html", want: []string{"../../a.html", "../../b.html"}},
+		{file: "_site/tags/project/web.html", want: []string{"../../../a.html"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			if got := siteLinks(t, out, tt.file, sitePageListRegex); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tagged pages = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for file, want := range map[string]string{
+		"_site/tags/index.html": `<a class="tag" href="go.html">#go (1)</a> <a class="tag" href="project.html">#project (2)</a> <a class="tag" href="project/web.html">#project/web (1)</a>`,
+		"index.html":            `<a class="tag" href="_site/tags/go.html">#go (1)</a>`,
+		"a.html":                `<p class="page-tags"><a class="tag" href="_site/tags/go.html">#go</a> <a class="tag" href="_site/tags/project/web.html">#project/web</a> </p>`,
+	} {
+		if !strings.Contains(readSiteFile(t, out, file), want) {
+			t.Errorf("%s does not contain %q", file, want)
+		}
+	}
+
+	var entries []siteSearchEntry
+	if err := json.Unmarshal([]byte(readSiteFile(t, out, SiteSearchIndexFile)), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 || entries[0].URL != "a.html" || !reflect.DeepEqual(entries[0].Tags, []string{"go", "project/web"}) {
+		t.Errorf("search index = %+v, want four pages starting with a.html tagged go and project/web", entries)
+	}
+}
+
+func TestBuildSiteIncremental(t *testing.T) {
+	root := writeNotes(t, siteNotes())
+	out := t.TempDir()
+
+	steps := []struct {
+		name    string
+		change  func(t *testing.T)
+		rebuild bool
+		want    SiteResult
+	}{
+		{
+			name: "first build",
+			want: SiteResult{Pages: 4, Rendered: 4, Copied: 2},
+		},
+		{
+			name: "nothing changed",
+			want: SiteResult{Pages: 4},
+		},
+		{
+			name: "unlinked note edited",
+			change: func(t *testing.T) {
+				writeFile(t, root, "docs/a.md", "# Docs Alpha\n\nMore.\n")
+			},
+			want: SiteResult{Pages: 4, Rendered: 1},
+		},
+		{
+			name: "linked heading renamed",
+			change: func(t *testing.T) {
+				writeFile(t, root, "a.md", "---\ntags: [go]\n---\n# Alpha\n\n## Install Steps\n\nText #project/web\n")
+			},
+			want: SiteResult{Pages: 4, Rendered: 3},
+		},
+		{
+			name: "output deleted",
+			change: func(t *testing.T) {
+				if err := os.Remove(filepath.Join(out, "b.html")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: SiteResult{Pages: 4, Rendered: 1},
+		},
+		{
+			name: "asset changed",
+			change: func(t *testing.T) {
+				writeFile(t, root, "img/pic.png", "new-png-data")
+			},
+			want: SiteResult{Pages: 4, Copied: 1},
+		},
+		{
+			name:    "forced rebuild",
+			rebuild: true,
+			want:    SiteResult{Pages: 4, Rendered: 4, Copied: 2},
+		},
+	}
+	for _, step := range steps {
+		if step.change != nil {
+			step.change(t)
+		}
+		if got := buildSite(t, root, out, step.rebuild); got != step.want {
+			t.Errorf("%s: BuildSite() = %+v, want %+v", step.name, got, step.want)
+		}
+	}
+	if got := readSiteFile(t, out, "img/pic.png"); got != "new-png-data" {
+		t.Errorf("img/pic.png = %q, want the updated asset", got)
+	}
+}
+
+func TestBuildSiteRemovesStaleFiles(t *testing.T) {
+	root := writeNotes(t, siteNotes())
+	out := t.TempDir()
+	buildSite(t, root, out, false)
+	writeFile(t, out, "CNAME", "notes.example.com")
+
+	if err := os.RemoveAll(filepath.Join(root, "docs")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, root, "a.md", "# Alpha\n\n## Setup Steps\n\nText #project/web\n")
+	writeFile(t, root, "b.md", "# Beta\n\n[[a]] #project\n")
+
+	result := buildSite(t, root, out, false)
+	if result.Removed != 5 {
+		t.Errorf("Removed = %d, want 5", result.Removed)
+	}
+
+	var files []string
+	err := filepath.WalkDir(out, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(out, p)
+		files = append(files, filepath.ToSlash(rel))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	want := []string{
+		SiteManifestFile, "CNAME", "_site/search-index.json", "_site/search.js", "_site/style.css",
+		"_site/tags/index.html", "_site/tags/project.html", "_site/tags/project/web.html",
+		"a.html", "b.html", "index.html",
+	}
+	sort.Strings(want)
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("site files = %q, want %q", files, want)
+	}
+	for _, dir := range []string{"docs", "img"} {
+		if _, err := os.Stat(filepath.Join(out, dir)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("empty folder %s was kept", dir)
+		}
+	}
+}
+
+func TestBuildSiteErrors(t *testing.T) {
+	root := writeNotes(t, map[string]string{"a.md": "# A\n"})
+	tests := []struct {
+		name string
+		root string
+		out  string
+		opts Options
+		want error
+	}{
+		{name: "output contains the workspace", root: root, out: filepath.Dir(root), want: ErrSiteOutput},
+		{name: "no notes", root: writeNotes(t, map[string]string{"pic.png": "png"}), out: t.TempDir(), want: ErrSiteNoNotes},
+		{name: "linked file clashes with a page", root: writeNotes(t, map[string]string{"a.md": "[old](a.html)\n", "a.html": "<p>old</p>"}), out: t.TempDir(), want: ErrSiteConflict},
+		{name: "unknown theme", root: root, out: t.TempDir(), opts: Options{Theme: "neon"}, want: ErrExportTheme},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := BuildSite(tt.root, tt.out, tt.opts, false); !errors.Is(err, tt.want) {
+				t.Errorf("BuildSite() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"markdown-editor/internal/slides"
+)
+
+var deckTemplate = template.Must(template.New("deck").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="generator" content="markdown-editor">
+<title>{{.Title}}</title>
+<style>
+{{.CSS}}</style>
+</head>
+<body>
+{{range .Slides}}<section class="slide" id="slide-{{.Number}}" hidden>
+<div class="slide-content">
+{{.Body}}</div>
+{{if .Notes}}<aside class="notes">
+{{.Notes}}</aside>
+{{end}}</section>
+{{end}}<div class="slide-number"></div>
+<script>
+{{.Script}}</script>
+</body>
+</html>
+`))
+
+type deckSlide struct {
+	Number int
+	Body   template.HTML
+	Notes  template.HTML
+}
+
+const deckCSS = `html,body{height:100%;overflow:hidden}
+.slide{position:absolute;inset:0;display:flex;flex-direction:column;justify-content:center;padding:5vh 8vw;overflow:auto}
+.slide[hidden]{display:none}
+.slide-content{width:100%;max-width:1200px;margin:0 auto;font-size:min(3.4vh,2.2vw)}
+.slide-content h1,.slide-content h2{border-bottom:0}
+.slide-content h1{font-size:2.2em}
+.slide-content h2{font-size:1.7em}
+.notes{display:none}
+.show-notes .notes{display:block;position:fixed;left:0;right:0;bottom:0;max-height:30vh;overflow:auto;padding:12px 8vw;background:var(--surface);border-top:1px solid var(--border);font-size:16px}
+.slide-number{position:fixed;right:16px;bottom:12px;color:var(--muted);font-size:14px}
+`
+
+const deckScript = `(function () {
+  var slides = document.querySelectorAll(".slide");
+  var counter = document.querySelector(".slide-number");
+  var current = 0;
+
+  function show(i) {
+    current = Math.max(0, Math.min(slides.length - 1, i));
+    for (var j = 0; j < slides.length; j++) slides[j].hidden = j !== current;
+    counter.textContent = (current + 1) + " / " + slides.length;
+    if (history.replaceState) history.replaceState(null, "", "#" + (current + 1));
+  }
+
+  document.addEventListener("keydown", function (e) {
+    if (e.altKey || e.ctrlKey || e.metaKey) return;
+    switch (e.key) {
+      case "ArrowRight": case "ArrowDown": case "PageDown": case " ": case "Enter": show(current + 1); break;
+      case "ArrowLeft": case "ArrowUp": case "PageUp": case "Backspace": show(current - 1); break;
+      case "Home": show(0); break;
+      case "End": show(slides.length - 1); break;
+      case "n": case "N": document.body.classList.toggle("show-notes"); break;
+      case "f": case "F":
+        if (document.fullscreenElement) document.exitFullscreen();
+        else if (document.documentElement.requestFullscreen) document.documentElement.requestFullscreen();
+        break;
+      default: return;
+    }
+    e.preventDefault();
+  });
+  window.addEventListener("hashchange", function () {
+    show((parseInt(location.hash.slice(1), 10) || 1) - 1);
+  });
+  show((parseInt(location.hash.slice(1), 10) || 1) - 1);
+})();
+`
+
+func Slides(content string, opts Options) ([]byte, error) {
+	theme, err := checkTheme(opts.Theme)
+	if err != nil {
+		return nil, err
+	}
+	deck := slides.Split(content)
+	if len(deck) == 0 {
+		return nil, slides.ErrNoSlides
+	}
+
+	rendered := make([]deckSlide, 0, len(deck))
+	for i, slide := range deck {
+		body, err := renderBody(parse(slide.Content, opts), opts, theme)
+		if err != nil {
+			return nil, err
+		}
+		s := deckSlide{Number: i + 1, Body: template.HTML(body)}
+		if slide.Notes != "" {
+			notes, err := renderBody(parse(slide.Notes, opts), opts, theme)
+			if err != nil {
+				return nil, err
+			}
+			s.Notes = template.HTML(notes)
+		}
+		rendered = append(rendered, s)
+	}
+
+	var out bytes.Buffer
+	if err := deckTemplate.Execute(&out, map[string]any{
+		"Title":  parse(content, opts).title,
+		"CSS":    template.CSS(stylesheet(theme) + deckCSS),
+		"Slides": rendered,
+		"Script": template.JS(deckScript),
+	}); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExportRender, err)
+	}
+	return out.Bytes(), nil
+}
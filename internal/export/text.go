@@ -0,0 +1,126 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+)
+
+const textIndent = "  "
+
+func Text(content string, opts Options) ([]byte, error) {
+	flow := buildFlow(content, opts)
+	var b strings.Builder
+	counters := make(map[int]int)
+	var indents []string
+	var previous *flowBlock
+	for _, block := range flow.blocks {
+		if previous != nil {
+			b.WriteString("\n")
+			if previous.list == nil || block.list == nil || rootList(previous.list) != rootList(block.list) {
+				b.WriteString(strings.TrimRight(strings.Repeat("> ", min(previous.quote, block.quote)), " ") + "\n")
+			}
+		}
+		previous = block
+
+		prefix := strings.Repeat("> ", block.quote)
+		first := prefix
+		if l := block.list; l != nil {
+			indents = indents[:min(len(indents), l.level+1)]
+			for len(indents) <= l.level {
+				indents = append(indents, "")
+			}
+			base := ""
+			if l.level > 0 {
+				base = indents[l.level-1]
+			}
+			if block.item {
+				marker := "- "
+				if l.ordered {
+					marker = fmt.Sprintf("%d. ", l.start+counters[l.id])
+					counters[l.id]++
+				}
+				first += base + marker
+				indents[l.level] = base + strings.Repeat(" ", len(marker))
+			} else {
+				first += indents[l.level]
+			}
+			prefix += indents[l.level]
+		}
+
+		var lines []string
+		switch block.kind {
+		case flowCode:
+			for _, line := range block.lines {
+				lines = append(lines, textRuns(line))
+			}
+		case flowTable:
+			for _, row := range block.rows {
+				cells := make([]string, len(row))
+				for i, cell := range row {
+					cells[i] = strings.ReplaceAll(textRuns(cell), "\n", " ")
+				}
+				lines = append(lines, strings.Join(cells, "\t"))
+			}
+		case flowRule:
+			lines = []string{"---"}
+		default:
+			lines = strings.Split(textRuns(block.runs), "\n")
+		}
+		for i, line := range lines {
+			if i == 0 {
+				b.WriteString(first)
+			} else {
+				b.WriteString("\n" + prefix)
+			}
+			b.WriteString(line)
+		}
+	}
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+	return []byte(b.String()), nil
+}
+
+func rootList(l *flowList) *flowList {
+	for l.parent != nil {
+		l = l.parent
+	}
+	return l
+}
+
+func textRuns(runs []flowRun) string {
+	var b strings.Builder
+	for i, r := range runs {
+		switch {
+		case r.lineBreak:
+			b.WriteString("\n")
+		case r.super:
+			fmt.Fprintf(&b, "[%s]", r.text)
+		default:
+			b.WriteString(r.text)
+		}
+		if r.link == "" || r.super || strings.HasPrefix(r.link, "#") {
+			continue
+		}
+		if i+1 < len(runs) && runs[i+1].link == r.link {
+			continue
+		}
+		label := linkLabel(runs, i)
+		if url := strings.TrimPrefix(r.link, "mailto:"); url != label {
+			fmt.Fprintf(&b, " (%s)", url)
+		}
+	}
+	return b.String()
+}
+
+func linkLabel(runs []flowRun, end int) string {
+	start := end
+	for start > 0 && runs[start-1].link == runs[end].link {
+		start--
+	}
+	var label strings.Builder
+	for _, r := range runs[start : end+1] {
+		label.WriteString(r.text)
+	}
+	return label.String()
+}
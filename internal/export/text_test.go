@@ -0,0 +1,100 @@
+package export
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "links keep their targets",
+			content: "See [Go](https://go.dev), <https://x.org>, [top](#intro) and [mail](mailto:a@b.c).\n",
+			want:    "See Go (https://go.dev), https://x.org, top and mail (a@b.c).\n",
+		},
+		{
+			name:    "tables become tab separated rows",
+			content: "| Name | Qty |\n| :-- | --: |\n| apple | 3 |\n| pear | 10 |\n",
+			want:    "Name\tQty\napple\t3\npear\t10\n",
+		},
+		{
+			name:    "task items and nested lists",
+			content: "- [x] done\n- [ ] todo\n  1. sub\n  2. sub2\n",
+			want:    "- ☑ done\n- ☐ todo\n  1. sub\n  2. sub2\n",
+		},
+		{
+			name:    "callout with a title",
+			content: "> [!NOTE] Heads up\n> Body **text**\n",
+			want:    "> Heads up\n>\n> Body text\n",
+		},
+		{
+			name:    "callout without a title",
+			content: "> [!WARNING]\n> Careful\n",
+			want:    "> Warning\n>\n> Careful\n",
+		},
+		{
+			name:    "headings, rules and code",
+			content: "# Title\n\nPara one\nline two\n\n---\n\n```go\nx := 1\n```\n",
+			want:    "Title\n\nPara one line two\n\n---\n\nx := 1\n",
+		},
+		{
+			name:    "footnotes",
+			content: "Text[^1]\n\n[^1]: Foot\n",
+			want:    "Text[1]\n\n---\n\n1. Foot\n",
+		},
+		{
+			name:    "front matter is dropped",
+			content: "---\ntitle: x\n---\nBody\n",
+			want:    "Body\n",
+		},
+		{
+			name: "empty note",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Text(tt.content, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Text() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLFragment(t *testing.T) {
+	dir := writeNotes(t, map[string]string{"pic.png": string(pngData(t))})
+	content := "---\ntitle: x\n---\n# Title\n\n> [!TIP] Try\n> this\n\n- [x] done\n\n![pic](pic.png) [Go](https://go.dev)\n\n<script>alert(1)</script>\n"
+	got, err := HTMLFragment(content, Options{BaseDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(got)
+	for _, want := range []string{
+		`<h1 id="title">Title</h1>`,
+		`<div class="callout callout-tip">`,
+		`<p class="callout-title">Try</p>`,
+		`<input checked="" disabled="" type="checkbox"> done`,
+		`<img src="data:image/png;base64,`,
+		`<a href="https://go.dev">Go</a>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("fragment does not contain %q:\n%s", want, html)
+		}
+	}
+	for _, unwanted := range []string{"<html", "<head", "<style", "<body", "title: x", "<script", "alert(1)"} {
+		if strings.Contains(html, unwanted) {
+			t.Errorf("fragment contains %q:\n%s", unwanted, html)
+		}
+	}
+
+	if _, err := HTMLFragment("x", Options{Theme: "neon"}); err == nil {
+		t.Error("HTMLFragment() accepted an unknown theme")
+	}
+}
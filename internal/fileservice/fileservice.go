@@ -3,7 +3,9 @@ package fileservice
 import (
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
@@ -21,6 +23,8 @@ var (
 	ErrFileServiceExistenceCheck = errors.New("fileservice: existence check failed")
 	ErrFileServiceListDirFailed  = errors.New("fileservice: list directory failed")
 	ErrFileServiceMkdirAllFailed = errors.New("fileservice: mkdirall failed")
+	ErrFileServiceWalkFailed     = errors.New("fileservice: walk directory failed")
+	ErrFileServiceChtimesFailed  = errors.New("fileservice: set modification time failed")
 	ErrFilenameGenDirNil         = errors.New("fileservice: directory cannot be nil for filename generation")
 	ErrFilenameGenURI            = errors.New("fileservice: failed to create URI for unique filename check")
 	ErrFilenameGenExistsCheck    = errors.New("fileservice: failed to check existence of potential filename")
@@ -108,6 +112,37 @@ func (s *Service) ListDirectory(dir fyne.ListableURI) ([]fyne.URI, error) {
 	return items, nil
 }
 
+func (s *Service) ListFilesRecursive(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasPrefix(d.Name(), ".") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: walking '%s': %v", ErrFileServiceWalkFailed, root, err)
+	}
+
+	return files, nil
+}
+
+func (s *Service) SetModTime(uri fyne.URI, modTime time.Time) error {
+	if err := os.Chtimes(uri.Path(), time.Time{}, modTime); err != nil {
+		return fmt.Errorf("%w: '%s': %v", ErrFileServiceChtimesFailed, uri.Path(), err)
+	}
+	return nil
+}
+
 func (s *Service) CreateDirectoryAll(path string) error {
 	if err := os.MkdirAll(path, 0755); err != nil {
 		return fmt.Errorf("%w: creating directory '%s': %v", ErrFileServiceMkdirAllFailed, path, err)
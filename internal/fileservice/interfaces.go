@@ -1,14 +1,21 @@
 package fileservice
 
-import "fyne.io/fyne/v2"
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+)
 
 type FileOperations interface {
 	ReadFile(uri fyne.URI) ([]byte, error)
 	WriteFile(uri fyne.URI, content []byte) error
 	DeleteFile(uri fyne.URI) error
+	MoveToTrash(uri fyne.URI) error
 	RenameFile(oldURI, newURI fyne.URI) error
 	FileExists(uri fyne.URI) (bool, error)
 	ListDirectory(dir fyne.ListableURI) ([]fyne.URI, error)
+	ListFilesRecursive(root string) ([]string, error)
+	SetModTime(uri fyne.URI, modTime time.Time) error
 	CreateDirectoryAll(path string) error
 	GenerateUniqueFilename(dir fyne.ListableURI, basePrefix, extension string) (string, error)
 	SanitizeFilenameComponent(input string) string
@@ -0,0 +1,111 @@
+package fileservice
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+var ErrFileServiceTrashFailed = errors.New("fileservice: move to trash failed")
+
+const (
+	trashInfoTimeFormat = "2006-01-02T15:04:05"
+	trashMaxAttempts    = 1000
+)
+
+func trashDir() (string, error) {
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+		return filepath.Join(dataHome, "Trash"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "Trash"), nil
+}
+
+func (s *Service) MoveToTrash(uri fyne.URI) error {
+	path, err := filepath.Abs(uri.Path())
+	if err != nil {
+		return fmt.Errorf("%w: resolving '%s': %v", ErrFileServiceTrashFailed, uri.Path(), err)
+	}
+
+	trash, err := trashDir()
+	if err != nil {
+		return fmt.Errorf("%w: locating trash: %v", ErrFileServiceTrashFailed, err)
+	}
+	filesDir := filepath.Join(trash, "files")
+	infoDir := filepath.Join(trash, "info")
+	if err := os.MkdirAll(filesDir, 0700); err != nil {
+		return fmt.Errorf("%w: creating '%s': %v", ErrFileServiceTrashFailed, filesDir, err)
+	}
+	if err := os.MkdirAll(infoDir, 0700); err != nil {
+		return fmt.Errorf("%w: creating '%s': %v", ErrFileServiceTrashFailed, infoDir, err)
+	}
+
+	name, infoFile, err := reserveTrashName(infoDir, filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrFileServiceTrashFailed, err)
+	}
+
+	info := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: path}).EscapedPath(), time.Now().Format(trashInfoTimeFormat))
+	if _, err := infoFile.WriteString(info); err != nil {
+		err = errors.Join(err, infoFile.Close(), os.Remove(infoFile.Name()))
+		return fmt.Errorf("%w: writing trash info for '%s': %v", ErrFileServiceTrashFailed, path, err)
+	}
+	if err := infoFile.Close(); err != nil {
+		err = errors.Join(err, os.Remove(infoFile.Name()))
+		return fmt.Errorf("%w: %v: %v", ErrFileServiceCloseFailed, infoFile.Name(), err)
+	}
+
+	if err := moveFile(path, filepath.Join(filesDir, name)); err != nil {
+		err = errors.Join(err, os.Remove(infoFile.Name()))
+		return fmt.Errorf("%w: moving '%s': %v", ErrFileServiceTrashFailed, path, err)
+	}
+	return nil
+}
+
+func reserveTrashName(infoDir, base string) (string, *os.File, error) {
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	name := base
+	for i := 1; i <= trashMaxAttempts; i++ {
+		f, err := os.OpenFile(filepath.Join(infoDir, name+".trashinfo"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			return name, f, nil
+		}
+		if !os.IsExist(err) {
+			return "", nil, err
+		}
+		name = fmt.Sprintf("%s.%d%s", stem, i, ext)
+	}
+	return "", nil, fmt.Errorf("no free trash name for '%s'", base)
+}
+
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return errors.Join(err, in.Close())
+	}
+	_, copyErr := io.Copy(out, in)
+	if err := errors.Join(copyErr, out.Close(), in.Close()); err != nil {
+		return errors.Join(err, os.Remove(dst))
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,377 @@
+package frontmatter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrFrontmatterParse        = errors.New("frontmatter: parse failed")
+	ErrFrontmatterEncode       = errors.New("frontmatter: encode failed")
+	ErrFrontmatterNotMapping   = errors.New("frontmatter: top level is not a mapping")
+	ErrFrontmatterDuplicateKey = errors.New("frontmatter: duplicate property key")
+)
+
+type Format int
+
+const (
+	FormatNone Format = iota
+	FormatYAML
+	FormatTOML
+)
+
+const (
+	yamlDelimiter    = "---"
+	yamlEndDelimiter = "..."
+	tomlDelimiter    = "+++"
+	DateLayout       = "2006-01-02"
+)
+
+const (
+	KeyTitle    = "title"
+	KeyTags     = "tags"
+	KeyDate     = "date"
+	KeyCreated  = "created"
+	KeyModified = "modified"
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatYAML:
+		return "YAML"
+	case FormatTOML:
+		return "TOML"
+	default:
+		return "None"
+	}
+}
+
+type Block struct {
+	Format   Format
+	Raw      string
+	Body     string
+	BodyLine int
+}
+
+type Property struct {
+	Key   string
+	Value any
+}
+
+type Document struct {
+	Block
+	Properties []Property
+}
+
+func Split(content string) Block {
+	lines := strings.SplitAfter(content, "\n")
+	if len(lines) == 0 {
+		return Block{Body: content}
+	}
+
+	var format Format
+	switch trimLine(lines[0]) {
+	case yamlDelimiter:
+		format = FormatYAML
+	case tomlDelimiter:
+		format = FormatTOML
+	default:
+		return Block{Body: content}
+	}
+
+	offset := len(lines[0])
+	for i := 1; i < len(lines); i++ {
+		line := trimLine(lines[i])
+		closes := (format == FormatYAML && (line == yamlDelimiter || line == yamlEndDelimiter)) ||
+			(format == FormatTOML && line == tomlDelimiter)
+		if closes {
+			return Block{
+				Format:   format,
+				Raw:      strings.Join(lines[1:i], ""),
+				Body:     content[offset+len(lines[i]):],
+				BodyLine: i + 1,
+			}
+		}
+		offset += len(lines[i])
+	}
+
+	return Block{Body: content}
+}
+
+func Parse(content string) (*Document, error) {
+	doc := &Document{Block: Split(content)}
+
+	var err error
+	switch doc.Format {
+	case FormatYAML:
+		doc.Properties, err = parseYAML(doc.Raw)
+	case FormatTOML:
+		doc.Properties, err = parseTOML(doc.Raw)
+	}
+	if err != nil {
+		return doc, err
+	}
+	return doc, nil
+}
+
+func (d *Document) Get(key string) (any, bool) {
+	for _, p := range d.Properties {
+		if p.Key == key {
+			return p.Value, true
+		}
+	}
+	return nil, false
+}
+
+func (d *Document) Tags() []string {
+	value, ok := d.Get(KeyTags)
+	if !ok {
+		return nil
+	}
+	return Tags(value)
+}
+
+func Tags(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+	case []string:
+		return v
+	case []any:
+		tags := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				tags = append(tags, s)
+			}
+		}
+		return tags
+	}
+	return nil
+}
+
+func Render(content string, props []Property) (string, error) {
+	doc, err := Parse(content)
+	if err != nil {
+		return content, err
+	}
+	if reflect.DeepEqual(doc.Properties, props) || (len(doc.Properties) == 0 && len(props) == 0) {
+		return content, nil
+	}
+
+	seen := make(map[string]bool, len(props))
+	for _, p := range props {
+		if seen[p.Key] {
+			return content, fmt.Errorf("%w: '%s'", ErrFrontmatterDuplicateKey, p.Key)
+		}
+		seen[p.Key] = true
+	}
+
+	if len(props) == 0 {
+		return doc.Body, nil
+	}
+
+	format := doc.Format
+	if format == FormatNone {
+		format = FormatYAML
+	}
+
+	var encoded string
+	var delimiter string
+	switch format {
+	case FormatTOML:
+		delimiter = tomlDelimiter
+		encoded, err = encodeTOML(props)
+	default:
+		delimiter = yamlDelimiter
+		encoded, err = encodeYAML(doc.Raw, props)
+	}
+	if err != nil {
+		return content, err
+	}
+
+	return delimiter + "\n" + encoded + delimiter + "\n" + doc.Body, nil
+}
+
+func trimLine(line string) string {
+	return strings.TrimRight(line, "\r\n")
+}
+
+func parseYAML(raw string) ([]Property, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(raw), &root); err != nil {
+		return nil, fmt.Errorf("%w: yaml: %v", ErrFrontmatterParse, err)
+	}
+	mapping, err := yamlMapping(&root)
+	if err != nil || mapping == nil {
+		return nil, err
+	}
+
+	props := make([]Property, 0, len(mapping.Content)/2)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		var value any
+		if err := mapping.Content[i+1].Decode(&value); err != nil {
+			return nil, fmt.Errorf("%w: yaml key '%s': %v", ErrFrontmatterParse, mapping.Content[i].Value, err)
+		}
+		props = append(props, Property{Key: mapping.Content[i].Value, Value: value})
+	}
+	return props, nil
+}
+
+func yamlMapping(root *yaml.Node) (*yaml.Node, error) {
+	if root.Kind == 0 {
+		return nil, nil
+	}
+	if root.Kind == yaml.DocumentNode {
+		if len(root.Content) == 0 {
+			return nil, nil
+		}
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, ErrFrontmatterNotMapping
+	}
+	return root, nil
+}
+
+func encodeYAML(raw string, props []Property) (string, error) {
+	var root yaml.Node
+	if strings.TrimSpace(raw) != "" {
+		if err := yaml.Unmarshal([]byte(raw), &root); err != nil {
+			return "", fmt.Errorf("%w: yaml: %v", ErrFrontmatterParse, err)
+		}
+	}
+	mapping, err := yamlMapping(&root)
+	if err != nil {
+		return "", err
+	}
+	if mapping == nil {
+		mapping = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		root = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{mapping}}
+	}
+
+	existing := make(map[string][2]*yaml.Node, len(mapping.Content)/2)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		existing[mapping.Content[i].Value] = [2]*yaml.Node{mapping.Content[i], mapping.Content[i+1]}
+	}
+
+	content := make([]*yaml.Node, 0, len(props)*2)
+	for _, p := range props {
+		pair, ok := existing[p.Key]
+		if !ok {
+			valueNode, err := yamlValueNode(p.Value, nil)
+			if err != nil {
+				return "", err
+			}
+			keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: p.Key}
+			content = append(content, keyNode, valueNode)
+			continue
+		}
+
+		var previous any
+		if err := pair[1].Decode(&previous); err == nil && reflect.DeepEqual(previous, p.Value) {
+			content = append(content, pair[0], pair[1])
+			continue
+		}
+		valueNode, err := yamlValueNode(p.Value, pair[1])
+		if err != nil {
+			return "", err
+		}
+		content = append(content, pair[0], valueNode)
+	}
+	mapping.Content = content
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&root); err != nil {
+		return "", fmt.Errorf("%w: yaml: %v", ErrFrontmatterEncode, err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("%w: yaml: %v", ErrFrontmatterEncode, err)
+	}
+	return buf.String(), nil
+}
+
+func yamlValueNode(value any, previous *yaml.Node) (*yaml.Node, error) {
+	if t, ok := value.(time.Time); ok {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!timestamp", Value: FormatValue(t)}, nil
+	}
+
+	node := &yaml.Node{}
+	if err := node.Encode(value); err != nil {
+		return nil, fmt.Errorf("%w: yaml: %v", ErrFrontmatterEncode, err)
+	}
+	if previous != nil && previous.Kind == node.Kind {
+		switch {
+		case node.Kind == yaml.SequenceNode:
+			node.Style = previous.Style
+		case node.Kind == yaml.ScalarNode && node.Tag == "!!str" && previous.Tag == "!!str":
+			node.Style = previous.Style
+		}
+		node.LineComment = previous.LineComment
+	}
+	return node, nil
+}
+
+func parseTOML(raw string) ([]Property, error) {
+	values := make(map[string]any)
+	meta, err := toml.Decode(raw, &values)
+	if err != nil {
+		return nil, fmt.Errorf("%w: toml: %v", ErrFrontmatterParse, err)
+	}
+
+	props := make([]Property, 0, len(values))
+	for _, key := range meta.Keys() {
+		if len(key) != 1 {
+			continue
+		}
+		props = append(props, Property{Key: key[0], Value: values[key[0]]})
+	}
+	if len(props) != len(values) {
+		known := make(map[string]bool, len(props))
+		for _, p := range props {
+			known[p.Key] = true
+		}
+		var missing []string
+		for key := range values {
+			if !known[key] {
+				missing = append(missing, key)
+			}
+		}
+		sort.Strings(missing)
+		for _, key := range missing {
+			props = append(props, Property{Key: key, Value: values[key]})
+		}
+	}
+	return props, nil
+}
+
+func encodeTOML(props []Property) (string, error) {
+	var scalars, tables bytes.Buffer
+	for _, p := range props {
+		target := &scalars
+		if _, ok := p.Value.(map[string]any); ok {
+			target = &tables
+		}
+		enc := toml.NewEncoder(target)
+		enc.Indent = ""
+		if err := enc.Encode(map[string]any{p.Key: p.Value}); err != nil {
+			return "", fmt.Errorf("%w: toml key '%s': %v", ErrFrontmatterEncode, p.Key, err)
+		}
+	}
+	if tables.Len() > 0 && scalars.Len() > 0 {
+		scalars.WriteString("\n")
+	}
+	scalars.Write(tables.Bytes())
+	return scalars.String(), nil
+}
@@ -0,0 +1,177 @@
+package frontmatter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    Block
+	}{
+		{"none", "# Title\n", Block{Body: "# Title\n"}},
+		{"yaml", "---\ntitle: a\n---\nbody\n", Block{Format: FormatYAML, Raw: "title: a\n", Body: "body\n", BodyLine: 3}},
+		{"yaml dots", "---\ntitle: a\n...\nbody", Block{Format: FormatYAML, Raw: "title: a\n", Body: "body", BodyLine: 3}},
+		{"toml", "+++\ntitle = \"a\"\n+++\nbody", Block{Format: FormatTOML, Raw: "title = \"a\"\n", Body: "body", BodyLine: 3}},
+		{"crlf", "---\r\ntitle: a\r\n---\r\nbody", Block{Format: FormatYAML, Raw: "title: a\r\n", Body: "body", BodyLine: 3}},
+		{"unterminated", "---\ntitle: a\nbody", Block{Body: "---\ntitle: a\nbody"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Split(tt.content); got != tt.want {
+				t.Errorf("Split() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []Property
+		wantErr error
+	}{
+		{
+			name:    "yaml keeps order",
+			content: "---\nzeta: 1\ntags: [a, b]\ndraft: true\n---\n",
+			want: []Property{
+				{Key: "zeta", Value: 1},
+				{Key: "tags", Value: []any{"a", "b"}},
+				{Key: "draft", Value: true},
+			},
+		},
+		{
+			name:    "toml keeps order",
+			content: "+++\nzeta = 1\ntags = [\"a\"]\n+++\n",
+			want: []Property{
+				{Key: "zeta", Value: int64(1)},
+				{Key: "tags", Value: []any{"a"}},
+			},
+		},
+		{name: "empty yaml", content: "---\n---\nbody"},
+		{name: "not a mapping", content: "---\n- a\n---\n", wantErr: ErrFrontmatterNotMapping},
+		{name: "invalid yaml", content: "---\ntitle: [\n---\n", wantErr: ErrFrontmatterParse},
+		{name: "invalid toml", content: "+++\ntitle = \n+++\n", wantErr: ErrFrontmatterParse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Parse(tt.content)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(doc.Properties, tt.want) {
+				t.Errorf("Parse() = %#v, want %#v", doc.Properties, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		content string
+		props   []Property
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "adds yaml to plain note",
+			content: "body\n",
+			props:   []Property{{Key: "title", Value: "Hi"}, {Key: "date", Value: date}},
+			want:    "---\ntitle: Hi\ndate: 2024-03-01\n---\nbody\n",
+		},
+		{
+			name:    "keeps untouched yaml styling",
+			content: "---\ntitle: \"Quoted\" # note\ntags: [a, b]\n---\nbody\n",
+			props:   []Property{{Key: "title", Value: "Quoted"}, {Key: "tags", Value: []any{"a", "b", "c"}}},
+			want:    "---\ntitle: \"Quoted\" # note\ntags: [a, b, c]\n---\nbody\n",
+		},
+		{
+			name:    "toml stays toml",
+			content: "+++\ntitle = \"a\"\n+++\nbody",
+			props:   []Property{{Key: "title", Value: "b"}},
+			want:    "+++\ntitle = \"b\"\n+++\nbody",
+		},
+		{
+			name:    "removing all properties drops the block",
+			content: "---\ntitle: a\n---\nbody",
+			want:    "body",
+		},
+		{
+			name:    "duplicate keys",
+			content: "body",
+			props:   []Property{{Key: "a", Value: 1}, {Key: "a", Value: 2}},
+			want:    "body",
+			wantErr: ErrFrontmatterDuplicateKey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.content, tt.props)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Render() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, content := range []string{
+		"---\ntitle: Note\ntags:\n  - a\n  - b\ncount: 3\ndraft: false\n---\n# Body\n",
+		"+++\ncount = 3\ndraft = false\ntitle = \"Note\"\n+++\n# Body\n",
+	} {
+		doc, err := Parse(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rendered, err := Render(content, doc.Properties)
+		if err != nil || rendered != content {
+			t.Errorf("Render(Parse(%q)) = %q, %v", content, rendered, err)
+		}
+
+		edited := append([]Property(nil), doc.Properties...)
+		edited = append(edited, Property{Key: "extra", Value: "x"})
+		rendered, err = Render(content, edited)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reparsed, err := Parse(rendered)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(reparsed.Properties, edited) || reparsed.Body != doc.Body {
+			t.Errorf("round trip of %q = %#v, want %#v", content, reparsed.Properties, edited)
+		}
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		text     string
+		previous any
+		want     any
+	}{
+		{"true", false, true},
+		{"maybe", false, "maybe"},
+		{"42", 1, 42},
+		{"42", int64(1), int64(42)},
+		{"1.5", 0.5, 1.5},
+		{"2024-01-02", time.Time{}, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"a, b,", []any{}, []any{"a", "b"}},
+		{" text ", nil, "text"},
+	}
+	for _, tt := range tests {
+		if got := ParseValue(tt.text, tt.previous); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseValue(%q, %#v) = %#v, want %#v", tt.text, tt.previous, got, tt.want)
+		}
+	}
+}
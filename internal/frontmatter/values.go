@@ -0,0 +1,97 @@
+package frontmatter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func FormatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Nanosecond() == 0 {
+			return v.Format(DateLayout)
+		}
+		return v.Format(time.RFC3339)
+	case []string:
+		return strings.Join(v, ", ")
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, FormatValue(item))
+		}
+		return strings.Join(items, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func IsEditable(value any) bool {
+	switch v := value.(type) {
+	case map[string]any:
+		return false
+	case []any:
+		for _, item := range v {
+			if !IsEditable(item) {
+				return false
+			}
+			if _, ok := item.([]any); ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func ParseValue(text string, previous any) any {
+	text = strings.TrimSpace(text)
+
+	switch previous.(type) {
+	case bool:
+		if b, err := strconv.ParseBool(text); err == nil {
+			return b
+		}
+	case int, int64:
+		if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+			if _, ok := previous.(int); ok {
+				return int(n)
+			}
+			return n
+		}
+	case float64:
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			return f
+		}
+	case time.Time:
+		if t, ok := ParseDate(text); ok {
+			return t
+		}
+	case []string, []any:
+		return SplitList(text)
+	}
+	return text
+}
+
+func ParseDate(text string) (time.Time, bool) {
+	for _, layout := range []string{DateLayout, time.RFC3339, "2006-01-02 15:04", "2006-01-02T15:04"} {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func SplitList(text string) []any {
+	items := make([]any, 0)
+	for _, item := range strings.Split(text, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,173 @@
+package highlight
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+var ErrHighlightTokenize = errors.New("highlight: failed to tokenize code")
+
+type Kind int
+
+const (
+	KindPlain Kind = iota
+	KindKeyword
+	KindType
+	KindFunction
+	KindString
+	KindNumber
+	KindComment
+	KindOperator
+	KindInserted
+	KindDeleted
+	KindHeading
+	KindEmphasis
+	KindStrong
+	KindStrike
+	KindCode
+	KindLink
+	KindURL
+	KindQuote
+	KindListMarker
+	KindMarkup
+	KindFrontmatter
+	KindTag
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindKeyword:
+		return "keyword"
+	case KindType:
+		return "type"
+	case KindFunction:
+		return "function"
+	case KindString:
+		return "string"
+	case KindNumber:
+		return "number"
+	case KindComment:
+		return "comment"
+	case KindOperator:
+		return "operator"
+	case KindInserted:
+		return "inserted"
+	case KindDeleted:
+		return "deleted"
+	case KindHeading:
+		return "heading"
+	case KindEmphasis:
+		return "emphasis"
+	case KindStrong:
+		return "strong"
+	case KindStrike:
+		return "strike"
+	case KindCode:
+		return "code"
+	case KindLink:
+		return "link"
+	case KindURL:
+		return "url"
+	case KindQuote:
+		return "quote"
+	case KindListMarker:
+		return "list-marker"
+	case KindMarkup:
+		return "markup"
+	case KindFrontmatter:
+		return "frontmatter"
+	case KindTag:
+		return "tag"
+	default:
+		return "plain"
+	}
+}
+
+type Token struct {
+	Kind Kind
+	Text string
+}
+
+var aliases = map[string]string{
+	"sh":     "bash",
+	"shell":  "bash",
+	"zsh":    "bash",
+	"js":     "javascript",
+	"jsx":    "javascript",
+	"ts":     "typescript",
+	"tsx":    "typescript",
+	"yml":    "yaml",
+	"py":     "python",
+	"golang": "go",
+	"patch":  "diff",
+}
+
+func Language(info string) string {
+	fields := strings.Fields(info)
+	if len(fields) == 0 {
+		return ""
+	}
+	lang := strings.ToLower(strings.Trim(fields[0], "{}."))
+	if alias, ok := aliases[lang]; ok {
+		return alias
+	}
+	return lang
+}
+
+func Supported(lang string) bool {
+	return lang != "" && lexers.Get(lang) != nil
+}
+
+func Tokenize(lang, code string) ([]Token, error) {
+	lexer := lexers.Get(lang)
+	if lang == "" || lexer == nil {
+		return []Token{{Kind: KindPlain, Text: code}}, nil
+	}
+
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrHighlightTokenize, lang, err)
+	}
+
+	var tokens []Token
+	for _, t := range iterator.Tokens() {
+		kind := kindOf(t.Type)
+		if n := len(tokens); n > 0 && tokens[n-1].Kind == kind {
+			tokens[n-1].Text += t.Value
+			continue
+		}
+		tokens = append(tokens, Token{Kind: kind, Text: t.Value})
+	}
+	return tokens, nil
+}
+
+func kindOf(t chroma.TokenType) Kind {
+	switch {
+	case t == chroma.KeywordType || t == chroma.NameBuiltin || t == chroma.NameClass:
+		return KindType
+	case t.InSubCategory(chroma.LiteralNumber) || t == chroma.KeywordConstant:
+		return KindNumber
+	case t.InCategory(chroma.Keyword) || t == chroma.NameTag:
+		return KindKeyword
+	case t == chroma.NameFunction || t == chroma.NameAttribute:
+		return KindFunction
+	case t.InSubCategory(chroma.LiteralString):
+		return KindString
+	case t.InCategory(chroma.Comment):
+		return KindComment
+	case t.InCategory(chroma.Operator):
+		return KindOperator
+	case t == chroma.GenericInserted:
+		return KindInserted
+	case t == chroma.GenericDeleted:
+		return KindDeleted
+	case t == chroma.GenericHeading || t == chroma.GenericSubheading:
+		return KindHeading
+	default:
+		return KindPlain
+	}
+}
@@ -0,0 +1,181 @@
+package highlight
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLanguage(t *testing.T) {
+	tests := []struct {
+		info string
+		want string
+	}{
+		{info: "go", want: "go"},
+		{info: "Go", want: "go"},
+		{info: "golang", want: "go"},
+		{info: "js", want: "javascript"},
+		{info: "JSX", want: "javascript"},
+		{info: "tsx", want: "typescript"},
+		{info: "sh", want: "bash"},
+		{info: "shell", want: "bash"},
+		{info: "zsh", want: "bash"},
+		{info: "yml", want: "yaml"},
+		{info: "py", want: "python"},
+		{info: "patch", want: "diff"},
+		{info: "python title=\"main.py\"", want: "python"},
+		{info: "  ts   linenums  ", want: "typescript"},
+		{info: "{.py}", want: "python"},
+		{info: ".rust", want: "rust"},
+		{info: "c++", want: "c++"},
+		{info: "mermaid", want: "mermaid"},
+		{info: "", want: ""},
+		{info: "   ", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.info, func(t *testing.T) {
+			if got := Language(tt.info); got != tt.want {
+				t.Errorf("Language(%q) = %q, want %q", tt.info, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSupported(t *testing.T) {
+	for _, lang := range []string{"go", "javascript", "typescript", "bash", "yaml", "python", "diff", "c++", "rust"} {
+		if !Supported(lang) {
+			t.Errorf("Supported(%q) = false, want true", lang)
+		}
+	}
+	for _, lang := range []string{"", "no-such-language", "mermaid"} {
+		if Supported(lang) {
+			t.Errorf("Supported(%q) = true, want false", lang)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name     string
+		lang     string
+		code     string
+		wantKind map[string]Kind
+	}{
+		{
+			name:     "go",
+			lang:     "go",
+			code:     "func main() { return 42 } // done",
+			wantKind: map[string]Kind{"func": KindKeyword, "42": KindNumber, "// done": KindComment},
+		},
+		{
+			name:     "alias resolved first",
+			lang:     Language("py"),
+			code:     "def f(): return 'x'",
+			wantKind: map[string]Kind{"def": KindKeyword, "'x'": KindString},
+		},
+		{
+			name:     "unknown language",
+			lang:     "no-such-language",
+			code:     "func main() {}",
+			wantKind: map[string]Kind{"func main() {}": KindPlain},
+		},
+		{
+			name:     "no language",
+			code:     "plain text",
+			wantKind: map[string]Kind{"plain text": KindPlain},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := Tokenize(tt.lang, tt.code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var text strings.Builder
+			kinds := make(map[string]Kind)
+			for _, token := range tokens {
+				text.WriteString(token.Text)
+				kinds[strings.TrimSpace(token.Text)] = token.Kind
+			}
+			if text.String() != tt.code {
+				t.Errorf("tokens join to %q, want %q", text.String(), tt.code)
+			}
+			for want, kind := range tt.wantKind {
+				if got, ok := kinds[want]; !ok || got != kind {
+					t.Errorf("token %q = %v (found %v), want %v", want, got, ok, kind)
+				}
+			}
+		})
+	}
+}
+
+func TestFenceLanguage(t *testing.T) {
+	tests := []struct {
+		name      string
+		lines     []string
+		wantCode  bool
+		wantSpans []Span
+	}{
+		{
+			name:      "backtick fence",
+			lines:     []string{"```go", "return nil"},
+			wantCode:  true,
+			wantSpans: []Span{{Start: 0, End: 6, Kind: KindKeyword}, {Start: 7, End: 10, Kind: KindNumber}},
+		},
+		{
+			name:      "tilde fence with an alias and attributes",
+			lines:     []string{"~~~ golang {linenos=true}", "return nil"},
+			wantCode:  true,
+			wantSpans: []Span{{Start: 0, End: 6, Kind: KindKeyword}, {Start: 7, End: 10, Kind: KindNumber}},
+		},
+		{
+			name:      "unsupported language",
+			lines:     []string{"```mermaid", "return nil"},
+			wantCode:  true,
+			wantSpans: []Span{{Start: 0, End: 10, Kind: KindCode}},
+		},
+		{
+			name:      "no language",
+			lines:     []string{"```", "return nil"},
+			wantCode:  true,
+			wantSpans: []Span{{Start: 0, End: 10, Kind: KindCode}},
+		},
+		{
+			name:      "indented fence",
+			lines:     []string{"   ```go", "return nil"},
+			wantCode:  true,
+			wantSpans: []Span{{Start: 0, End: 6, Kind: KindKeyword}, {Start: 7, End: 10, Kind: KindNumber}},
+		},
+		{
+			name:  "closed fence",
+			lines: []string{"```go", "x := 1", "```", "return nil"},
+		},
+		{
+			name:      "longer fence is not closed by a shorter one",
+			lines:     []string{"````go", "```", "return nil"},
+			wantCode:  true,
+			wantSpans: []Span{{Start: 0, End: 6, Kind: KindKeyword}, {Start: 7, End: 10, Kind: KindNumber}},
+		},
+		{
+			name:      "backtick fence is not closed by tildes",
+			lines:     []string{"```go", "~~~", "return nil"},
+			wantCode:  true,
+			wantSpans: []Span{{Start: 0, End: 6, Kind: KindKeyword}, {Start: 7, End: 10, Kind: KindNumber}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var state LineState
+			var spans []Span
+			for _, line := range tt.lines {
+				spans, state = MarkdownLine(line, state)
+			}
+			if state.InCode() != tt.wantCode {
+				t.Errorf("InCode() = %v, want %v", state.InCode(), tt.wantCode)
+			}
+			if tt.wantCode && !reflect.DeepEqual(spans, tt.wantSpans) {
+				t.Errorf("spans = %+v, want %+v", spans, tt.wantSpans)
+			}
+		})
+	}
+}
@@ -0,0 +1,380 @@
+package highlight
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	fenceOpenRegex  = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})\\s*([^`\\s]*)")
+	headingRegex    = regexp.MustCompile(`^ {0,3}#{1,6}(\s|$)`)
+	ruleRegex       = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,}|=+[ \t]*)$`)
+	quoteRegex      = regexp.MustCompile(`^(?: {0,3}>[ \t]?)+`)
+	listMarkerRegex = regexp.MustCompile(`^(\s*)((?:[-*+]|\d+[.)])\s+(?:\[[ xX]\](?:\s+|$))?)`)
+)
+
+type Span struct {
+	Start int
+	End   int
+	Kind  Kind
+}
+
+type LineState struct {
+	body        bool
+	frontmatter string
+	fence       string
+	lang        string
+}
+
+func (s LineState) InCode() bool {
+	return s.fence != ""
+}
+
+func (s LineState) InFrontmatter() bool {
+	return s.frontmatter != ""
+}
+
+func MarkdownLine(line string, state LineState) ([]Span, LineState) {
+	runes := []rune(line)
+	first := !state.body
+	state.body = true
+
+	switch {
+	case state.frontmatter != "":
+		trimmed := strings.TrimRight(line, " \t")
+		if trimmed == state.frontmatter || (state.frontmatter == "---" && trimmed == "...") {
+			state.frontmatter = ""
+			return whole(runes, KindMarkup), state
+		}
+		return whole(runes, KindFrontmatter), state
+	case state.fence != "":
+		if closesFence(line, state.fence) {
+			state.fence, state.lang = "", ""
+			return whole(runes, KindMarkup), state
+		}
+		return codeLine(runes, state.lang), state
+	}
+
+	if first && (line == "---" || line == "+++") {
+		state.frontmatter = line
+		return whole(runes, KindMarkup), state
+	}
+	if m := fenceOpenRegex.FindStringSubmatch(line); m != nil {
+		state.fence = m[1]
+		state.lang = Language(m[2])
+		return whole(runes, KindMarkup), state
+	}
+	if headingRegex.MatchString(line) {
+		return whole(runes, KindHeading), state
+	}
+	if ruleRegex.MatchString(line) {
+		return whole(runes, KindMarkup), state
+	}
+
+	var spans []Span
+	base := KindPlain
+	offset := 0
+	if m := quoteRegex.FindString(line); m != "" {
+		offset = len([]rune(m))
+		spans = append(spans, Span{Start: 0, End: offset, Kind: KindQuote})
+		base = KindQuote
+	}
+	if m := listMarkerRegex.FindStringSubmatch(string(runes[offset:])); m != nil {
+		start := offset + len([]rune(m[1]))
+		offset = start + len([]rune(m[2]))
+		spans = append(spans, Span{Start: start, End: offset, Kind: KindListMarker})
+	}
+	return scanInline(runes, offset, base, spans), state
+}
+
+func whole(runes []rune, kind Kind) []Span {
+	if len(runes) == 0 {
+		return nil
+	}
+	return []Span{{Start: 0, End: len(runes), Kind: kind}}
+}
+
+func closesFence(line, fence string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || !strings.HasPrefix(trimmed, fence) {
+		return false
+	}
+	rest := strings.TrimLeft(trimmed, fence[:1])
+	return strings.TrimSpace(rest) == ""
+}
+
+func codeLine(runes []rune, lang string) []Span {
+	if !Supported(lang) {
+		return whole(runes, KindCode)
+	}
+
+	tokens, err := Tokenize(lang, string(runes))
+	if err != nil {
+		return whole(runes, KindCode)
+	}
+
+	var spans []Span
+	pos := 0
+	for _, token := range tokens {
+		end := min(pos+len([]rune(token.Text)), len(runes))
+		if token.Kind != KindPlain && end > pos {
+			spans = append(spans, Span{Start: pos, End: end, Kind: token.Kind})
+		}
+		pos = end
+	}
+	return spans
+}
+
+type inlineScanner struct {
+	runes []rune
+	base  Kind
+	spans []Span
+	plain int
+}
+
+func (s *inlineScanner) emit(start, end int, kind Kind) {
+	if s.base != KindPlain && s.plain < start {
+		s.spans = append(s.spans, Span{Start: s.plain, End: start, Kind: s.base})
+	}
+	if end > start {
+		s.spans = append(s.spans, Span{Start: start, End: end, Kind: kind})
+	}
+	s.plain = end
+}
+
+func scanInline(runes []rune, from int, base Kind, spans []Span) []Span {
+	s := &inlineScanner{runes: runes, base: base, spans: spans, plain: from}
+	n := len(runes)
+	for i := from; i < n; {
+		c := runes[i]
+		switch {
+		case c == '\\':
+			i += 2
+			continue
+		case c == '`':
+			run := runLength(runes, i, c)
+			if end := findRun(runes, i+run, c, run); end >= 0 {
+				s.emit(i, end+run, KindCode)
+				i = end + run
+			} else {
+				i += run
+			}
+			continue
+		case c == '*' || c == '_' || c == '~':
+			run := runLength(runes, i, c)
+			if end, kind, ok := emphasis(runes, i, run); ok {
+				s.emit(i, end, kind)
+				i = end
+			} else {
+				i += run
+			}
+			continue
+		case c == '[' || (c == '!' && i+1 < n && runes[i+1] == '['):
+			start := i
+			if c == '!' {
+				i++
+			}
+			if textEnd, end, ok := link(runes, i); ok {
+				s.emit(start, textEnd, KindLink)
+				if end > textEnd {
+					s.emit(textEnd, end, KindURL)
+				}
+				i = end
+				continue
+			}
+		case c == '<':
+			if end := autolink(runes, i); end > 0 {
+				s.emit(i, end, KindURL)
+				i = end
+				continue
+			}
+		case c == 'h' && (i == 0 || unicode.IsSpace(runes[i-1]) || runes[i-1] == '('):
+			if end := bareURL(runes, i); end > 0 {
+				s.emit(i, end, KindURL)
+				i = end
+				continue
+			}
+		case c == '#' && (i == 0 || unicode.IsSpace(runes[i-1])):
+			if end := tag(runes, i+1); end > 0 {
+				s.emit(i, end, KindTag)
+				i = end
+				continue
+			}
+		}
+		i++
+	}
+	s.emit(n, n, KindPlain)
+	return s.spans
+}
+
+func runLength(runes []rune, i int, c rune) int {
+	n := 0
+	for i+n < len(runes) && runes[i+n] == c {
+		n++
+	}
+	return n
+}
+
+func findRun(runes []rune, from int, c rune, run int) int {
+	for i := from; i < len(runes); {
+		if runes[i] != c {
+			i++
+			continue
+		}
+		length := runLength(runes, i, c)
+		if length == run {
+			return i
+		}
+		i += length
+	}
+	return -1
+}
+
+func emphasis(runes []rune, i, run int) (int, Kind, bool) {
+	c := runes[i]
+	kind := KindEmphasis
+	switch {
+	case c == '~' && run == 2:
+		kind = KindStrike
+	case c == '~' || run > 3:
+		return 0, KindPlain, false
+	case run >= 2:
+		kind = KindStrong
+	}
+
+	open := i + run
+	if open >= len(runes) || unicode.IsSpace(runes[open]) {
+		return 0, KindPlain, false
+	}
+	if c == '_' && i > 0 && isWordRune(runes[i-1]) {
+		return 0, KindPlain, false
+	}
+
+	for j := open + 1; j < len(runes); j++ {
+		if runes[j] == '\\' {
+			j++
+			continue
+		}
+		if runes[j] != c || runLength(runes, j, c) != run || unicode.IsSpace(runes[j-1]) {
+			continue
+		}
+		end := j + run
+		if c == '_' && end < len(runes) && isWordRune(runes[end]) {
+			continue
+		}
+		return end, kind, true
+	}
+	return 0, KindPlain, false
+}
+
+func link(runes []rune, i int) (int, int, bool) {
+	depth := 0
+	textEnd := -1
+	for j := i; j < len(runes); j++ {
+		switch runes[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				textEnd = j + 1
+			}
+		}
+		if textEnd >= 0 {
+			break
+		}
+	}
+	if textEnd < 0 {
+		return 0, 0, false
+	}
+
+	if textEnd < len(runes) {
+		switch runes[textEnd] {
+		case '(':
+			if end := closing(runes, textEnd, '(', ')'); end > 0 {
+				return textEnd, end, true
+			}
+		case '[':
+			if end := closing(runes, textEnd, '[', ']'); end > 0 {
+				return textEnd, end, true
+			}
+		case ':':
+			return textEnd, len(runes), true
+		}
+	}
+	if textEnd-i > 2 && runes[i+1] == '^' {
+		return textEnd, textEnd, true
+	}
+	return 0, 0, false
+}
+
+func closing(runes []rune, i int, open, close rune) int {
+	depth := 0
+	for j := i; j < len(runes); j++ {
+		switch runes[j] {
+		case '\\':
+			j++
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return j + 1
+			}
+		}
+	}
+	return -1
+}
+
+func autolink(runes []rune, i int) int {
+	for j := i + 1; j < len(runes); j++ {
+		switch {
+		case runes[j] == '>':
+			body := string(runes[i+1 : j])
+			if strings.Contains(body, "://") || strings.HasPrefix(body, "mailto:") || strings.Contains(body, "@") {
+				return j + 1
+			}
+			return -1
+		case unicode.IsSpace(runes[j]) || runes[j] == '<':
+			return -1
+		}
+	}
+	return -1
+}
+
+func bareURL(runes []rune, i int) int {
+	rest := string(runes[i:min(i+8, len(runes))])
+	if !strings.HasPrefix(rest, "http://") && !strings.HasPrefix(rest, "https://") {
+		return -1
+	}
+	end := i
+	for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != ')' && runes[end] != '>' {
+		end++
+	}
+	for end > i && strings.ContainsRune(".,;:!?", runes[end-1]) {
+		end--
+	}
+	return end
+}
+
+func tag(runes []rune, i int) int {
+	end := i
+	letter := false
+	for end < len(runes) && (isWordRune(runes[end]) || runes[end] == '/' || runes[end] == '-') {
+		if !unicode.IsDigit(runes[end]) {
+			letter = true
+		}
+		end++
+	}
+	if end == i || !letter {
+		return -1
+	}
+	return end
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
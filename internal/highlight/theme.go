@@ -0,0 +1,52 @@
+package highlight
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+)
+
+var kindColors = map[Kind]fyne.ThemeColorName{
+	KindKeyword:     theme.ColorNamePrimary,
+	KindType:        theme.ColorNameHyperlink,
+	KindFunction:    theme.ColorNameHyperlink,
+	KindString:      theme.ColorNameSuccess,
+	KindNumber:      theme.ColorNameWarning,
+	KindComment:     theme.ColorNamePlaceHolder,
+	KindInserted:    theme.ColorNameSuccess,
+	KindDeleted:     theme.ColorNameError,
+	KindHeading:     theme.ColorNamePrimary,
+	KindStrike:      theme.ColorNameDisabled,
+	KindCode:        theme.ColorNameSuccess,
+	KindLink:        theme.ColorNameHyperlink,
+	KindURL:         theme.ColorNamePlaceHolder,
+	KindQuote:       theme.ColorNamePlaceHolder,
+	KindListMarker:  theme.ColorNameWarning,
+	KindMarkup:      theme.ColorNamePlaceHolder,
+	KindFrontmatter: theme.ColorNamePlaceHolder,
+	KindTag:         theme.ColorNamePrimary,
+}
+
+var kindTextStyles = map[Kind]fyne.TextStyle{
+	KindKeyword:  {Bold: true},
+	KindHeading:  {Bold: true},
+	KindStrong:   {Bold: true},
+	KindEmphasis: {Italic: true},
+	KindComment:  {Italic: true},
+	KindLink:     {Underline: true},
+}
+
+func Kinds() []Kind {
+	kinds := make([]Kind, 0, int(KindTag)+1)
+	for kind := KindPlain; kind <= KindTag; kind++ {
+		kinds = append(kinds, kind)
+	}
+	return kinds
+}
+
+func (k Kind) ColorName() fyne.ThemeColorName {
+	return kindColors[k]
+}
+
+func (k Kind) TextStyle() fyne.TextStyle {
+	return kindTextStyles[k]
+}
@@ -0,0 +1,649 @@
+package htmltomd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var ErrParse = errors.New("htmltomd: failed to parse HTML")
+
+const hardBreak = "\x00"
+
+var (
+	spaceRegex      = regexp.MustCompile(`[ \t\r\n\f]+`)
+	blankLinesRegex = regexp.MustCompile(`\n{3,}`)
+	blockStartRegex = regexp.MustCompile(`^(#{1,6}(?:[ \t]|$)|[-+*>=][ \t]|[-+*]$|(\d{1,9})([.)])(?:[ \t]|$))`)
+	languageRegex   = regexp.MustCompile(`(?:^|\s)(?:language-|lang-|highlight-source-)([\w#+.-]+)`)
+)
+
+var skipped = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Title:    true,
+	atom.Meta:     true,
+	atom.Link:     true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Svg:      true,
+	atom.Canvas:   true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Textarea: true,
+}
+
+var blocks = map[atom.Atom]bool{
+	atom.Address:    true,
+	atom.Article:    true,
+	atom.Aside:      true,
+	atom.Blockquote: true,
+	atom.Body:       true,
+	atom.Center:     true,
+	atom.Dd:         true,
+	atom.Details:    true,
+	atom.Div:        true,
+	atom.Dl:         true,
+	atom.Dt:         true,
+	atom.Figcaption: true,
+	atom.Figure:     true,
+	atom.Footer:     true,
+	atom.Form:       true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Header:     true,
+	atom.Hr:         true,
+	atom.Html:       true,
+	atom.Li:         true,
+	atom.Main:       true,
+	atom.Nav:        true,
+	atom.Ol:         true,
+	atom.P:          true,
+	atom.Pre:        true,
+	atom.Section:    true,
+	atom.Summary:    true,
+	atom.Table:      true,
+	atom.Ul:         true,
+}
+
+func Convert(source string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrParse, err)
+	}
+	root := doc
+	if body := find(doc, atom.Body); body != nil {
+		root = body
+	}
+	return tidy(convertBlocks(root)), nil
+}
+
+func find(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := find(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func tidy(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	text = blankLinesRegex.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.Trim(text, "\n")
+}
+
+func isBlock(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	return blocks[n.DataAtom] || preformatted(n)
+}
+
+func preformatted(n *html.Node) bool {
+	if n.DataAtom == atom.Pre {
+		return true
+	}
+	if !blocks[n.DataAtom] {
+		return false
+	}
+	return strings.HasPrefix(style(n, "white-space"), "pre")
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func style(n *html.Node, property string) string {
+	for _, decl := range strings.Split(attr(n, "style"), ";") {
+		name, value, ok := strings.Cut(decl, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), property) {
+			return strings.ToLower(strings.TrimSpace(value))
+		}
+	}
+	return ""
+}
+
+func childBlocks(n *html.Node) []string {
+	var out []string
+	var inline strings.Builder
+	flush := func() {
+		if text := finishInline(inline.String()); text != "" {
+			out = append(out, escapeBlockStart(text))
+		}
+		inline.Reset()
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if !isBlock(c) {
+			inline.WriteString(convertInline(c))
+			continue
+		}
+		flush()
+		if block := convertBlock(c); strings.TrimSpace(block) != "" {
+			out = append(out, block)
+		}
+	}
+	flush()
+	return out
+}
+
+func convertBlocks(n *html.Node) string {
+	return strings.Join(childBlocks(n), "\n\n")
+}
+
+func finishInline(text string) string {
+	text = spaceRegex.ReplaceAllString(text, " ")
+	text = strings.ReplaceAll(text, " "+hardBreak, hardBreak)
+	text = strings.ReplaceAll(text, hardBreak+" ", hardBreak)
+	text = strings.Trim(text, " "+hardBreak)
+	return strings.ReplaceAll(text, hardBreak, "\\\n")
+}
+
+func escapeBlockStart(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		m := blockStartRegex.FindStringSubmatchIndex(line)
+		switch {
+		case m == nil:
+		case m[4] >= 0:
+			lines[i] = line[:m[6]] + "\\" + line[m[6]:]
+		default:
+			lines[i] = "\\" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(text, "\\\n", " ")), " ")
+}
+
+func convertBlock(n *html.Node) string {
+	if skipped[n.DataAtom] {
+		return ""
+	}
+	if preformatted(n) {
+		return convertPre(n)
+	}
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := singleLine(finishInline(inlineChildren(n)))
+		if text == "" {
+			return ""
+		}
+		level := int(n.Data[1] - '0')
+		return strings.Repeat("#", level) + " " + text
+	case atom.Hr:
+		return "---"
+	case atom.Blockquote:
+		return prefixLines(convertBlocks(n), "> ", "> ")
+	case atom.Ul, atom.Ol:
+		return convertList(n)
+	case atom.Li:
+		return convertItem(n, "- ")
+	case atom.Table:
+		return convertTable(n)
+	case atom.Dl:
+		return convertDefinitions(n)
+	}
+	return convertBlocks(n)
+}
+
+func prefixLines(text, first, rest string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		prefix := rest
+		if i == 0 {
+			prefix = first
+		}
+		if line == "" {
+			prefix = strings.TrimRight(prefix, " ")
+		}
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+func convertPre(n *html.Node) string {
+	code := strings.TrimRight(rawText(n), "\n")
+	if strings.TrimSpace(code) == "" {
+		return ""
+	}
+	language := codeLanguage(n)
+	fence := strings.Repeat("`", max(3, longestRun(code, '`')+1))
+	return fence + language + "\n" + code + "\n" + fence
+}
+
+func codeLanguage(n *html.Node) string {
+	for c := n; c != nil; c = c.FirstChild {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		if m := languageRegex.FindStringSubmatch(attr(c, "class")); m != nil {
+			return m[1]
+		}
+		if lang := attr(c, "data-lang"); lang != "" {
+			return lang
+		}
+	}
+	if parent := n.Parent; parent != nil && parent.Type == html.ElementNode {
+		if m := languageRegex.FindStringSubmatch(attr(parent, "class")); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+func rawText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(strings.ReplaceAll(n.Data, "\u00a0", " "))
+		case n.Type != html.ElementNode:
+		case n.DataAtom == atom.Br:
+			b.WriteString("\n")
+		case skipped[n.DataAtom]:
+		default:
+			if (n.DataAtom == atom.Div || n.DataAtom == atom.P) && b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+				b.WriteString("\n")
+			}
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+func longestRun(text string, r rune) int {
+	longest, run := 0, 0
+	for _, c := range text {
+		if c == r {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
+
+func convertList(n *html.Node) string {
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil && start >= 0 {
+		number = start
+	}
+	var items []string
+	loose := false
+	indent := ""
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		if c.DataAtom == atom.Ul || c.DataAtom == atom.Ol {
+			if nested := convertList(c); nested != "" && len(items) > 0 {
+				items[len(items)-1] += "\n" + prefixLines(nested, indent, indent)
+			}
+			continue
+		}
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		indent = strings.Repeat(" ", len(marker))
+		item := convertItem(c, marker)
+		if strings.Contains(item, "\n\n") {
+			loose = true
+		}
+		items = append(items, item)
+	}
+	if loose {
+		return strings.Join(items, "\n\n")
+	}
+	return strings.Join(items, "\n")
+}
+
+func convertItem(n *html.Node, marker string) string {
+	parts := childBlocks(n)
+	var b strings.Builder
+	for i, part := range parts {
+		if i > 0 {
+			if isList(part) && !isList(parts[i-1]) {
+				b.WriteString("\n")
+			} else {
+				b.WriteString("\n\n")
+			}
+		}
+		b.WriteString(part)
+	}
+	content := b.String()
+	if content == "" {
+		return strings.TrimRight(marker, " ")
+	}
+	return prefixLines(content, marker, strings.Repeat(" ", len(marker)))
+}
+
+func isList(block string) bool {
+	m := blockStartRegex.FindStringSubmatch(block)
+	return m != nil && (m[2] != "" || strings.HasPrefix(m[1], "-") || strings.HasPrefix(m[1], "+") || strings.HasPrefix(m[1], "*"))
+}
+
+func convertDefinitions(n *html.Node) string {
+	var out []string
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		switch c.DataAtom {
+		case atom.Dt:
+			if term := singleLine(finishInline(inlineChildren(c))); term != "" {
+				out = append(out, term)
+			}
+		case atom.Dd:
+			if definition := convertBlocks(c); definition != "" {
+				out = append(out, prefixLines(definition, ": ", "  "))
+			}
+		case atom.Div:
+			if nested := convertDefinitions(c); nested != "" {
+				out = append(out, nested)
+			}
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+func convertTable(n *html.Node) string {
+	var rows [][]*html.Node
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				collect(c)
+			case atom.Tr:
+				var cells []*html.Node
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+						cells = append(cells, cell)
+					}
+				}
+				if len(cells) > 0 {
+					rows = append(rows, cells)
+				}
+			}
+		}
+	}
+	collect(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	var lines []string
+	for i, row := range rows {
+		cells := make([]string, columns)
+		for j, cell := range row {
+			cells[j] = tableCell(cell)
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+		if i > 0 {
+			continue
+		}
+		separators := make([]string, columns)
+		for j := range separators {
+			align := ""
+			if j < len(row) {
+				align = cellAlign(row[j])
+			}
+			switch align {
+			case "left":
+				separators[j] = ":---"
+			case "center":
+				separators[j] = ":---:"
+			case "right":
+				separators[j] = "---:"
+			default:
+				separators[j] = "---"
+			}
+		}
+		lines = append(lines, "| "+strings.Join(separators, " | ")+" |")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func tableCell(n *html.Node) string {
+	text := convertBlocks(n)
+	text = strings.ReplaceAll(text, "\\\n", "<br>")
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.ReplaceAll(text, "|", "\\|")
+}
+
+func cellAlign(n *html.Node) string {
+	if align := strings.ToLower(attr(n, "align")); align != "" {
+		return align
+	}
+	return style(n, "text-align")
+}
+
+func inlineChildren(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(convertInline(c))
+	}
+	return b.String()
+}
+
+func convertInline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return escape(strings.ReplaceAll(n.Data, "\u00a0", " "))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	if skipped[n.DataAtom] {
+		return ""
+	}
+	if isBlock(n) {
+		return " " + singleLine(convertBlock(n)) + " "
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		return hardBreak
+	case atom.Img:
+		return convertImage(n)
+	case atom.A:
+		return convertLink(n)
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		return codeSpan(rawText(n))
+	case atom.Input:
+		if !strings.EqualFold(attr(n, "type"), "checkbox") {
+			return ""
+		}
+		for _, a := range n.Attr {
+			if a.Key == "checked" {
+				return "[x] "
+			}
+		}
+		return "[ ] "
+	case atom.Strong, atom.B:
+		if weight := style(n, "font-weight"); weight != "" && !bold(weight) {
+			return inlineChildren(n)
+		}
+		return wrap(inlineChildren(n), "**")
+	case atom.Em, atom.I, atom.Cite, atom.Dfn, atom.Var:
+		if style(n, "font-style") == "normal" {
+			return inlineChildren(n)
+		}
+		return wrap(inlineChildren(n), "*")
+	case atom.Del, atom.S, atom.Strike:
+		return wrap(inlineChildren(n), "~~")
+	}
+
+	text := inlineChildren(n)
+	if bold(style(n, "font-weight")) {
+		text = wrap(text, "**")
+	}
+	if style(n, "font-style") == "italic" {
+		text = wrap(text, "*")
+	}
+	if strings.Contains(style(n, "text-decoration"), "line-through") {
+		text = wrap(text, "~~")
+	}
+	return text
+}
+
+func bold(weight string) bool {
+	if weight == "bold" || weight == "bolder" {
+		return true
+	}
+	value, err := strconv.Atoi(weight)
+	return err == nil && value >= 600
+}
+
+func wrap(text, delimiter string) string {
+	trimmed := strings.Trim(text, " \t\r\n"+hardBreak)
+	if trimmed == "" {
+		return text
+	}
+	return surround(text, delimiter+trimmed+delimiter)
+}
+
+func surround(text, inner string) string {
+	trimmed := strings.Trim(text, " \t\r\n"+hardBreak)
+	start := strings.Index(text, trimmed)
+	return text[:start] + inner + text[start+len(trimmed):]
+}
+
+func codeSpan(code string) string {
+	code = spaceRegex.ReplaceAllString(code, " ")
+	if strings.TrimSpace(code) == "" {
+		return ""
+	}
+	fence := strings.Repeat("`", longestRun(code, '`')+1)
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		code = " " + code + " "
+	}
+	return fence + code + fence
+}
+
+func convertLink(n *html.Node) string {
+	text := inlineChildren(n)
+	href := strings.TrimSpace(attr(n, "href"))
+	if href == "" || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		return text
+	}
+	label := strings.Trim(text, " \t\r\n"+hardBreak)
+	if label == "" {
+		if find(n, atom.Img) == nil {
+			return ""
+		}
+		label = strings.TrimSpace(text)
+	}
+	plain := strings.ReplaceAll(label, "\\", "")
+	if (plain == href || "mailto:"+plain == href) && !strings.ContainsAny(href, " <>") {
+		return surround(text, "<"+href+">")
+	}
+	destination := linkDestination(href)
+	if title := attr(n, "title"); title != "" {
+		destination += ` "` + strings.ReplaceAll(title, `"`, `\"`) + `"`
+	}
+	return surround(text, "["+label+"]("+destination+")")
+}
+
+func linkDestination(href string) string {
+	if strings.ContainsAny(href, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(href) + ">"
+	}
+	return href
+}
+
+func convertImage(n *html.Node) string {
+	src := strings.TrimSpace(attr(n, "src"))
+	if src == "" {
+		return ""
+	}
+	alt := escape(singleLine(attr(n, "alt")))
+	destination := linkDestination(src)
+	if title := attr(n, "title"); title != "" {
+		destination += ` "` + strings.ReplaceAll(title, `"`, `\"`) + `"`
+	}
+	return "![" + alt + "](" + destination + ")"
+}
+
+func escape(text string) string {
+	var b strings.Builder
+	for i, r := range text {
+		switch r {
+		case '\\', '*', '`', '[', ']', '<', '~':
+			b.WriteByte('\\')
+		case '_':
+			before, _ := utf8.DecodeLastRuneInString(text[:i])
+			after, _ := utf8.DecodeRuneInString(text[i+1:])
+			if !isWordRune(before) || !isWordRune(after) {
+				b.WriteByte('\\')
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
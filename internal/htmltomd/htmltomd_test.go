@@ -0,0 +1,137 @@
+package htmltomd
+
+import "testing"
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "headings and paragraphs",
+			html: "<html><head><title>x</title></head><body><h2>Title <em>here</em></h2><p>One\n  two</p><p>Three<br>four</p></body></html>",
+			want: "## Title *here*\n\nOne two\n\nThree\\\nfour",
+		},
+		{
+			name: "inline formatting and links",
+			html: `<p><b>bold</b>, <i>it</i>, <del>gone</del>, <a href="https://example.com" title="Ex">site</a>, <a href="https://go.dev">https://go.dev</a> and <img src="a b.png" alt="pic"></p>`,
+			want: "**bold**, *it*, ~~gone~~, [site](https://example.com \"Ex\"), <https://go.dev> and ![pic](<a b.png>)",
+		},
+		{
+			name: "styled spans",
+			html: `<p><span style="font-weight: 700">heavy</span> <span style="font-style:italic">slanted</span> <b style="font-weight:normal">plain</b></p>`,
+			want: "**heavy** *slanted* plain",
+		},
+		{
+			name: "markdown characters are escaped",
+			html: "<p>a *star* [link] snake_case _x_ 1. not a list</p><p># not a heading</p>",
+			want: "a \\*star\\* \\[link\\] snake_case \\_x\\_ 1. not a list\n\n\\# not a heading",
+		},
+		{
+			name: "unordered list",
+			html: "<ul><li>one</li><li>two</li></ul>",
+			want: "- one\n- two",
+		},
+		{
+			name: "ordered list with start",
+			html: `<ol start="3"><li>three</li><li>four</li></ol>`,
+			want: "3. three\n4. four",
+		},
+		{
+			name: "nested lists",
+			html: "<ul><li>a<ul><li>a1</li><li>a2</li></ul></li><li>b<ol><li>b1</li></ol></li></ul>",
+			want: "- a\n  - a1\n  - a2\n- b\n  1. b1",
+		},
+		{
+			name: "list as sibling of items",
+			html: "<ol><li>a</li><ol><li>a1</li></ol><li>b</li></ol>",
+			want: "1. a\n   1. a1\n2. b",
+		},
+		{
+			name: "loose list",
+			html: "<ul><li><p>first</p><p>more</p></li><li><p>second</p></li></ul>",
+			want: "- first\n\n  more\n\n- second",
+		},
+		{
+			name: "task list",
+			html: `<ul><li><input type="checkbox" checked> done</li><li><input type="checkbox"> todo</li></ul>`,
+			want: "- [x] done\n- [ ] todo",
+		},
+		{
+			name: "simple table",
+			html: "<table><tr><th>Name</th><th>Qty</th></tr><tr><td>apple</td><td>3</td></tr></table>",
+			want: "| Name | Qty |\n| --- | --- |\n| apple | 3 |",
+		},
+		{
+			name: "table sections, alignment and ragged rows",
+			html: `<table><thead><tr><th align="left">A</th><th style="text-align: center">B</th><th align="right">C</th></tr></thead>` +
+				`<tbody><tr><td>1</td><td>2</td></tr><tr><td>x|y</td><td>line<br>break</td><td><p>para</p></td></tr></tbody></table>`,
+			want: "| A | B | C |\n| :--- | :---: | ---: |\n| 1 | 2 |  |\n| x\\|y | line<br>break | para |",
+		},
+		{
+			name: "empty table",
+			html: "<table></table><p>after</p>",
+			want: "after",
+		},
+		{
+			name: "code block with language",
+			html: `<pre><code class="language-go">func main() {
+	fmt.Println("hi")
+}
+</code></pre>`,
+			want: "```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```",
+		},
+		{
+			name: "code block containing fences",
+			html: "<pre>```\ninner\n```</pre>",
+			want: "````\n```\ninner\n```\n````",
+		},
+		{
+			name: "highlighter wrapper language",
+			html: `<div class="highlight highlight-source-python"><pre><span>print</span>(1)</pre></div>`,
+			want: "```python\nprint(1)\n```",
+		},
+		{
+			name: "pre-wrap div with line divs",
+			html: `<div style="white-space: pre-wrap"><div>a  b</div><div>c</div></div>`,
+			want: "```\na  b\nc\n```",
+		},
+		{
+			name: "inline code",
+			html: "<p>Run <code>go  test</code> or <code>a`b</code></p>",
+			want: "Run `go test` or ``a`b``",
+		},
+		{
+			name: "blockquote and rule",
+			html: "<blockquote><p>quoted</p><p>more</p></blockquote><hr><p>end</p>",
+			want: "> quoted\n>\n> more\n\n---\n\nend",
+		},
+		{
+			name: "definition list",
+			html: "<dl><dt>Term</dt><dd>Meaning</dd></dl>",
+			want: "Term\n: Meaning",
+		},
+		{
+			name: "scripts and styles are dropped",
+			html: "<style>p{}</style><script>alert(1)</script><p>kept</p>",
+			want: "kept",
+		},
+		{
+			name: "javascript links keep their text",
+			html: `<p><a href="javascript:void(0)">click</a></p>`,
+			want: "click",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert(tt.html)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Convert() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
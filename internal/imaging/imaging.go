@@ -0,0 +1,185 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"markdown-editor/internal/fileservice"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2/storage"
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+	_ "golang.org/x/image/bmp"
+	xdraw "golang.org/x/image/draw"
+)
+
+const (
+	maxCacheEntries = 64
+	defaultSVGSize  = 512
+)
+
+var (
+	ErrImageNotFound    = errors.New("imaging: image not found")
+	ErrImageUnsupported = errors.New("imaging: unsupported image format")
+	ErrImageDecode      = errors.New("imaging: failed to decode image")
+)
+
+var supportedExtensions = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".gif":  true,
+	".bmp":  true,
+	".svg":  true,
+}
+
+type cacheKey struct {
+	path     string
+	modTime  time.Time
+	size     int64
+	maxWidth int
+}
+
+type Loader struct {
+	mu    sync.Mutex
+	fs    fileservice.FileOperations
+	cache map[cacheKey]image.Image
+	order []cacheKey
+}
+
+func NewLoader(fs fileservice.FileOperations) *Loader {
+	return &Loader{
+		fs:    fs,
+		cache: make(map[cacheKey]image.Image),
+	}
+}
+
+func IsSupported(path string) bool {
+	return supportedExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+func (l *Loader) Load(path string) (image.Image, error) {
+	return l.Scaled(path, 0)
+}
+
+func (l *Loader) Scaled(path string, maxWidth int) (image.Image, error) {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, path)
+	}
+	if !IsSupported(path) {
+		return nil, fmt.Errorf("%w: %s", ErrImageUnsupported, path)
+	}
+
+	key := cacheKey{path: path, modTime: info.ModTime(), size: info.Size(), maxWidth: maxWidth}
+	if img, ok := l.lookup(key); ok {
+		return img, nil
+	}
+
+	var img image.Image
+	if maxWidth > 0 {
+		original, err := l.Scaled(path, 0)
+		if err != nil {
+			return nil, err
+		}
+		img = Scale(original, maxWidth)
+	} else {
+		data, err := l.fs.ReadFile(storage.NewFileURI(path))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrImageNotFound, path, err)
+		}
+		if img, err = Decode(path, data); err != nil {
+			return nil, err
+		}
+	}
+
+	l.store(key, img)
+	return img, nil
+}
+
+func (l *Loader) lookup(key cacheKey) (image.Image, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	img, ok := l.cache[key]
+	if ok {
+		l.touch(key)
+	}
+	return img, ok
+}
+
+func (l *Loader) store(key cacheKey, img image.Image) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.cache[key]; ok {
+		l.touch(key)
+	} else {
+		l.order = append(l.order, key)
+	}
+	l.cache[key] = img
+	for len(l.order) > maxCacheEntries {
+		delete(l.cache, l.order[0])
+		l.order = l.order[1:]
+	}
+}
+
+func (l *Loader) touch(key cacheKey) {
+	for i, k := range l.order {
+		if k == key {
+			l.order = append(append(l.order[:i:i], l.order[i+1:]...), key)
+			return
+		}
+	}
+}
+
+func Decode(path string, data []byte) (image.Image, error) {
+	if strings.EqualFold(filepath.Ext(path), ".svg") {
+		return rasterizeSVG(path, data)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrImageDecode, path, err)
+	}
+	return img, nil
+}
+
+func rasterizeSVG(path string, data []byte) (image.Image, error) {
+	icon, err := oksvg.ReadIconStream(bytes.NewReader(data), oksvg.WarnErrorMode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrImageDecode, path, err)
+	}
+
+	w, h := int(icon.ViewBox.W), int(icon.ViewBox.H)
+	if w <= 0 || h <= 0 {
+		w, h = defaultSVGSize, defaultSVGSize
+	}
+	icon.SetTarget(0, 0, float64(w), float64(h))
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	scanner := rasterx.NewScannerGV(w, h, img, img.Bounds())
+	icon.Draw(rasterx.NewDasher(w, h, scanner), 1)
+	return img, nil
+}
+
+func Scale(img image.Image, maxWidth int) image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() <= maxWidth {
+		return img
+	}
+
+	height := max(1, bounds.Dy()*maxWidth/bounds.Dx())
+	scaled := image.NewRGBA(image.Rect(0, 0, maxWidth, height))
+	xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Src, nil)
+	return scaled
+}
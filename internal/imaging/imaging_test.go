@@ -0,0 +1,270 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"markdown-editor/internal/fileservice"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"golang.org/x/image/bmp"
+)
+
+type countingFS struct {
+	fileservice.FileOperations
+	reads map[string]int
+}
+
+func newCountingFS() *countingFS {
+	return &countingFS{reads: make(map[string]int)}
+}
+
+func (f *countingFS) ReadFile(uri fyne.URI) ([]byte, error) {
+	f.reads[uri.Path()]++
+	return os.ReadFile(uri.Path())
+}
+
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := range w {
+		for y := range h {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 200, 255})
+		}
+	}
+	return img
+}
+
+func encode(t *testing.T, format string, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	case "bmp":
+		err = bmp.Encode(&buf, img)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func writeImage(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIsSupported(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "a.png", want: true},
+		{path: "dir/b.JPG", want: true},
+		{path: "c.jpeg", want: true},
+		{path: "d.gif", want: true},
+		{path: "e.Bmp", want: true},
+		{path: "f.svg", want: true},
+		{path: "g.webp"},
+		{path: "h.md"},
+		{path: "png"},
+		{path: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := IsSupported(tt.path); got != tt.want {
+				t.Errorf("IsSupported(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	img := testImage(30, 20)
+	tests := []struct {
+		name     string
+		path     string
+		data     []byte
+		wantSize image.Point
+		wantErr  bool
+	}{
+		{name: "png", path: "a.png", data: encode(t, "png", img), wantSize: image.Pt(30, 20)},
+		{name: "jpeg", path: "a.jpg", data: encode(t, "jpeg", img), wantSize: image.Pt(30, 20)},
+		{name: "gif", path: "a.gif", data: encode(t, "gif", img), wantSize: image.Pt(30, 20)},
+		{name: "bmp", path: "a.bmp", data: encode(t, "bmp", img), wantSize: image.Pt(30, 20)},
+		{name: "content wins over extension", path: "a.jpg", data: encode(t, "png", img), wantSize: image.Pt(30, 20)},
+		{name: "svg with a view box", path: "a.svg", data: []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 40 10"><rect width="40" height="10" fill="red"/></svg>`), wantSize: image.Pt(40, 10)},
+		{name: "svg without a size", path: "a.SVG", data: []byte(`<svg xmlns="http://www.w3.org/2000/svg"><circle cx="5" cy="5" r="4"/></svg>`), wantSize: image.Pt(defaultSVGSize, defaultSVGSize)},
+		{name: "truncated png", path: "a.png", data: encode(t, "png", img)[:40], wantErr: true},
+		{name: "text", path: "a.png", data: []byte("not an image"), wantErr: true},
+		{name: "empty", path: "a.bmp", wantErr: true},
+		{name: "invalid svg", path: "a.svg", data: []byte("<svg"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(tt.path, tt.data)
+			if tt.wantErr {
+				if !errors.Is(err, ErrImageDecode) {
+					t.Errorf("Decode() error = %v, want %v", err, ErrImageDecode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if size := got.Bounds().Size(); size != tt.wantSize {
+				t.Errorf("Decode() size = %v, want %v", size, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestScale(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     image.Point
+		maxWidth int
+		want     image.Point
+	}{
+		{name: "wider than the limit", size: image.Pt(200, 100), maxWidth: 50, want: image.Pt(50, 25)},
+		{name: "narrower than the limit", size: image.Pt(40, 100), maxWidth: 50, want: image.Pt(40, 100)},
+		{name: "exactly the limit", size: image.Pt(50, 10), maxWidth: 50, want: image.Pt(50, 10)},
+		{name: "very flat", size: image.Pt(1000, 2), maxWidth: 10, want: image.Pt(10, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := testImage(tt.size.X, tt.size.Y)
+			got := Scale(img, tt.maxWidth)
+			if size := got.Bounds().Size(); size != tt.want {
+				t.Errorf("Scale() size = %v, want %v", size, tt.want)
+			}
+			if tt.size.X <= tt.maxWidth && got != image.Image(img) {
+				t.Error("Scale() copied an image that already fits")
+			}
+		})
+	}
+}
+
+func TestLoaderErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		path string
+		want error
+	}{
+		{name: "missing", path: filepath.Join(dir, "missing.png"), want: ErrImageNotFound},
+		{name: "directory", path: dir, want: ErrImageNotFound},
+		{name: "unsupported", path: writeImage(t, dir, "notes.txt", []byte("text")), want: ErrImageUnsupported},
+		{name: "undecodable", path: writeImage(t, dir, "broken.png", []byte("broken")), want: ErrImageDecode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewLoader(newCountingFS()).Load(tt.path); !errors.Is(err, tt.want) {
+				t.Errorf("Load() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoaderCache(t *testing.T) {
+	dir := t.TempDir()
+	path := writeImage(t, dir, "pic.png", encode(t, "png", testImage(100, 50)))
+	fs := newCountingFS()
+	loader := NewLoader(fs)
+
+	first, err := loader.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := loader.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second || fs.reads[path] != 1 {
+		t.Errorf("second Load() read the file again: %d reads", fs.reads[path])
+	}
+
+	scaled, err := loader.Scaled(path, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := scaled.Bounds().Size(); size != image.Pt(20, 10) {
+		t.Errorf("Scaled() size = %v, want 20x10", size)
+	}
+	if again, _ := loader.Scaled(path, 20); again != scaled || fs.reads[path] != 1 {
+		t.Errorf("Scaled() did not reuse the cached images: %d reads", fs.reads[path])
+	}
+
+	writeImage(t, dir, "pic.png", encode(t, "png", testImage(30, 30)))
+	if err := os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := loader.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := changed.Bounds().Size(); size != image.Pt(30, 30) || fs.reads[path] != 2 {
+		t.Errorf("Load() after a change = %v with %d reads, want the new 30x30 image", size, fs.reads[path])
+	}
+}
+
+func TestLoaderEviction(t *testing.T) {
+	dir := t.TempDir()
+	data := encode(t, "png", testImage(2, 2))
+	paths := make([]string, maxCacheEntries+1)
+	for i := range paths {
+		paths[i] = writeImage(t, dir, fmt.Sprintf("pic%02d.png", i), data)
+	}
+	fs := newCountingFS()
+	loader := NewLoader(fs)
+	load := func(path string) {
+		t.Helper()
+		if _, err := loader.Load(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, path := range paths[:maxCacheEntries] {
+		load(path)
+	}
+	load(paths[0])
+	load(paths[maxCacheEntries])
+
+	if len(loader.cache) != maxCacheEntries || len(loader.order) != maxCacheEntries {
+		t.Fatalf("cache holds %d images in %d slots, want %d", len(loader.cache), len(loader.order), maxCacheEntries)
+	}
+	tests := []struct {
+		name      string
+		path      string
+		wantReads int
+	}{
+		{name: "recently used image is kept", path: paths[0], wantReads: 1},
+		{name: "newest image is kept", path: paths[maxCacheEntries], wantReads: 1},
+		{name: "untouched image is kept", path: paths[2], wantReads: 1},
+		{name: "least recently used image is evicted", path: paths[1], wantReads: 2},
+	}
+	for _, tt := range tests {
+		load(tt.path)
+		if got := fs.reads[tt.path]; got != tt.wantReads {
+			t.Errorf("%s: %d reads of %s, want %d", tt.name, got, filepath.Base(tt.path), tt.wantReads)
+		}
+	}
+}
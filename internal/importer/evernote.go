@@ -0,0 +1,275 @@
+package importer
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"markdown-editor/internal/frontmatter"
+	"markdown-editor/internal/htmltomd"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	enexExtension   = ".enex"
+	enexTimeLayout  = "20060102T150405Z"
+	enexResourceRef = "evernote-resource:"
+)
+
+var (
+	enexTaskRegex      = regexp.MustCompile(`(?m)^\[( |x)\] `)
+	enexTaskBreakRegex = regexp.MustCompile(`(?m)^(- \[[ x]\] .*)\n\n(- \[[ x]\] )`)
+)
+
+type enexNote struct {
+	Title      string   `xml:"title"`
+	Content    string   `xml:"content"`
+	Created    string   `xml:"created"`
+	Updated    string   `xml:"updated"`
+	Tags       []string `xml:"tag"`
+	Attributes struct {
+		SourceURL string `xml:"source-url"`
+		Author    string `xml:"author"`
+	} `xml:"note-attributes"`
+	Resources []enexResource `xml:"resource"`
+}
+
+type enexResource struct {
+	Data struct {
+		Encoding string `xml:"encoding,attr"`
+		Value    string `xml:",chardata"`
+	} `xml:"data"`
+	Mime       string `xml:"mime"`
+	Attributes struct {
+		FileName string `xml:"file-name"`
+	} `xml:"resource-attributes"`
+}
+
+func (s *Service) readEvernote(x *export, from string) error {
+	info, err := os.Stat(from)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrImportRead, err)
+	}
+	files := []string{from}
+	if info.IsDir() {
+		all, err := s.fs.ListFilesRecursive(from)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrImportRead, err)
+		}
+		files = files[:0]
+		for _, file := range all {
+			if strings.EqualFold(filepath.Ext(file), enexExtension) {
+				files = append(files, file)
+			}
+		}
+		sort.Strings(files)
+	}
+
+	for _, file := range files {
+		notebook := ""
+		if info.IsDir() {
+			notebook = s.fs.SanitizeFilenameComponent(strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)))
+		}
+		if err := s.readENEX(x, file, notebook); err != nil {
+			if !info.IsDir() {
+				return err
+			}
+			x.skip("%s: %v", filepath.Base(file), err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) readENEX(x *export, file, notebook string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrImportRead, err)
+	}
+	defer f.Close()
+
+	decoder := xml.NewDecoder(f)
+	decoder.Strict = false
+	count := 0
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrImportRead, filepath.Base(file), err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "note" {
+			continue
+		}
+		var en enexNote
+		if err := decoder.DecodeElement(&en, &start); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrImportRead, filepath.Base(file), err)
+		}
+		count++
+		s.addEvernoteNote(x, en, notebook, fmt.Sprintf("%s#%d", file, count))
+	}
+	return nil
+}
+
+func (s *Service) addEvernoteNote(x *export, en enexNote, notebook, key string) {
+	title := strings.TrimSpace(en.Title)
+	n := &note{
+		key:   key,
+		title: title,
+		dir:   notebook,
+		name:  title,
+		tags:  en.Tags,
+		links: make(map[string]target),
+	}
+	n.created, _ = time.Parse(enexTimeLayout, strings.TrimSpace(en.Created))
+	n.modified, _ = time.Parse(enexTimeLayout, strings.TrimSpace(en.Updated))
+	if n.modified.IsZero() {
+		n.modified = n.created
+	}
+	if url := strings.TrimSpace(en.Attributes.SourceURL); url != "" {
+		n.properties = append(n.properties, frontmatter.Property{Key: propertySource, Value: url})
+	}
+	if author := strings.TrimSpace(en.Attributes.Author); author != "" {
+		n.properties = append(n.properties, frontmatter.Property{Key: "author", Value: author})
+	}
+
+	resources := make(map[string]*attachment, len(en.Resources))
+	for i, r := range en.Resources {
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(r.Data.Value), ""))
+		if err != nil {
+			x.warn("'%s': attachment %d could not be decoded: %v", title, i+1, err)
+			continue
+		}
+		sum := md5.Sum(data)
+		hash := hex.EncodeToString(sum[:])
+		name := strings.TrimSpace(r.Attributes.FileName)
+		if name == "" {
+			name = "attachment-" + strconv.Itoa(i+1) + extensionFor(r.Mime)
+		} else if filepath.Ext(name) == "" {
+			name += extensionFor(r.Mime)
+		}
+		resources[hash] = &attachment{id: contentID(data), name: name, read: dataReader(data)}
+	}
+
+	body, used, err := enmlToMarkdown(en.Content, resources)
+	if err != nil {
+		x.warn("'%s': the note content could not be converted: %v", title, err)
+	}
+	hashes := make([]string, 0, len(resources))
+	for hash := range resources {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	for _, hash := range hashes {
+		file := resources[hash]
+		n.links[enexResourceRef+hash] = target{file: file}
+		if !used[hash] {
+			body += fmt.Sprintf("\n\n[%s](%s%s)", file.name, enexResourceRef, hash)
+		}
+	}
+	n.body = body
+	x.notes = append(x.notes, n)
+}
+
+func enmlToMarkdown(content string, resources map[string]*attachment) (string, map[string]bool, error) {
+	used := make(map[string]bool)
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return "", used, err
+	}
+
+	var elements []*html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "en-note" {
+			n.Data, n.DataAtom = "div", atom.Div
+		}
+		if n.Type == html.ElementNode && (n.Data == "en-media" || n.Data == "en-todo" || n.Data == "en-crypt") {
+			elements = append(elements, n)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	for _, el := range elements {
+		var replacement *html.Node
+		switch el.Data {
+		case "en-media":
+			hash := strings.ToLower(attrValue(el, "hash"))
+			file, ok := resources[hash]
+			if !ok {
+				break
+			}
+			used[hash] = true
+			if strings.HasPrefix(attrValue(el, "type"), "image/") {
+				replacement = &html.Node{Type: html.ElementNode, Data: "img", DataAtom: atom.Img, Attr: []html.Attribute{
+					{Key: "src", Val: enexResourceRef + hash},
+					{Key: "alt", Val: strings.TrimSuffix(file.name, filepath.Ext(file.name))},
+				}}
+			} else {
+				replacement = &html.Node{Type: html.ElementNode, Data: "a", DataAtom: atom.A, Attr: []html.Attribute{
+					{Key: "href", Val: enexResourceRef + hash},
+				}}
+				replacement.AppendChild(&html.Node{Type: html.TextNode, Data: file.name})
+			}
+		case "en-todo":
+			input := &html.Node{Type: html.ElementNode, Data: "input", DataAtom: atom.Input, Attr: []html.Attribute{{Key: "type", Val: "checkbox"}}}
+			if strings.EqualFold(attrValue(el, "checked"), "true") {
+				input.Attr = append(input.Attr, html.Attribute{Key: "checked"})
+			}
+			replacement = input
+		case "en-crypt":
+			replacement = &html.Node{Type: html.TextNode, Data: "[encrypted content not imported]"}
+		}
+		next := el.NextSibling
+		for c := el.FirstChild; c != nil; c = el.FirstChild {
+			el.RemoveChild(c)
+			if el.Data != "en-crypt" {
+				el.Parent.InsertBefore(c, next)
+			}
+		}
+		if replacement != nil {
+			el.Parent.InsertBefore(replacement, el)
+		}
+		el.Parent.RemoveChild(el)
+	}
+
+	var buf bytes.Buffer
+	if err := html.Render(&buf, doc); err != nil {
+		return "", used, err
+	}
+	markdown, err := htmltomd.Convert(buf.String())
+	if err != nil {
+		return "", used, err
+	}
+	markdown = enexTaskRegex.ReplaceAllString(markdown, "- [$1] ")
+	for enexTaskBreakRegex.MatchString(markdown) {
+		markdown = enexTaskBreakRegex.ReplaceAllString(markdown, "$1\n$2")
+	}
+	return markdown, used, nil
+}
+
+func attrValue(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,428 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"markdown-editor/internal/fileservice"
+	"markdown-editor/internal/frontmatter"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2/storage"
+)
+
+var (
+	ErrImportSource = errors.New("importer: unknown source")
+	ErrImportRead   = errors.New("importer: failed to read export")
+	ErrImportWrite  = errors.New("importer: failed to write note")
+	ErrImportTarget = errors.New("importer: invalid target folder")
+	ErrImportEmpty  = errors.New("importer: export contains no notes")
+)
+
+type Source string
+
+const (
+	SourceEvernote Source = "Evernote"
+	SourceJoplin   Source = "Joplin"
+	SourceNotion   Source = "Notion"
+	SourceObsidian Source = "Obsidian"
+)
+
+const (
+	noteExtension  = ".md"
+	untitledNote   = "untitled"
+	reportPrefix   = "import-report-"
+	propertySource = "source"
+)
+
+var linkRegex = regexp.MustCompile(`(!?\[(?:[^\[\]]|\\\[|\\\])*\])\(\s*(<[^<>\n]*>|[^()\s]+)((?:\s+"[^"\n]*")?)\s*\)`)
+
+var mimeExtensions = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"image/svg+xml":   ".svg",
+	"image/bmp":       ".bmp",
+	"application/pdf": ".pdf",
+	"text/plain":      ".txt",
+	"audio/mpeg":      ".mp3",
+	"audio/wav":       ".wav",
+	"video/mp4":       ".mp4",
+}
+
+func Sources() []Source {
+	return []Source{SourceEvernote, SourceJoplin, SourceNotion, SourceObsidian}
+}
+
+type note struct {
+	key         string
+	title       string
+	dir         string
+	name        string
+	body        string
+	properties  []frontmatter.Property
+	tags        []string
+	created     time.Time
+	modified    time.Time
+	keepHeader  bool
+	keepName    bool
+	links       map[string]target
+	attachments int
+}
+
+type target struct {
+	note     string
+	file     *attachment
+	fragment string
+}
+
+type attachment struct {
+	id   string
+	name string
+	read func() ([]byte, error)
+}
+
+type export struct {
+	notes   []*note
+	files   []*attachment
+	report  *Report
+	written map[string]string
+	closers []func()
+}
+
+func (x *export) close() {
+	for _, c := range x.closers {
+		c()
+	}
+}
+
+func (x *export) warn(format string, args ...any) {
+	x.report.Warnings = append(x.report.Warnings, fmt.Sprintf(format, args...))
+}
+
+func (x *export) skip(format string, args ...any) {
+	x.report.Skipped = append(x.report.Skipped, fmt.Sprintf(format, args...))
+}
+
+type Service struct {
+	fs             fileservice.FileOperations
+	attachmentsDir func(notePath string) string
+}
+
+func New(fs fileservice.FileOperations, attachmentsDir func(notePath string) string) *Service {
+	return &Service{
+		fs:             fs,
+		attachmentsDir: attachmentsDir,
+	}
+}
+
+func (s *Service) Import(source Source, from, targetDir string) (*Report, error) {
+	from, targetDir = filepath.Clean(from), filepath.Clean(targetDir)
+	if rel, err := filepath.Rel(from, targetDir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("%w: '%s' is inside the export '%s'", ErrImportTarget, targetDir, from)
+	}
+
+	x := &export{
+		report: &Report{
+			Source:  source,
+			From:    from,
+			Target:  targetDir,
+			Started: time.Now().Truncate(time.Second),
+		},
+		written: make(map[string]string),
+	}
+	defer x.close()
+	var err error
+	switch source {
+	case SourceEvernote:
+		err = s.readEvernote(x, from)
+	case SourceJoplin:
+		err = s.readJoplin(x, from)
+	case SourceNotion:
+		err = s.readNotion(x, from)
+	case SourceObsidian:
+		err = s.readObsidian(x, from)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrImportSource, source)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(x.notes) == 0 {
+		return nil, fmt.Errorf("%w: '%s'", ErrImportEmpty, from)
+	}
+
+	if err := s.fs.CreateDirectoryAll(targetDir); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImportTarget, err)
+	}
+	paths := s.assignPaths(x, targetDir)
+	for _, n := range x.notes {
+		if err := s.writeNote(x, n, paths); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range x.files {
+		if _, ok := x.written[file.id]; ok {
+			continue
+		}
+		dest := s.uniquePath(filepath.Join(targetDir, filepath.FromSlash(path.Dir(file.name))), path.Base(file.name), nil)
+		if err := s.writeAttachment(x, file, dest); err != nil {
+			x.warn("Could not copy '%s': %v", file.name, err)
+		}
+	}
+
+	if err := s.writeReport(x.report); err != nil {
+		return nil, err
+	}
+	return x.report, nil
+}
+
+func (s *Service) assignPaths(x *export, targetDir string) map[string]string {
+	paths := make(map[string]string, len(x.notes))
+	taken := make(map[string]bool, len(x.notes))
+	for _, n := range x.notes {
+		name := n.name
+		if !n.keepName {
+			name = s.fs.SanitizeFilenameComponent(name)
+		}
+		if name == "" {
+			name = untitledNote
+		}
+		dir := filepath.Join(targetDir, filepath.FromSlash(n.dir))
+		notePath := s.uniquePath(dir, name+noteExtension, taken)
+		if n.keepName && filepath.Base(notePath) != name+noteExtension {
+			x.warn("'%s' already exists in the workspace; imported as '%s'.", path.Join(n.dir, name+noteExtension), filepath.Base(notePath))
+		}
+		paths[n.key] = notePath
+	}
+	return paths
+}
+
+func (s *Service) uniquePath(dir, name string, taken map[string]bool) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	candidate := filepath.Join(dir, name)
+	for counter := 2; ; counter++ {
+		exists, err := s.fs.FileExists(storage.NewFileURI(candidate))
+		if (err != nil || !exists) && !taken[strings.ToLower(candidate)] {
+			break
+		}
+		candidate = filepath.Join(dir, fmt.Sprintf("%s-%d%s", base, counter, ext))
+	}
+	if taken != nil {
+		taken[strings.ToLower(candidate)] = true
+	}
+	return candidate
+}
+
+func (s *Service) writeNote(x *export, n *note, paths map[string]string) error {
+	notePath := paths[n.key]
+	noteDir := filepath.Dir(notePath)
+	saved := make(map[string]bool)
+
+	body := linkRegex.ReplaceAllStringFunc(n.body, func(link string) string {
+		m := linkRegex.FindStringSubmatch(link)
+		dest := strings.TrimSuffix(strings.TrimPrefix(m[2], "<"), ">")
+		t, ok := n.links[dest]
+		if !ok {
+			return link
+		}
+		var resolved string
+		switch {
+		case t.file != nil:
+			written, err := s.attachmentPath(x, t.file, notePath)
+			if err != nil {
+				x.warn("'%s': could not save attachment '%s': %v", n.title, t.file.name, err)
+				return link
+			}
+			if !saved[t.file.id] {
+				saved[t.file.id] = true
+				n.attachments++
+			}
+			resolved = written
+		case t.note != "":
+			linked, ok := paths[t.note]
+			if !ok {
+				return link
+			}
+			resolved = linked
+		default:
+			return link
+		}
+		rel, err := filepath.Rel(noteDir, resolved)
+		if err != nil {
+			return link
+		}
+		destination := (&url.URL{Path: filepath.ToSlash(rel)}).EscapedPath()
+		if t.fragment != "" {
+			destination += "#" + t.fragment
+		}
+		return m[1] + "(" + destination + m[3] + ")"
+	})
+
+	content := body
+	if !n.keepHeader {
+		rendered, err := frontmatter.Render(body, properties(n))
+		if err != nil {
+			x.warn("'%s': could not write its properties: %v", n.title, err)
+		} else {
+			content = rendered
+		}
+	}
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+
+	if err := s.fs.CreateDirectoryAll(noteDir); err != nil {
+		return fmt.Errorf("%w: %v", ErrImportWrite, err)
+	}
+	uri := storage.NewFileURI(notePath)
+	if err := s.fs.WriteFile(uri, []byte(content)); err != nil {
+		return fmt.Errorf("%w: '%s': %v", ErrImportWrite, n.title, err)
+	}
+	if !n.modified.IsZero() {
+		if err := s.fs.SetModTime(uri, n.modified); err != nil {
+			x.warn("'%s': could not keep its modification time: %v", n.title, err)
+		}
+	}
+
+	rel, _ := filepath.Rel(x.report.Target, notePath)
+	x.report.Notes = append(x.report.Notes, ReportNote{
+		Title:       n.title,
+		Path:        filepath.ToSlash(rel),
+		Attachments: n.attachments,
+	})
+	return nil
+}
+
+func properties(n *note) []frontmatter.Property {
+	props := []frontmatter.Property{{Key: frontmatter.KeyTitle, Value: n.title}}
+	if len(n.tags) > 0 {
+		tags := make([]any, 0, len(n.tags))
+		seen := make(map[string]bool, len(n.tags))
+		for _, tag := range n.tags {
+			tag = normalizeTag(tag)
+			if tag != "" && !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+		if len(tags) > 0 {
+			props = append(props, frontmatter.Property{Key: frontmatter.KeyTags, Value: tags})
+		}
+	}
+	if !n.created.IsZero() {
+		props = append(props, frontmatter.Property{Key: frontmatter.KeyCreated, Value: n.created})
+	}
+	if !n.modified.IsZero() {
+		props = append(props, frontmatter.Property{Key: frontmatter.KeyModified, Value: n.modified})
+	}
+	for _, p := range n.properties {
+		if !hasProperty(props, p.Key) {
+			props = append(props, p)
+		}
+	}
+	return props
+}
+
+func hasProperty(props []frontmatter.Property, key string) bool {
+	for _, p := range props {
+		if strings.EqualFold(p.Key, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeTag(tag string) string {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+	return strings.Join(strings.Fields(tag), "-")
+}
+
+func (s *Service) attachmentPath(x *export, file *attachment, notePath string) (string, error) {
+	if written, ok := x.written[file.id]; ok {
+		return written, nil
+	}
+	dir := s.attachmentsDir(notePath)
+	if err := s.fs.CreateDirectoryAll(dir); err != nil {
+		return "", err
+	}
+	dest := s.uniquePath(dir, attachmentName(s.fs, path.Base(file.name)), nil)
+	if err := s.writeAttachment(x, file, dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+func (s *Service) writeAttachment(x *export, file *attachment, dest string) error {
+	data, err := file.read()
+	if err != nil {
+		return err
+	}
+	if err := s.fs.CreateDirectoryAll(filepath.Dir(dest)); err != nil {
+		return err
+	}
+	if err := s.fs.WriteFile(storage.NewFileURI(dest), data); err != nil {
+		return err
+	}
+	x.written[file.id] = dest
+	x.report.Attachments++
+	return nil
+}
+
+func attachmentName(fs fileservice.FileOperations, name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	base := fs.SanitizeFilenameComponent(strings.TrimSuffix(name, filepath.Ext(name)))
+	if base == "" {
+		base = "attachment"
+	}
+	return base + ext
+}
+
+func extensionFor(mimeType string) string {
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	if ext, ok := mimeExtensions[mimeType]; ok {
+		return ext
+	}
+	if exts, err := mime.ExtensionsByType(mimeType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+func contentID(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+func dataReader(data []byte) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		return data, nil
+	}
+}
+
+func (s *Service) fileReader(path string) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		return s.fs.ReadFile(storage.NewFileURI(path))
+	}
+}
+
+func fileTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
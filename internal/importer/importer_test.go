@@ -0,0 +1,366 @@
+package importer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"markdown-editor/internal/fileservice"
+	"markdown-editor/internal/frontmatter"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+)
+
+type wantNote struct {
+	path     string
+	title    string
+	tags     []any
+	created  time.Time
+	modified time.Time
+	modTime  time.Time
+	contains []string
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func joplinID(c byte) string {
+	return strings.Repeat(string(c), 32)
+}
+
+func enexFixture(t *testing.T) string {
+	image := []byte("map-image")
+	sum := md5.Sum(image)
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export3.dtd">
+<en-export>
+<note>
+<title>Trip Plan</title>
+<content><![CDATA[<?xml version="1.0" encoding="UTF-8"?><!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd"><en-note><div>Pack <b>bags</b></div><div><en-todo checked="true"/>Book hotel</div><div><en-media hash="` + hex.EncodeToString(sum[:]) + `" type="image/png"/></div></en-note>]]></content>
+<created>20240102T030405Z</created>
+<updated>20240203T040506Z</updated>
+<tag>travel</tag>
+<tag>summer plans</tag>
+<note-attributes><source-url>https://example.com/trip</source-url></note-attributes>
+<resource><data encoding="base64">` + base64.StdEncoding.EncodeToString(image) + `</data><mime>image/png</mime><resource-attributes><file-name>map.png</file-name></resource-attributes></resource>
+</note>
+</en-export>
+`
+	from := filepath.Join(t.TempDir(), "Travel.enex")
+	writeFiles(t, filepath.Dir(from), map[string]string{filepath.Base(from): content})
+	return from
+}
+
+func joplinFixture(t *testing.T) string {
+	folder, meeting, other, chart, tag, noteTag := joplinID('a'), joplinID('b'), joplinID('c'), joplinID('d'), joplinID('e'), joplinID('f')
+	entries := []struct{ name, content string }{
+		{folder + ".md", "Work\n\nid: " + folder + "\nparent_id: \ntype_: 2"},
+		{meeting + ".md", "Meeting\n\nSee ![chart](:/" + chart + ") and [other](:/" + other + "#part).\n\nid: " + meeting +
+			"\nparent_id: " + folder + "\ncreated_time: 2024-05-06T07:08:09.000Z\nupdated_time: 2024-05-08T07:08:09.000Z" +
+			"\nuser_created_time: 2024-05-06T07:08:09.000Z\nuser_updated_time: 2024-05-07T07:08:09.000Z\nmarkup_language: 1\ntype_: 1"},
+		{other + ".md", "Other\n\n## Part\n\nid: " + other + "\nparent_id: \ntype_: 1"},
+		{chart + ".md", "chart.png\n\nid: " + chart + "\nmime: image/png\nfile_extension: png\ntype_: 4"},
+		{tag + ".md", "Project X\n\nid: " + tag + "\ntype_: 5"},
+		{noteTag + ".md", "id: " + noteTag + "\nnote_id: " + meeting + "\ntag_id: " + tag + "\ntype_: 6"},
+		{"resources/" + chart + ".png", "chart-image"},
+	}
+
+	from := filepath.Join(t.TempDir(), "export.jex")
+	f, err := os.Create(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	for _, e := range entries {
+		if err := tw.WriteHeader(&tar.Header{Name: e.name, Mode: 0o644, Size: int64(len(e.content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return from
+}
+
+func notionFixture(t *testing.T) string {
+	home, tasks, task := "Home "+joplinID('1'), "Tasks "+joplinID('2'), "Task One "+joplinID('3')
+	escape := func(s string) string { return strings.ReplaceAll(s, " ", "%20") }
+	entries := []struct{ name, content string }{
+		{home + ".md", "# Home\n\n![diagram](" + escape(home) + "/diagram.png)\n\n[Task](" + escape(tasks+"/"+task) + ".md)\n"},
+		{home + "/diagram.png", "diagram-image"},
+		{tasks + ".csv", "\ufeffName,Tags,Created,Last edited time,Status\nTask One,\"alpha, beta\",\"March 4, 2024 10:30 AM\",\"March 5, 2024 11:00 AM\",Done\n"},
+		{tasks + "/" + task + ".md", "# Task One\n\nTags: alpha, beta\nCreated: March 4, 2024 10:30 AM\nLast edited time: March 5, 2024 11:00 AM\nStatus: Done\n\nDo it.\n"},
+	}
+
+	from := filepath.Join(t.TempDir(), "notion.zip")
+	f, err := os.Create(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		w, err := zw.Create(e.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return from
+}
+
+func obsidianFixture(t *testing.T) string {
+	from := t.TempDir()
+	writeFiles(t, from, map[string]string{
+		"Daily/2024-01-01.md": "---\ntags: [journal]\n---\n![[photo.jpg|300]] and ![[Docs/spec.pdf]] link [[Other]] and ![alt](../Media/photo.jpg)\n",
+		"Other.md":            "Plain note\n",
+		"Media/photo.jpg":     "photo-image",
+		"Docs/spec.pdf":       "spec-document",
+		"Unused/extra.txt":    "extra",
+		".obsidian/app.json":  "{}",
+	})
+	modified := time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(from, "Daily", "2024-01-01.md"), modified, modified); err != nil {
+		t.Fatal(err)
+	}
+	return from
+}
+
+func TestImport(t *testing.T) {
+	test.NewTempApp(t)
+
+	tests := []struct {
+		name            string
+		source          Source
+		fixture         func(t *testing.T) string
+		notes           []wantNote
+		files           map[string]string
+		wantAttachments int
+	}{
+		{
+			name:    "evernote",
+			source:  SourceEvernote,
+			fixture: enexFixture,
+			notes: []wantNote{{
+				path:     "trip-plan.md",
+				title:    "Trip Plan",
+				tags:     []any{"travel", "summer-plans"},
+				created:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				modified: time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC),
+				modTime:  time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC),
+				contains: []string{"Pack **bags**", "- [x] Book hotel", "![map](assets/trip-plan/map.png)", "source: https://example.com/trip"},
+			}},
+			files:           map[string]string{"assets/trip-plan/map.png": "map-image"},
+			wantAttachments: 1,
+		},
+		{
+			name:    "joplin",
+			source:  SourceJoplin,
+			fixture: joplinFixture,
+			notes: []wantNote{
+				{
+					path:     "work/meeting.md",
+					title:    "Meeting",
+					tags:     []any{"Project-X"},
+					created:  time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC),
+					modified: time.Date(2024, 5, 7, 7, 8, 9, 0, time.UTC),
+					modTime:  time.Date(2024, 5, 7, 7, 8, 9, 0, time.UTC),
+					contains: []string{"![chart](assets/meeting/chart.png)", "[other](../other.md#part)"},
+				},
+				{path: "other.md", title: "Other", contains: []string{"## Part"}},
+			},
+			files:           map[string]string{"work/assets/meeting/chart.png": "chart-image"},
+			wantAttachments: 1,
+		},
+		{
+			name:    "notion",
+			source:  SourceNotion,
+			fixture: notionFixture,
+			notes: []wantNote{
+				{
+					path:     "home.md",
+					title:    "Home",
+					contains: []string{"![diagram](assets/home/diagram.png)", "[Task](tasks/task-one.md)"},
+				},
+				{
+					path:     "tasks/task-one.md",
+					title:    "Task One",
+					tags:     []any{"alpha", "beta"},
+					created:  time.Date(2024, 3, 4, 10, 30, 0, 0, time.Local),
+					modified: time.Date(2024, 3, 5, 11, 0, 0, 0, time.Local),
+					modTime:  time.Date(2024, 3, 5, 11, 0, 0, 0, time.Local),
+					contains: []string{"Status: Done", "# Task One\n\nDo it."},
+				},
+			},
+			files:           map[string]string{"assets/home/diagram.png": "diagram-image"},
+			wantAttachments: 1,
+		},
+		{
+			name:    "obsidian",
+			source:  SourceObsidian,
+			fixture: obsidianFixture,
+			notes: []wantNote{
+				{
+					path:    "Daily/2024-01-01.md",
+					tags:    []any{"journal"},
+					modTime: time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC),
+					contains: []string{
+						"![photo](assets/2024-01-01/photo.jpg) and ![spec](assets/2024-01-01/spec.pdf)",
+						"link [[Other]]",
+						"![alt](assets/2024-01-01/photo.jpg)",
+					},
+				},
+				{path: "Other.md", contains: []string{"Plain note"}},
+			},
+			files: map[string]string{
+				"Daily/assets/2024-01-01/photo.jpg": "photo-image",
+				"Daily/assets/2024-01-01/spec.pdf":  "spec-document",
+				"Unused/extra.txt":                  "extra",
+			},
+			wantAttachments: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := tt.fixture(t)
+			target := t.TempDir()
+			service := New(fileservice.New(), func(notePath string) string {
+				return filepath.Join(filepath.Dir(notePath), "assets", strings.TrimSuffix(filepath.Base(notePath), filepath.Ext(notePath)))
+			})
+
+			report, err := service.Import(tt.source, from, target)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var gotPaths, wantPaths []string
+			for _, n := range report.Notes {
+				gotPaths = append(gotPaths, n.Path)
+			}
+			for _, n := range tt.notes {
+				wantPaths = append(wantPaths, n.path)
+			}
+			sort.Strings(gotPaths)
+			sort.Strings(wantPaths)
+			if !reflect.DeepEqual(gotPaths, wantPaths) {
+				t.Errorf("report notes = %v, want %v", gotPaths, wantPaths)
+			}
+			if report.Attachments != tt.wantAttachments {
+				t.Errorf("report attachments = %d, want %d", report.Attachments, tt.wantAttachments)
+			}
+			if len(report.Warnings) > 0 {
+				t.Errorf("unexpected warnings: %v", report.Warnings)
+			}
+			if data, err := os.ReadFile(report.Path); err != nil || !strings.Contains(string(data), report.Summary()) {
+				t.Errorf("report note %s = %q, %v", report.Path, data, err)
+			}
+
+			for _, want := range tt.notes {
+				checkNote(t, target, want)
+			}
+			for name, content := range tt.files {
+				data, err := os.ReadFile(filepath.Join(target, filepath.FromSlash(name)))
+				if err != nil || string(data) != content {
+					t.Errorf("%s = %q, %v, want %q", name, data, err, content)
+				}
+			}
+		})
+	}
+}
+
+func checkNote(t *testing.T, target string, want wantNote) {
+	t.Helper()
+	path := filepath.Join(target, filepath.FromSlash(want.path))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("%s was not written: %v", want.path, err)
+		return
+	}
+	content := string(data)
+	for _, s := range want.contains {
+		if !strings.Contains(content, s) {
+			t.Errorf("%s does not contain %q:\n%s", want.path, s, content)
+		}
+	}
+
+	doc, err := frontmatter.Parse(content)
+	if err != nil {
+		t.Errorf("%s: %v", want.path, err)
+		return
+	}
+	props := make(map[string]any)
+	for _, p := range doc.Properties {
+		props[p.Key] = p.Value
+	}
+	if want.title != "" && props[frontmatter.KeyTitle] != want.title {
+		t.Errorf("%s title = %v, want %q", want.path, props[frontmatter.KeyTitle], want.title)
+	}
+	if tags, _ := props[frontmatter.KeyTags].([]any); !reflect.DeepEqual(tags, want.tags) {
+		t.Errorf("%s tags = %v, want %v", want.path, props[frontmatter.KeyTags], want.tags)
+	}
+	for key, wantTime := range map[string]time.Time{frontmatter.KeyCreated: want.created, frontmatter.KeyModified: want.modified} {
+		if got, _ := props[key].(time.Time); !got.Equal(wantTime) {
+			t.Errorf("%s %s = %v, want %v", want.path, key, props[key], wantTime)
+		}
+	}
+	if !want.modTime.IsZero() {
+		if info, err := os.Stat(path); err != nil || !info.ModTime().Equal(want.modTime) {
+			t.Errorf("%s modification time = %v, %v, want %v", want.path, info, err, want.modTime)
+		}
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	test.NewTempApp(t)
+
+	service := New(fileservice.New(), filepath.Dir)
+	empty := t.TempDir()
+	tests := []struct {
+		name    string
+		source  Source
+		from    string
+		target  string
+		wantErr error
+	}{
+		{"unknown source", Source("Bear"), empty, t.TempDir(), ErrImportSource},
+		{"target inside export", SourceObsidian, empty, filepath.Join(empty, "notes"), ErrImportTarget},
+		{"empty export", SourceObsidian, empty, t.TempDir(), ErrImportEmpty},
+		{"missing file", SourceEvernote, filepath.Join(empty, "missing.enex"), t.TempDir(), ErrImportRead},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.Import(tt.source, tt.from, tt.target); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Import() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
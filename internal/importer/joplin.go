@@ -0,0 +1,297 @@
+package importer
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"markdown-editor/internal/frontmatter"
+	"markdown-editor/internal/htmltomd"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2/storage"
+)
+
+const (
+	joplinNote     = "1"
+	joplinFolder   = "2"
+	joplinResource = "4"
+	joplinTag      = "5"
+	joplinNoteTag  = "6"
+	joplinHTML     = "2"
+	joplinResDir   = "resources"
+)
+
+var (
+	joplinPropertyRegex = regexp.MustCompile(`^([a-z_]+): ?(.*)$`)
+	joplinLinkRegex     = regexp.MustCompile(`^:/([0-9a-fA-F]{32})(?:#(.*))?$`)
+)
+
+type joplinItem struct {
+	title string
+	body  string
+	props map[string]string
+}
+
+func (it joplinItem) time(keys ...string) time.Time {
+	for _, key := range keys {
+		if t, err := time.Parse(time.RFC3339Nano, it.props[key]); err == nil && t.Unix() > 0 {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func parseJoplinItem(content string) joplinItem {
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(content, "\r\n", "\n"), "\n"), "\n")
+	end := len(lines)
+	props := make(map[string]string)
+	for end > 0 {
+		m := joplinPropertyRegex.FindStringSubmatch(lines[end-1])
+		if m == nil {
+			break
+		}
+		props[m[1]] = m[2]
+		end--
+	}
+
+	item := joplinItem{props: props}
+	if end > 0 {
+		item.title = strings.TrimSpace(lines[0])
+		item.body = strings.Trim(strings.Join(lines[1:end], "\n"), "\n")
+	}
+	return item
+}
+
+type joplinExport struct {
+	items     map[string]joplinItem
+	resources map[string]*attachment
+}
+
+func (s *Service) readJoplin(x *export, from string) error {
+	info, err := os.Stat(from)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrImportRead, err)
+	}
+	var files map[string][]byte
+	if info.IsDir() {
+		files, err = s.joplinDirectory(from)
+	} else {
+		files, err = joplinArchive(from)
+	}
+	if err != nil {
+		return err
+	}
+
+	jx := &joplinExport{
+		items:     make(map[string]joplinItem),
+		resources: make(map[string]*attachment),
+	}
+	var ids []string
+	for name, data := range files {
+		if path.Dir(name) != "." || path.Ext(name) != noteExtension {
+			continue
+		}
+		item := parseJoplinItem(string(data))
+		id := item.props["id"]
+		if id == "" {
+			continue
+		}
+		if item.props["encryption_applied"] == "1" {
+			x.skip("%s: the item is encrypted; decrypt the notebook in Joplin before exporting", name)
+			continue
+		}
+		jx.items[id] = item
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		item := jx.items[id]
+		if item.props["type_"] != joplinResource {
+			continue
+		}
+		ext := item.props["file_extension"]
+		stored := path.Join(joplinResDir, id)
+		if ext != "" {
+			stored += "." + ext
+		}
+		name := item.title
+		if name == "" {
+			name = path.Base(stored)
+		} else if filepath.Ext(name) == "" && ext != "" {
+			name += "." + ext
+		}
+		file := &attachment{id: "joplin:" + id, name: name}
+		if data, ok := files[stored]; ok {
+			file.read = dataReader(data)
+		} else if stored := filepath.Join(from, filepath.FromSlash(stored)); info.IsDir() && fileExists(stored) {
+			file.read = s.fileReader(stored)
+		} else {
+			x.skip("%s: the attachment file is missing from the export", name)
+			continue
+		}
+		jx.resources[id] = file
+	}
+
+	tags := make(map[string][]string)
+	for _, id := range ids {
+		item := jx.items[id]
+		if item.props["type_"] != joplinNoteTag {
+			continue
+		}
+		if tag, ok := jx.items[item.props["tag_id"]]; ok && tag.props["type_"] == joplinTag {
+			tags[item.props["note_id"]] = append(tags[item.props["note_id"]], tag.title)
+		}
+	}
+
+	for _, id := range ids {
+		item := jx.items[id]
+		if item.props["type_"] != joplinNote {
+			continue
+		}
+		x.notes = append(x.notes, s.joplinNote(x, jx, id, item, tags[id]))
+	}
+
+	for _, id := range ids {
+		if file, ok := jx.resources[id]; ok && !jx.referenced(id, x.notes) {
+			x.skip("%s: the attachment is not used by any note", file.name)
+		}
+	}
+	return nil
+}
+
+func (jx *joplinExport) referenced(id string, notes []*note) bool {
+	for _, n := range notes {
+		for _, t := range n.links {
+			if t.file != nil && t.file == jx.resources[id] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Service) joplinNote(x *export, jx *joplinExport, id string, item joplinItem, tags []string) *note {
+	n := &note{
+		key:      id,
+		title:    item.title,
+		name:     item.title,
+		dir:      s.joplinFolderPath(jx, item.props["parent_id"]),
+		tags:     tags,
+		created:  item.time("user_created_time", "created_time"),
+		modified: item.time("user_updated_time", "updated_time"),
+		links:    make(map[string]target),
+		body:     item.body,
+	}
+	if item.props["markup_language"] == joplinHTML {
+		body, err := htmltomd.Convert(item.body)
+		if err != nil {
+			x.warn("'%s': the HTML note could not be converted: %v", item.title, err)
+		} else {
+			n.body = body
+		}
+	}
+	if url := item.props["source_url"]; url != "" {
+		n.properties = append(n.properties, frontmatter.Property{Key: propertySource, Value: url})
+	}
+	if author := item.props["author"]; author != "" {
+		n.properties = append(n.properties, frontmatter.Property{Key: "author", Value: author})
+	}
+	if item.props["is_todo"] == "1" {
+		done := item.props["todo_completed"] != "" && item.props["todo_completed"] != "0"
+		n.properties = append(n.properties, frontmatter.Property{Key: "completed", Value: done})
+	}
+
+	for _, m := range linkRegex.FindAllStringSubmatch(n.body, -1) {
+		dest := strings.TrimSuffix(strings.TrimPrefix(m[2], "<"), ">")
+		ref := joplinLinkRegex.FindStringSubmatch(dest)
+		if ref == nil {
+			continue
+		}
+		refID := strings.ToLower(ref[1])
+		if file, ok := jx.resources[refID]; ok {
+			n.links[dest] = target{file: file}
+			continue
+		}
+		if linked, ok := jx.items[refID]; ok && linked.props["type_"] == joplinNote {
+			n.links[dest] = target{note: refID, fragment: ref[2]}
+			continue
+		}
+		x.warn("'%s': links to an item that is not in the export (%s)", item.title, dest)
+	}
+	return n
+}
+
+func (s *Service) joplinFolderPath(jx *joplinExport, id string) string {
+	var parts []string
+	seen := make(map[string]bool)
+	for id != "" && !seen[id] {
+		seen[id] = true
+		folder, ok := jx.items[id]
+		if !ok || folder.props["type_"] != joplinFolder {
+			break
+		}
+		name := s.fs.SanitizeFilenameComponent(folder.title)
+		if name == "" {
+			name = untitledNote
+		}
+		parts = append([]string{name}, parts...)
+		id = folder.props["parent_id"]
+	}
+	return path.Join(parts...)
+}
+
+func (s *Service) joplinDirectory(dir string) (map[string][]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImportRead, err)
+	}
+	files := make(map[string][]byte)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != noteExtension {
+			continue
+		}
+		data, err := s.fs.ReadFile(storage.NewFileURI(filepath.Join(dir, entry.Name())))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrImportRead, err)
+		}
+		files[entry.Name()] = data
+	}
+	return files, nil
+}
+
+func joplinArchive(file string) (map[string][]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImportRead, err)
+	}
+	defer f.Close()
+
+	files := make(map[string][]byte)
+	reader := tar.NewReader(f)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrImportRead, filepath.Base(file), err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrImportRead, header.Name, err)
+		}
+		files[path.Clean(strings.TrimPrefix(header.Name, "./"))] = data
+	}
+	return files, nil
+}
@@ -0,0 +1,333 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io/fs"
+	"markdown-editor/internal/frontmatter"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	notionDatabase    = ".csv"
+	notionAllSuffix   = "_all"
+	notionArchive     = ".zip"
+	notionByteOrder   = "\ufeff"
+	notionTitlePrefix = "# "
+)
+
+var (
+	notionIDRegex       = regexp.MustCompile(`\s+[0-9a-f]{32}$`)
+	notionPropertyRegex = regexp.MustCompile(`^([^:\n]+): (.*)$`)
+	schemeRegex         = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+)
+
+var notionDateLayouts = []string{
+	"January 2, 2006 3:04 PM",
+	"January 2, 2006 15:04",
+	"January 2, 2006",
+	"2006/01/02 15:04",
+	"2006/01/02",
+	time.RFC3339,
+	"2006-01-02",
+}
+
+var (
+	notionTagColumns      = map[string]bool{"tags": true, "tag": true, "labels": true, "label": true, "keywords": true, "categories": true, "category": true}
+	notionCreatedColumns  = map[string]bool{"created": true, "created time": true, "created at": true, "date created": true}
+	notionModifiedColumns = map[string]bool{"last edited time": true, "last edited": true, "updated": true, "updated at": true, "modified": true, "last modified": true}
+)
+
+type notionFile struct {
+	fsys fs.FS
+	name string
+}
+
+func (f notionFile) read() ([]byte, error) {
+	return fs.ReadFile(f.fsys, f.name)
+}
+
+type notionRow struct {
+	header []string
+	values []string
+}
+
+func (s *Service) readNotion(x *export, from string) error {
+	files, closeAll, err := notionFiles(from)
+	x.closers = append(x.closers, closeAll)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := make(map[string]notionRow)
+	for _, name := range names {
+		if !strings.EqualFold(path.Ext(name), notionDatabase) {
+			continue
+		}
+		stem := strings.TrimSuffix(name, path.Ext(name))
+		if !strings.HasSuffix(stem, notionAllSuffix) {
+			if _, ok := files[stem+notionAllSuffix+path.Ext(name)]; ok {
+				continue
+			}
+		}
+		if err := notionDatabaseRows(files[name], strings.TrimSuffix(stem, notionAllSuffix), rows); err != nil {
+			x.warn("Database '%s' could not be read, so its pages have no properties: %v", notionClean(path.Base(stem)), err)
+		}
+	}
+
+	notes := make(map[string]*note)
+	attachments := make(map[string]*attachment)
+	for _, name := range names {
+		switch ext := strings.ToLower(path.Ext(name)); ext {
+		case noteExtension:
+			data, err := files[name].read()
+			if err != nil {
+				x.warn("'%s' could not be read: %v", name, err)
+				continue
+			}
+			n := s.notionNote(name, string(data), rows)
+			notes[name] = n
+			x.notes = append(x.notes, n)
+		case notionDatabase:
+		default:
+			attachments[name] = &attachment{id: "notion:" + name, name: notionClean(path.Base(strings.TrimSuffix(name, ext))) + ext, read: files[name].read}
+		}
+	}
+
+	referenced := make(map[string]bool)
+	for _, name := range names {
+		n, ok := notes[name]
+		if !ok {
+			continue
+		}
+		for _, m := range linkRegex.FindAllStringSubmatch(n.body, -1) {
+			dest := strings.TrimSuffix(strings.TrimPrefix(m[2], "<"), ">")
+			if schemeRegex.MatchString(dest) || strings.HasPrefix(dest, "#") {
+				continue
+			}
+			link, fragment, _ := strings.Cut(dest, "#")
+			decoded, err := url.PathUnescape(link)
+			if err != nil {
+				decoded = link
+			}
+			resolved := path.Join(path.Dir(name), decoded)
+			switch {
+			case notes[resolved] != nil:
+				n.links[dest] = target{note: resolved, fragment: fragment}
+			case attachments[resolved] != nil:
+				n.links[dest] = target{file: attachments[resolved]}
+				referenced[resolved] = true
+			case strings.EqualFold(path.Ext(resolved), notionDatabase):
+			default:
+				x.warn("'%s': links to '%s', which is not in the export", n.title, decoded)
+			}
+		}
+	}
+	for _, name := range names {
+		if a, ok := attachments[name]; ok && !referenced[name] {
+			x.skip("%s: the file is not used by any page", a.name)
+		}
+	}
+	return nil
+}
+
+func notionClean(name string) string {
+	return strings.TrimSpace(notionIDRegex.ReplaceAllString(name, ""))
+}
+
+func (s *Service) notionNote(name, content string, rows map[string]notionRow) *note {
+	stem := notionClean(path.Base(strings.TrimSuffix(name, path.Ext(name))))
+	var dirs []string
+	if dir := path.Dir(name); dir != "." {
+		for _, part := range strings.Split(dir, "/") {
+			if part = s.fs.SanitizeFilenameComponent(notionClean(part)); part != "" {
+				dirs = append(dirs, part)
+			}
+		}
+	}
+
+	content = strings.TrimPrefix(strings.ReplaceAll(content, "\r\n", "\n"), notionByteOrder)
+	title := stem
+	if first, _, _ := strings.Cut(content, "\n"); strings.HasPrefix(first, notionTitlePrefix) {
+		title = strings.TrimSpace(strings.TrimPrefix(first, notionTitlePrefix))
+	}
+
+	n := &note{
+		key:   name,
+		title: title,
+		name:  stem,
+		dir:   path.Join(dirs...),
+		body:  content,
+		links: make(map[string]target),
+	}
+	row, ok := rows[path.Dir(name)+"\x00"+strings.ToLower(title)]
+	if !ok {
+		return n
+	}
+
+	columns := make(map[string]bool, len(row.header))
+	for i, column := range row.header {
+		columns[column] = true
+		if i == 0 || i >= len(row.values) {
+			continue
+		}
+		value := strings.TrimSpace(row.values[i])
+		if value == "" {
+			continue
+		}
+		key := strings.ToLower(column)
+		switch {
+		case notionTagColumns[key]:
+			for _, tag := range strings.Split(value, ",") {
+				n.tags = append(n.tags, strings.TrimSpace(tag))
+			}
+		case notionCreatedColumns[key]:
+			n.created = notionDate(value)
+		case notionModifiedColumns[key]:
+			n.modified = notionDate(value)
+		default:
+			n.properties = append(n.properties, frontmatter.Property{Key: column, Value: value})
+		}
+	}
+	n.body = stripNotionProperties(n.body, columns)
+	return n
+}
+
+func stripNotionProperties(body string, columns map[string]bool) string {
+	lines := strings.Split(body, "\n")
+	start := 0
+	if len(lines) > 0 && strings.HasPrefix(lines[0], notionTitlePrefix) {
+		start = 1
+	}
+	for start < len(lines) && strings.TrimSpace(lines[start]) == "" {
+		start++
+	}
+	end := start
+	for end < len(lines) {
+		m := notionPropertyRegex.FindStringSubmatch(lines[end])
+		if m == nil || !columns[m[1]] {
+			break
+		}
+		end++
+	}
+	if end == start {
+		return body
+	}
+	head := strings.TrimRight(strings.Join(lines[:start], "\n"), "\n")
+	rest := strings.TrimLeft(strings.Join(lines[end:], "\n"), "\n")
+	if head == "" || rest == "" {
+		return head + rest
+	}
+	return head + "\n\n" + rest
+}
+
+func notionDate(value string) time.Time {
+	for _, layout := range notionDateLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t
+		}
+	}
+	if t, ok := frontmatter.ParseDate(value); ok {
+		return t
+	}
+	return time.Time{}
+}
+
+func notionDatabaseRows(file notionFile, stem string, rows map[string]notionRow) error {
+	data, err := file.read()
+	if err != nil {
+		return err
+	}
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte(notionByteOrder))))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(records) < 2 {
+		return nil
+	}
+	header := records[0]
+	for _, record := range records[1:] {
+		if len(record) == 0 || strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		rows[stem+"\x00"+strings.ToLower(strings.TrimSpace(record[0]))] = notionRow{header: header, values: record}
+	}
+	return nil
+}
+
+func notionFiles(from string) (map[string]notionFile, func(), error) {
+	files := make(map[string]notionFile)
+	var closers []func() error
+	closeAll := func() {
+		for _, c := range closers {
+			c()
+		}
+	}
+
+	var root fs.FS
+	info, err := os.Stat(from)
+	if err != nil {
+		return nil, closeAll, fmt.Errorf("%w: %v", ErrImportRead, err)
+	}
+	if info.IsDir() {
+		root = os.DirFS(from)
+	} else {
+		archive, err := zip.OpenReader(from)
+		if err != nil {
+			return nil, closeAll, fmt.Errorf("%w: %v", ErrImportRead, err)
+		}
+		closers = append(closers, archive.Close)
+		root = archive
+	}
+
+	var walk func(fsys fs.FS, prefix string) error
+	walk = func(fsys fs.FS, prefix string) error {
+		return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if name != "." && strings.HasPrefix(d.Name(), ".") {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if strings.HasPrefix(d.Name(), ".") {
+				return nil
+			}
+			if strings.EqualFold(path.Ext(name), notionArchive) {
+				data, err := fs.ReadFile(fsys, name)
+				if err != nil {
+					return err
+				}
+				nested, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+				if err != nil {
+					return err
+				}
+				return walk(nested, path.Join(prefix, path.Dir(name)))
+			}
+			files[path.Join(prefix, name)] = notionFile{fsys: fsys, name: name}
+			return nil
+		})
+	}
+	if err := walk(root, ""); err != nil {
+		return nil, closeAll, fmt.Errorf("%w: %v", ErrImportRead, err)
+	}
+	return files, closeAll, nil
+}
@@ -0,0 +1,144 @@
+package importer
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2/storage"
+)
+
+const obsidianRef = "obsidian-attachment:"
+
+var obsidianEmbedRegex = regexp.MustCompile(`(!?)\[\[([^\[\]|#\n]+)(?:#[^\[\]|\n]*)?(?:\|([^\[\]\n]*))?\]\]`)
+
+type obsidianVault struct {
+	files map[string]*attachment
+	names map[string][]string
+}
+
+func (v *obsidianVault) resolve(noteDir, link string) (string, bool) {
+	link = strings.TrimSpace(link)
+	if rel := path.Join(noteDir, link); v.files[rel] != nil {
+		return rel, true
+	}
+	if rel := strings.TrimPrefix(path.Clean("/"+link), "/"); v.files[rel] != nil {
+		return rel, true
+	}
+	if matches := v.names[strings.ToLower(path.Base(link))]; len(matches) > 0 {
+		return matches[0], true
+	}
+	return "", false
+}
+
+func (s *Service) readObsidian(x *export, from string) error {
+	all, err := s.fs.ListFilesRecursive(from)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrImportRead, err)
+	}
+	sort.Strings(all)
+
+	vault := &obsidianVault{
+		files: make(map[string]*attachment),
+		names: make(map[string][]string),
+	}
+	var notes []string
+	for _, file := range all {
+		rel, err := filepath.Rel(from, file)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if strings.EqualFold(path.Ext(rel), noteExtension) {
+			notes = append(notes, rel)
+			continue
+		}
+		file := &attachment{id: "obsidian:" + rel, name: rel, read: s.fileReader(file)}
+		vault.files[rel] = file
+		x.files = append(x.files, file)
+		base := strings.ToLower(path.Base(rel))
+		vault.names[base] = append(vault.names[base], rel)
+	}
+	for _, matches := range vault.names {
+		sort.SliceStable(matches, func(i, j int) bool {
+			return strings.Count(matches[i], "/") < strings.Count(matches[j], "/")
+		})
+	}
+
+	for _, rel := range notes {
+		file := filepath.Join(from, filepath.FromSlash(rel))
+		data, err := s.fs.ReadFile(storage.NewFileURI(file))
+		if err != nil {
+			x.warn("'%s' could not be read: %v", rel, err)
+			continue
+		}
+		dir := path.Dir(rel)
+		if dir == "." {
+			dir = ""
+		}
+		stem := strings.TrimSuffix(path.Base(rel), path.Ext(rel))
+		n := &note{
+			key:        rel,
+			title:      stem,
+			dir:        dir,
+			name:       stem,
+			keepName:   true,
+			keepHeader: true,
+			modified:   fileTime(file),
+			links:      make(map[string]target),
+		}
+		n.body = vault.rewrite(n, string(data))
+		x.notes = append(x.notes, n)
+	}
+	return nil
+}
+
+func (v *obsidianVault) rewrite(n *note, body string) string {
+	body = obsidianEmbedRegex.ReplaceAllStringFunc(body, func(match string) string {
+		m := obsidianEmbedRegex.FindStringSubmatch(match)
+		link := strings.TrimSpace(m[2])
+		if ext := path.Ext(link); ext == "" || strings.EqualFold(ext, noteExtension) {
+			return match
+		}
+		rel, ok := v.resolve(n.dir, link)
+		if !ok {
+			return match
+		}
+		ref := obsidianRef + url.PathEscape(rel)
+		n.links[ref] = target{file: v.files[rel]}
+
+		label := strings.TrimSpace(m[3])
+		if label == "" || isImageSize(label) {
+			label = strings.TrimSuffix(path.Base(rel), path.Ext(rel))
+		}
+		label = strings.NewReplacer("[", `\[`, "]", `\]`).Replace(label)
+		return m[1] + "[" + label + "](" + ref + ")"
+	})
+
+	for _, m := range linkRegex.FindAllStringSubmatch(body, -1) {
+		dest := strings.TrimSuffix(strings.TrimPrefix(m[2], "<"), ">")
+		if _, ok := n.links[dest]; ok || schemeRegex.MatchString(dest) || strings.HasPrefix(dest, "#") {
+			continue
+		}
+		decoded, err := url.PathUnescape(dest)
+		if err != nil {
+			decoded = dest
+		}
+		if ext := path.Ext(decoded); ext == "" || strings.EqualFold(ext, noteExtension) {
+			continue
+		}
+		if rel, ok := v.resolve(n.dir, decoded); ok {
+			n.links[dest] = target{file: v.files[rel]}
+		}
+	}
+	return body
+}
+
+func isImageSize(label string) bool {
+	width, height, _ := strings.Cut(label, "x")
+	return strings.Trim(width, "0123456789") == "" && strings.Trim(height, "0123456789") == "" && width != ""
+}
@@ -0,0 +1,107 @@
+package importer
+
+import (
+	"fmt"
+	"markdown-editor/internal/frontmatter"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2/storage"
+)
+
+const reportTag = "import"
+
+type ReportNote struct {
+	Title       string
+	Path        string
+	Attachments int
+}
+
+type Report struct {
+	Source      Source
+	From        string
+	Target      string
+	Path        string
+	Started     time.Time
+	Notes       []ReportNote
+	Attachments int
+	Skipped     []string
+	Warnings    []string
+}
+
+func (r *Report) Summary() string {
+	summary := fmt.Sprintf("Imported %s and %s from %s.", plural(len(r.Notes), "note"), plural(r.Attachments, "attachment"), r.Source)
+	if len(r.Skipped) > 0 || len(r.Warnings) > 0 {
+		summary += fmt.Sprintf(" %s skipped, %s.", plural(len(r.Skipped), "item"), plural(len(r.Warnings), "warning"))
+	}
+	return summary
+}
+
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s Import Report\n\n", r.Source)
+	fmt.Fprintf(&b, "%s\n\n", r.Summary())
+	fmt.Fprintf(&b, "- Source: `%s`\n", r.From)
+	fmt.Fprintf(&b, "- Imported into: `%s`\n", r.Target)
+	fmt.Fprintf(&b, "- Started: %s\n", r.Started.Format("2006-01-02 15:04"))
+
+	if len(r.Warnings) > 0 {
+		b.WriteString("\n## Warnings\n\n")
+		for _, w := range r.Warnings {
+			fmt.Fprintf(&b, "- %s\n", w)
+		}
+	}
+	if len(r.Skipped) > 0 {
+		b.WriteString("\n## Skipped\n\n")
+		for _, s := range r.Skipped {
+			fmt.Fprintf(&b, "- %s\n", s)
+		}
+	}
+
+	b.WriteString("\n## Notes\n\n")
+	for _, n := range r.Notes {
+		title := strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(n.Title)
+		if title == "" {
+			title = n.Path
+		}
+		fmt.Fprintf(&b, "- [%s](%s)", title, (&url.URL{Path: n.Path}).EscapedPath())
+		if n.Attachments > 0 {
+			fmt.Fprintf(&b, " (%s)", plural(n.Attachments, "attachment"))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+func (s *Service) writeReport(r *Report) error {
+	dir, err := storage.ListerForURI(storage.NewFileURI(r.Target))
+	if err != nil {
+		return fmt.Errorf("%w: report folder '%s': %v", ErrImportWrite, r.Target, err)
+	}
+	name, err := s.fs.GenerateUniqueFilename(dir, reportPrefix+strings.ToLower(string(r.Source))+"-", noteExtension)
+	if err != nil {
+		return fmt.Errorf("%w: report: %v", ErrImportWrite, err)
+	}
+	content, err := frontmatter.Render(r.Markdown(), []frontmatter.Property{
+		{Key: frontmatter.KeyTitle, Value: fmt.Sprintf("%s Import Report", r.Source)},
+		{Key: frontmatter.KeyTags, Value: []any{reportTag}},
+		{Key: frontmatter.KeyCreated, Value: r.Started},
+	})
+	if err != nil {
+		return fmt.Errorf("%w: report: %v", ErrImportWrite, err)
+	}
+	r.Path = filepath.Join(r.Target, name)
+	if err := s.fs.WriteFile(storage.NewFileURI(r.Path), []byte(content)); err != nil {
+		return fmt.Errorf("%w: report: %v", ErrImportWrite, err)
+	}
+	return nil
+}
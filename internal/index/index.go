@@ -0,0 +1,229 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	"markdown-editor/internal/fileservice"
+	"markdown-editor/internal/tasks"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2/storage"
+)
+
+var (
+	ErrIndexNoRoot    = errors.New("index: workspace root is empty")
+	ErrIndexListFiles = errors.New("index: failed to list workspace files")
+)
+
+const markdownExtension = ".md"
+
+type Note struct {
+	Path      string
+	Tags      []string
+	Tasks     []tasks.Task
+	Links     []string
+	WikiLinks []string
+}
+
+type TaskItem struct {
+	Path string
+	tasks.Task
+}
+
+type TagCount struct {
+	Name  string
+	Count int
+}
+
+type Index struct {
+	mu      sync.RWMutex
+	root    string
+	fs      fileservice.FileOperations
+	notes   map[string]*Note
+	exclude []string
+
+	attachmentFilter func(path string) bool
+}
+
+func New(root string, fs fileservice.FileOperations) *Index {
+	return &Index{
+		root:  root,
+		fs:    fs,
+		notes: make(map[string]*Note),
+	}
+}
+
+func IsMarkdownFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), markdownExtension)
+}
+
+func (idx *Index) Root() string {
+	return idx.root
+}
+
+func (idx *Index) Exclude(dirs ...string) {
+	idx.exclude = append(idx.exclude, dirs...)
+}
+
+func (idx *Index) excluded(path string) bool {
+	for _, dir := range idx.exclude {
+		if rel, err := filepath.Rel(dir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (idx *Index) Build() error {
+	if idx.root == "" {
+		return ErrIndexNoRoot
+	}
+
+	files, err := idx.fs.ListFilesRecursive(idx.root)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrIndexListFiles, err)
+	}
+
+	notes := make(map[string]*Note)
+	var readErrs []error
+	for _, path := range files {
+		if !IsMarkdownFile(path) || idx.excluded(path) {
+			continue
+		}
+		content, err := idx.fs.ReadFile(storage.NewFileURI(path))
+		if err != nil {
+			readErrs = append(readErrs, err)
+			continue
+		}
+		notes[path] = parseNote(path, string(content))
+	}
+
+	idx.mu.Lock()
+	idx.notes = notes
+	idx.mu.Unlock()
+
+	return errors.Join(readErrs...)
+}
+
+func (idx *Index) Update(path string, content []byte) {
+	if idx.excluded(path) {
+		return
+	}
+	note := parseNote(path, string(content))
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.notes[path] = note
+}
+
+func (idx *Index) Remove(path string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	delete(idx.notes, path)
+}
+
+func (idx *Index) Note(path string) (Note, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	note, ok := idx.notes[path]
+	if !ok {
+		return Note{}, false
+	}
+	return *note, true
+}
+
+func (idx *Index) Tags() []TagCount {
+	idx.mu.RLock()
+	counts := make(map[string]int)
+	for _, note := range idx.notes {
+		for _, tag := range note.Tags {
+			counts[tag]++
+		}
+	}
+	idx.mu.RUnlock()
+
+	tags := make([]TagCount, 0, len(counts))
+	for name, count := range counts {
+		tags = append(tags, TagCount{Name: name, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+	return tags
+}
+
+func (idx *Index) HasTags(path string, tags ...string) bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	note, ok := idx.notes[path]
+	return ok && hasAllTags(note.Tags, tags)
+}
+
+func (idx *Index) NotesWithTagTree(tag string) []string {
+	tag = NormalizeTag(tag)
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var paths []string
+	for path, note := range idx.notes {
+		for _, noteTag := range note.Tags {
+			if tagMatches(noteTag, tag) {
+				paths = append(paths, path)
+				break
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (idx *Index) Tasks() []TaskItem {
+	idx.mu.RLock()
+	var items []TaskItem
+	for path, note := range idx.notes {
+		for _, task := range note.Tasks {
+			items = append(items, TaskItem{Path: path, Task: task})
+		}
+	}
+	idx.mu.RUnlock()
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Path != items[j].Path {
+			return items[i].Path < items[j].Path
+		}
+		return items[i].Line < items[j].Line
+	})
+	return items
+}
+
+func parseNote(path, content string) *Note {
+	links, wikiLinks := ParseLinks(path, content)
+	return &Note{
+		Path:      path,
+		Tags:      ParseTags(content),
+		Tasks:     tasks.Parse(content),
+		Links:     links,
+		WikiLinks: wikiLinks,
+	}
+}
+
+func hasAllTags(noteTags, wanted []string) bool {
+	for _, tag := range wanted {
+		tag = NormalizeTag(tag)
+		found := false
+		for _, noteTag := range noteTags {
+			if tagMatches(noteTag, tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,46 @@
+package index
+
+import (
+	"markdown-editor/internal/fileservice"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildSkipsExcludedFolders(t *testing.T) {
+	root := t.TempDir()
+	templates := filepath.Join(root, "templates")
+	if err := os.MkdirAll(templates, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	note := filepath.Join(root, "note.md")
+	template := filepath.Join(templates, "meeting.md")
+	for path, content := range map[string]string{
+		note:     "#real\n- [ ] real task",
+		template: "# {{.Title}}\n#meeting\n- [ ] template task",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	idx := New(root, fileservice.New())
+	idx.Exclude(templates)
+	if err := idx.Build(); err != nil {
+		t.Fatal(err)
+	}
+	idx.Update(template, []byte("#meeting"))
+
+	if _, ok := idx.Note(template); ok {
+		t.Error("template was indexed")
+	}
+	if _, ok := idx.Note(note); !ok {
+		t.Error("note was not indexed")
+	}
+	if tags := idx.Tags(); len(tags) != 1 || tags[0].Name != "real" {
+		t.Errorf("Tags() = %v, want only real", tags)
+	}
+	if items := idx.Tasks(); len(items) != 1 || items[0].Path != note {
+		t.Errorf("Tasks() = %v, want only the note's task", items)
+	}
+}
@@ -0,0 +1,231 @@
+package index
+
+import (
+	"fmt"
+	"markdown-editor/internal/frontmatter"
+	"markdown-editor/internal/markdown"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2/storage"
+)
+
+const BuildManifestFile = ".build-manifest.json"
+
+var (
+	frontmatterWikiRegex = regexp.MustCompile(`\[\[([^\[\]|#]+)[^\[\]]*\]\]`)
+	frontmatterLinkRegex = regexp.MustCompile(`\]\(\s*<?([^()<>\s]+)>?`)
+	frontmatterPathRegex = regexp.MustCompile(`^[^\s\[\]()<>]+\.[A-Za-z0-9]+$`)
+)
+
+type Attachment struct {
+	Path      string
+	Notes     []string
+	Cleanable bool
+}
+
+func (a Attachment) Referenced() bool {
+	return len(a.Notes) > 0
+}
+
+func (a Attachment) Orphaned() bool {
+	return a.Cleanable && !a.Referenced()
+}
+
+func ParseLinks(path, content string) (links, wikiLinks []string) {
+	dests, wikiTargets := markdown.References([]byte(frontmatter.Split(content).Body))
+	fmDests, fmWikiTargets := frontmatterReferences(content)
+
+	seen := make(map[string]bool)
+	for _, dest := range append(dests, fmDests...) {
+		target, ok := markdown.ResolveLink(filepath.Dir(path), dest)
+		if !ok || seen[target] {
+			continue
+		}
+		seen[target] = true
+		links = append(links, target)
+	}
+
+	seenWiki := make(map[string]bool)
+	for _, target := range append(wikiTargets, fmWikiTargets...) {
+		target = strings.TrimSpace(target)
+		if target == "" || seenWiki[target] {
+			continue
+		}
+		seenWiki[target] = true
+		wikiLinks = append(wikiLinks, target)
+	}
+	return links, wikiLinks
+}
+
+func frontmatterReferences(content string) (dests, wikiTargets []string) {
+	doc, err := frontmatter.Parse(content)
+	if err != nil {
+		return nil, nil
+	}
+
+	var visit func(value any)
+	visit = func(value any) {
+		switch v := value.(type) {
+		case string:
+			for _, match := range frontmatterWikiRegex.FindAllStringSubmatch(v, -1) {
+				wikiTargets = append(wikiTargets, match[1])
+			}
+			for _, match := range frontmatterLinkRegex.FindAllStringSubmatch(v, -1) {
+				dests = append(dests, match[1])
+			}
+			if text := strings.TrimSpace(v); frontmatterPathRegex.MatchString(text) {
+				dests = append(dests, text)
+			}
+		case []any:
+			for _, item := range v {
+				visit(item)
+			}
+		case map[string]any:
+			for _, item := range v {
+				visit(item)
+			}
+		}
+	}
+	for _, p := range doc.Properties {
+		visit(p.Value)
+	}
+	return dests, wikiTargets
+}
+
+func (idx *Index) SetAttachmentFilter(filter func(path string) bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.attachmentFilter = filter
+}
+
+func (idx *Index) ResolveLinks(path, content string) ([]string, error) {
+	if idx.root == "" {
+		return nil, ErrIndexNoRoot
+	}
+
+	files, err := idx.fs.ListFilesRecursive(idx.root)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIndexListFiles, err)
+	}
+
+	links, wikiLinks := ParseLinks(path, content)
+	resolver := newLinkResolver(idx.root, files)
+	for _, target := range wikiLinks {
+		if resolved, ok := resolver.resolve(path, target); ok {
+			links = append(links, resolved)
+		}
+	}
+	return links, nil
+}
+
+func (idx *Index) Attachments() ([]Attachment, error) {
+	if idx.root == "" {
+		return nil, ErrIndexNoRoot
+	}
+
+	files, err := idx.fs.ListFilesRecursive(idx.root)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIndexListFiles, err)
+	}
+
+	resolver := newLinkResolver(idx.root, files)
+	idx.mu.RLock()
+	filter := idx.attachmentFilter
+	references := make(map[string][]string)
+	for path, note := range idx.notes {
+		linked := make(map[string]bool)
+		for _, link := range note.Links {
+			linked[link] = true
+		}
+		for _, target := range note.WikiLinks {
+			if resolved, ok := resolver.resolve(path, target); ok {
+				linked[resolved] = true
+			}
+		}
+		for link := range linked {
+			references[link] = append(references[link], path)
+		}
+	}
+	idx.mu.RUnlock()
+
+	buildOutput := make(map[string]bool)
+	var attachments []Attachment
+	for _, path := range files {
+		if IsMarkdownFile(path) {
+			continue
+		}
+		notes := references[filepath.Clean(path)]
+		sort.Strings(notes)
+		attachments = append(attachments, Attachment{
+			Path:      path,
+			Notes:     notes,
+			Cleanable: idx.cleanupCandidate(path, filter, buildOutput),
+		})
+	}
+	sort.Slice(attachments, func(i, j int) bool {
+		return attachments[i].Path < attachments[j].Path
+	})
+	return attachments, nil
+}
+
+func (idx *Index) cleanupCandidate(path string, filter func(string) bool, buildOutput map[string]bool) bool {
+	if idx.excluded(path) || (filter != nil && !filter(path)) {
+		return false
+	}
+	return !idx.inBuildOutput(filepath.Dir(path), buildOutput)
+}
+
+func (idx *Index) inBuildOutput(dir string, cache map[string]bool) bool {
+	if found, ok := cache[dir]; ok {
+		return found
+	}
+	found, _ := idx.fs.FileExists(storage.NewFileURI(filepath.Join(dir, BuildManifestFile)))
+	if parent := filepath.Dir(dir); !found && dir != idx.root && parent != dir {
+		found = idx.inBuildOutput(parent, cache)
+	}
+	cache[dir] = found
+	return found
+}
+
+type linkResolver struct {
+	root  string
+	files map[string]string
+	names map[string]string
+}
+
+func newLinkResolver(root string, files []string) linkResolver {
+	r := linkResolver{
+		root:  root,
+		files: make(map[string]string, len(files)),
+		names: make(map[string]string, len(files)),
+	}
+	for _, path := range files {
+		r.files[strings.ToLower(filepath.Clean(path))] = path
+		name := strings.ToLower(filepath.Base(path))
+		if other, ok := r.names[name]; !ok || strings.Count(path, string(filepath.Separator)) < strings.Count(other, string(filepath.Separator)) {
+			r.names[name] = path
+		}
+	}
+	return r
+}
+
+func (r linkResolver) resolve(notePath, target string) (string, bool) {
+	target = strings.Trim(filepath.ToSlash(strings.TrimSpace(target)), "/")
+	if target == "" {
+		return "", false
+	}
+	key := filepath.FromSlash(target)
+	for _, base := range []string{filepath.Dir(notePath), r.root} {
+		if path, ok := r.files[strings.ToLower(filepath.Join(base, key))]; ok {
+			return path, true
+		}
+	}
+	if strings.Contains(target, "/") {
+		return "", false
+	}
+	path, ok := r.names[strings.ToLower(target)]
+	return path, ok
+}
@@ -0,0 +1,146 @@
+package index
+
+import (
+	"markdown-editor/internal/fileservice"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+)
+
+func TestParseLinks(t *testing.T) {
+	dir := filepath.FromSlash("/notes/sub")
+	tests := []struct {
+		name      string
+		content   string
+		wantLinks []string
+		wantWiki  []string
+	}{
+		{
+			name:      "markdown links and images",
+			content:   "[doc](files/a.pdf) ![pic](<img b.png>) [web](https://example.com)",
+			wantLinks: []string{filepath.Join(dir, "files/a.pdf"), filepath.Join(dir, "img b.png")},
+		},
+		{
+			name:     "wiki links and embeds",
+			content:  "See [[Other note#Part|alias]] and ![[pic.png]]",
+			wantWiki: []string{"Other note", "pic.png"},
+		},
+		{
+			name:      "raw html",
+			content:   "<img src=\"inline.png\"> text <a href='doc.pdf'>x</a>\n\n<div>\n<img src=block.jpg>\n</div>\n",
+			wantLinks: []string{filepath.Join(dir, "inline.png"), filepath.Join(dir, "doc.pdf"), filepath.Join(dir, "block.jpg")},
+		},
+		{
+			name:      "frontmatter references",
+			content:   "---\ncover: assets/cover.png\nsee: \"[[diagram.svg]]\"\nlinks:\n  - \"[spec](spec.pdf)\"\ntitle: Plain words\n---\nbody\n",
+			wantLinks: []string{filepath.Join(dir, "assets/cover.png"), filepath.Join(dir, "spec.pdf")},
+			wantWiki:  []string{"diagram.svg"},
+		},
+		{
+			name:    "code is ignored",
+			content: "`![[a.png]]`\n\n```\n<img src=\"b.png\">\n```\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links, wikiLinks := ParseLinks(filepath.Join(dir, "note.md"), tt.content)
+			if !reflect.DeepEqual(links, tt.wantLinks) {
+				t.Errorf("links = %v, want %v", links, tt.wantLinks)
+			}
+			if !reflect.DeepEqual(wikiLinks, tt.wantWiki) {
+				t.Errorf("wikiLinks = %v, want %v", wikiLinks, tt.wantWiki)
+			}
+		})
+	}
+}
+
+func TestAttachments(t *testing.T) {
+	test.NewTempApp(t)
+
+	root := t.TempDir()
+	files := map[string]string{
+		"notes/note.md":                   "![[pic.png]] <img src=\"../assets/note/inline.png\"> ![[sub/deep.png]]",
+		"notes/other.md":                  "---\ncover: \"[[cover.png]]\"\n---\n",
+		"assets/note/pic.png":             "",
+		"assets/note/inline.png":          "",
+		"assets/note/orphan.png":          "",
+		"assets/other/cover.png":          "",
+		"sub/deep.png":                    "",
+		"docs/manual.pdf":                 "",
+		"out/index.html":                  "",
+		"out/assets/x/copy.png":           "",
+		"out/" + BuildManifestFile:        "{}",
+		"templates/assets/t/template.png": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	idx := New(root, fileservice.New())
+	idx.Exclude(filepath.Join(root, "templates"))
+	idx.SetAttachmentFilter(func(path string) bool {
+		rel, _ := filepath.Rel(root, path)
+		return strings.HasPrefix(filepath.ToSlash(rel), "assets/") || strings.Contains(filepath.ToSlash(rel), "/assets/")
+	})
+	if err := idx.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	attachments, err := idx.Attachments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	type listed struct {
+		notes     []string
+		cleanable bool
+	}
+	got := make(map[string]listed)
+	var orphans []string
+	for _, a := range attachments {
+		rel, _ := filepath.Rel(root, a.Path)
+		var notes []string
+		for _, note := range a.Notes {
+			name, _ := filepath.Rel(root, note)
+			notes = append(notes, filepath.ToSlash(name))
+		}
+		got[filepath.ToSlash(rel)] = listed{notes: notes, cleanable: a.Cleanable}
+		if a.Orphaned() {
+			orphans = append(orphans, filepath.ToSlash(rel))
+		}
+	}
+	want := map[string]listed{
+		"assets/note/pic.png":             {notes: []string{"notes/note.md"}, cleanable: true},
+		"assets/note/inline.png":          {notes: []string{"notes/note.md"}, cleanable: true},
+		"assets/note/orphan.png":          {cleanable: true},
+		"assets/other/cover.png":          {notes: []string{"notes/other.md"}, cleanable: true},
+		"sub/deep.png":                    {notes: []string{"notes/note.md"}},
+		"docs/manual.pdf":                 {},
+		"out/index.html":                  {},
+		"out/assets/x/copy.png":           {},
+		"templates/assets/t/template.png": {},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Attachments() = %v, want %v", got, want)
+	}
+	if wantOrphans := []string{"assets/note/orphan.png"}; !reflect.DeepEqual(orphans, wantOrphans) {
+		t.Errorf("orphaned attachments = %v, want %v", orphans, wantOrphans)
+	}
+
+	links, err := idx.ResolveLinks(filepath.Join(root, "notes", "new.md"), "![[orphan.png]]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wantLinks := []string{filepath.Join(root, "assets", "note", "orphan.png")}; !reflect.DeepEqual(links, wantLinks) {
+		t.Errorf("ResolveLinks() = %v, want %v", links, wantLinks)
+	}
+}
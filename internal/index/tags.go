@@ -0,0 +1,204 @@
+package index
+
+import (
+	"markdown-editor/internal/frontmatter"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var (
+	inlineTagRegex = regexp.MustCompile(`(^|\s)#([\p{L}\p{N}_/-]+)`)
+	validTagRegex  = regexp.MustCompile(`^[\p{L}\p{N}_/-]+$`)
+	codeSpanRegex  = regexp.MustCompile("`[^`]*`")
+)
+
+func NormalizeTag(tag string) string {
+	tag = strings.TrimSpace(tag)
+	tag = strings.TrimPrefix(tag, "#")
+	return strings.ToLower(tag)
+}
+
+func IsValidTag(tag string) bool {
+	if !validTagRegex.MatchString(tag) {
+		return false
+	}
+	return strings.IndexFunc(tag, func(r rune) bool { return !unicode.IsDigit(r) }) >= 0
+}
+
+func ParseTags(content string) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	add := func(raw string) {
+		tag := NormalizeTag(raw)
+		if !IsValidTag(tag) || seen[tag] {
+			return
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	doc, err := frontmatter.Parse(content)
+	if err == nil {
+		for _, tag := range doc.Tags() {
+			add(tag)
+		}
+	}
+
+	scanInlineTags(strings.Split(doc.Body, "\n"), func(line string, start, end int) {
+		add(line[start:end])
+	})
+
+	sort.Strings(tags)
+	return tags
+}
+
+func RenameTag(content, oldTag, newTag string) (string, bool) {
+	oldTag = NormalizeTag(oldTag)
+	newTag = NormalizeTag(newTag)
+	if oldTag == "" || newTag == "" || oldTag == newTag {
+		return content, false
+	}
+
+	lines := strings.Split(content, "\n")
+	changed := false
+	block := frontmatter.Split(content)
+	bodyStart := block.BodyLine
+	if block.Format != frontmatter.FormatNone {
+		separator := ":"
+		if block.Format == frontmatter.FormatTOML {
+			separator = "="
+		}
+		if renameFrontmatterTags(lines[1:bodyStart-1], separator, oldTag, newTag) {
+			changed = true
+		}
+	}
+
+	body := lines[bodyStart:]
+	type replacement struct {
+		line, start, end int
+	}
+	var replacements []replacement
+	scanInlineTagLines(body, func(i int, line string, start, end int) {
+		if tagMatches(strings.ToLower(line[start:end]), oldTag) {
+			replacements = append(replacements, replacement{line: i, start: start, end: end})
+		}
+	})
+
+	for i := len(replacements) - 1; i >= 0; i-- {
+		r := replacements[i]
+		line := body[r.line]
+		body[r.line] = line[:r.start] + renamedTag(line[r.start:r.end], oldTag, newTag) + line[r.end:]
+		changed = true
+	}
+
+	if !changed {
+		return content, false
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+func tagMatches(tag, target string) bool {
+	return tag == target || strings.HasPrefix(tag, target+"/")
+}
+
+func renamedTag(token, oldTag, newTag string) string {
+	lowered := strings.ToLower(token)
+	if len(lowered) != len(token) {
+		token = lowered
+	}
+	return newTag + token[len(oldTag):]
+}
+
+func scanInlineTags(lines []string, fn func(line string, start, end int)) {
+	scanInlineTagLines(lines, func(_ int, line string, start, end int) {
+		fn(line, start, end)
+	})
+}
+
+func scanInlineTagLines(lines []string, fn func(i int, line string, start, end int)) {
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if marker := fenceMarker(trimmed); marker != "" {
+			if fence == "" {
+				fence = marker
+			} else if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+
+		masked := codeSpanRegex.ReplaceAllStringFunc(line, func(span string) string {
+			return strings.Repeat(" ", len(span))
+		})
+		for _, m := range inlineTagRegex.FindAllStringSubmatchIndex(masked, -1) {
+			fn(i, line, m[4], m[5])
+		}
+	}
+}
+
+func fenceMarker(trimmed string) string {
+	for _, marker := range []string{"```", "~~~"} {
+		if strings.HasPrefix(trimmed, marker) {
+			return marker
+		}
+	}
+	return ""
+}
+
+func renameFrontmatterTags(lines []string, separator, oldTag, newTag string) bool {
+	changed := false
+	inTags := false
+	for i, line := range lines {
+		if line != "" && !unicode.IsSpace(rune(line[0])) && !strings.HasPrefix(line, "-") {
+			key, value, found := strings.Cut(line, separator)
+			inTags = found && strings.TrimSpace(key) == frontmatter.KeyTags
+			if inTags && strings.TrimSpace(value) != "" {
+				if renamed := replaceTagTokens(value, oldTag, newTag); renamed != value {
+					lines[i] = key + separator + renamed
+					changed = true
+				}
+			}
+			continue
+		}
+		if inTags && strings.HasPrefix(strings.TrimSpace(line), "-") {
+			if renamed := replaceTagTokens(line, oldTag, newTag); renamed != line {
+				lines[i] = renamed
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func replaceTagTokens(s, oldTag, newTag string) string {
+	isTagRune := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '/' || r == '-'
+	}
+
+	var b strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		if !isTagRune(runes[i]) {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && isTagRune(runes[j]) {
+			j++
+		}
+		token := string(runes[i:j])
+		if tagMatches(strings.ToLower(token), oldTag) {
+			token = renamedTag(token, oldTag, newTag)
+		}
+		b.WriteString(token)
+		i = j
+	}
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package index
+
+import (
+	"markdown-editor/internal/fileservice"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"inline", "Some #Work and #project/alpha", []string{"project/alpha", "work"}},
+		{"frontmatter", "---\ntags: [a, b]\n---\nbody #c", []string{"a", "b", "c"}},
+		{"code ignored", "`#nope` and\n```\n#nope\n```\n#yes", []string{"yes"}},
+		{"numeric ignored", "issue #123", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseTags(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenameTag(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		oldTag      string
+		newTag      string
+		want        string
+		wantChanged bool
+	}{
+		{"inline", "a #old b", "old", "new", "a #new b", true},
+		{"nested", "#old/x and #older", "old", "new", "#new/x and #older", true},
+		{"frontmatter list", "---\ntags:\n  - old\n---\n", "old", "new", "---\ntags:\n  - new\n---\n", true},
+		{"code untouched", "`#old`", "old", "new", "`#old`", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := RenameTag(tt.content, tt.oldTag, tt.newTag)
+			if got != tt.want || changed != tt.wantChanged {
+				t.Errorf("RenameTag() = %q, %v, want %q, %v", got, changed, tt.want, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestTagFilterMatchesRenameScope(t *testing.T) {
+	root := t.TempDir()
+	notes := map[string]string{
+		"parent.md": "#project",
+		"child.md":  "#project/x",
+		"other.md":  "#projects",
+	}
+	for name, content := range notes {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	idx := New(root, fileservice.New())
+	if err := idx.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	renamed := idx.NotesWithTagTree("project")
+	var filtered []string
+	for _, name := range []string{"child.md", "other.md", "parent.md"} {
+		if path := filepath.Join(root, name); idx.HasTags(path, "#project") {
+			filtered = append(filtered, path)
+		}
+	}
+	want := []string{filepath.Join(root, "child.md"), filepath.Join(root, "parent.md")}
+	if !reflect.DeepEqual(renamed, want) {
+		t.Errorf("NotesWithTagTree() = %v, want %v", renamed, want)
+	}
+	if !reflect.DeepEqual(filtered, want) {
+		t.Errorf("HasTags() matched %v, want %v", filtered, want)
+	}
+}
@@ -0,0 +1,129 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+	"markdown-editor/internal/fileservice"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2/storage"
+)
+
+var (
+	ErrJournalInvalidPattern = errors.New("journal: path pattern must contain YYYY, MM and DD")
+)
+
+const (
+	tokenYear  = "YYYY"
+	tokenMonth = "MM"
+	tokenDay   = "DD"
+	DateLayout = "2006-01-02"
+)
+
+type Journal struct {
+	root    string
+	pattern string
+	matcher *regexp.Regexp
+	fs      fileservice.FileOperations
+}
+
+func New(root, pattern string, fs fileservice.FileOperations) (*Journal, error) {
+	if err := ValidatePattern(pattern); err != nil {
+		return nil, err
+	}
+
+	expr := regexp.QuoteMeta(filepath.ToSlash(pattern))
+	expr = strings.ReplaceAll(expr, tokenYear, `(?P<year>\d{4})`)
+	expr = strings.ReplaceAll(expr, tokenMonth, `(?P<month>\d{2})`)
+	expr = strings.ReplaceAll(expr, tokenDay, `(?P<day>\d{2})`)
+	matcher, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s': %v", ErrJournalInvalidPattern, pattern, err)
+	}
+
+	return &Journal{
+		root:    root,
+		pattern: filepath.ToSlash(pattern),
+		matcher: matcher,
+		fs:      fs,
+	}, nil
+}
+
+func ValidatePattern(pattern string) error {
+	for _, token := range []string{tokenYear, tokenMonth, tokenDay} {
+		if !strings.Contains(pattern, token) {
+			return fmt.Errorf("%w: '%s'", ErrJournalInvalidPattern, pattern)
+		}
+	}
+	return nil
+}
+
+func (j *Journal) Path(date time.Time) string {
+	rel := strings.NewReplacer(
+		tokenYear, fmt.Sprintf("%04d", date.Year()),
+		tokenMonth, fmt.Sprintf("%02d", int(date.Month())),
+		tokenDay, fmt.Sprintf("%02d", date.Day()),
+	).Replace(j.pattern)
+	return filepath.Join(j.root, filepath.FromSlash(rel))
+}
+
+func (j *Journal) DateForPath(path string) (time.Time, bool) {
+	rel, err := filepath.Rel(j.root, path)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	match := j.matcher.FindStringSubmatch(filepath.ToSlash(rel))
+	if match == nil {
+		return time.Time{}, false
+	}
+
+	parts := make(map[string]int, 3)
+	for i, name := range j.matcher.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		value, err := strconv.Atoi(match[i])
+		if err != nil {
+			return time.Time{}, false
+		}
+		if previous, seen := parts[name]; seen && previous != value {
+			return time.Time{}, false
+		}
+		parts[name] = value
+	}
+
+	date := time.Date(parts["year"], time.Month(parts["month"]), parts["day"], 0, 0, 0, 0, time.Local)
+	if date.Month() != time.Month(parts["month"]) || date.Day() != parts["day"] {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+func (j *Journal) Adjacent(path string, offset int) (time.Time, bool) {
+	date, ok := j.DateForPath(path)
+	if !ok {
+		return time.Time{}, false
+	}
+	return date.AddDate(0, 0, offset), true
+}
+
+func (j *Journal) DaysWithNotes(month time.Time) map[int]bool {
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.Local)
+	days := make(map[int]bool)
+	for day := first; day.Month() == first.Month(); day = day.AddDate(0, 0, 1) {
+		exists, err := j.fs.FileExists(storage.NewFileURI(j.Path(day)))
+		if err == nil && exists {
+			days[day.Day()] = true
+		}
+	}
+	return days
+}
+
+func Day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
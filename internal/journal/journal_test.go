@@ -0,0 +1,162 @@
+package journal
+
+import (
+	"errors"
+	"markdown-editor/internal/fileservice"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+)
+
+const journalRoot = "/notes"
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
+
+func newJournal(t *testing.T, pattern string) *Journal {
+	t.Helper()
+	j, err := New(journalRoot, pattern, nil)
+	if err != nil {
+		t.Fatalf("New(%q) error = %v", pattern, err)
+	}
+	return j
+}
+
+func TestNewRejectsIncompletePatterns(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+	}{
+		{name: "no year", pattern: "journal/MM-DD.md"},
+		{name: "two digit year", pattern: "journal/YY-MM-DD.md"},
+		{name: "no month", pattern: "journal/YYYY/DD.md"},
+		{name: "no day", pattern: "journal/YYYY-MM.md"},
+		{name: "lower case tokens", pattern: "journal/yyyy-mm-dd.md"},
+		{name: "empty", pattern: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(journalRoot, tt.pattern, nil); !errors.Is(err, ErrJournalInvalidPattern) {
+				t.Errorf("New(%q) error = %v, want %v", tt.pattern, err, ErrJournalInvalidPattern)
+			}
+		})
+	}
+}
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		date    time.Time
+		want    string
+	}{
+		{pattern: "journal/YYYY/MM/YYYY-MM-DD.md", date: date(2024, time.March, 5), want: "journal/2024/03/2024-03-05.md"},
+		{pattern: "YYYY-MM-DD.md", date: date(2023, time.December, 31), want: "2023-12-31.md"},
+		{pattern: "daily/DD.MM.YYYY.md", date: date(2024, time.February, 29), want: "daily/29.02.2024.md"},
+		{pattern: "log/YYYYMMDD.md", date: date(999, time.January, 1), want: "log/09990101.md"},
+		{pattern: "journal/YYYY/MM/YYYY-MM-DD.md", date: time.Date(2024, time.March, 5, 23, 59, 0, 0, time.Local), want: "journal/2024/03/2024-03-05.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := newJournal(t, tt.pattern).Path(tt.date); got != filepath.Join(journalRoot, filepath.FromSlash(tt.want)) {
+				t.Errorf("Path() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateForPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		path    string
+		want    time.Time
+		wantOK  bool
+	}{
+		{name: "nested folders", pattern: "journal/YYYY/MM/YYYY-MM-DD.md", path: "journal/2024/03/2024-03-05.md", want: date(2024, time.March, 5), wantOK: true},
+		{name: "day first", pattern: "daily/DD.MM.YYYY.md", path: "daily/29.02.2024.md", want: date(2024, time.February, 29), wantOK: true},
+		{name: "no separators", pattern: "log/YYYYMMDD.md", path: "log/20231231.md", want: date(2023, time.December, 31), wantOK: true},
+		{name: "folders disagree with the name", pattern: "journal/YYYY/MM/YYYY-MM-DD.md", path: "journal/2024/04/2024-03-05.md"},
+		{name: "not a leap year", pattern: "YYYY-MM-DD.md", path: "2023-02-29.md"},
+		{name: "month out of range", pattern: "YYYY-MM-DD.md", path: "2023-13-01.md"},
+		{name: "day zero", pattern: "YYYY-MM-DD.md", path: "2023-01-00.md"},
+		{name: "single digit day", pattern: "YYYY-MM-DD.md", path: "2023-01-5.md"},
+		{name: "other note", pattern: "YYYY-MM-DD.md", path: "ideas.md"},
+		{name: "wrong folder", pattern: "journal/YYYY-MM-DD.md", path: "archive/2024-03-05.md"},
+		{name: "dots are literal", pattern: "daily/DD.MM.YYYY.md", path: "daily/29x02x2024.md"},
+		{name: "outside the root", pattern: "YYYY-MM-DD.md", path: "../2024-03-05.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := newJournal(t, tt.pattern).DateForPath(filepath.Join(journalRoot, filepath.FromSlash(tt.path)))
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("DateForPath(%q) = %v, %v, want %v, %v", tt.path, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestAdjacent(t *testing.T) {
+	j := newJournal(t, "journal/YYYY/MM/YYYY-MM-DD.md")
+	tests := []struct {
+		name   string
+		path   string
+		offset int
+		want   string
+	}{
+		{name: "next day", path: "journal/2024/03/2024-03-05.md", offset: 1, want: "journal/2024/03/2024-03-06.md"},
+		{name: "previous day", path: "journal/2024/03/2024-03-05.md", offset: -1, want: "journal/2024/03/2024-03-04.md"},
+		{name: "into the next month", path: "journal/2024/04/2024-04-30.md", offset: 1, want: "journal/2024/05/2024-05-01.md"},
+		{name: "into the previous month", path: "journal/2024/05/2024-05-01.md", offset: -1, want: "journal/2024/04/2024-04-30.md"},
+		{name: "leap day", path: "journal/2024/02/2024-02-28.md", offset: 1, want: "journal/2024/02/2024-02-29.md"},
+		{name: "after a leap day", path: "journal/2024/02/2024-02-29.md", offset: 1, want: "journal/2024/03/2024-03-01.md"},
+		{name: "no leap day", path: "journal/2023/03/2023-03-01.md", offset: -1, want: "journal/2023/02/2023-02-28.md"},
+		{name: "into the next year", path: "journal/2023/12/2023-12-31.md", offset: 1, want: "journal/2024/01/2024-01-01.md"},
+		{name: "into the previous year", path: "journal/2024/01/2024-01-01.md", offset: -1, want: "journal/2023/12/2023-12-31.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := j.Adjacent(filepath.Join(journalRoot, filepath.FromSlash(tt.path)), tt.offset)
+			if !ok {
+				t.Fatalf("Adjacent(%q) found no date", tt.path)
+			}
+			if got != Day(got) {
+				t.Errorf("Adjacent(%q) = %v, want midnight", tt.path, got)
+			}
+			if path := j.Path(got); path != filepath.Join(journalRoot, filepath.FromSlash(tt.want)) {
+				t.Errorf("Adjacent(%q) leads to %q, want %q", tt.path, path, tt.want)
+			}
+		})
+	}
+
+	if _, ok := j.Adjacent(filepath.Join(journalRoot, "ideas.md"), 1); ok {
+		t.Error("Adjacent() found a date for a note outside the journal")
+	}
+}
+
+func TestDaysWithNotes(t *testing.T) {
+	test.NewTempApp(t)
+	dir := t.TempDir()
+	j, err := New(dir, "YYYY/MM-DD.md", fileservice.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []time.Time{date(2024, time.January, 31), date(2024, time.February, 1), date(2024, time.February, 29), date(2024, time.March, 1)} {
+		path := j.Path(d)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("# Day\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := j.DaysWithNotes(date(2024, time.February, 15))
+	if want := map[int]bool{1: true, 29: true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DaysWithNotes() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,151 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var KindCallout = ast.NewNodeKind("Callout")
+
+var calloutMarkerRegex = regexp.MustCompile(`^\[!([A-Za-z][\w-]*)\][+-]?(?:[ \t]+(.*))?$`)
+
+var calloutCategories = map[string]string{
+	"note":      "note",
+	"info":      "note",
+	"abstract":  "note",
+	"summary":   "note",
+	"tldr":      "note",
+	"todo":      "note",
+	"example":   "note",
+	"quote":     "note",
+	"cite":      "note",
+	"tip":       "tip",
+	"hint":      "tip",
+	"success":   "tip",
+	"check":     "tip",
+	"done":      "tip",
+	"important": "important",
+	"question":  "important",
+	"help":      "important",
+	"faq":       "important",
+	"warning":   "warning",
+	"attention": "warning",
+	"caution":   "caution",
+	"danger":    "caution",
+	"error":     "caution",
+	"failure":   "caution",
+	"fail":      "caution",
+	"missing":   "caution",
+	"bug":       "caution",
+}
+
+type Callout struct {
+	ast.BaseBlock
+	Variant string
+	Title   string
+}
+
+func (n *Callout) Kind() ast.NodeKind {
+	return KindCallout
+}
+
+func (n *Callout) Category() string {
+	if category, ok := calloutCategories[n.Variant]; ok {
+		return category
+	}
+	return "note"
+}
+
+func (n *Callout) DisplayTitle() string {
+	if n.Title != "" {
+		return n.Title
+	}
+	return strings.ToUpper(n.Variant[:1]) + n.Variant[1:]
+}
+
+func (n *Callout) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Variant": n.Variant, "Title": n.Title}, nil)
+}
+
+type calloutExtension struct{}
+
+var Callouts goldmark.Extender = &calloutExtension{}
+
+func (e *calloutExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(util.Prioritized(&calloutTransformer{}, 500)))
+}
+
+type calloutTransformer struct{}
+
+func (t *calloutTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	var quotes []*ast.Blockquote
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if quote, ok := n.(*ast.Blockquote); ok && entering {
+			quotes = append(quotes, quote)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, quote := range quotes {
+		para, ok := quote.FirstChild().(*ast.Paragraph)
+		if !ok || para.Lines().Len() == 0 {
+			continue
+		}
+		first := para.Lines().At(0)
+		m := calloutMarkerRegex.FindSubmatch(util.TrimRightSpace(first.Value(source)))
+		if m == nil {
+			continue
+		}
+
+		callout := &Callout{Variant: strings.ToLower(string(m[1])), Title: strings.TrimSpace(string(m[2]))}
+		stripFirstLine(para, first.Stop, source)
+		for child := quote.FirstChild(); child != nil; {
+			next := child.NextSibling()
+			if child != para || para.HasChildren() {
+				callout.AppendChild(callout, child)
+			}
+			child = next
+		}
+		quote.Parent().ReplaceChild(quote.Parent(), quote, callout)
+	}
+}
+
+func stripFirstLine(para *ast.Paragraph, stop int, source []byte) {
+	for child := para.FirstChild(); child != nil; {
+		start, ok := inlineStart(child)
+		if ok && start >= stop {
+			break
+		}
+		next := child.NextSibling()
+		para.RemoveChild(para, child)
+		child = next
+	}
+	if t, ok := para.FirstChild().(*ast.Text); ok {
+		t.Segment = t.Segment.TrimLeftSpace(source)
+	}
+
+	lines := text.NewSegments()
+	for i := 1; i < para.Lines().Len(); i++ {
+		lines.Append(para.Lines().At(i))
+	}
+	para.SetLines(lines)
+}
+
+func inlineStart(n ast.Node) (int, bool) {
+	start, found := 0, false
+	_ = ast.Walk(n, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if t, ok := node.(*ast.Text); ok && entering {
+			start, found = t.Segment.Start, true
+			return ast.WalkStop, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return start, found
+}
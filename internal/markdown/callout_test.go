@@ -0,0 +1,172 @@
+package markdown
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+func describeChildren(n ast.Node, source []byte) []string {
+	var children []string
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		children = append(children, fmt.Sprintf("%s: %s", child.Kind(), PlainText(child, source)))
+	}
+	return children
+}
+
+func TestCallouts(t *testing.T) {
+	tests := []struct {
+		name         string
+		source       string
+		wantVariant  string
+		wantTitle    string
+		wantCategory string
+		wantDisplay  string
+		wantBody     []string
+	}{
+		{
+			name:         "marker only",
+			source:       "> [!NOTE]\n> Body text\n",
+			wantVariant:  "note",
+			wantCategory: "note",
+			wantDisplay:  "Note",
+			wantBody:     []string{"Paragraph: Body text"},
+		},
+		{
+			name:         "custom title",
+			source:       "> [!tip] Try this first\n> Body\n",
+			wantVariant:  "tip",
+			wantTitle:    "Try this first",
+			wantCategory: "tip",
+			wantDisplay:  "Try this first",
+			wantBody:     []string{"Paragraph: Body"},
+		},
+		{
+			name:         "foldable open",
+			source:       "> [!WARNING]+ Open by default\n> Body\n",
+			wantVariant:  "warning",
+			wantTitle:    "Open by default",
+			wantCategory: "warning",
+			wantDisplay:  "Open by default",
+			wantBody:     []string{"Paragraph: Body"},
+		},
+		{
+			name:         "foldable closed without title",
+			source:       "> [!faq]-\n> Body\n",
+			wantVariant:  "faq",
+			wantCategory: "important",
+			wantDisplay:  "Faq",
+			wantBody:     []string{"Paragraph: Body"},
+		},
+		{
+			name:         "alias category",
+			source:       "> [!Bug] Crash on save\n",
+			wantVariant:  "bug",
+			wantTitle:    "Crash on save",
+			wantCategory: "caution",
+			wantDisplay:  "Crash on save",
+		},
+		{
+			name:         "unknown variant",
+			source:       "> [!my-custom_type]\n> Body\n",
+			wantVariant:  "my-custom_type",
+			wantCategory: "note",
+			wantDisplay:  "My-custom_type",
+			wantBody:     []string{"Paragraph: Body"},
+		},
+		{
+			name:         "title is trimmed",
+			source:       "> [!NOTE]   Spaced title   \n> Body\n",
+			wantVariant:  "note",
+			wantTitle:    "Spaced title",
+			wantCategory: "note",
+			wantDisplay:  "Spaced title",
+			wantBody:     []string{"Paragraph: Body"},
+		},
+		{
+			name:         "inline markup in the body",
+			source:       "> [!IMPORTANT]\n> **Bold** and `code`\n> second line\n",
+			wantVariant:  "important",
+			wantCategory: "important",
+			wantDisplay:  "Important",
+			wantBody:     []string{"Paragraph: Bold and code second line"},
+		},
+		{
+			name:         "several blocks",
+			source:       "> [!CAUTION] Careful\n>\n> First paragraph\n>\n> - one\n> - two\n>\n> ```\n> code\n> ```\n",
+			wantVariant:  "caution",
+			wantTitle:    "Careful",
+			wantCategory: "caution",
+			wantDisplay:  "Careful",
+			wantBody:     []string{"Paragraph: First paragraph", "List: onetwo", "FencedCodeBlock: "},
+		},
+		{
+			name:         "lazy continuation",
+			source:       "> [!NOTE]\nlazy line\n",
+			wantVariant:  "note",
+			wantCategory: "note",
+			wantDisplay:  "Note",
+			wantBody:     []string{"Paragraph: lazy line"},
+		},
+		{
+			name:         "nested callout",
+			source:       "> [!NOTE] Outer\n> > [!TIP] Inner\n> > text\n",
+			wantVariant:  "note",
+			wantTitle:    "Outer",
+			wantCategory: "note",
+			wantDisplay:  "Outer",
+			wantBody:     []string{"Callout: text"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := []byte(tt.source)
+			callout, ok := Parse(source).FirstChild().(*Callout)
+			if !ok {
+				t.Fatalf("first block is not a callout")
+			}
+			if callout.Variant != tt.wantVariant || callout.Title != tt.wantTitle {
+				t.Errorf("callout = %q %q, want %q %q", callout.Variant, callout.Title, tt.wantVariant, tt.wantTitle)
+			}
+			if got := callout.Category(); got != tt.wantCategory {
+				t.Errorf("Category() = %q, want %q", got, tt.wantCategory)
+			}
+			if got := callout.DisplayTitle(); got != tt.wantDisplay {
+				t.Errorf("DisplayTitle() = %q, want %q", got, tt.wantDisplay)
+			}
+			if got := describeChildren(callout, source); !reflect.DeepEqual(got, tt.wantBody) {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestNotCallouts(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{name: "plain quote", source: "> Just a quote\n"},
+		{name: "unclosed marker", source: "> [!NOTE\n> Body\n"},
+		{name: "variant starting with a digit", source: "> [!1note]\n> Body\n"},
+		{name: "empty variant", source: "> [!]\n> Body\n"},
+		{name: "marker after text", source: "> See [!NOTE]\n"},
+		{name: "title without a space", source: "> [!NOTE]Title\n"},
+		{name: "marker on the second line", source: "> Intro\n> [!NOTE]\n"},
+		{name: "code block first", source: "> ```\n> [!NOTE]\n> ```\n"},
+		{name: "outside a quote", source: "[!NOTE] Title\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Parse([]byte(tt.source))
+			_ = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+				if _, ok := n.(*Callout); ok && entering {
+					t.Errorf("%q parsed as a callout", tt.source)
+				}
+				return ast.WalkContinue, nil
+			})
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+func New(opts ...goldmark.Option) goldmark.Markdown {
+	base := []goldmark.Option{
+		goldmark.WithExtensions(
+			extension.GFM,
+			extension.Footnote,
+			extension.DefinitionList,
+			Math,
+			Callouts,
+		),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	}
+	return goldmark.New(append(base, opts...)...)
+}
+
+func Parse(source []byte) ast.Node {
+	return New().Parser().Parse(text.NewReader(source))
+}
+
+type Lines struct {
+	starts []int
+}
+
+func NewLines(source []byte) *Lines {
+	starts := []int{0}
+	for i, b := range source {
+		if b == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return &Lines{starts: starts}
+}
+
+func (l *Lines) LineOf(offset int) int {
+	return sort.Search(len(l.starts), func(i int) bool {
+		return l.starts[i] > offset
+	}) - 1
+}
+
+func (l *Lines) Start(line int) int {
+	if line < 0 {
+		return 0
+	}
+	if line >= len(l.starts) {
+		return l.starts[len(l.starts)-1]
+	}
+	return l.starts[line]
+}
+
+func Span(n ast.Node, source []byte) (int, int, bool) {
+	start, end := -1, -1
+	extend := func(s, e int) {
+		if start < 0 || s < start {
+			start = s
+		}
+		if e > end {
+			end = e
+		}
+	}
+
+	_ = ast.Walk(n, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if node.Type() == ast.TypeBlock {
+			lines := node.Lines()
+			if lines != nil && lines.Len() > 0 {
+				extend(lines.At(0).Start, lines.At(lines.Len()-1).Stop)
+			}
+		}
+		if t, ok := node.(*ast.Text); ok {
+			extend(t.Segment.Start, t.Segment.Stop)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	if start < 0 {
+		return 0, 0, false
+	}
+	for start > 0 && source[start-1] != '\n' {
+		start--
+	}
+	for end < len(source) && source[end] != '\n' {
+		end++
+	}
+	return start, end, true
+}
+
+var (
+	openingFenceRegex = regexp.MustCompile("(`{3,}|~{3,})")
+	closingFenceRegex = regexp.MustCompile("^[ \t>]*(`{3,}|~{3,})[ \t]*$")
+)
+
+func BlockSpan(n ast.Node, source []byte) (int, int, bool) {
+	start, end, ok := Span(n, source)
+	if !ok {
+		start, end = len(source), 0
+	}
+
+	lines := NewLines(source)
+	lineText := func(line int) string {
+		from, to := lines.Start(line), len(source)
+		if next := lines.Start(line + 1); next > from {
+			to = next
+		}
+		return strings.TrimRight(string(source[from:to]), "\r\n")
+	}
+	lineEnd := func(line int) int {
+		return lines.Start(line) + len(lineText(line))
+	}
+	lastLine := len(lines.starts) - 1
+
+	_ = ast.Walk(n, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || node.Type() != ast.TypeBlock {
+			return ast.WalkContinue, nil
+		}
+		segments := node.Lines()
+		switch b := node.(type) {
+		case *ast.FencedCodeBlock:
+			var open int
+			switch {
+			case b.Info != nil:
+				open = lines.LineOf(b.Info.Segment.Start)
+			case segments.Len() > 0:
+				open = lines.LineOf(segments.At(0).Start) - 1
+			default:
+				return ast.WalkContinue, nil
+			}
+			if open < 0 {
+				return ast.WalkContinue, nil
+			}
+			fence := openingFenceRegex.FindString(lineText(open))
+			if fence == "" {
+				return ast.WalkContinue, nil
+			}
+			closing := open
+			if segments.Len() > 0 {
+				closing = lines.LineOf(segments.At(segments.Len()-1).Stop - 1)
+			}
+			if closing < lastLine {
+				if m := closingFenceRegex.FindStringSubmatch(lineText(closing + 1)); m != nil && m[1][0] == fence[0] && len(m[1]) >= len(fence) {
+					closing++
+				}
+			}
+			start, end, ok = min(start, lines.Start(open)), max(end, lineEnd(closing)), true
+		case *MathBlock:
+			if segments.Len() == 0 {
+				return ast.WalkContinue, nil
+			}
+			open := lines.LineOf(segments.At(0).Start)
+			if !strings.HasPrefix(strings.TrimLeft(lineText(open), " \t>"), string(mathDelimiter)) && open > 0 {
+				open--
+			}
+			closing := lines.LineOf(segments.At(segments.Len()-1).Stop - 1)
+			if !strings.HasSuffix(strings.TrimRight(lineText(closing), " \t"), string(mathDelimiter)) && closing < lastLine &&
+				strings.Trim(lineText(closing+1), " \t>") == string(mathDelimiter) {
+				closing++
+			}
+			start, end, ok = min(start, lines.Start(open)), max(end, lineEnd(closing)), true
+		}
+		return ast.WalkContinue, nil
+	})
+	return start, end, ok
+}
+
+func PlainText(n ast.Node, source []byte) string {
+	var b strings.Builder
+	_ = ast.Walk(n, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := node.(type) {
+		case *ast.Text:
+			b.Write(t.Segment.Value(source))
+			if t.SoftLineBreak() || t.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(t.Value)
+		case *ast.CodeSpan:
+			for c := t.FirstChild(); c != nil; c = c.NextSibling() {
+				if seg, ok := c.(*ast.Text); ok {
+					b.Write(seg.Segment.Value(source))
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		case *extast.TaskCheckBox:
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(b.String())
+}
+
+func HeadingID(n *ast.Heading) string {
+	id, ok := n.AttributeString("id")
+	if !ok {
+		return ""
+	}
+	if b, ok := id.([]byte); ok {
+		return string(b)
+	}
+	return ""
+}
+
+func BlockText(n ast.Node, source []byte) string {
+	var b strings.Builder
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		b.Write(line.Value(source))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+var htmlReferenceRegex = regexp.MustCompile(`(?i)\s(?:src|href)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+
+func References(source []byte) (dests, wikiTargets []string) {
+	root := New(goldmark.WithExtensions(WikiLinks)).Parser().Parse(text.NewReader(source))
+	_ = ast.Walk(root, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := node.(type) {
+		case *ast.Link:
+			dests = append(dests, string(n.Destination))
+		case *ast.Image:
+			dests = append(dests, string(n.Destination))
+		case *WikiLink:
+			if n.Target != "" {
+				wikiTargets = append(wikiTargets, n.Target)
+			}
+		case *ast.RawHTML:
+			var raw []byte
+			for i := 0; i < n.Segments.Len(); i++ {
+				segment := n.Segments.At(i)
+				raw = append(raw, segment.Value(source)...)
+			}
+			dests = append(dests, htmlReferences(raw)...)
+		case *ast.HTMLBlock:
+			var raw []byte
+			for i := 0; i < n.Lines().Len(); i++ {
+				line := n.Lines().At(i)
+				raw = append(raw, line.Value(source)...)
+			}
+			if n.HasClosure() {
+				raw = append(raw, n.ClosureLine.Value(source)...)
+			}
+			dests = append(dests, htmlReferences(raw)...)
+		}
+		return ast.WalkContinue, nil
+	})
+	return dests, wikiTargets
+}
+
+func htmlReferences(raw []byte) []string {
+	var dests []string
+	for _, match := range htmlReferenceRegex.FindAllSubmatch(raw, -1) {
+		for _, value := range match[1:] {
+			if len(value) > 0 {
+				dests = append(dests, html.UnescapeString(string(value)))
+				break
+			}
+		}
+	}
+	return dests
+}
+
+func ResolveLink(baseDir, dest string) (string, bool) {
+	dest = strings.TrimSpace(dest)
+	if dest == "" || strings.HasPrefix(dest, "#") {
+		return "", false
+	}
+
+	if u, err := url.Parse(dest); err == nil && len(u.Scheme) > 1 {
+		if u.Scheme != "file" {
+			return "", false
+		}
+		return filepath.Clean(u.Path), true
+	}
+
+	if i := strings.IndexAny(dest, "?#"); i >= 0 {
+		dest = dest[:i]
+	}
+	if unescaped, err := url.PathUnescape(dest); err == nil {
+		dest = unescaped
+	}
+	path := filepath.FromSlash(dest)
+	if !filepath.IsAbs(path) {
+		if baseDir == "" {
+			return "", false
+		}
+		path = filepath.Join(baseDir, path)
+	}
+	return filepath.Clean(path), true
+}
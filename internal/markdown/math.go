@@ -0,0 +1,209 @@
+package markdown
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var (
+	KindMathBlock  = ast.NewNodeKind("MathBlock")
+	KindMathInline = ast.NewNodeKind("MathInline")
+)
+
+var mathDelimiter = []byte("$$")
+
+type MathBlock struct {
+	ast.BaseBlock
+}
+
+func (n *MathBlock) Kind() ast.NodeKind {
+	return KindMathBlock
+}
+
+func (n *MathBlock) IsRaw() bool {
+	return true
+}
+
+func (n *MathBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+type MathInline struct {
+	ast.BaseInline
+	Display bool
+}
+
+func (n *MathInline) Kind() ast.NodeKind {
+	return KindMathInline
+}
+
+func (n *MathInline) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Display": boolString(n.Display)}, nil)
+}
+
+func Formula(n ast.Node, source []byte) string {
+	if n.Type() == ast.TypeBlock {
+		return strings.TrimSpace(BlockText(n, source))
+	}
+	var b strings.Builder
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		if t, ok := child.(*ast.Text); ok {
+			b.Write(t.Segment.Value(source))
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+type mathExtension struct{}
+
+var Math goldmark.Extender = &mathExtension{}
+
+func (e *mathExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(&mathBlockParser{}, 150)),
+		parser.WithInlineParsers(util.Prioritized(&mathInlineParser{}, 150)),
+	)
+}
+
+type mathBlockData struct {
+	indent int
+	closed bool
+}
+
+var mathBlockInfoKey = parser.NewContextKey()
+
+type mathBlockParser struct{}
+
+func (b *mathBlockParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (b *mathBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.HasPrefix(line[pos:], mathDelimiter) {
+		return nil, parser.NoChildren
+	}
+
+	node := &MathBlock{}
+	data := &mathBlockData{indent: pos}
+	start := pos + len(mathDelimiter)
+	rest := bytes.TrimRight(line[start:], " \t\r\n")
+	if end := bytes.Index(rest, mathDelimiter); end >= 0 {
+		if !util.IsBlank(rest[end+len(mathDelimiter):]) {
+			return nil, parser.NoChildren
+		}
+		node.Lines().Append(text.NewSegment(segment.Start+start, segment.Start+start+end))
+		data.closed = true
+	} else if !util.IsBlank(rest) {
+		node.Lines().Append(text.NewSegment(segment.Start+start, segment.Stop))
+	}
+	pc.Set(mathBlockInfoKey, data)
+	return node, parser.NoChildren
+}
+
+func (b *mathBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	data := pc.Get(mathBlockInfoKey).(*mathBlockData)
+	if data.closed {
+		return parser.Close
+	}
+
+	line, segment := reader.PeekLine()
+	if line == nil {
+		return parser.Close
+	}
+	trimmed := bytes.TrimRight(line, " \t\r\n")
+	if bytes.HasSuffix(trimmed, mathDelimiter) {
+		content := len(trimmed) - len(mathDelimiter)
+		if !util.IsBlank(trimmed[:content]) {
+			node.Lines().Append(text.NewSegment(segment.Start, segment.Start+content))
+		}
+		newline := 1
+		if line[len(line)-1] != '\n' {
+			newline = 0
+		}
+		reader.Advance(segment.Stop - segment.Start - newline + segment.Padding)
+		return parser.Close
+	}
+
+	pos, padding := util.IndentPositionPadding(line, reader.LineOffset(), segment.Padding, data.indent)
+	if pos < 0 {
+		pos, padding = 0, 0
+	}
+	seg := text.NewSegmentPadding(segment.Start+pos, segment.Stop, padding)
+	seg.ForceNewline = true
+	node.Lines().Append(seg)
+	reader.AdvanceAndSetPadding(segment.Stop-segment.Start-pos-1, padding)
+	return parser.Continue | parser.NoChildren
+}
+
+func (b *mathBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {
+	pc.Set(mathBlockInfoKey, nil)
+}
+
+func (b *mathBlockParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (b *mathBlockParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+type mathInlineParser struct{}
+
+func (p *mathInlineParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (p *mathInlineParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	open := 1
+	if len(line) > 1 && line[1] == '$' {
+		open = 2
+	}
+	if len(line) <= open || (open == 1 && util.IsSpace(line[open])) {
+		return nil
+	}
+
+	end := -1
+	for i := open; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if line[i] != '$' {
+			continue
+		}
+		if open == 2 {
+			if i+1 < len(line) && line[i+1] == '$' {
+				end = i
+			}
+			break
+		}
+		if !util.IsSpace(line[i-1]) && (i+1 >= len(line) || !util.IsNumeric(line[i+1])) {
+			end = i
+		}
+		break
+	}
+	if end <= open || util.IsBlank(line[open:end]) {
+		return nil
+	}
+
+	node := &MathInline{Display: open == 2}
+	node.AppendChild(node, ast.NewRawTextSegment(text.NewSegment(segment.Start+open, segment.Start+end)))
+	block.Advance(end + open)
+	return node
+}
+
+func boolString(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
@@ -0,0 +1,97 @@
+package markdown
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var KindWikiLink = ast.NewNodeKind("WikiLink")
+
+var (
+	wikiLinkOpen  = []byte("[[")
+	wikiLinkClose = []byte("]]")
+)
+
+type WikiLink struct {
+	ast.BaseInline
+	Target   string
+	Fragment string
+	Embed    bool
+}
+
+func (n *WikiLink) Kind() ast.NodeKind {
+	return KindWikiLink
+}
+
+func (n *WikiLink) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{
+		"Target":   n.Target,
+		"Fragment": n.Fragment,
+		"Embed":    boolString(n.Embed),
+	}, nil)
+}
+
+type wikiLinkExtension struct{}
+
+var WikiLinks goldmark.Extender = &wikiLinkExtension{}
+
+func (e *wikiLinkExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithInlineParsers(util.Prioritized(&wikiLinkParser{}, 100)))
+}
+
+type wikiLinkParser struct{}
+
+func (p *wikiLinkParser) Trigger() []byte {
+	return []byte{'!', '['}
+}
+
+func (p *wikiLinkParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	start := 0
+	if len(line) > 0 && line[0] == '!' {
+		start = 1
+	}
+	if !bytes.HasPrefix(line[start:], wikiLinkOpen) {
+		return nil
+	}
+	innerStart := start + len(wikiLinkOpen)
+	end := bytes.Index(line[innerStart:], wikiLinkClose)
+	if end < 0 {
+		return nil
+	}
+	innerEnd := innerStart + end
+	inner := line[innerStart:innerEnd]
+	if util.IsBlank(inner) || bytes.ContainsAny(inner, "[]\n") {
+		return nil
+	}
+
+	target, labelStart, labelEnd := inner, innerStart, innerEnd
+	if i := bytes.IndexByte(inner, '|'); i >= 0 {
+		target = inner[:i]
+		if util.IsBlank(inner[i+1:]) {
+			labelEnd = innerStart + i
+		} else {
+			labelStart = innerStart + i + 1
+		}
+	}
+	node := &WikiLink{Embed: start == 1}
+	name, fragment, _ := strings.Cut(string(target), "#")
+	node.Target = strings.TrimSpace(name)
+	node.Fragment = strings.TrimSpace(fragment)
+	if node.Target == "" && node.Fragment == "" {
+		return nil
+	}
+
+	label := text.NewSegment(segment.Start+labelStart, segment.Start+labelEnd)
+	label = label.TrimLeftSpace(block.Source())
+	label = label.TrimRightSpace(block.Source())
+	node.AppendChild(node, ast.NewTextSegment(label))
+	block.Advance(innerEnd + len(wikiLinkClose))
+	return node
+}
@@ -0,0 +1,121 @@
+package mathtex
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/go-fonts/dejavu/dejavumathtexgyre"
+	"github.com/go-fonts/dejavu/dejavuserif"
+	"github.com/go-fonts/dejavu/dejavuserifbold"
+	"github.com/go-fonts/dejavu/dejavuserifitalic"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+)
+
+type typeface int
+
+const (
+	typefaceRoman typeface = iota
+	typefaceItalic
+	typefaceBold
+	typefaceMath
+	typefaceCount
+)
+
+var (
+	fontsOnce sync.Once
+	fonts     [typefaceCount]*opentype.Font
+	fontsErr  error
+)
+
+func loadFonts() error {
+	fontsOnce.Do(func() {
+		data := [typefaceCount][]byte{
+			typefaceRoman:  dejavuserif.TTF,
+			typefaceItalic: dejavuserifitalic.TTF,
+			typefaceBold:   dejavuserifbold.TTF,
+			typefaceMath:   dejavumathtexgyre.TTF,
+		}
+		for i, ttf := range data {
+			if fonts[i], fontsErr = opentype.Parse(ttf); fontsErr != nil {
+				fontsErr = fmt.Errorf("%w: loading fonts: %v", ErrMathRender, fontsErr)
+				return
+			}
+		}
+	})
+	return fontsErr
+}
+
+var fallbacks = map[fontStyle][]typeface{
+	fontRoman:  {typefaceRoman, typefaceMath},
+	fontItalic: {typefaceItalic, typefaceRoman, typefaceMath},
+	fontBold:   {typefaceBold, typefaceRoman, typefaceMath},
+}
+
+type faceKey struct {
+	typeface typeface
+	size     float64
+}
+
+type faceCache struct {
+	faces map[faceKey]font.Face
+}
+
+func newFaceCache() *faceCache {
+	return &faceCache{faces: make(map[faceKey]font.Face)}
+}
+
+func (c *faceCache) get(tf typeface, size float64) font.Face {
+	key := faceKey{typeface: tf, size: size}
+	if face, ok := c.faces[key]; ok {
+		return face
+	}
+	face, err := opentype.NewFace(fonts[tf], &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingNone})
+	if err != nil && tf != typefaceRoman {
+		face = c.get(typefaceRoman, size)
+	}
+	c.faces[key] = face
+	return face
+}
+
+func (c *faceCache) faceFor(r rune, fs fontStyle, size float64) font.Face {
+	candidates := fallbacks[fs]
+	if candidates == nil {
+		candidates = fallbacks[fontRoman]
+	}
+	for _, tf := range candidates {
+		face := c.get(tf, size)
+		if _, ok := face.GlyphAdvance(r); ok {
+			return face
+		}
+	}
+	return c.get(candidates[0], size)
+}
+
+func (c *faceCache) face(text string, fs fontStyle, size float64) font.Face {
+	for _, r := range text {
+		return c.faceFor(r, fs, size)
+	}
+	return c.get(typefaceRoman, size)
+}
+
+type run struct {
+	face font.Face
+	text string
+}
+
+func (c *faceCache) runs(text string, fs fontStyle, size float64) []run {
+	var runs []run
+	for _, r := range text {
+		face := c.faceFor(r, fs, size)
+		if r >= 0x300 && r < 0x370 && len(runs) > 0 {
+			face = runs[len(runs)-1].face
+		}
+		if len(runs) > 0 && runs[len(runs)-1].face == face {
+			runs[len(runs)-1].text += string(r)
+			continue
+		}
+		runs = append(runs, run{face: face, text: string(r)})
+	}
+	return runs
+}
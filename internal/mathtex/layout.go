@@ -0,0 +1,563 @@
+package mathtex
+
+import (
+	"math"
+
+	"golang.org/x/image/font"
+)
+
+const (
+	levelDisplay = iota
+	levelText
+	levelScript
+	levelScriptScript
+)
+
+const (
+	axisHeight       = 0.25
+	ruleWidth        = 0.05
+	scriptScale      = 0.7
+	scriptScript     = 0.5
+	scriptSpace      = 0.05
+	italicCorrection = 0.06
+	nullDelim        = 0.12
+	jot              = 0.3
+	muPerEm          = 18
+)
+
+type opKind int
+
+const (
+	opGlyph opKind = iota
+	opRule
+	opStretch
+)
+
+type drawOp struct {
+	kind opKind
+	x, y float64
+	w, h float64
+	face font.Face
+	text string
+}
+
+type box struct {
+	width, height, depth float64
+	italic               bool
+	ops                  []drawOp
+}
+
+func (b *box) place(child *box, x, shift float64) {
+	for _, op := range child.ops {
+		op.x += x
+		op.y -= shift
+		b.ops = append(b.ops, op)
+	}
+	b.height = max(b.height, child.height+shift)
+	b.depth = max(b.depth, child.depth-shift)
+	b.width = max(b.width, x+child.width)
+}
+
+func (b *box) append(child *box) {
+	b.place(child, b.width, 0)
+	b.italic = child.italic
+}
+
+type style struct {
+	level int
+	size  float64
+}
+
+type typesetter struct {
+	faces *faceCache
+	base  float64
+}
+
+func (t *typesetter) style(level int) style {
+	size := t.base
+	switch {
+	case level == levelScript:
+		size *= scriptScale
+	case level >= levelScriptScript:
+		size *= scriptScript
+	}
+	return style{level: level, size: size}
+}
+
+func (t *typesetter) script(st style) style {
+	return t.style(max(levelScript, min(st.level+2, levelScriptScript)))
+}
+
+func (t *typesetter) fraction(st style) style {
+	return t.style(min(st.level+1, levelScriptScript))
+}
+
+func (t *typesetter) glyphs(text string, fs fontStyle, size float64) *box {
+	b := &box{italic: fs == fontItalic}
+	first := true
+	for _, run := range t.faces.runs(text, fs, size) {
+		bounds, advance := font.BoundString(run.face, run.text)
+		height := -fixedFloat(bounds.Min.Y)
+		depth := fixedFloat(bounds.Max.Y)
+		if first {
+			b.height, b.depth = height, depth
+			first = false
+		} else {
+			b.height, b.depth = max(b.height, height), max(b.depth, depth)
+		}
+		b.ops = append(b.ops, drawOp{kind: opGlyph, x: b.width, face: run.face, text: run.text})
+		b.width += fixedFloat(advance)
+	}
+	return b
+}
+
+func (t *typesetter) layout(n node, st style) *box {
+	switch n := n.(type) {
+	case *listNode:
+		return t.list(n.items, st)
+	case *symbolNode:
+		fs := n.font
+		if fs == fontDefault {
+			fs = fontRoman
+			if n.letter {
+				fs = fontItalic
+			}
+		}
+		return t.glyphs(n.text, fs, st.size)
+	case *textNode:
+		return t.glyphs(n.text, n.font, st.size)
+	case *spaceNode:
+		return &box{width: n.em * st.size}
+	case *opNode:
+		return t.operator(n, st)
+	case *scriptsNode:
+		return t.scripts(n, st)
+	case *fracNode:
+		return t.frac(n, st)
+	case *sqrtNode:
+		return t.sqrt(n, st)
+	case *delimNode:
+		return t.delimited(n, st)
+	case *bigDelimNode:
+		return t.delimiter(n.text, n.scale*st.size, st)
+	case *accentNode:
+		return t.accent(n, st)
+	case *arrayNode:
+		return t.array(n, st)
+	}
+	return &box{}
+}
+
+func classOf(n node) atomClass {
+	switch n := n.(type) {
+	case *symbolNode:
+		return n.class
+	case *bigDelimNode:
+		return n.class
+	case *opNode:
+		return classOp
+	case *scriptsNode:
+		return classOf(n.base)
+	case *spaceNode, nil:
+		return classNone
+	}
+	return classOrd
+}
+
+func (t *typesetter) list(items []node, st style) *box {
+	classes := make([]atomClass, len(items))
+	prev := -1
+	for i, item := range items {
+		classes[i] = classOf(item)
+		if classes[i] == classNone {
+			continue
+		}
+		if classes[i] == classBin && (prev < 0 || leadsToOrd(classes[prev])) {
+			classes[i] = classOrd
+		}
+		if prev >= 0 && classes[prev] == classBin && (classes[i] == classRel || classes[i] == classClose || classes[i] == classPunct) {
+			classes[prev] = classOrd
+		}
+		prev = i
+	}
+	if prev >= 0 && classes[prev] == classBin {
+		classes[prev] = classOrd
+	}
+
+	b := &box{}
+	last := classNone
+	for i, item := range items {
+		if classes[i] != classNone && last != classNone {
+			b.width += spacing(last, classes[i], st.level) * st.size / muPerEm
+		}
+		child := t.layout(item, st)
+		if b.italic && !child.italic {
+			b.width += italicCorrection * st.size
+		}
+		b.append(child)
+		if classes[i] != classNone {
+			last = classes[i]
+		}
+	}
+	return b
+}
+
+func leadsToOrd(c atomClass) bool {
+	return c == classBin || c == classOp || c == classRel || c == classOpen || c == classPunct
+}
+
+func spacing(left, right atomClass, level int) float64 {
+	script := level >= levelScript
+	switch {
+	case left == classOpen || right == classClose || right == classPunct:
+		return 0
+	case right == classOp && (left == classOrd || left == classOp || left == classClose):
+		return 3
+	case left == classOp && (right == classOrd || right == classOp):
+		return 3
+	case left == classBin || right == classBin:
+		if script {
+			return 0
+		}
+		return 4
+	case left == classRel && right == classRel:
+		return 0
+	case left == classRel || right == classRel:
+		if script {
+			return 0
+		}
+		return 5
+	case left == classPunct:
+		if script {
+			return 0
+		}
+		return 3
+	}
+	return 0
+}
+
+func (t *typesetter) operator(n *opNode, st style) *box {
+	if n.function {
+		return t.glyphs(n.text, fontRoman, st.size)
+	}
+
+	scale := 1.0
+	if st.level == levelDisplay {
+		scale = 1.45
+		if !n.limits {
+			scale = 1.9
+		}
+	} else if st.level == levelText {
+		scale = 1.1
+	}
+	glyph := t.glyphs(n.text, fontRoman, st.size*scale)
+	b := &box{}
+	b.place(glyph, 0, axisHeight*st.size-(glyph.height-glyph.depth)/2)
+	if !n.limits {
+		b.width += 0.1 * st.size
+	}
+	return b
+}
+
+func (t *typesetter) scripts(n *scriptsNode, st style) *box {
+	base := t.layout(n.base, st)
+	if op, ok := n.base.(*opNode); ok && op.limits && st.level == levelDisplay {
+		return t.limits(base, n, st)
+	}
+
+	sst := t.script(st)
+	b := &box{}
+	b.append(base)
+	x := base.width
+
+	var sup, sub *box
+	var u, v float64
+	if n.sup != nil {
+		sup = t.layout(n.sup, sst)
+		u = max(base.height-0.3*sst.size, 0.35*st.size, sup.depth+0.12*st.size)
+		if st.level == levelDisplay {
+			u = max(u, 0.4*st.size)
+		}
+	}
+	if n.sub != nil {
+		sub = t.layout(n.sub, sst)
+		v = max(base.depth+0.05*st.size, 0.15*st.size, sub.height-0.33*st.size)
+	}
+	if sup != nil && sub != nil {
+		if gap := (u - sup.depth) - (sub.height - v); gap < 0.12*st.size {
+			v += 0.12*st.size - gap
+		}
+	}
+
+	width := 0.0
+	if sup != nil {
+		correction := 0.0
+		if base.italic {
+			correction = italicCorrection * st.size
+		}
+		b.place(sup, x+correction, u)
+		width = sup.width + correction
+	}
+	if sub != nil {
+		b.place(sub, x, -v)
+		width = max(width, sub.width)
+	}
+	b.width = x + width + scriptSpace*st.size
+	return b
+}
+
+func (t *typesetter) limits(op *box, n *scriptsNode, st style) *box {
+	sst := t.script(st)
+	gap := 0.15 * st.size
+
+	var sup, sub *box
+	width := op.width
+	if n.sup != nil {
+		sup = t.layout(n.sup, sst)
+		width = max(width, sup.width)
+	}
+	if n.sub != nil {
+		sub = t.layout(n.sub, sst)
+		width = max(width, sub.width)
+	}
+
+	b := &box{}
+	b.place(op, (width-op.width)/2, 0)
+	if sup != nil {
+		b.place(sup, (width-sup.width)/2, op.height+gap+sup.depth)
+	}
+	if sub != nil {
+		b.place(sub, (width-sub.width)/2, -(op.depth + gap + sub.height))
+	}
+	b.width = width
+	return b
+}
+
+func (t *typesetter) frac(n *fracNode, st style) *box {
+	outer := st
+	if n.level >= 0 {
+		outer = t.style(n.level)
+	}
+	inner := t.fraction(outer)
+	num := t.layout(n.num, inner)
+	den := t.layout(n.den, inner)
+
+	axis := axisHeight * st.size
+	thickness := 0.0
+	if n.rule {
+		thickness = max(1, ruleWidth*st.size)
+	}
+	gap := 0.12 * st.size
+	if outer.level == levelDisplay {
+		gap = 0.2 * st.size
+	}
+	pad := 0.12 * st.size
+	width := max(num.width, den.width) + 2*pad
+
+	b := &box{}
+	b.place(num, (width-num.width)/2, axis+thickness/2+gap+num.depth)
+	b.place(den, (width-den.width)/2, axis-thickness/2-gap-den.height)
+	if n.rule {
+		b.ops = append(b.ops, drawOp{kind: opRule, x: pad / 2, y: -(axis + thickness/2), w: width - pad, h: thickness})
+	}
+	b.width = width
+
+	if n.left == "" && n.right == "" {
+		return b
+	}
+	return t.fence(n.left, n.right, b, st)
+}
+
+func (t *typesetter) sqrt(n *sqrtNode, st style) *box {
+	body := t.layout(n.body, st)
+	thickness := max(1, ruleWidth*st.size)
+	gap := 0.15 * st.size
+	if st.level == levelDisplay {
+		gap = 0.25 * st.size
+	}
+
+	top := max(body.height, 0.7*st.size) + gap + thickness
+	bottom := max(body.depth, 0.1*st.size)
+	radical := t.stretched("√", top+bottom, st)
+	radicalX := 0.0
+
+	b := &box{}
+	if n.index != nil {
+		index := t.layout(n.index, t.style(levelScriptScript))
+		radicalX = max(0, index.width-0.5*radical.w)
+		b.place(index, 0, 0.6*top-bottom+index.depth)
+	}
+
+	radical.x, radical.y = radicalX, -top
+	b.ops = append(b.ops, radical)
+	bodyX := radicalX + radical.w + 0.05*st.size
+	ruleX := radicalX + radical.w - thickness/2
+	b.ops = append(b.ops, drawOp{kind: opRule, x: ruleX, y: -top, w: bodyX + body.width + 0.1*st.size - ruleX, h: thickness})
+	b.place(body, bodyX, 0)
+	b.height = max(b.height, top)
+	b.depth = max(b.depth, bottom)
+	b.width = bodyX + body.width + 0.15*st.size
+	return b
+}
+
+func (t *typesetter) stretched(text string, height float64, st style) drawOp {
+	face := t.faces.face(text, fontRoman, st.size)
+	bounds, _ := font.BoundString(face, text)
+	inkWidth := fixedFloat(bounds.Max.X - bounds.Min.X)
+	inkHeight := fixedFloat(bounds.Max.Y - bounds.Min.Y)
+	stretch := 1.0
+	if inkHeight > 0 {
+		stretch = max(1, height/inkHeight)
+	}
+	width := inkWidth * min(1.6, 1+(stretch-1)*0.15)
+	return drawOp{kind: opStretch, w: width, h: height, face: face, text: text}
+}
+
+func (t *typesetter) delimiter(text string, size float64, st style) *box {
+	if text == "" {
+		return &box{width: nullDelim * st.size}
+	}
+
+	axis := axisHeight * st.size
+	glyph := t.glyphs(text, fontRoman, st.size)
+	b := &box{}
+	if natural := glyph.height + glyph.depth; size <= natural*1.1 {
+		b.place(glyph, 0, axis-(glyph.height-glyph.depth)/2)
+		return b
+	}
+
+	pad := 0.05 * st.size
+	op := t.stretched(text, size, st)
+	op.x, op.y = pad, -(axis + size/2)
+	b.ops = append(b.ops, op)
+	b.width = op.w + 2*pad
+	b.height = axis + size/2
+	b.depth = size/2 - axis
+	return b
+}
+
+func (t *typesetter) fence(left, right string, body *box, st style) *box {
+	axis := axisHeight * st.size
+	size := 2*max(body.height-axis, body.depth+axis) + 0.1*st.size
+
+	b := &box{}
+	b.append(t.delimiter(left, size, st))
+	b.append(body)
+	b.append(t.delimiter(right, size, st))
+	return b
+}
+
+func (t *typesetter) delimited(n *delimNode, st style) *box {
+	return t.fence(n.left, n.right, t.layout(n.body, st), st)
+}
+
+func (t *typesetter) accent(n *accentNode, st style) *box {
+	body := t.layout(n.body, st)
+	gap := 0.08 * st.size
+	b := &box{}
+	b.place(body, 0, 0)
+
+	if n.accent.rule {
+		thickness := max(1, ruleWidth*st.size)
+		y := -(body.height + gap + thickness)
+		if n.under {
+			y = body.depth + gap
+			b.depth = max(b.depth, y+thickness)
+		} else {
+			b.height = max(b.height, -y)
+		}
+		b.ops = append(b.ops, drawOp{kind: opRule, x: 0, y: y, w: body.width, h: thickness})
+		return b
+	}
+
+	size := st.size
+	if n.accent.text == "→" {
+		size *= 0.75
+	}
+	mark := t.glyphs(n.accent.text, fontRoman, size)
+	skew := 0.0
+	if body.italic {
+		skew = 0.08 * st.size
+	}
+	b.place(mark, (body.width-mark.width)/2+skew, body.height+gap+mark.depth)
+	b.width = body.width
+	b.italic = body.italic
+	return b
+}
+
+func (t *typesetter) array(n *arrayNode, st style) *box {
+	cellStyle := t.style(max(st.level, levelText))
+	if n.display {
+		cellStyle = st
+	}
+
+	columns := len(n.align)
+	widths := make([]float64, columns)
+	cells := make([][]*box, len(n.rows))
+	heights := make([]float64, len(n.rows))
+	depths := make([]float64, len(n.rows))
+	for r, row := range n.rows {
+		heights[r], depths[r] = 0.7*st.size, 0.3*st.size
+		for c, cell := range row {
+			b := t.layout(cell, cellStyle)
+			cells[r] = append(cells[r], b)
+			widths[c] = max(widths[c], b.width)
+			heights[r] = max(heights[r], b.height)
+			depths[r] = max(depths[r], b.depth)
+		}
+	}
+
+	total := 0.0
+	for r := range n.rows {
+		total += heights[r] + depths[r]
+		if r > 0 {
+			total += jot * st.size
+		}
+	}
+
+	b := &box{}
+	top := axisHeight*st.size + total/2
+	y := top
+	for r, row := range cells {
+		if r > 0 {
+			y -= jot * st.size
+		}
+		y -= heights[r]
+		x := 0.0
+		for c := range widths {
+			x += n.gaps[c] * st.size
+			if c < len(row) {
+				cell := row[c]
+				offset := 0.0
+				switch n.align[c] {
+				case 'c':
+					offset = (widths[c] - cell.width) / 2
+				case 'r':
+					offset = widths[c] - cell.width
+				}
+				b.place(cell, x+offset, y)
+			}
+			x += widths[c]
+		}
+		b.width = max(b.width, x)
+		y -= depths[r]
+	}
+	b.height = max(b.height, top)
+	b.depth = max(b.depth, total-top)
+
+	if n.left == "" && n.right == "" {
+		return b
+	}
+	padded := &box{}
+	padded.place(b, 0.1*st.size, 0)
+	padded.width += 0.1 * st.size
+	return t.fence(n.left, n.right, padded, st)
+}
+
+func fixedFloat[T ~int32](v T) float64 {
+	return float64(v) / 64
+}
+
+func roundPx(v float64) int {
+	return int(math.Round(v))
+}
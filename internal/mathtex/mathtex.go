@@ -0,0 +1,156 @@
+package mathtex
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sync"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	maxCacheEntries = 256
+	maxDimension    = 4096
+	imagePadding    = 2
+)
+
+var (
+	ErrMathParse  = errors.New("mathtex: failed to parse formula")
+	ErrMathRender = errors.New("mathtex: failed to render formula")
+)
+
+type Options struct {
+	Size    float64
+	Color   color.Color
+	Display bool
+}
+
+type Formula struct {
+	Image    *image.RGBA
+	Baseline int
+}
+
+type cacheKey struct {
+	expr    string
+	size    float64
+	color   color.RGBA
+	display bool
+}
+
+type Renderer struct {
+	mu    sync.Mutex
+	faces *faceCache
+	cache map[cacheKey]*Formula
+	order []cacheKey
+}
+
+func NewRenderer() *Renderer {
+	return &Renderer{
+		faces: newFaceCache(),
+		cache: make(map[cacheKey]*Formula),
+	}
+}
+
+func (r *Renderer) Render(expr string, opts Options) (*Formula, error) {
+	if opts.Size <= 0 {
+		return nil, fmt.Errorf("%w: invalid size %g", ErrMathRender, opts.Size)
+	}
+	if opts.Color == nil {
+		opts.Color = color.Black
+	}
+	key := cacheKey{expr: expr, size: opts.Size, color: color.RGBAModel.Convert(opts.Color).(color.RGBA), display: opts.Display}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if formula, ok := r.cache[key]; ok {
+		return formula, nil
+	}
+
+	tree, err := parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadFonts(); err != nil {
+		return nil, err
+	}
+
+	t := &typesetter{faces: r.faces, base: opts.Size}
+	level := levelText
+	if opts.Display {
+		level = levelDisplay
+	}
+	b := t.layout(tree, t.style(level))
+	if b.width+b.height+b.depth <= 0 {
+		return nil, fmt.Errorf("%w: formula is empty", ErrMathRender)
+	}
+	if b.width > maxDimension || b.height+b.depth > maxDimension {
+		return nil, fmt.Errorf("%w: formula is too large", ErrMathRender)
+	}
+
+	formula := rasterize(b, opts.Color)
+	r.store(key, formula)
+	return formula, nil
+}
+
+func (r *Renderer) store(key cacheKey, formula *Formula) {
+	if _, ok := r.cache[key]; !ok {
+		r.order = append(r.order, key)
+	}
+	r.cache[key] = formula
+	for len(r.order) > maxCacheEntries {
+		delete(r.cache, r.order[0])
+		r.order = r.order[1:]
+	}
+}
+
+func rasterize(b *box, col color.Color) *Formula {
+	width := int(math.Ceil(b.width)) + 2*imagePadding
+	height := int(math.Ceil(b.height)+math.Ceil(b.depth)) + 2*imagePadding
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	src := image.NewUniform(col)
+
+	originX := float64(imagePadding)
+	originY := float64(imagePadding) + math.Ceil(b.height)
+	for _, op := range b.ops {
+		x, y := originX+op.x, originY+op.y
+		switch op.kind {
+		case opGlyph:
+			d := font.Drawer{Dst: img, Src: src, Face: op.face, Dot: fixed.Point26_6{X: toFixed(x), Y: toFixed(y)}}
+			d.DrawString(op.text)
+		case opRule:
+			rect := image.Rect(roundPx(x), roundPx(y), roundPx(x+op.w), roundPx(y)+max(1, roundPx(op.h)))
+			draw.Draw(img, rect, src, image.Point{}, draw.Over)
+		case opStretch:
+			drawStretched(img, src, op, x, y)
+		}
+	}
+	return &Formula{Image: img, Baseline: int(originY)}
+}
+
+func drawStretched(dst *image.RGBA, src image.Image, op drawOp, x, y float64) {
+	bounds, _ := font.BoundString(op.face, op.text)
+	glyph := image.NewAlpha(image.Rect(0, 0, (bounds.Max.X - bounds.Min.X).Ceil(), (bounds.Max.Y - bounds.Min.Y).Ceil()))
+	if glyph.Rect.Empty() {
+		return
+	}
+	d := font.Drawer{Dst: glyph, Src: image.Opaque, Face: op.face, Dot: fixed.Point26_6{X: -bounds.Min.X, Y: -bounds.Min.Y}}
+	d.DrawString(op.text)
+
+	rect := image.Rect(roundPx(x), roundPx(y), roundPx(x+op.w), roundPx(y+op.h))
+	if rect.Empty() {
+		return
+	}
+	mask := image.NewAlpha(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	xdraw.BiLinear.Scale(mask, mask.Bounds(), glyph, glyph.Bounds(), draw.Src, nil)
+	draw.DrawMask(dst, rect, src, image.Point{}, mask, image.Point{}, draw.Over)
+}
+
+func toFixed(v float64) fixed.Int26_6 {
+	return fixed.Int26_6(math.Round(v * 64))
+}
@@ -0,0 +1,135 @@
+package mathtex
+
+import (
+	"errors"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+var validFormulas = []string{
+	`x^2 + y^2 = z^2`,
+	`\frac{a}{b} + \dfrac{1}{2}`,
+	`\sqrt[3]{x} \sqrt{\alpha}`,
+	`\sum_{i=1}^{n} i = \frac{n(n+1)}{2}`,
+	`\int_0^\infty e^{-x} \, dx`,
+	`\left( \frac{a}{b} \right]`,
+	`\begin{pmatrix} a & b \\ c & d \end{pmatrix}`,
+	`\begin{cases} 1 & x > 0 \\ 0 & \text{otherwise} \end{cases}`,
+	`\hat{x} \vec{v} \overline{AB} \underline{y}`,
+	`\mathbb{R} \mathcal{L} \mathfrak{g} \mathbf{v}`,
+	`\operatorname*{argmax}_x f(x) \not= \lim_{n \to \infty} a_n`,
+	`a \\ b`,
+	`\bigl( x \bigr)`,
+}
+
+func TestRender(t *testing.T) {
+	r := NewRenderer()
+	for _, expr := range validFormulas {
+		for _, display := range []bool{false, true} {
+			formula, err := r.Render(expr, Options{Size: 16, Display: display})
+			if err != nil {
+				t.Errorf("Render(%q, display=%v) error = %v", expr, display, err)
+				continue
+			}
+			bounds := formula.Image.Bounds()
+			if bounds.Dx() <= 0 || bounds.Dy() <= 0 || formula.Baseline < 0 || formula.Baseline > bounds.Dy() {
+				t.Errorf("Render(%q) = %v with baseline %d", expr, bounds, formula.Baseline)
+			}
+		}
+	}
+}
+
+func TestRenderCache(t *testing.T) {
+	r := NewRenderer()
+	first, err := r.Render(`x_1`, Options{Size: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := r.Render(`x_1`, Options{Size: 16, Color: color.Black})
+	other, _ := r.Render(`x_1`, Options{Size: 16, Color: color.White})
+	if first != again {
+		t.Error("same formula and options were rendered twice")
+	}
+	if first == other {
+		t.Error("a different colour reused the cached formula")
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		size    float64
+		wantErr error
+		wantMsg string
+	}{
+		{"empty", "  ", 16, ErrMathParse, "empty formula"},
+		{"unknown command", `\nosuchcommand x`, 16, ErrMathParse, `\nosuchcommand`},
+		{"trailing backslash", `x \`, 16, ErrMathParse, "trailing backslash"},
+		{"unclosed group", `\frac{a}{b`, 16, ErrMathParse, ""},
+		{"unbalanced brace", `a}`, 16, ErrMathParse, ""},
+		{"missing fraction argument", `\frac{a}`, 16, ErrMathParse, ""},
+		{"left without right", `\left( x`, 16, ErrMathParse, ""},
+		{"unknown environment", `\begin{nosuch} x \end{nosuch}`, 16, ErrMathParse, "nosuch"},
+		{"mismatched environment", `\begin{matrix} x \end{cases}`, 16, ErrMathParse, ""},
+		{"double superscript", `x^2^3`, 16, ErrMathParse, ""},
+		{"script without argument", `x^`, 16, ErrMathParse, ""},
+		{"invalid size", `x`, 0, ErrMathRender, "invalid size"},
+	}
+	r := NewRenderer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formula, err := r.Render(tt.expr, Options{Size: tt.size})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Render(%q) = %v, %v, want %v", tt.expr, formula, err, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("error %q does not mention %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestRenderMalformedInputDoesNotPanic(t *testing.T) {
+	r := NewRenderer()
+	fragments := []string{`\`, `{`, `}`, `^`, `_`, `&`, `\\`, `\left`, `\right`, `\begin{matrix}`, `\end{matrix}`, `\sqrt[`, `]`, `\frac`, `\not`, `\text{`, `\big`}
+	var inputs []string
+	for _, expr := range validFormulas {
+		runes := []rune(expr)
+		for i := range runes {
+			inputs = append(inputs, string(runes[:i]), string(runes[i:]))
+		}
+	}
+	for _, a := range fragments {
+		for _, b := range fragments {
+			inputs = append(inputs, a+b, a+"x"+b, "x"+a+b+"y")
+		}
+	}
+	for _, expr := range inputs {
+		func() {
+			defer func() {
+				if p := recover(); p != nil {
+					t.Errorf("Render(%q) panicked: %v", expr, p)
+				}
+			}()
+			_, err := r.Render(expr, Options{Size: 12})
+			if err != nil && !errors.Is(err, ErrMathParse) && !errors.Is(err, ErrMathRender) {
+				t.Errorf("Render(%q) error = %v, want a mathtex error", expr, err)
+			}
+		}()
+	}
+}
+
+func FuzzRender(f *testing.F) {
+	for _, expr := range validFormulas {
+		f.Add(expr)
+	}
+	r := NewRenderer()
+	f.Fuzz(func(t *testing.T, expr string) {
+		_, err := r.Render(expr, Options{Size: 12})
+		if err != nil && !errors.Is(err, ErrMathParse) && !errors.Is(err, ErrMathRender) {
+			t.Errorf("Render(%q) error = %v, want a mathtex error", expr, err)
+		}
+	})
+}
@@ -0,0 +1,761 @@
+package mathtex
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type fontStyle int
+
+const (
+	fontDefault fontStyle = iota
+	fontRoman
+	fontItalic
+	fontBold
+)
+
+type node interface{}
+
+type listNode struct {
+	items []node
+}
+
+type symbolNode struct {
+	text   string
+	class  atomClass
+	font   fontStyle
+	letter bool
+}
+
+type textNode struct {
+	text string
+	font fontStyle
+}
+
+type spaceNode struct {
+	em float64
+}
+
+type scriptsNode struct {
+	base node
+	sup  node
+	sub  node
+}
+
+type opNode struct {
+	text     string
+	limits   bool
+	function bool
+}
+
+type fracNode struct {
+	num, den    node
+	rule        bool
+	level       int
+	left, right string
+}
+
+type sqrtNode struct {
+	body  node
+	index node
+}
+
+type delimNode struct {
+	left, right string
+	body        node
+}
+
+type bigDelimNode struct {
+	text  string
+	scale float64
+	class atomClass
+}
+
+type accentNode struct {
+	body   node
+	accent accent
+	under  bool
+}
+
+type arrayNode struct {
+	rows        [][]node
+	align       []byte
+	gaps        []float64
+	left, right string
+	display     bool
+}
+
+type stopKind int
+
+const (
+	stopEOF stopKind = iota
+	stopBrace
+	stopAmp
+	stopNewline
+	stopEnd
+	stopRight
+)
+
+var stopNames = map[stopKind]string{
+	stopBrace:   "}",
+	stopAmp:     "&",
+	stopNewline: `\\`,
+	stopEnd:     `\end`,
+	stopRight:   `\right`,
+}
+
+var bigDelimiters = map[string]struct {
+	scale float64
+	class atomClass
+}{
+	"big": {1.2, classOrd}, "Big": {1.8, classOrd}, "bigg": {2.4, classOrd}, "Bigg": {3, classOrd},
+	"bigl": {1.2, classOpen}, "Bigl": {1.8, classOpen}, "biggl": {2.4, classOpen}, "Biggl": {3, classOpen},
+	"bigr": {1.2, classClose}, "Bigr": {1.8, classClose}, "biggr": {2.4, classClose}, "Biggr": {3, classClose},
+	"bigm": {1.2, classRel}, "Bigm": {1.8, classRel}, "biggm": {2.4, classRel}, "Biggm": {3, classRel},
+}
+
+var negations = map[string]string{
+	"=": "≠", "∈": "∉", "≡": "≢", "⊂": "⊄", "⊃": "⊅", "⊆": "⊈", "⊇": "⊉",
+	"∼": "≁", "≈": "≉", "<": "≮", ">": "≯", "≤": "≰", "≥": "≱", "∃": "∄",
+}
+
+var environments = map[string][2]string{
+	"matrix": {"", ""}, "pmatrix": {"(", ")"}, "bmatrix": {"[", "]"}, "Bmatrix": {"{", "}"},
+	"vmatrix": {"|", "|"}, "Vmatrix": {"‖", "‖"}, "smallmatrix": {"", ""}, "cases": {"{", ""},
+	"aligned": {"", ""}, "align": {"", ""}, "align*": {"", ""}, "split": {"", ""},
+	"gathered": {"", ""}, "gather": {"", ""}, "gather*": {"", ""}, "array": {"", ""},
+}
+
+type parser struct {
+	src []rune
+	pos int
+}
+
+func parse(expr string) (node, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, fmt.Errorf("%w: empty formula", ErrMathParse)
+	}
+	p := &parser{src: []rune(expr)}
+	rows, err := p.parseRows("")
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 1 && len(rows[0]) == 1 {
+		return rows[0][0], nil
+	}
+
+	env := "gathered"
+	for _, row := range rows {
+		if len(row) > 1 {
+			env = "aligned"
+		}
+	}
+	return newArray(env, "", rows), nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s at position %d", ErrMathParse, fmt.Sprintf(format, args...), p.pos)
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *parser) peekCommand() string {
+	if p.eof() || p.src[p.pos] != '\\' {
+		return ""
+	}
+	end := p.pos + 1
+	for end < len(p.src) && isCommandLetter(p.src[end]) {
+		end++
+	}
+	if end == p.pos+1 && end < len(p.src) {
+		end++
+	}
+	return string(p.src[p.pos+1 : end])
+}
+
+func (p *parser) readCommand() (string, error) {
+	name := p.peekCommand()
+	if name == "" {
+		return "", p.errorf("trailing backslash")
+	}
+	p.pos += 1 + len([]rune(name))
+	return name, nil
+}
+
+func isCommandLetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
+func (p *parser) parseRows(env string) ([][]node, error) {
+	var rows [][]node
+	var row []node
+	for {
+		cell, stop, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		row = append(row, cell)
+
+		switch stop {
+		case stopAmp:
+			continue
+		case stopNewline:
+			rows = append(rows, row)
+			row = nil
+			continue
+		case stopEnd:
+			if env == "" {
+				return nil, p.errorf(`unexpected \end`)
+			}
+			name, err := p.readBraced()
+			if err != nil {
+				return nil, err
+			}
+			if name != env {
+				return nil, p.errorf(`\begin{%s} ended by \end{%s}`, env, name)
+			}
+		case stopEOF:
+			if env != "" {
+				return nil, p.errorf(`missing \end{%s}`, env)
+			}
+		default:
+			return nil, p.errorf("unexpected %s", stopNames[stop])
+		}
+
+		if len(row) > 1 || len(cell.items) > 0 || len(rows) == 0 {
+			rows = append(rows, row)
+		}
+		return rows, nil
+	}
+}
+
+func (p *parser) parseList() (*listNode, stopKind, error) {
+	list := &listNode{}
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return list, stopEOF, nil
+		}
+
+		switch c := p.src[p.pos]; c {
+		case '}':
+			p.pos++
+			return list, stopBrace, nil
+		case '&':
+			p.pos++
+			return list, stopAmp, nil
+		case '^', '_':
+			p.pos++
+			arg, err := p.parseArg()
+			if err != nil {
+				return nil, 0, err
+			}
+			if err := p.attachScript(list, c == '^', arg); err != nil {
+				return nil, 0, err
+			}
+			continue
+		case '\\':
+			stop := stopEOF
+			switch p.peekCommand() {
+			case `\`:
+				stop = stopNewline
+			case "end":
+				stop = stopEnd
+			case "right":
+				stop = stopRight
+			}
+			if stop != stopEOF {
+				if _, err := p.readCommand(); err != nil {
+					return nil, 0, err
+				}
+				return list, stop, nil
+			}
+		}
+
+		atom, err := p.parseAtom()
+		if err != nil {
+			return nil, 0, err
+		}
+		if atom != nil {
+			list.items = append(list.items, atom)
+		}
+	}
+}
+
+func (p *parser) scriptsTarget(list *listNode) *scriptsNode {
+	if len(list.items) == 0 {
+		s := &scriptsNode{base: &listNode{}}
+		list.items = append(list.items, s)
+		return s
+	}
+	last := list.items[len(list.items)-1]
+	if s, ok := last.(*scriptsNode); ok {
+		return s
+	}
+	s := &scriptsNode{base: last}
+	list.items[len(list.items)-1] = s
+	return s
+}
+
+func (p *parser) attachScript(list *listNode, sup bool, arg node) error {
+	s := p.scriptsTarget(list)
+	switch {
+	case sup && s.sup != nil:
+		return p.errorf("double superscript")
+	case sup:
+		s.sup = arg
+	case s.sub != nil:
+		return p.errorf("double subscript")
+	default:
+		s.sub = arg
+	}
+	return nil
+}
+
+func (p *parser) parseGroup() (node, error) {
+	list, stop, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+	if stop != stopBrace {
+		if stop == stopEOF {
+			return nil, p.errorf("missing }")
+		}
+		return nil, p.errorf("unexpected %s", stopNames[stop])
+	}
+	return list, nil
+}
+
+func (p *parser) parseArg() (node, error) {
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf("missing argument")
+	}
+	switch p.src[p.pos] {
+	case '{':
+		p.pos++
+		return p.parseGroup()
+	case '}', '&', '^', '_':
+		return nil, p.errorf("missing argument")
+	}
+	atom, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	if atom == nil {
+		return &listNode{}, nil
+	}
+	return atom, nil
+}
+
+func (p *parser) parseAtom() (node, error) {
+	c := p.src[p.pos]
+	switch c {
+	case '{':
+		p.pos++
+		return p.parseGroup()
+	case '\\':
+		return p.parseCommand()
+	case '~':
+		p.pos++
+		return &spaceNode{em: spaces[" "]}, nil
+	}
+	p.pos++
+	return charNode(c), nil
+}
+
+func charNode(c rune) *symbolNode {
+	sym := &symbolNode{text: string(c), class: classOrd}
+	if text, ok := charText[c]; ok {
+		sym.text = text
+	}
+	if class, ok := charClasses[c]; ok {
+		sym.class = class
+	}
+	sym.letter = unicode.IsLetter(c)
+	return sym
+}
+
+func (p *parser) parseCommand() (node, error) {
+	name, err := p.readCommand()
+	if err != nil {
+		return nil, err
+	}
+
+	if ignored[name] {
+		return nil, nil
+	}
+	if em, ok := spaces[name]; ok {
+		return &spaceNode{em: em}, nil
+	}
+	if text, ok := greek[name]; ok {
+		r := []rune(text)[0]
+		return &symbolNode{text: text, class: classOrd, letter: unicode.IsLower(r)}, nil
+	}
+	if sym, ok := symbols[name]; ok {
+		return &symbolNode{text: sym.text, class: sym.class, font: fontRoman}, nil
+	}
+	if op, ok := bigOperators[name]; ok {
+		return &opNode{text: op.text, limits: op.limits}, nil
+	}
+	if limits, ok := functions[name]; ok {
+		text := name
+		if display, ok := functionNames[name]; ok {
+			text = display
+		}
+		return &opNode{text: text, limits: limits, function: true}, nil
+	}
+	if acc, ok := accents[name]; ok {
+		body, err := p.parseArg()
+		if err != nil {
+			return nil, err
+		}
+		return &accentNode{body: body, accent: acc}, nil
+	}
+	if big, ok := bigDelimiters[name]; ok {
+		text, err := p.readDelimiter()
+		if err != nil {
+			return nil, err
+		}
+		return &bigDelimNode{text: text, scale: big.scale, class: big.class}, nil
+	}
+
+	switch name {
+	case "frac", "dfrac", "tfrac", "cfrac", "binom", "dbinom", "tbinom":
+		return p.parseFraction(name)
+	case "sqrt":
+		return p.parseSqrt()
+	case "left":
+		return p.parseDelimited()
+	case "begin":
+		return p.parseEnvironment()
+	case "not":
+		return p.parseNot()
+	case "underline":
+		body, err := p.parseArg()
+		if err != nil {
+			return nil, err
+		}
+		return &accentNode{body: body, accent: accent{rule: true}, under: true}, nil
+	case "text", "textrm", "textnormal", "textup", "mbox", "hbox", "textsf", "texttt":
+		return p.parseText(fontRoman)
+	case "textit", "emph":
+		return p.parseText(fontItalic)
+	case "textbf":
+		return p.parseText(fontBold)
+	case "operatorname":
+		limits := p.consume('*')
+		text, err := p.readBraced()
+		if err != nil {
+			return nil, err
+		}
+		return &opNode{text: text, limits: limits, function: true}, nil
+	case "mathrm", "mathup", "mathsf", "mathtt", "rm":
+		return p.parseStyled(func(s *symbolNode) { setFont(s, fontRoman) })
+	case "mathit":
+		return p.parseStyled(func(s *symbolNode) { setFont(s, fontItalic) })
+	case "mathbf", "boldsymbol", "bm", "bf":
+		return p.parseStyled(func(s *symbolNode) { setFont(s, fontBold) })
+	case "mathbb":
+		return p.parseStyled(func(s *symbolNode) { mapLetters(s, 0x1D538, 0x1D552, doubleStruck) })
+	case "mathcal", "mathscr":
+		return p.parseStyled(func(s *symbolNode) { mapLetters(s, 0x1D49C, 0x1D4B6, script) })
+	case "mathfrak":
+		return p.parseStyled(func(s *symbolNode) { mapLetters(s, 0x1D504, 0x1D51E, fraktur) })
+	}
+	return nil, p.errorf(`unknown command \%s`, name)
+}
+
+func (p *parser) consume(r rune) bool {
+	if !p.eof() && p.src[p.pos] == r {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) readBraced() (string, error) {
+	p.skipSpace()
+	if !p.consume('{') {
+		return "", p.errorf("expected {")
+	}
+	start, depth := p.pos, 1
+	for ; !p.eof(); p.pos++ {
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				text := string(p.src[start:p.pos])
+				p.pos++
+				return text, nil
+			}
+		}
+	}
+	return "", p.errorf("missing }")
+}
+
+func (p *parser) readDelimiter() (string, error) {
+	p.skipSpace()
+	if p.eof() {
+		return "", p.errorf("missing delimiter")
+	}
+	key := string(p.src[p.pos])
+	if p.src[p.pos] == '\\' {
+		name, err := p.readCommand()
+		if err != nil {
+			return "", err
+		}
+		key = `\` + name
+	} else {
+		p.pos++
+	}
+	text, ok := delimiters[key]
+	if !ok {
+		return "", p.errorf("unknown delimiter %s", key)
+	}
+	return text, nil
+}
+
+func (p *parser) parseFraction(name string) (node, error) {
+	num, err := p.parseArg()
+	if err != nil {
+		return nil, err
+	}
+	den, err := p.parseArg()
+	if err != nil {
+		return nil, err
+	}
+
+	frac := &fracNode{num: num, den: den, rule: true, level: -1}
+	if strings.HasSuffix(name, "binom") {
+		frac.rule = false
+		frac.left, frac.right = "(", ")"
+	}
+	switch name[0] {
+	case 'd', 'c':
+		frac.level = levelDisplay
+	case 't':
+		frac.level = levelText
+	}
+	return frac, nil
+}
+
+func (p *parser) parseSqrt() (node, error) {
+	var index node
+	p.skipSpace()
+	if p.consume('[') {
+		start := p.pos
+		for !p.eof() && p.src[p.pos] != ']' {
+			p.pos++
+		}
+		if p.eof() {
+			return nil, p.errorf("missing ]")
+		}
+		sub := &parser{src: p.src[start:p.pos]}
+		p.pos++
+		list, stop, err := sub.parseList()
+		if err != nil {
+			return nil, err
+		}
+		if stop != stopEOF {
+			return nil, p.errorf("unexpected %s", stopNames[stop])
+		}
+		index = list
+	}
+	body, err := p.parseArg()
+	if err != nil {
+		return nil, err
+	}
+	return &sqrtNode{body: body, index: index}, nil
+}
+
+func (p *parser) parseDelimited() (node, error) {
+	left, err := p.readDelimiter()
+	if err != nil {
+		return nil, err
+	}
+	body, stop, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+	if stop != stopRight {
+		return nil, p.errorf(`\left without \right`)
+	}
+	right, err := p.readDelimiter()
+	if err != nil {
+		return nil, err
+	}
+	return &delimNode{left: left, right: right, body: body}, nil
+}
+
+func (p *parser) parseEnvironment() (node, error) {
+	name, err := p.readBraced()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := environments[name]; !ok {
+		return nil, p.errorf("unknown environment %s", name)
+	}
+	var spec string
+	if name == "array" {
+		if spec, err = p.readBraced(); err != nil {
+			return nil, err
+		}
+	}
+	rows, err := p.parseRows(name)
+	if err != nil {
+		return nil, err
+	}
+	return newArray(name, spec, rows), nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf(`missing argument to \not`)
+	}
+	atom, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	sym, ok := atom.(*symbolNode)
+	if !ok {
+		return nil, p.errorf(`\not applies only to symbols`)
+	}
+	if negated, ok := negations[sym.text]; ok {
+		sym.text = negated
+	} else {
+		sym.text += "\u0338"
+	}
+	return sym, nil
+}
+
+func (p *parser) parseText(font fontStyle) (node, error) {
+	text, err := p.readBraced()
+	if err != nil {
+		return nil, err
+	}
+	replacer := strings.NewReplacer(`\{`, "{", `\}`, "}", `\$`, "$", `\%`, "%", `\&`, "&", `\#`, "#", `\_`, "_")
+	return &textNode{text: replacer.Replace(text), font: font}, nil
+}
+
+func (p *parser) parseStyled(apply func(*symbolNode)) (node, error) {
+	arg, err := p.parseArg()
+	if err != nil {
+		return nil, err
+	}
+	visitSymbols(arg, apply)
+	return arg, nil
+}
+
+func setFont(s *symbolNode, font fontStyle) {
+	if s.font == fontDefault || s.letter || unicode.IsDigit([]rune(s.text)[0]) {
+		s.font = font
+	}
+}
+
+func mapLetters(s *symbolNode, upper, lower rune, exceptions map[rune]rune) {
+	runes := []rune(s.text)
+	if len(runes) != 1 || runes[0] > unicode.MaxASCII || !unicode.IsLetter(runes[0]) {
+		return
+	}
+	s.text = string(mathAlphabet(runes[0], upper, lower, exceptions))
+	s.font = fontRoman
+}
+
+func visitSymbols(n node, fn func(*symbolNode)) {
+	switch n := n.(type) {
+	case *symbolNode:
+		fn(n)
+	case *listNode:
+		for _, item := range n.items {
+			visitSymbols(item, fn)
+		}
+	case *scriptsNode:
+		visitSymbols(n.base, fn)
+		visitSymbols(n.sup, fn)
+		visitSymbols(n.sub, fn)
+	case *fracNode:
+		visitSymbols(n.num, fn)
+		visitSymbols(n.den, fn)
+	case *sqrtNode:
+		visitSymbols(n.body, fn)
+	case *delimNode:
+		visitSymbols(n.body, fn)
+	case *accentNode:
+		visitSymbols(n.body, fn)
+	}
+}
+
+func newArray(env, spec string, rows [][]node) *arrayNode {
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+
+	delims := environments[env]
+	a := &arrayNode{
+		rows:  rows,
+		align: make([]byte, columns),
+		gaps:  make([]float64, columns),
+		left:  delims[0],
+		right: delims[1],
+	}
+	for i := range a.align {
+		a.align[i] = 'c'
+		if i > 0 {
+			a.gaps[i] = 1
+		}
+	}
+
+	switch env {
+	case "cases":
+		for i := range a.align {
+			a.align[i] = 'l'
+		}
+	case "aligned", "align", "align*", "split":
+		a.display = true
+		for i := range a.align {
+			if i%2 == 0 {
+				a.align[i] = 'r'
+				if i > 0 {
+					a.gaps[i] = 2
+				}
+			} else {
+				a.align[i] = 'l'
+				a.gaps[i] = 0
+			}
+		}
+		for _, row := range rows {
+			for i := 1; i < len(row); i += 2 {
+				if cell, ok := row[i].(*listNode); ok {
+					cell.items = append([]node{&listNode{}}, cell.items...)
+				}
+			}
+		}
+	case "gathered", "gather", "gather*":
+		a.display = true
+	case "array":
+		i := 0
+		for _, r := range spec {
+			if (r == 'l' || r == 'c' || r == 'r') && i < columns {
+				a.align[i] = byte(r)
+				i++
+			}
+		}
+	}
+	return a
+}
@@ -0,0 +1,171 @@
+package mathtex
+
+type atomClass int
+
+const (
+	classOrd atomClass = iota
+	classOp
+	classBin
+	classRel
+	classOpen
+	classClose
+	classPunct
+	classNone
+)
+
+type symbol struct {
+	text  string
+	class atomClass
+}
+
+var greek = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ", "varepsilon": "ε",
+	"zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ", "iota": "ι", "kappa": "κ",
+	"lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ", "omicron": "ο", "pi": "π", "varpi": "ϖ",
+	"rho": "ρ", "varrho": "ϱ", "sigma": "σ", "varsigma": "ς", "tau": "τ", "upsilon": "υ",
+	"phi": "ϕ", "varphi": "φ", "chi": "χ", "psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ", "Pi": "Π",
+	"Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+}
+
+var symbols = map[string]symbol{
+	"infty": {"∞", classOrd}, "partial": {"∂", classOrd}, "nabla": {"∇", classOrd},
+	"forall": {"∀", classOrd}, "exists": {"∃", classOrd}, "nexists": {"∄", classOrd},
+	"emptyset": {"∅", classOrd}, "varnothing": {"∅", classOrd}, "hbar": {"ℏ", classOrd},
+	"ell": {"ℓ", classOrd}, "Re": {"ℜ", classOrd}, "Im": {"ℑ", classOrd}, "aleph": {"ℵ", classOrd},
+	"wp": {"℘", classOrd}, "angle": {"∠", classOrd}, "top": {"⊤", classOrd}, "bot": {"⊥", classOrd},
+	"prime": {"′", classOrd}, "degree": {"°", classOrd}, "neg": {"¬", classOrd}, "lnot": {"¬", classOrd},
+	"ldots": {"…", classOrd}, "dots": {"…", classOrd}, "cdots": {"⋯", classOrd},
+	"vdots": {"⋮", classOrd}, "ddots": {"⋱", classOrd}, "triangle": {"△", classOrd},
+	"checkmark": {"✓", classOrd}, "dagger": {"†", classOrd}, "ddagger": {"‡", classOrd},
+	"vert": {"|", classOrd}, "Vert": {"‖", classOrd}, "|": {"‖", classOrd}, "backslash": {"\\", classOrd},
+	"$": {"$", classOrd}, "%": {"%", classOrd}, "#": {"#", classOrd}, "&": {"&", classOrd}, "_": {"_", classOrd},
+
+	"pm": {"±", classBin}, "mp": {"∓", classBin}, "times": {"×", classBin}, "div": {"÷", classBin},
+	"cdot": {"⋅", classBin}, "ast": {"∗", classBin}, "star": {"⋆", classBin}, "circ": {"∘", classBin},
+	"bullet": {"∙", classBin}, "oplus": {"⊕", classBin}, "ominus": {"⊖", classBin},
+	"otimes": {"⊗", classBin}, "oslash": {"⊘", classBin}, "odot": {"⊙", classBin},
+	"cup": {"∪", classBin}, "cap": {"∩", classBin}, "setminus": {"∖", classBin},
+	"wedge": {"∧", classBin}, "land": {"∧", classBin}, "vee": {"∨", classBin}, "lor": {"∨", classBin},
+	"sqcup": {"⊔", classBin}, "sqcap": {"⊓", classBin}, "uplus": {"⊎", classBin},
+
+	"leq": {"≤", classRel}, "le": {"≤", classRel}, "geq": {"≥", classRel}, "ge": {"≥", classRel},
+	"neq": {"≠", classRel}, "ne": {"≠", classRel}, "approx": {"≈", classRel}, "equiv": {"≡", classRel},
+	"sim": {"∼", classRel}, "simeq": {"≃", classRel}, "cong": {"≅", classRel}, "propto": {"∝", classRel},
+	"ll": {"≪", classRel}, "gg": {"≫", classRel}, "prec": {"≺", classRel}, "succ": {"≻", classRel},
+	"preceq": {"⪯", classRel}, "succeq": {"⪰", classRel}, "doteq": {"≐", classRel},
+	"in": {"∈", classRel}, "notin": {"∉", classRel}, "ni": {"∋", classRel},
+	"subset": {"⊂", classRel}, "supset": {"⊃", classRel}, "subseteq": {"⊆", classRel},
+	"supseteq": {"⊇", classRel}, "subsetneq": {"⊊", classRel}, "supsetneq": {"⊋", classRel},
+	"mid": {"∣", classRel}, "parallel": {"∥", classRel}, "perp": {"⊥", classRel},
+	"models": {"⊨", classRel}, "vdash": {"⊢", classRel}, "dashv": {"⊣", classRel},
+	"to": {"→", classRel}, "rightarrow": {"→", classRel}, "leftarrow": {"←", classRel},
+	"gets": {"←", classRel}, "leftrightarrow": {"↔", classRel}, "Rightarrow": {"⇒", classRel},
+	"Leftarrow": {"⇐", classRel}, "Leftrightarrow": {"⇔", classRel}, "implies": {"⟹", classRel},
+	"impliedby": {"⟸", classRel}, "iff": {"⟺", classRel}, "mapsto": {"↦", classRel},
+	"longrightarrow": {"⟶", classRel}, "longleftarrow": {"⟵", classRel},
+	"Longrightarrow": {"⟹", classRel}, "Longleftarrow": {"⟸", classRel},
+	"uparrow": {"↑", classRel}, "downarrow": {"↓", classRel}, "hookrightarrow": {"↪", classRel},
+	"rightleftharpoons": {"⇌", classRel}, "therefore": {"∴", classRel}, "because": {"∵", classRel},
+
+	"{": {"{", classOpen}, "}": {"}", classClose}, "lbrace": {"{", classOpen}, "rbrace": {"}", classClose},
+	"langle": {"⟨", classOpen}, "rangle": {"⟩", classClose}, "lfloor": {"⌊", classOpen},
+	"rfloor": {"⌋", classClose}, "lceil": {"⌈", classOpen}, "rceil": {"⌉", classClose},
+	"lvert": {"|", classOpen}, "rvert": {"|", classClose}, "lVert": {"‖", classOpen}, "rVert": {"‖", classClose},
+
+	"colon": {":", classPunct},
+}
+
+type bigOperator struct {
+	text   string
+	limits bool
+}
+
+var bigOperators = map[string]bigOperator{
+	"sum": {"∑", true}, "prod": {"∏", true}, "coprod": {"∐", true},
+	"bigcup": {"⋃", true}, "bigcap": {"⋂", true}, "bigvee": {"⋁", true}, "bigwedge": {"⋀", true},
+	"bigoplus": {"⨁", true}, "bigotimes": {"⨂", true}, "bigodot": {"⨀", true},
+	"int": {"∫", false}, "iint": {"∬", false}, "iiint": {"∭", false}, "oint": {"∮", false},
+}
+
+var functions = map[string]bool{
+	"sin": false, "cos": false, "tan": false, "sec": false, "csc": false, "cot": false,
+	"arcsin": false, "arccos": false, "arctan": false, "sinh": false, "cosh": false, "tanh": false,
+	"coth": false, "log": false, "ln": false, "lg": false, "exp": false, "dim": false, "ker": false,
+	"deg": false, "arg": false, "hom": false,
+	"lim": true, "liminf": true, "limsup": true, "max": true, "min": true, "sup": true,
+	"inf": true, "det": true, "gcd": true, "Pr": true, "argmax": true, "argmin": true,
+}
+
+var functionNames = map[string]string{
+	"liminf": "lim inf", "limsup": "lim sup", "argmax": "arg max", "argmin": "arg min",
+}
+
+var spaces = map[string]float64{
+	",": 3.0 / 18, ":": 4.0 / 18, ">": 4.0 / 18, ";": 5.0 / 18, "!": -3.0 / 18, " ": 5.0 / 18,
+	"thinspace": 3.0 / 18, "medspace": 4.0 / 18, "thickspace": 5.0 / 18, "enspace": 0.5,
+	"quad": 1, "qquad": 2,
+}
+
+type accent struct {
+	text string
+	rule bool
+}
+
+var accents = map[string]accent{
+	"hat": {"ˆ", false}, "widehat": {"ˆ", false}, "tilde": {"˜", false}, "widetilde": {"˜", false},
+	"dot": {"˙", false}, "ddot": {"¨", false}, "vec": {"→", false}, "acute": {"ˊ", false},
+	"grave": {"ˋ", false}, "check": {"ˇ", false}, "breve": {"˘", false},
+	"bar": {"", true}, "overline": {"", true},
+}
+
+var delimiters = map[string]string{
+	"(": "(", ")": ")", "[": "[", "]": "]", "|": "|", "/": "/", ".": "",
+	"\\{": "{", "\\}": "}", "\\lbrace": "{", "\\rbrace": "}", "\\langle": "⟨", "\\rangle": "⟩",
+	"\\lfloor": "⌊", "\\rfloor": "⌋", "\\lceil": "⌈", "\\rceil": "⌉", "\\|": "‖",
+	"\\vert": "|", "\\Vert": "‖", "\\lvert": "|", "\\rvert": "|", "\\lVert": "‖", "\\rVert": "‖",
+	"<": "⟨", ">": "⟩",
+}
+
+var ignored = map[string]bool{
+	"displaystyle": true, "textstyle": true, "scriptstyle": true, "limits": true,
+	"nolimits": true, "nonumber": true, "notag": true,
+}
+
+var charClasses = map[rune]atomClass{
+	'+': classBin, '-': classBin, '*': classBin,
+	'=': classRel, '<': classRel, '>': classRel, ':': classRel,
+	',': classPunct, ';': classPunct,
+	'(': classOpen, '[': classOpen,
+	')': classClose, ']': classClose, '!': classClose, '?': classClose,
+}
+
+var charText = map[rune]string{
+	'-': "−", '*': "∗", '\'': "′",
+}
+
+var doubleStruck = map[rune]rune{
+	'C': 'ℂ', 'H': 'ℍ', 'N': 'ℕ', 'P': 'ℙ', 'Q': 'ℚ', 'R': 'ℝ', 'Z': 'ℤ',
+}
+
+var script = map[rune]rune{
+	'B': 'ℬ', 'E': 'ℰ', 'F': 'ℱ', 'H': 'ℋ', 'I': 'ℐ', 'L': 'ℒ', 'M': 'ℳ', 'R': 'ℛ',
+	'e': 'ℯ', 'g': 'ℊ', 'o': 'ℴ',
+}
+
+var fraktur = map[rune]rune{
+	'C': 'ℭ', 'H': 'ℌ', 'I': 'ℑ', 'R': 'ℜ', 'Z': 'ℨ',
+}
+
+func mathAlphabet(r rune, upper, lower rune, exceptions map[rune]rune) rune {
+	if mapped, ok := exceptions[r]; ok {
+		return mapped
+	}
+	switch {
+	case r >= 'A' && r <= 'Z':
+		return upper + r - 'A'
+	case r >= 'a' && r <= 'z' && lower != 0:
+		return lower + r - 'a'
+	}
+	return r
+}
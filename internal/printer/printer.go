@@ -0,0 +1,167 @@
+package printer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	commandTimeout = 10 * time.Second
+	previewTimeout = 30 * time.Second
+)
+
+var (
+	ErrPrinterUnavailable = errors.New("printer: lp not found (install CUPS to print)")
+	ErrPrinterFailed      = errors.New("printer: failed to send the document to the print queue")
+	ErrPreviewUnavailable = errors.New("printer: pdftoppm not found (install poppler-utils for page previews)")
+	ErrPreviewFailed      = errors.New("printer: failed to render the page preview")
+)
+
+var (
+	requestRegex = regexp.MustCompile(`request id is (\S+)`)
+	pageRegex    = regexp.MustCompile(`/Type\s*/Page\b`)
+)
+
+type Destination struct {
+	Name    string
+	Default bool
+}
+
+type Job struct {
+	Printer string
+	Title   string
+	Copies  int
+	Media   string
+}
+
+func Available() bool {
+	_, err := exec.LookPath("lp")
+	return err == nil
+}
+
+func PreviewAvailable() bool {
+	_, err := exec.LookPath("pdftoppm")
+	return err == nil
+}
+
+func run(timeout time.Duration, input []byte, name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	if input != nil {
+		cmd.Stdin = bytes.NewReader(input)
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %v: %s", name, err, msg)
+		}
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+func PageCount(pdf []byte) int {
+	return len(pageRegex.FindAll(pdf, -1))
+}
+
+func Destinations() ([]Destination, error) {
+	if _, err := exec.LookPath("lpstat"); err != nil {
+		return nil, nil
+	}
+	out, err := run(commandTimeout, nil, "lpstat", "-e")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPrinterFailed, err)
+	}
+	defaultName := ""
+	if out, err := run(commandTimeout, nil, "lpstat", "-d"); err == nil {
+		if _, name, ok := strings.Cut(string(out), ":"); ok {
+			defaultName = strings.TrimSpace(name)
+		}
+	}
+
+	var destinations []Destination
+	for _, line := range strings.Split(string(out), "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			destinations = append(destinations, Destination{Name: name, Default: name == defaultName})
+		}
+	}
+	return destinations, nil
+}
+
+func Print(pdf []byte, job Job) (string, error) {
+	if !Available() {
+		return "", ErrPrinterUnavailable
+	}
+	var args []string
+	if job.Printer != "" {
+		args = append(args, "-d", job.Printer)
+	}
+	if job.Copies > 1 {
+		args = append(args, "-n", strconv.Itoa(job.Copies))
+	}
+	if job.Title != "" {
+		args = append(args, "-t", job.Title)
+	}
+	if job.Media != "" {
+		args = append(args, "-o", "media="+job.Media)
+	}
+	out, err := run(commandTimeout, pdf, "lp", args...)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrPrinterFailed, err)
+	}
+	if m := requestRegex.FindStringSubmatch(string(out)); m != nil {
+		return m[1], nil
+	}
+	return "", nil
+}
+
+func Preview(pdf []byte, dpi int) ([]image.Image, error) {
+	if !PreviewAvailable() {
+		return nil, ErrPreviewUnavailable
+	}
+	dir, err := os.MkdirTemp("", "markdown-editor-print-")
+	if err != nil {
+		return nil, fmt.Errorf("%w: creating work directory: %v", ErrPreviewFailed, err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := run(previewTimeout, pdf, "pdftoppm", "-png", "-r", strconv.Itoa(dpi), "-", filepath.Join(dir, "page")); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPreviewFailed, err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "page-*.png"))
+	if err != nil || len(files) == 0 {
+		return nil, fmt.Errorf("%w: pdftoppm produced no pages", ErrPreviewFailed)
+	}
+	sort.Strings(files)
+
+	pages := make([]image.Image, 0, len(files))
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrPreviewFailed, err)
+		}
+		page, err := png.Decode(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%w: decoding %s: %v", ErrPreviewFailed, filepath.Base(file), err)
+		}
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
@@ -0,0 +1,159 @@
+package printer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func fakeTools(t *testing.T, scripts map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+body), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return dir
+}
+
+func fakeLP(t *testing.T, output string) (string, string) {
+	t.Helper()
+	work := t.TempDir()
+	args := filepath.Join(work, "args")
+	input := filepath.Join(work, "input")
+	fakeTools(t, map[string]string{
+		"lp": `for arg in "$@"; do printf '%s\n' "$arg" >> ` + args + "; done\ncat > " + input + "\nprintf '%s' '" + output + "'\n",
+	})
+	return args, input
+}
+
+func TestPrint(t *testing.T) {
+	tests := []struct {
+		name        string
+		job         Job
+		output      string
+		wantArgs    []string
+		wantRequest string
+	}{
+		{
+			name:        "default printer",
+			job:         Job{},
+			output:      "request id is office-7 (1 file(s))\n",
+			wantRequest: "office-7",
+		},
+		{
+			name:        "every option",
+			job:         Job{Printer: "office", Title: "My Note.md", Copies: 3, Media: "A4"},
+			output:      "request id is office-42 (1 file(s))\n",
+			wantArgs:    []string{"-d", "office", "-n", "3", "-t", "My Note.md", "-o", "media=A4"},
+			wantRequest: "office-42",
+		},
+		{
+			name:        "single copy is not passed",
+			job:         Job{Printer: "lab", Copies: 1},
+			output:      "request id is lab-1 (1 file(s))\n",
+			wantArgs:    []string{"-d", "lab"},
+			wantRequest: "lab-1",
+		},
+		{
+			name:     "no request id in the output",
+			job:      Job{Media: "Letter"},
+			output:   "queued\n",
+			wantArgs: []string{"-o", "media=Letter"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			argsFile, inputFile := fakeLP(t, tt.output)
+			pdf := []byte("%PDF-1.4 test")
+
+			request, err := Print(pdf, tt.job)
+			if err != nil {
+				t.Fatalf("Print() error = %v", err)
+			}
+			if request != tt.wantRequest {
+				t.Errorf("Print() = %q, want %q", request, tt.wantRequest)
+			}
+
+			var args []string
+			if data, err := os.ReadFile(argsFile); err == nil {
+				args = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+			} else if !errors.Is(err, os.ErrNotExist) {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("lp arguments = %q, want %q", args, tt.wantArgs)
+			}
+			input, err := os.ReadFile(inputFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(input) != string(pdf) {
+				t.Errorf("lp input = %q, want %q", input, pdf)
+			}
+		})
+	}
+}
+
+func TestPrintFailure(t *testing.T) {
+	fakeTools(t, map[string]string{
+		"lp": "cat > /dev/null\necho 'lp: The printer or class does not exist.' >&2\nexit 1\n",
+	})
+	_, err := Print([]byte("%PDF-1.4"), Job{Printer: "missing"})
+	if !errors.Is(err, ErrPrinterFailed) {
+		t.Fatalf("Print() error = %v, want %v", err, ErrPrinterFailed)
+	}
+	if !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("Print() error = %q, want the lp message", err)
+	}
+}
+
+func TestPrintUnavailable(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	if Available() {
+		t.Fatal("Available() = true without lp on PATH")
+	}
+	if _, err := Print([]byte("%PDF-1.4"), Job{}); !errors.Is(err, ErrPrinterUnavailable) {
+		t.Errorf("Print() error = %v, want %v", err, ErrPrinterUnavailable)
+	}
+}
+
+func TestDestinations(t *testing.T) {
+	fakeTools(t, map[string]string{
+		"lpstat": "case \"$1\" in\n-e) printf 'lab\\noffice\\n\\n' ;;\n-d) echo 'system default destination: office' ;;\nesac\n",
+	})
+	got, err := Destinations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Destination{{Name: "lab"}, {Name: "office", Default: true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Destinations() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPageCount(t *testing.T) {
+	tests := []struct {
+		name string
+		pdf  string
+		want int
+	}{
+		{name: "empty", pdf: "", want: 0},
+		{name: "page tree only", pdf: "<< /Type /Pages /Kids [] /Count 0 >>", want: 0},
+		{name: "one page", pdf: "<< /Type /Pages >> << /Type /Page /Parent 1 0 R >>", want: 1},
+		{name: "compact spacing", pdf: "<</Type/Page/Parent 1 0 R>><</Type/Page>>", want: 2},
+		{name: "line breaks", pdf: "<< /Type\n/Page >>\n<< /Type /Page\n>>\n<< /Type /Page>>", want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PageCount([]byte(tt.pdf)); got != tt.want {
+				t.Errorf("PageCount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
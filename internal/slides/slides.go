@@ -0,0 +1,150 @@
+package slides
+
+import (
+	"errors"
+	"markdown-editor/internal/frontmatter"
+	"markdown-editor/internal/markdown"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+)
+
+var ErrNoSlides = errors.New("slides: document has no slides")
+
+var (
+	notesMarkerRegex = regexp.MustCompile(`^(?i:notes?):[ \t]*`)
+	setextLineRegex  = regexp.MustCompile(`^ {0,3}(?:=+|-+)[ \t]*(?:\n|$)`)
+)
+
+type Slide struct {
+	Content string
+	Notes   string
+	Line    int
+}
+
+func (s Slide) Title() string {
+	source := []byte(s.Content)
+	root := markdown.Parse(source)
+	for n := root.FirstChild(); n != nil; n = n.NextSibling() {
+		if h, ok := n.(*ast.Heading); ok {
+			return markdown.PlainText(h, source)
+		}
+	}
+	if n := root.FirstChild(); n != nil {
+		return markdown.PlainText(n, source)
+	}
+	return ""
+}
+
+type group struct {
+	start         int
+	end           int
+	notes         int
+	footnotes     []int
+	noteFootnotes []int
+}
+
+type footnote struct {
+	start int
+	text  string
+}
+
+func Split(content string) []Slide {
+	doc := frontmatter.Split(content)
+	source := []byte(doc.Body)
+	root := markdown.Parse(source)
+	lines := markdown.NewLines(source)
+
+	footnotes := make(map[int]footnote)
+	for n := root.FirstChild(); n != nil; n = n.NextSibling() {
+		if list, ok := n.(*extast.FootnoteList); ok {
+			for f := list.FirstChild(); f != nil; f = f.NextSibling() {
+				if fn, ok := f.(*extast.Footnote); ok {
+					if start, end, ok := markdown.BlockSpan(fn, source); ok {
+						footnotes[fn.Index] = footnote{start: start, text: strings.TrimSpace(string(source[start:end]))}
+					}
+				}
+			}
+		}
+	}
+
+	var slides []Slide
+	var current *group
+	flush := func() {
+		if current == nil {
+			return
+		}
+		slide := Slide{
+			Content: strings.TrimSpace(string(source[current.start:current.end])),
+			Line:    lines.LineOf(current.start) + doc.BodyLine,
+		}
+		if current.notes >= 0 {
+			slide.Content = strings.TrimSpace(string(source[current.start:current.notes]))
+			notes := notesMarkerRegex.ReplaceAllString(string(source[current.notes:current.end]), "")
+			slide.Notes = strings.TrimSpace(notes)
+		}
+		slide.Content = appendFootnotes(slide.Content, current.footnotes, footnotes, current)
+		slide.Notes = appendFootnotes(slide.Notes, current.noteFootnotes, footnotes, current)
+		slides = append(slides, slide)
+		current = nil
+	}
+
+	for n := root.FirstChild(); n != nil; n = n.NextSibling() {
+		switch node := n.(type) {
+		case *ast.ThematicBreak:
+			flush()
+			continue
+		case *extast.FootnoteList:
+			continue
+		case *ast.Heading:
+			if node.Level == 2 {
+				flush()
+			}
+		}
+
+		start, end, ok := markdown.BlockSpan(n, source)
+		if !ok {
+			continue
+		}
+		if _, ok := n.(*ast.Heading); ok && end < len(source) && !strings.HasPrefix(strings.TrimLeft(string(source[start:end]), " "), "#") {
+			if m := setextLineRegex.Find(source[end+1:]); m != nil {
+				end += 1 + len(strings.TrimRight(string(m), "\n"))
+			}
+		}
+		if current == nil {
+			current = &group{start: start, notes: -1}
+		}
+		if _, ok := n.(*ast.Paragraph); ok && current.notes < 0 && notesMarkerRegex.Match(source[start:end]) {
+			current.notes = start
+		}
+		current.end = end
+
+		_ = ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+			if link, ok := child.(*extast.FootnoteLink); ok && entering {
+				if current.notes >= 0 {
+					current.noteFootnotes = append(current.noteFootnotes, link.Index)
+				} else {
+					current.footnotes = append(current.footnotes, link.Index)
+				}
+			}
+			return ast.WalkContinue, nil
+		})
+	}
+	flush()
+	return slides
+}
+
+func appendFootnotes(text string, indexes []int, footnotes map[int]footnote, g *group) string {
+	seen := make(map[int]bool)
+	for _, index := range indexes {
+		f, ok := footnotes[index]
+		if !ok || seen[index] || (f.start >= g.start && f.start < g.end) {
+			continue
+		}
+		seen[index] = true
+		text += "\n\n" + f.text
+	}
+	return text
+}
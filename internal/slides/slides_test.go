@@ -0,0 +1,111 @@
+package slides
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []Slide
+	}{
+		{
+			name:    "empty document",
+			content: "  \n",
+		},
+		{
+			name:    "fenced code keeps its fences",
+			content: "---\n\n```go\nfmt.Println(1)\n```",
+			want:    []Slide{{Content: "```go\nfmt.Println(1)\n```", Line: 2}},
+		},
+		{
+			name:    "tilde fence without language",
+			content: "# A\n\n~~~~\ncode\n~~~~\n\n---\n\nafter\n",
+			want: []Slide{
+				{Content: "# A\n\n~~~~\ncode\n~~~~"},
+				{Content: "after", Line: 8},
+			},
+		},
+		{
+			name:    "fences inside quotes and lists",
+			content: "> ```\n> code\n> ```\n\n- ```\n  x\n  ```\n",
+			want:    []Slide{{Content: "> ```\n> code\n> ```\n\n- ```\n  x\n  ```"}},
+		},
+		{
+			name:    "math blocks keep their delimiters",
+			content: "$$\nx^2\n$$\n\ntext\n\n$$ y $$\n",
+			want:    []Slide{{Content: "$$\nx^2\n$$\n\ntext\n\n$$ y $$"}},
+		},
+		{
+			name:    "thematic breaks and level two headings",
+			content: "# Deck\n\nIntro\n\n## First\n\nOne\n\n***\n\nTwo\n",
+			want: []Slide{
+				{Content: "# Deck\n\nIntro"},
+				{Content: "## First\n\nOne", Line: 4},
+				{Content: "Two", Line: 10},
+			},
+		},
+		{
+			name:    "setext headings",
+			content: "Title\n=====\n\nbody\n\nSub\n---\n\nmore",
+			want: []Slide{
+				{Content: "Title\n=====\n\nbody"},
+				{Content: "Sub\n---\n\nmore", Line: 5},
+			},
+		},
+		{
+			name:    "speaker notes",
+			content: "# A\n\nBody\n\nNote: say this\n\nand this\n\n---\n\nNotes:\nfirst\n",
+			want: []Slide{
+				{Content: "# A\n\nBody", Notes: "say this\n\nand this"},
+				{Content: "", Notes: "first", Line: 10},
+			},
+		},
+		{
+			name:    "footnote defined on another slide",
+			content: "## One\n\nSee[^1].\n\n## Two\n\nText[^2]\n\n[^1]: Defined here.\n\n[^2]: Local.\n",
+			want: []Slide{
+				{Content: "## One\n\nSee[^1].\n\n[^1]: Defined here."},
+				{Content: "## Two\n\nText[^2]\n\n[^2]: Local.", Line: 4},
+			},
+		},
+		{
+			name:    "footnote referenced from the notes",
+			content: "# A\n\nNote: see[^n]\n\n---\n\n[^n]: Source.\n",
+			want:    []Slide{{Content: "# A", Notes: "see[^n]\n\n[^n]: Source."}},
+		},
+		{
+			name:    "front matter shifts line numbers",
+			content: "---\ntitle: x\n---\n# A\n\n---\n\nB\n",
+			want: []Slide{
+				{Content: "# A", Line: 3},
+				{Content: "B", Line: 7},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Split(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split() =\n%#v\nwant\n%#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlideTitle(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{content: "Intro text\n\n## Heading *here*", want: "Heading here"},
+		{content: "Just **text**", want: "Just text"},
+		{content: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := (Slide{Content: tt.content}).Title(); got != tt.want {
+			t.Errorf("Title(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"markdown-editor/internal/frontmatter"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTaskNotFound  = errors.New("tasks: task no longer matches the source line")
+	ErrTaskAmbiguous = errors.New("tasks: task text matches several lines")
+)
+
+const DueLayout = "2006-01-02"
+
+var (
+	taskLineRegex = regexp.MustCompile(`^(\s*(?:[-*+]|\d+[.)])\s+\[)([ xX])(\](?:\s+|$))(.*)$`)
+	headingRegex  = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*#*\s*$`)
+	dueRegex      = regexp.MustCompile(`@due\((\d{4}-\d{2}-\d{2})\)`)
+)
+
+type Task struct {
+	Line    int
+	Text    string
+	Done    bool
+	Heading string
+	Due     time.Time
+}
+
+func (t Task) HasDue() bool {
+	return !t.Due.IsZero()
+}
+
+func (t Task) Title() string {
+	return strings.Join(strings.Fields(dueRegex.ReplaceAllString(t.Text, "")), " ")
+}
+
+func Parse(content string) []Task {
+	lines := strings.Split(content, "\n")
+	start := frontmatter.Split(content).BodyLine
+
+	var tasks []Task
+	heading := ""
+	fence := ""
+	for i := start; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r")
+		trimmed := strings.TrimLeft(line, " \t")
+		if marker := fenceMarker(trimmed); marker != "" {
+			if fence == "" {
+				fence = marker
+			} else if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+
+		if m := headingRegex.FindStringSubmatch(line); m != nil {
+			heading = m[1]
+			continue
+		}
+		if task, ok := parseLine(line); ok {
+			task.Line = i
+			task.Heading = heading
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+func ParseLine(line string) (Task, bool) {
+	return parseLine(strings.TrimRight(line, "\r"))
+}
+
+func SetDone(content string, task Task, done bool) (string, error) {
+	lines := strings.Split(content, "\n")
+
+	line := -1
+	if task.Line >= 0 && task.Line < len(lines) {
+		if candidate, ok := parseLine(strings.TrimRight(lines[task.Line], "\r")); ok && candidate.Text == task.Text {
+			line = task.Line
+		}
+	}
+	if line < 0 {
+		for _, candidate := range Parse(content) {
+			if candidate.Text != task.Text {
+				continue
+			}
+			if line >= 0 {
+				return content, fmt.Errorf("%w: '%s'", ErrTaskAmbiguous, task.Text)
+			}
+			line = candidate.Line
+		}
+	}
+	if line < 0 {
+		return content, fmt.Errorf("%w: line %d '%s'", ErrTaskNotFound, task.Line+1, task.Text)
+	}
+
+	updated, err := SetLineDone(lines[line], done)
+	if err != nil {
+		return content, err
+	}
+	lines[line] = updated
+	return strings.Join(lines, "\n"), nil
+}
+
+func SetLineDone(line string, done bool) (string, error) {
+	m := taskLineRegex.FindStringSubmatchIndex(line)
+	if m == nil {
+		return line, fmt.Errorf("%w: '%s'", ErrTaskNotFound, line)
+	}
+	mark := " "
+	if done {
+		mark = "x"
+	}
+	return line[:m[4]] + mark + line[m[5]:], nil
+}
+
+func parseLine(line string) (Task, bool) {
+	m := taskLineRegex.FindStringSubmatch(line)
+	if m == nil {
+		return Task{}, false
+	}
+
+	task := Task{
+		Text: m[4],
+		Done: m[2] != " ",
+	}
+	if due := dueRegex.FindStringSubmatch(task.Text); due != nil {
+		if t, err := time.ParseInLocation(DueLayout, due[1], time.Local); err == nil {
+			task.Due = t
+		}
+	}
+	return task, true
+}
+
+func fenceMarker(trimmed string) string {
+	for _, marker := range []string{"```", "~~~"} {
+		if strings.HasPrefix(trimmed, marker) {
+			return marker
+		}
+	}
+	return ""
+}
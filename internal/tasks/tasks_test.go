@@ -0,0 +1,100 @@
+package tasks
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	content := "---\ntitle: x\n---\n# Plan\n- [ ] write @due(2024-02-03)\n  * [x] nested\n1. [X] numbered\n```\n- [ ] in code\n```\n## Later\n+ [ ]\n- [] not a task\n-[ ] not a task"
+	due := time.Date(2024, 2, 3, 0, 0, 0, 0, time.Local)
+	want := []Task{
+		{Line: 4, Text: "write @due(2024-02-03)", Heading: "Plan", Due: due},
+		{Line: 5, Text: "nested", Done: true, Heading: "Plan"},
+		{Line: 6, Text: "numbered", Done: true, Heading: "Plan"},
+		{Line: 11, Text: "", Heading: "Later"},
+	}
+	if got := Parse(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %+v, want %+v", got, want)
+	}
+	if title := want[0].Title(); title != "write" {
+		t.Errorf("Title() = %q, want %q", title, "write")
+	}
+}
+
+func TestSetLineDone(t *testing.T) {
+	tests := []struct {
+		line    string
+		done    bool
+		want    string
+		wantErr error
+	}{
+		{"- [ ] a", true, "- [x] a", nil},
+		{"  - [X] a", false, "  - [ ] a", nil},
+		{"3) [ ] a [ ] b", true, "3) [x] a [ ] b", nil},
+		{"plain text", true, "plain text", ErrTaskNotFound},
+	}
+	for _, tt := range tests {
+		got, err := SetLineDone(tt.line, tt.done)
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("SetLineDone(%q, %v) = %q, %v, want %q, %v", tt.line, tt.done, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSetDone(t *testing.T) {
+	content := "- [ ] same\n- [ ] other\n- [ ] same"
+	tests := []struct {
+		name    string
+		content string
+		task    Task
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "line still matches",
+			content: content,
+			task:    Task{Line: 2, Text: "same"},
+			want:    "- [ ] same\n- [ ] other\n- [x] same",
+		},
+		{
+			name:    "duplicate text at known line",
+			content: content,
+			task:    Task{Line: 0, Text: "same"},
+			want:    "- [x] same\n- [ ] other\n- [ ] same",
+		},
+		{
+			name:    "moved unique task",
+			content: "intro\n" + content,
+			task:    Task{Line: 1, Text: "other"},
+			want:    "intro\n- [ ] same\n- [x] other\n- [ ] same",
+		},
+		{
+			name:    "moved duplicate task",
+			content: "intro\n" + content,
+			task:    Task{Line: 2, Text: "same"},
+			want:    "intro\n" + content,
+			wantErr: ErrTaskAmbiguous,
+		},
+		{
+			name:    "removed task",
+			content: content,
+			task:    Task{Line: 1, Text: "gone"},
+			want:    content,
+			wantErr: ErrTaskNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SetDone(tt.content, tt.task, true)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetDone() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SetDone() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package templates
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"markdown-editor/internal/fileservice"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"fyne.io/fyne/v2/storage"
+)
+
+var (
+	ErrTemplateList     = errors.New("templates: failed to list templates")
+	ErrTemplateNotFound = errors.New("templates: template not found")
+	ErrTemplateRead     = errors.New("templates: failed to read template")
+	ErrTemplateParse    = errors.New("templates: failed to parse template")
+	ErrTemplateExecute  = errors.New("templates: failed to expand template")
+)
+
+const (
+	templateExtension = ".md"
+	defaultDateLayout = "2006-01-02"
+	defaultTimeLayout = "15:04"
+)
+
+type Template struct {
+	Name string
+	Path string
+}
+
+type Variables struct {
+	Title  string
+	Now    time.Time
+	Fields map[string]string
+}
+
+type Service struct {
+	dir string
+	fs  fileservice.FileOperations
+}
+
+func New(dir string, fs fileservice.FileOperations) *Service {
+	return &Service{
+		dir: dir,
+		fs:  fs,
+	}
+}
+
+func (s *Service) Dir() string {
+	return s.dir
+}
+
+func (s *Service) List() ([]Template, error) {
+	if _, err := os.Stat(s.dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	files, err := s.fs.ListFilesRecursive(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTemplateList, err)
+	}
+
+	var list []Template
+	for _, path := range files {
+		if !strings.EqualFold(filepath.Ext(path), templateExtension) {
+			continue
+		}
+		rel, err := filepath.Rel(s.dir, path)
+		if err != nil {
+			continue
+		}
+		list = append(list, Template{
+			Name: strings.TrimSuffix(filepath.ToSlash(rel), filepath.Ext(rel)),
+			Path: path,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list, nil
+}
+
+func (s *Service) Load(name string) (string, error) {
+	path := filepath.Join(s.dir, filepath.FromSlash(name)+templateExtension)
+	uri := storage.NewFileURI(path)
+
+	exists, err := s.fs.FileExists(uri)
+	if err != nil {
+		return "", fmt.Errorf("%w: '%s': %v", ErrTemplateRead, name, err)
+	}
+	if !exists {
+		return "", fmt.Errorf("%w: '%s' in '%s'", ErrTemplateNotFound, name, s.dir)
+	}
+
+	content, err := s.fs.ReadFile(uri)
+	if err != nil {
+		return "", fmt.Errorf("%w: '%s': %v", ErrTemplateRead, name, err)
+	}
+	return string(content), nil
+}
+
+func Fields(content string) ([]string, error) {
+	tmpl, err := newTemplate(Variables{}).Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTemplateParse, err)
+	}
+
+	seen := make(map[string]bool)
+	var fields []string
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.FieldNode:
+			if len(n.Ident) > 0 && !seen[n.Ident[0]] {
+				seen[n.Ident[0]] = true
+				fields = append(fields, n.Ident[0])
+			}
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		}
+	}
+	if tmpl.Tree != nil {
+		walk(tmpl.Root)
+	}
+	return fields, nil
+}
+
+func Expand(content string, vars Variables) (string, error) {
+	tmpl, err := newTemplate(vars).Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrTemplateParse, err)
+	}
+
+	fields := vars.Fields
+	if fields == nil {
+		fields = make(map[string]string)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, fields); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrTemplateExecute, err)
+	}
+	return out.String(), nil
+}
+
+func newTemplate(vars Variables) *template.Template {
+	now := vars.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	return template.New("note").Option("missingkey=zero").Funcs(template.FuncMap{
+		"date": func(layout ...string) string {
+			return now.Format(layoutOrDefault(layout, defaultDateLayout))
+		},
+		"time": func(layout ...string) string {
+			return now.Format(layoutOrDefault(layout, defaultTimeLayout))
+		},
+		"title": func() string {
+			return vars.Title
+		},
+		"uuid": newUUID,
+	})
+}
+
+func layoutOrDefault(layout []string, fallback string) string {
+	if len(layout) > 0 && layout[0] != "" {
+		return layout[0]
+	}
+	return fallback
+}
+
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
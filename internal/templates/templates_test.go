@@ -0,0 +1,103 @@
+package templates
+
+import (
+	"errors"
+	"markdown-editor/internal/fileservice"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestExpand(t *testing.T) {
+	now := time.Date(2024, 5, 6, 14, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		content string
+		vars    Variables
+		want    string
+		wantErr error
+	}{
+		{"title", "# {{title}}", Variables{Title: "Plan"}, "# Plan", nil},
+		{"date default", "{{date}} {{time}}", Variables{Now: now}, "2024-05-06 14:30", nil},
+		{"date layout", `{{date "Jan 2, 2006"}}`, Variables{Now: now}, "May 6, 2024", nil},
+		{"fields", "{{.Client}}: {{.Topic}}", Variables{Fields: map[string]string{"Client": "Acme", "Topic": "Q3"}}, "Acme: Q3", nil},
+		{"missing field", "[{{.Missing}}]", Variables{}, "[]", nil},
+		{"parse error", "{{title", Variables{}, "", ErrTemplateParse},
+		{"execute error", "{{date 1}}", Variables{}, "", ErrTemplateExecute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Expand(tt.content, tt.vars)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expand() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Expand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandUUID(t *testing.T) {
+	got, err := Expand("{{uuid}}", Variables{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(got) {
+		t.Errorf("uuid = %q, want a version 4 UUID", got)
+	}
+}
+
+func TestFields(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"{{title}} {{date}}", nil},
+		{"{{.A}} {{.B}} {{.A}}", []string{"A", "B"}},
+		{"{{if .Done}}{{.Yes}}{{else}}{{.No}}{{end}}", []string{"Done", "Yes", "No"}},
+		{"{{with .Owner}}{{.}}{{end}}", []string{"Owner"}},
+	}
+	for _, tt := range tests {
+		got, err := Fields(tt.content)
+		if err != nil {
+			t.Fatalf("Fields(%q) error = %v", tt.content, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Fields(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"meeting.md", "work/standup.md", "notes.txt", ".hidden/secret.md"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := New(dir, fileservice.New()).List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tmpl := range list {
+		names = append(names, tmpl.Name)
+	}
+	if want := []string{"meeting", "work/standup"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("List() = %v, want %v", names, want)
+	}
+
+	missing, err := New(filepath.Join(dir, "missing"), fileservice.New()).List()
+	if err != nil || missing != nil {
+		t.Errorf("List() of a missing folder = %v, %v, want nil, nil", missing, err)
+	}
+}
@@ -0,0 +1,179 @@
+package attachmentscomponent
+
+import (
+	"fmt"
+	"log"
+	"markdown-editor/internal/index"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const maxListedNotes = 3
+
+type AttachmentsComponent struct {
+	attachmentList *widget.List
+	all            []index.Attachment
+	visible        []index.Attachment
+	root           string
+
+	orphansOnly *widget.Check
+	count       *widget.Label
+
+	OnOpen    func(index.Attachment)
+	OnRefresh func()
+	OnCleanup func()
+
+	widget fyne.CanvasObject
+}
+
+func NewAttachmentsComponent(onOpen func(index.Attachment), onRefresh func(), onCleanup func()) *AttachmentsComponent {
+	ac := &AttachmentsComponent{
+		count:     widget.NewLabel(""),
+		OnOpen:    onOpen,
+		OnRefresh: onRefresh,
+		OnCleanup: onCleanup,
+	}
+	ac.orphansOnly = widget.NewCheck("Unreferenced only", func(bool) { ac.applyFilter() })
+
+	ac.attachmentList = widget.NewList(
+		func() int { return len(ac.visible) },
+		func() fyne.CanvasObject {
+			detail := widget.NewLabel("template")
+			detail.TextStyle = fyne.TextStyle{Italic: true}
+			detail.Truncation = fyne.TextTruncateEllipsis
+			name := widget.NewLabel("template")
+			name.Truncation = fyne.TextTruncateEllipsis
+			return container.NewVBox(name, detail)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			container, ok := item.(*fyne.Container)
+			if !ok {
+				log.Printf("Error: Failed to cast item to container for attachment ID %d", id)
+				return
+			}
+			name, ok := container.Objects[0].(*widget.Label)
+			if !ok {
+				log.Printf("Error: Failed to cast object to label for attachment ID %d", id)
+				return
+			}
+			detail, ok := container.Objects[1].(*widget.Label)
+			if !ok {
+				log.Printf("Error: Failed to cast object to label for attachment ID %d", id)
+				return
+			}
+
+			attachment := ac.visible[id]
+			name.SetText(ac.relative(attachment.Path))
+			detail.SetText(ac.describe(attachment))
+			if attachment.Orphaned() {
+				detail.Importance = widget.WarningImportance
+			} else {
+				detail.Importance = widget.MediumImportance
+			}
+			detail.Refresh()
+		},
+	)
+	ac.attachmentList.OnSelected = func(id widget.ListItemID) {
+		if id >= 0 && id < len(ac.visible) && ac.OnOpen != nil {
+			ac.OnOpen(ac.visible[id])
+		}
+		ac.attachmentList.UnselectAll()
+	}
+
+	refreshButton := widget.NewButtonWithIcon("Refresh", theme.ViewRefreshIcon(), func() {
+		if ac.OnRefresh != nil {
+			ac.OnRefresh()
+		}
+	})
+	cleanupButton := widget.NewButtonWithIcon("Clean Up", theme.DeleteIcon(), func() {
+		if ac.OnCleanup != nil {
+			ac.OnCleanup()
+		}
+	})
+
+	ac.widget = container.NewBorder(
+		container.NewVBox(
+			container.NewGridWithColumns(2, refreshButton, cleanupButton),
+			ac.orphansOnly,
+			ac.count,
+		),
+		nil, nil, nil,
+		ac.attachmentList,
+	)
+	return ac
+}
+
+func (ac *AttachmentsComponent) SetRoot(root string) {
+	ac.root = root
+}
+
+func (ac *AttachmentsComponent) SetAttachments(attachments []index.Attachment) {
+	ac.all = attachments
+	ac.applyFilter()
+}
+
+func (ac *AttachmentsComponent) Orphans() []index.Attachment {
+	var orphans []index.Attachment
+	for _, attachment := range ac.all {
+		if attachment.Orphaned() {
+			orphans = append(orphans, attachment)
+		}
+	}
+	return orphans
+}
+
+func (ac *AttachmentsComponent) View() fyne.CanvasObject {
+	return ac.widget
+}
+
+func (ac *AttachmentsComponent) applyFilter() {
+	ac.visible = ac.visible[:0]
+	for _, attachment := range ac.all {
+		if ac.orphansOnly.Checked && attachment.Referenced() {
+			continue
+		}
+		ac.visible = append(ac.visible, attachment)
+	}
+
+	unreferenced := 0
+	for _, attachment := range ac.all {
+		if !attachment.Referenced() {
+			unreferenced++
+		}
+	}
+	ac.count.SetText(fmt.Sprintf("%d files, %d unreferenced, %d to clean up", len(ac.all), unreferenced, len(ac.Orphans())))
+	ac.attachmentList.Refresh()
+}
+
+func (ac *AttachmentsComponent) describe(attachment index.Attachment) string {
+	switch {
+	case attachment.Orphaned():
+		return "Unreferenced"
+	case !attachment.Referenced():
+		return "Unreferenced, kept by Clean Up"
+	}
+
+	names := make([]string, 0, min(len(attachment.Notes), maxListedNotes))
+	for _, note := range attachment.Notes[:min(len(attachment.Notes), maxListedNotes)] {
+		names = append(names, ac.relative(note))
+	}
+	text := strings.Join(names, ", ")
+	if extra := len(attachment.Notes) - maxListedNotes; extra > 0 {
+		text += fmt.Sprintf(" and %d more", extra)
+	}
+	return "Used in " + text
+}
+
+func (ac *AttachmentsComponent) relative(path string) string {
+	if ac.root != "" {
+		if rel, err := filepath.Rel(ac.root, path); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.Base(path)
+}
@@ -0,0 +1,108 @@
+package calendarcomponent
+
+import (
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+var weekdayNames = []string{"Mo", "Tu", "We", "Th", "Fr", "Sa", "Su"}
+
+type CalendarComponent struct {
+	month  time.Time
+	marked map[int]bool
+	title  *widget.Label
+	grid   *fyne.Container
+
+	OnSelectDate   func(time.Time)
+	OnMonthChanged func(time.Time)
+
+	widget fyne.CanvasObject
+}
+
+func NewCalendarComponent(onSelectDate func(time.Time), onMonthChanged func(time.Time)) *CalendarComponent {
+	cc := &CalendarComponent{
+		marked:         make(map[int]bool),
+		title:          widget.NewLabelWithStyle("", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		grid:           container.NewGridWithColumns(len(weekdayNames)),
+		OnSelectDate:   onSelectDate,
+		OnMonthChanged: onMonthChanged,
+	}
+
+	prevBtn := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+		cc.SetMonth(cc.month.AddDate(0, -1, 0))
+	})
+	nextBtn := widget.NewButtonWithIcon("", theme.NavigateNextIcon(), func() {
+		cc.SetMonth(cc.month.AddDate(0, 1, 0))
+	})
+
+	cc.widget = container.NewVBox(
+		container.NewBorder(nil, nil, prevBtn, nextBtn, cc.title),
+		cc.grid,
+	)
+	cc.SetMonth(time.Now())
+	return cc
+}
+
+func (cc *CalendarComponent) SetMonth(t time.Time) {
+	cc.month = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local)
+	cc.marked = make(map[int]bool)
+	cc.title.SetText(cc.month.Format("January 2006"))
+	cc.render()
+	if cc.OnMonthChanged != nil {
+		cc.OnMonthChanged(cc.month)
+	}
+}
+
+func (cc *CalendarComponent) Month() time.Time {
+	return cc.month
+}
+
+func (cc *CalendarComponent) SetMarkedDays(days map[int]bool) {
+	cc.marked = days
+	cc.render()
+}
+
+func (cc *CalendarComponent) View() fyne.CanvasObject {
+	return cc.widget
+}
+
+func (cc *CalendarComponent) render() {
+	cc.grid.RemoveAll()
+	for _, name := range weekdayNames {
+		cc.grid.Add(widget.NewLabelWithStyle(name, fyne.TextAlignCenter, fyne.TextStyle{Bold: true}))
+	}
+
+	offset := (int(cc.month.Weekday()) + 6) % 7
+	for range offset {
+		cc.grid.Add(widget.NewLabel(""))
+	}
+
+	today := time.Now()
+	for day := cc.month; day.Month() == cc.month.Month(); day = day.AddDate(0, 0, 1) {
+		date := day
+		btn := widget.NewButton(strconv.Itoa(day.Day()), func() {
+			if cc.OnSelectDate != nil {
+				cc.OnSelectDate(date)
+			}
+		})
+		switch {
+		case cc.marked[day.Day()]:
+			btn.Importance = widget.HighImportance
+		case sameDay(day, today):
+			btn.Importance = widget.MediumImportance
+		default:
+			btn.Importance = widget.LowImportance
+		}
+		cc.grid.Add(btn)
+	}
+	cc.grid.Refresh()
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.Month() == b.Month() && a.Day() == b.Day()
+}
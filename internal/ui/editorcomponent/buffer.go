@@ -0,0 +1,287 @@
+package editorcomponent
+
+import (
+	"strings"
+	"unicode"
+)
+
+const maxUndoChanges = 500
+
+type position struct {
+	line int
+	col  int
+}
+
+func (p position) before(o position) bool {
+	return p.line < o.line || (p.line == o.line && p.col < o.col)
+}
+
+type change struct {
+	start    position
+	removed  string
+	inserted string
+}
+
+type buffer struct {
+	lines [][]rune
+	undo  []change
+	redo  []change
+}
+
+func newBuffer(text string) *buffer {
+	b := &buffer{}
+	b.setText(text)
+	return b
+}
+
+func (b *buffer) setText(text string) {
+	parts := strings.Split(normalizeNewlines(text), "\n")
+	b.lines = make([][]rune, len(parts))
+	for i, part := range parts {
+		b.lines[i] = []rune(part)
+	}
+	b.undo, b.redo = nil, nil
+}
+
+func (b *buffer) text() string {
+	var sb strings.Builder
+	for i, line := range b.lines {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(string(line))
+	}
+	return sb.String()
+}
+
+func (b *buffer) positionAt(offset int) position {
+	for i, line := range b.lines {
+		if offset <= len(line) {
+			return position{line: i, col: max(0, offset)}
+		}
+		offset -= len(line) + 1
+	}
+	return b.end()
+}
+
+func (b *buffer) clamp(p position) position {
+	p.line = max(0, min(p.line, len(b.lines)-1))
+	p.col = max(0, min(p.col, len(b.lines[p.line])))
+	return p
+}
+
+func (b *buffer) end() position {
+	last := len(b.lines) - 1
+	return position{line: last, col: len(b.lines[last])}
+}
+
+func (b *buffer) textRange(start, end position) string {
+	if end.before(start) {
+		start, end = end, start
+	}
+	if start.line == end.line {
+		return string(b.lines[start.line][start.col:end.col])
+	}
+
+	var sb strings.Builder
+	sb.WriteString(string(b.lines[start.line][start.col:]))
+	for i := start.line + 1; i < end.line; i++ {
+		sb.WriteByte('\n')
+		sb.WriteString(string(b.lines[i]))
+	}
+	sb.WriteByte('\n')
+	sb.WriteString(string(b.lines[end.line][:end.col]))
+	return sb.String()
+}
+
+func (b *buffer) replace(start, end position, text string, merge bool) position {
+	if end.before(start) {
+		start, end = end, start
+	}
+	text = normalizeNewlines(text)
+	removed := b.textRange(start, end)
+	if removed == "" && text == "" {
+		return start
+	}
+
+	c := change{start: start, removed: removed, inserted: text}
+	if merge && b.mergeInsert(c) {
+		b.redo = nil
+		return b.apply(c)
+	}
+
+	b.undo = append(b.undo, c)
+	if len(b.undo) > maxUndoChanges {
+		b.undo = b.undo[1:]
+	}
+	b.redo = nil
+	return b.apply(c)
+}
+
+func (b *buffer) mergeInsert(c change) bool {
+	if len(b.undo) == 0 || c.removed != "" || strings.ContainsFunc(c.inserted, unicode.IsSpace) {
+		return false
+	}
+	last := &b.undo[len(b.undo)-1]
+	if last.removed != "" || strings.ContainsFunc(last.inserted, unicode.IsSpace) {
+		return false
+	}
+	if b.endOf(last.start, last.inserted) != c.start {
+		return false
+	}
+	last.inserted += c.inserted
+	return true
+}
+
+func (b *buffer) apply(c change) position {
+	b.remove(c.start, b.endOf(c.start, c.removed))
+	return b.insert(c.start, c.inserted)
+}
+
+func (b *buffer) undoChange() (position, int, bool) {
+	if len(b.undo) == 0 {
+		return position{}, 0, false
+	}
+	c := b.undo[len(b.undo)-1]
+	b.undo = b.undo[:len(b.undo)-1]
+	b.redo = append(b.redo, c)
+
+	b.remove(c.start, b.endOf(c.start, c.inserted))
+	return b.insert(c.start, c.removed), c.start.line, true
+}
+
+func (b *buffer) redoChange() (position, int, bool) {
+	if len(b.redo) == 0 {
+		return position{}, 0, false
+	}
+	c := b.redo[len(b.redo)-1]
+	b.redo = b.redo[:len(b.redo)-1]
+	b.undo = append(b.undo, c)
+	return b.apply(c), c.start.line, true
+}
+
+func shiftPosition(p, start, end, newEnd position) position {
+	switch {
+	case !start.before(p):
+		return p
+	case !p.before(end):
+		if p.line == end.line {
+			return position{line: newEnd.line, col: newEnd.col + p.col - end.col}
+		}
+		p.line += newEnd.line - end.line
+		return p
+	case newEnd.before(p):
+		return newEnd
+	default:
+		return p
+	}
+}
+
+func (b *buffer) endOf(start position, text string) position {
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		return position{line: start.line, col: start.col + len([]rune(text))}
+	}
+	return position{line: start.line + len(lines) - 1, col: len([]rune(lines[len(lines)-1]))}
+}
+
+func (b *buffer) remove(start, end position) {
+	if !start.before(end) {
+		return
+	}
+	head := b.lines[start.line][:start.col]
+	tail := b.lines[end.line][end.col:]
+	line := make([]rune, 0, len(head)+len(tail))
+	line = append(append(line, head...), tail...)
+
+	b.lines[start.line] = line
+	b.lines = append(b.lines[:start.line+1], b.lines[end.line+1:]...)
+}
+
+func (b *buffer) insert(p position, text string) position {
+	if text == "" {
+		return p
+	}
+	parts := strings.Split(text, "\n")
+	line := b.lines[p.line]
+	tail := append([]rune(nil), line[p.col:]...)
+
+	if len(parts) == 1 {
+		inserted := []rune(parts[0])
+		updated := make([]rune, 0, len(line)+len(inserted))
+		updated = append(append(append(updated, line[:p.col]...), inserted...), tail...)
+		b.lines[p.line] = updated
+		return position{line: p.line, col: p.col + len(inserted)}
+	}
+
+	added := make([][]rune, len(parts))
+	added[0] = append(append([]rune(nil), line[:p.col]...), []rune(parts[0])...)
+	for i := 1; i < len(parts)-1; i++ {
+		added[i] = []rune(parts[i])
+	}
+	last := []rune(parts[len(parts)-1])
+	added[len(parts)-1] = append(append([]rune(nil), last...), tail...)
+
+	lines := make([][]rune, 0, len(b.lines)+len(parts)-1)
+	lines = append(lines, b.lines[:p.line]...)
+	lines = append(lines, added...)
+	lines = append(lines, b.lines[p.line+1:]...)
+	b.lines = lines
+	return position{line: p.line + len(parts) - 1, col: len(last)}
+}
+
+func (b *buffer) wordBounds(p position) (position, position) {
+	line := b.lines[p.line]
+	start, end := p.col, p.col
+	for start > 0 && isWordRune(line[start-1]) {
+		start--
+	}
+	for end < len(line) && isWordRune(line[end]) {
+		end++
+	}
+	return position{line: p.line, col: start}, position{line: p.line, col: end}
+}
+
+func (b *buffer) wordLeft(p position) position {
+	if p.col == 0 {
+		if p.line == 0 {
+			return p
+		}
+		return position{line: p.line - 1, col: len(b.lines[p.line-1])}
+	}
+	line := b.lines[p.line]
+	col := p.col
+	for col > 0 && !isWordRune(line[col-1]) {
+		col--
+	}
+	for col > 0 && isWordRune(line[col-1]) {
+		col--
+	}
+	return position{line: p.line, col: col}
+}
+
+func (b *buffer) wordRight(p position) position {
+	line := b.lines[p.line]
+	if p.col == len(line) {
+		if p.line == len(b.lines)-1 {
+			return p
+		}
+		return position{line: p.line + 1}
+	}
+	col := p.col
+	for col < len(line) && !isWordRune(line[col]) {
+		col++
+	}
+	for col < len(line) && isWordRune(line[col]) {
+		col++
+	}
+	return position{line: p.line, col: col}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func normalizeNewlines(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+}
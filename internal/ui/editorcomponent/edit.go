@@ -2,36 +2,50 @@ package editorcomponent
 
 import (
 	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/container"
-	"fyne.io/fyne/v2/widget"
 )
 
 type EditComponent struct {
-	entry     *widget.Entry
-	container *container.Scroll
+	editor *textEditor
 }
 
 func NewEditComponent() *EditComponent {
-	entry := widget.NewMultiLineEntry()
-	entry.Wrapping = fyne.TextWrapWord
 	return &EditComponent{
-		entry:     entry,
-		container: container.NewScroll(entry),
+		editor: newTextEditor(),
 	}
 }
 
 func (ec *EditComponent) SetOnChanged(fn func(string)) {
-	ec.entry.OnChanged = fn
+	ec.editor.OnChanged = fn
 }
 
 func (ec *EditComponent) View() fyne.CanvasObject {
-	return ec.container
+	return ec.editor
 }
 
 func (ec *EditComponent) Content() string {
-	return ec.entry.Text
+	return ec.editor.Text()
 }
 
 func (ec *EditComponent) SetContent(text string) {
-	ec.entry.SetText(text)
+	ec.editor.SetText(text)
+}
+
+func (ec *EditComponent) ReplaceRange(start, end int, text string) {
+	ec.editor.Replace(start, end, text)
+}
+
+func (ec *EditComponent) InsertAtCursor(text string) {
+	ec.editor.Insert(text)
+}
+
+func (ec *EditComponent) SelectedText() string {
+	return ec.editor.SelectedText()
+}
+
+func (ec *EditComponent) CursorLine() int {
+	return ec.editor.cursor.line
+}
+
+func (ec *EditComponent) SetOnPaste(fn func() bool) {
+	ec.editor.OnPaste = fn
 }
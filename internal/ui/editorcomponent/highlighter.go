@@ -0,0 +1,77 @@
+package editorcomponent
+
+import (
+	"markdown-editor/internal/highlight"
+
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+type highlighter struct {
+	states []highlight.LineState
+	spans  [][]highlight.Span
+}
+
+func (h *highlighter) reset() {
+	h.states = h.states[:0]
+	h.spans = h.spans[:0]
+}
+
+func (h *highlighter) invalidate(line int) {
+	if line < len(h.spans) {
+		h.spans = h.spans[:line]
+		h.states = h.states[:line+1]
+	}
+}
+
+func (h *highlighter) lineSpans(lines [][]rune, n int) []highlight.Span {
+	if len(h.states) == 0 {
+		h.states = append(h.states, highlight.LineState{})
+	}
+	for len(h.spans) <= n && len(h.spans) < len(lines) {
+		i := len(h.spans)
+		spans, next := highlight.MarkdownLine(string(lines[i]), h.states[i])
+		h.spans = append(h.spans, spans)
+		h.states = append(h.states, next)
+	}
+	if n >= len(h.spans) {
+		return nil
+	}
+	return h.spans[n]
+}
+
+type gridStyles struct {
+	normal   map[highlight.Kind]widget.TextGridStyle
+	selected map[highlight.Kind]widget.TextGridStyle
+}
+
+func newGridStyles() *gridStyles {
+	selection := theme.Color(theme.ColorNameSelection)
+	styles := &gridStyles{
+		normal:   map[highlight.Kind]widget.TextGridStyle{},
+		selected: map[highlight.Kind]widget.TextGridStyle{},
+	}
+	styles.selected[highlight.KindPlain] = &widget.CustomTextGridStyle{BGColor: selection}
+
+	for _, kind := range highlight.Kinds() {
+		style := &widget.CustomTextGridStyle{TextStyle: kind.TextStyle()}
+		if name := kind.ColorName(); name != "" {
+			style.FGColor = theme.Color(name)
+		}
+		styles.normal[kind] = style
+		selected := *style
+		selected.BGColor = selection
+		styles.selected[kind] = &selected
+	}
+	return styles
+}
+
+func (s *gridStyles) style(kind highlight.Kind, selected bool) widget.TextGridStyle {
+	if selected {
+		if style, ok := s.selected[kind]; ok {
+			return style
+		}
+		return s.selected[highlight.KindPlain]
+	}
+	return s.normal[kind]
+}
@@ -0,0 +1,599 @@
+package editorcomponent
+
+import (
+	"image/color"
+	"markdown-editor/internal/highlight"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	tabWidth       = 4
+	caretWidth     = 2
+	scrollBarWidth = 4
+	scrollBarMin   = 16
+)
+
+type textEditor struct {
+	widget.BaseWidget
+
+	buf         *buffer
+	highlighter *highlighter
+	cursor      position
+	anchor      position
+	selecting   bool
+	shift       bool
+	focused     bool
+	dragging    bool
+	wantCol     int
+
+	top, left   int
+	rows, cols  int
+	cellSize    fyne.Size
+	scrollDelta float32
+
+	grid      *widget.TextGrid
+	caret     *canvas.Rectangle
+	scrollBar *canvas.Rectangle
+
+	OnChanged func(string)
+	OnPaste   func() bool
+}
+
+func newTextEditor() *textEditor {
+	e := &textEditor{
+		buf:         newBuffer(""),
+		highlighter: &highlighter{},
+		wantCol:     -1,
+		grid:        widget.NewTextGrid(),
+		caret:       canvas.NewRectangle(theme.Color(theme.ColorNamePrimary)),
+		scrollBar:   canvas.NewRectangle(color.Transparent),
+	}
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+func (e *textEditor) CreateRenderer() fyne.WidgetRenderer {
+	bg := canvas.NewRectangle(theme.Color(theme.ColorNameInputBackground))
+	return &textEditorRenderer{
+		editor:  e,
+		bg:      bg,
+		objects: []fyne.CanvasObject{bg, e.grid, e.caret, e.scrollBar},
+	}
+}
+
+func (e *textEditor) Text() string {
+	return e.buf.text()
+}
+
+func (e *textEditor) SetText(text string) {
+	e.buf.setText(text)
+	e.highlighter.reset()
+	e.cursor, e.anchor = position{}, position{}
+	e.selecting = false
+	e.top, e.left = 0, 0
+	e.wantCol = -1
+	e.Refresh()
+	if e.OnChanged != nil {
+		e.OnChanged(e.buf.text())
+	}
+}
+
+func (e *textEditor) Replace(start, end int, text string) {
+	from, to := e.buf.positionAt(start), e.buf.positionAt(end)
+	if to.before(from) {
+		from, to = to, from
+	}
+	if e.buf.textRange(from, to) == normalizeNewlines(text) {
+		return
+	}
+	newEnd := e.buf.replace(from, to, text, false)
+	e.cursor = e.buf.clamp(shiftPosition(e.cursor, from, to, newEnd))
+	e.anchor = e.buf.clamp(shiftPosition(e.anchor, from, to, newEnd))
+	e.wantCol = -1
+	e.highlighter.invalidate(from.line)
+	e.Refresh()
+	if e.OnChanged != nil {
+		e.OnChanged(e.buf.text())
+	}
+}
+
+func (e *textEditor) Insert(text string) {
+	e.replaceSelection(text, false)
+}
+
+func (e *textEditor) SelectedText() string {
+	start, end, ok := e.selection()
+	if !ok {
+		return ""
+	}
+	return e.buf.textRange(start, end)
+}
+
+func (e *textEditor) MinSize() fyne.Size {
+	e.ExtendBaseWidget(e)
+	return e.BaseWidget.MinSize()
+}
+
+func (e *textEditor) Cursor() desktop.Cursor {
+	return desktop.TextCursor
+}
+
+func (e *textEditor) AcceptsTab() bool {
+	return true
+}
+
+func (e *textEditor) FocusGained() {
+	e.focused = true
+	e.Refresh()
+}
+
+func (e *textEditor) FocusLost() {
+	e.focused = false
+	e.shift = false
+	e.Refresh()
+}
+
+func (e *textEditor) KeyDown(ev *fyne.KeyEvent) {
+	if ev.Name == desktop.KeyShiftLeft || ev.Name == desktop.KeyShiftRight {
+		e.shift = true
+	}
+}
+
+func (e *textEditor) KeyUp(ev *fyne.KeyEvent) {
+	if ev.Name == desktop.KeyShiftLeft || ev.Name == desktop.KeyShiftRight {
+		e.shift = false
+	}
+}
+
+func (e *textEditor) TypedRune(r rune) {
+	e.replaceSelection(string(r), true)
+}
+
+func (e *textEditor) TypedKey(ev *fyne.KeyEvent) {
+	line := e.buf.lines[e.cursor.line]
+	switch ev.Name {
+	case fyne.KeyLeft:
+		if start, _, ok := e.selection(); ok && !e.shift {
+			e.moveTo(start, false)
+			return
+		}
+		if e.cursor.col > 0 {
+			e.moveTo(position{line: e.cursor.line, col: e.cursor.col - 1}, e.shift)
+		} else if e.cursor.line > 0 {
+			e.moveTo(position{line: e.cursor.line - 1, col: len(e.buf.lines[e.cursor.line-1])}, e.shift)
+		}
+	case fyne.KeyRight:
+		if _, end, ok := e.selection(); ok && !e.shift {
+			e.moveTo(end, false)
+			return
+		}
+		if e.cursor.col < len(line) {
+			e.moveTo(position{line: e.cursor.line, col: e.cursor.col + 1}, e.shift)
+		} else if e.cursor.line < len(e.buf.lines)-1 {
+			e.moveTo(position{line: e.cursor.line + 1}, e.shift)
+		}
+	case fyne.KeyUp:
+		e.moveLines(-1)
+	case fyne.KeyDown:
+		e.moveLines(1)
+	case fyne.KeyPageUp:
+		e.moveLines(-max(1, e.rows-1))
+	case fyne.KeyPageDown:
+		e.moveLines(max(1, e.rows-1))
+	case fyne.KeyHome:
+		col := indentation(line)
+		if e.cursor.col == col {
+			col = 0
+		}
+		e.moveTo(position{line: e.cursor.line, col: col}, e.shift)
+	case fyne.KeyEnd:
+		e.moveTo(position{line: e.cursor.line, col: len(line)}, e.shift)
+	case fyne.KeyBackspace:
+		if _, _, ok := e.selection(); ok {
+			e.replaceSelection("", false)
+			return
+		}
+		start := e.cursor
+		if start.col > 0 {
+			start.col--
+		} else if start.line > 0 {
+			start = position{line: start.line - 1, col: len(e.buf.lines[start.line-1])}
+		}
+		e.replaceRange(start, e.cursor, "", false)
+	case fyne.KeyDelete:
+		if _, _, ok := e.selection(); ok {
+			e.replaceSelection("", false)
+			return
+		}
+		end := e.cursor
+		if end.col < len(line) {
+			end.col++
+		} else if end.line < len(e.buf.lines)-1 {
+			end = position{line: end.line + 1}
+		}
+		e.replaceRange(e.cursor, end, "", false)
+	case fyne.KeyReturn, fyne.KeyEnter:
+		e.replaceSelection("\n"+string(line[:min(indentation(line), e.cursor.col)]), false)
+	case fyne.KeyTab:
+		e.replaceSelection("\t", false)
+	}
+}
+
+func (e *textEditor) TypedShortcut(shortcut fyne.Shortcut) {
+	switch s := shortcut.(type) {
+	case *fyne.ShortcutCopy:
+		if text := e.SelectedText(); text != "" {
+			s.Clipboard.SetContent(text)
+		}
+	case *fyne.ShortcutCut:
+		if text := e.SelectedText(); text != "" {
+			s.Clipboard.SetContent(text)
+			e.replaceSelection("", false)
+		}
+	case *fyne.ShortcutPaste:
+		if e.OnPaste != nil && e.OnPaste() {
+			return
+		}
+		if text := s.Clipboard.Content(); text != "" {
+			e.replaceSelection(text, false)
+		}
+	case *fyne.ShortcutSelectAll:
+		e.anchor = position{}
+		e.cursor = e.buf.end()
+		e.selecting = true
+		e.Refresh()
+	case *fyne.ShortcutUndo:
+		e.restore(e.buf.undoChange())
+	case *fyne.ShortcutRedo:
+		e.restore(e.buf.redoChange())
+	case *desktop.CustomShortcut:
+		if !e.navigate(s) {
+			e.forwardShortcut(shortcut)
+		}
+	default:
+		e.forwardShortcut(shortcut)
+	}
+}
+
+func (e *textEditor) navigate(s *desktop.CustomShortcut) bool {
+	shift := s.Modifier&fyne.KeyModifierShift != 0
+	if s.Modifier&^fyne.KeyModifierShift != fyne.KeyModifierShortcutDefault {
+		return false
+	}
+
+	switch s.KeyName {
+	case fyne.KeyHome:
+		e.moveTo(position{}, shift)
+	case fyne.KeyEnd:
+		e.moveTo(e.buf.end(), shift)
+	case fyne.KeyLeft:
+		e.moveTo(e.buf.wordLeft(e.cursor), shift)
+	case fyne.KeyRight:
+		e.moveTo(e.buf.wordRight(e.cursor), shift)
+	case fyne.KeyZ:
+		if !shift {
+			return false
+		}
+		e.restore(e.buf.redoChange())
+	default:
+		return false
+	}
+	return true
+}
+
+func (e *textEditor) forwardShortcut(shortcut fyne.Shortcut) {
+	c := fyne.CurrentApp().Driver().CanvasForObject(e)
+	if handler, ok := c.(fyne.Shortcutable); ok {
+		handler.TypedShortcut(shortcut)
+	}
+}
+
+func (e *textEditor) Tapped(ev *fyne.PointEvent) {
+	e.requestFocus()
+	e.moveTo(e.positionAt(ev.Position), e.shift)
+}
+
+func (e *textEditor) DoubleTapped(ev *fyne.PointEvent) {
+	start, end := e.buf.wordBounds(e.positionAt(ev.Position))
+	e.anchor, e.cursor = start, end
+	e.selecting = start != end
+	e.wantCol = -1
+	e.Refresh()
+}
+
+func (e *textEditor) Dragged(ev *fyne.DragEvent) {
+	if !e.dragging {
+		e.dragging = true
+		e.requestFocus()
+		e.anchor = e.positionAt(ev.Position.Subtract(ev.Dragged))
+	}
+	e.cursor = e.positionAt(ev.Position)
+	e.selecting = e.cursor != e.anchor
+	e.wantCol = -1
+	e.ensureCursorVisible()
+	e.Refresh()
+}
+
+func (e *textEditor) DragEnd() {
+	e.dragging = false
+}
+
+func (e *textEditor) Scrolled(ev *fyne.ScrollEvent) {
+	if e.cellSize.Height == 0 {
+		return
+	}
+	e.scrollDelta += ev.Scrolled.DY
+	lines := int(e.scrollDelta / e.cellSize.Height)
+	e.scrollDelta -= float32(lines) * e.cellSize.Height
+
+	e.top = max(0, min(e.top-lines, len(e.buf.lines)-1))
+	if ev.Scrolled.DX != 0 {
+		e.left = max(0, e.left-int(ev.Scrolled.DX/e.cellSize.Width))
+	}
+	e.Refresh()
+}
+
+func (e *textEditor) requestFocus() {
+	if c := fyne.CurrentApp().Driver().CanvasForObject(e); c != nil && !e.focused {
+		c.Focus(e)
+	}
+}
+
+func (e *textEditor) selection() (position, position, bool) {
+	if !e.selecting || e.anchor == e.cursor {
+		return e.cursor, e.cursor, false
+	}
+	if e.anchor.before(e.cursor) {
+		return e.anchor, e.cursor, true
+	}
+	return e.cursor, e.anchor, true
+}
+
+func (e *textEditor) moveTo(p position, extend bool) {
+	if extend && !e.selecting {
+		e.anchor = e.cursor
+	}
+	e.selecting = extend
+	e.cursor = e.buf.clamp(p)
+	e.wantCol = -1
+	e.ensureCursorVisible()
+	e.Refresh()
+}
+
+func (e *textEditor) moveLines(delta int) {
+	col := e.wantCol
+	if col < 0 {
+		col = visualColumn(e.buf.lines[e.cursor.line], e.cursor.col)
+	}
+	line := max(0, min(e.cursor.line+delta, len(e.buf.lines)-1))
+	e.moveTo(position{line: line, col: runeIndex(e.buf.lines[line], col)}, e.shift)
+	e.wantCol = col
+}
+
+func (e *textEditor) replaceSelection(text string, merge bool) {
+	start, end, _ := e.selection()
+	e.replaceRange(start, end, text, merge)
+}
+
+func (e *textEditor) replaceRange(start, end position, text string, merge bool) {
+	first := min(start.line, end.line)
+	e.cursor = e.buf.replace(start, end, text, merge)
+	e.changed(first)
+}
+
+func (e *textEditor) restore(cursor position, line int, ok bool) {
+	if !ok {
+		return
+	}
+	e.cursor = e.buf.clamp(cursor)
+	e.changed(line)
+}
+
+func (e *textEditor) changed(line int) {
+	e.selecting = false
+	e.wantCol = -1
+	e.highlighter.invalidate(line)
+	e.ensureCursorVisible()
+	e.Refresh()
+	if e.OnChanged != nil {
+		e.OnChanged(e.buf.text())
+	}
+}
+
+func (e *textEditor) ensureCursorVisible() {
+	if e.rows > 0 {
+		if e.cursor.line < e.top {
+			e.top = e.cursor.line
+		} else if e.cursor.line >= e.top+e.rows {
+			e.top = e.cursor.line - e.rows + 1
+		}
+	}
+	if e.cols > 0 {
+		col := visualColumn(e.buf.lines[e.cursor.line], e.cursor.col)
+		if col < e.left {
+			e.left = col
+		} else if col >= e.left+e.cols {
+			e.left = col - e.cols + 1
+		}
+	}
+}
+
+func (e *textEditor) positionAt(pos fyne.Position) position {
+	if e.cellSize.Height == 0 || e.cellSize.Width == 0 {
+		return e.cursor
+	}
+	pad := theme.InnerPadding()
+	line := e.top + int(math.Floor(float64((pos.Y-pad)/e.cellSize.Height)))
+	line = max(0, min(line, len(e.buf.lines)-1))
+	col := e.left + int(math.Round(float64((pos.X-pad)/e.cellSize.Width)))
+	return position{line: line, col: runeIndex(e.buf.lines[line], max(0, col))}
+}
+
+func (e *textEditor) layout(size fyne.Size) {
+	e.cellSize = fyne.MeasureText("M", theme.TextSize(), fyne.TextStyle{Monospace: true})
+	e.cellSize.Width = float32(math.Round(float64(e.cellSize.Width)))
+	e.cellSize.Height = float32(math.Round(float64(e.cellSize.Height)))
+
+	pad := theme.InnerPadding()
+	inner := fyne.NewSize(size.Width-2*pad-scrollBarWidth, size.Height-2*pad)
+	e.rows = max(1, int(inner.Height/e.cellSize.Height))
+	e.cols = max(1, int(inner.Width/e.cellSize.Width))
+
+	e.grid.Move(fyne.NewPos(pad, pad))
+	e.grid.Resize(inner)
+	e.render()
+}
+
+func (e *textEditor) render() {
+	styles := newGridStyles()
+	selStart, selEnd, hasSelection := e.selection()
+	e.top = max(0, min(e.top, len(e.buf.lines)-1))
+
+	rows := make([]widget.TextGridRow, 0, e.rows)
+	for n := e.top; n < len(e.buf.lines) && n < e.top+e.rows; n++ {
+		line := e.buf.lines[n]
+		spans := e.highlighter.lineSpans(e.buf.lines, n)
+		cells := make([]widget.TextGridCell, 0, min(len(line), e.cols+1))
+
+		visual, span := 0, 0
+		for i, r := range line {
+			for span < len(spans) && spans[span].End <= i {
+				span++
+			}
+			kind := highlight.KindPlain
+			if span < len(spans) && spans[span].Start <= i {
+				kind = spans[span].Kind
+			}
+			current := position{line: n, col: i}
+			selected := hasSelection && !current.before(selStart) && current.before(selEnd)
+			style := styles.style(kind, selected)
+
+			width := 1
+			if r == '\t' {
+				width = tabWidth - visual%tabWidth
+				r = ' '
+			}
+			for range width {
+				if visual >= e.left && visual <= e.left+e.cols {
+					cells = append(cells, widget.TextGridCell{Rune: r, Style: style})
+				}
+				visual++
+			}
+		}
+		if hasSelection && n >= selStart.line && n < selEnd.line && visual >= e.left {
+			cells = append(cells, widget.TextGridCell{Rune: ' ', Style: styles.style(highlight.KindPlain, true)})
+		}
+		rows = append(rows, widget.TextGridRow{Cells: cells})
+	}
+	e.grid.Rows = rows
+	e.grid.Refresh()
+
+	e.renderCaret()
+	e.renderScrollBar()
+}
+
+func (e *textEditor) renderCaret() {
+	col := visualColumn(e.buf.lines[e.cursor.line], e.cursor.col) - e.left
+	row := e.cursor.line - e.top
+	if !e.focused || row < 0 || row >= e.rows || col < 0 || col > e.cols {
+		e.caret.Hide()
+		return
+	}
+
+	pad := theme.InnerPadding()
+	e.caret.FillColor = theme.Color(theme.ColorNamePrimary)
+	e.caret.Move(fyne.NewPos(pad+float32(col)*e.cellSize.Width, pad+float32(row)*e.cellSize.Height))
+	e.caret.Resize(fyne.NewSize(caretWidth, e.cellSize.Height))
+	e.caret.Show()
+	e.caret.Refresh()
+}
+
+func (e *textEditor) renderScrollBar() {
+	total := len(e.buf.lines)
+	size := e.Size()
+	if total <= e.rows || size.Height == 0 {
+		e.scrollBar.Hide()
+		return
+	}
+
+	height := max(scrollBarMin, size.Height*float32(e.rows)/float32(total))
+	y := (size.Height - height) * float32(e.top) / float32(max(1, total-e.rows))
+	e.scrollBar.FillColor = theme.Color(theme.ColorNameScrollBar)
+	e.scrollBar.Move(fyne.NewPos(size.Width-scrollBarWidth, min(y, size.Height-height)))
+	e.scrollBar.Resize(fyne.NewSize(scrollBarWidth, height))
+	e.scrollBar.Show()
+	e.scrollBar.Refresh()
+}
+
+type textEditorRenderer struct {
+	editor  *textEditor
+	bg      *canvas.Rectangle
+	objects []fyne.CanvasObject
+}
+
+func (r *textEditorRenderer) Layout(size fyne.Size) {
+	r.bg.Resize(size)
+	r.editor.layout(size)
+}
+
+func (r *textEditorRenderer) MinSize() fyne.Size {
+	cell := fyne.MeasureText("M", theme.TextSize(), fyne.TextStyle{Monospace: true})
+	pad := theme.InnerPadding()
+	return fyne.NewSize(cell.Width*10+2*pad, cell.Height*3+2*pad)
+}
+
+func (r *textEditorRenderer) Refresh() {
+	r.bg.FillColor = theme.Color(theme.ColorNameInputBackground)
+	r.bg.Refresh()
+	r.editor.render()
+}
+
+func (r *textEditorRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *textEditorRenderer) Destroy() {}
+
+func indentation(line []rune) int {
+	n := 0
+	for n < len(line) && (line[n] == ' ' || line[n] == '\t') {
+		n++
+	}
+	return n
+}
+
+func visualColumn(line []rune, col int) int {
+	visual := 0
+	for _, r := range line[:min(col, len(line))] {
+		if r == '\t' {
+			visual += tabWidth - visual%tabWidth
+		} else {
+			visual++
+		}
+	}
+	return visual
+}
+
+func runeIndex(line []rune, visual int) int {
+	current := 0
+	for i, r := range line {
+		width := 1
+		if r == '\t' {
+			width = tabWidth - current%tabWidth
+		}
+		if current+width > visual {
+			if visual-current > width/2 {
+				return i + 1
+			}
+			return i
+		}
+		current += width
+	}
+	return len(line)
+}
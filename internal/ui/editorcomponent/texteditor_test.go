@@ -0,0 +1,99 @@
+package editorcomponent
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+)
+
+func TestReplaceKeepsCursorAndUndo(t *testing.T) {
+	test.NewTempApp(t)
+
+	tests := []struct {
+		name       string
+		text       string
+		cursor     position
+		start, end int
+		insert     string
+		want       string
+		wantCursor position
+	}{
+		{
+			name:       "edit before cursor on same line",
+			text:       "- [ ] task one",
+			cursor:     position{line: 0, col: 10},
+			start:      3,
+			end:        4,
+			insert:     "x",
+			want:       "- [x] task one",
+			wantCursor: position{line: 0, col: 10},
+		},
+		{
+			name:       "lines added above cursor",
+			text:       "body\nmore",
+			cursor:     position{line: 1, col: 2},
+			start:      0,
+			end:        0,
+			insert:     "---\ntitle: a\n---\n",
+			want:       "---\ntitle: a\n---\nbody\nmore",
+			wantCursor: position{line: 4, col: 2},
+		},
+		{
+			name:       "edit after cursor",
+			text:       "one\n#old tag",
+			cursor:     position{line: 0, col: 1},
+			start:      5,
+			end:        8,
+			insert:     "new",
+			want:       "one\n#new tag",
+			wantCursor: position{line: 0, col: 1},
+		},
+		{
+			name:       "cursor inside removed text",
+			text:       "keep\nremove me\nend",
+			cursor:     position{line: 1, col: 8},
+			start:      5,
+			end:        14,
+			insert:     "x",
+			want:       "keep\nx\nend",
+			wantCursor: position{line: 1, col: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTextEditor()
+			e.Resize(fyne.NewSize(400, 300))
+			e.replaceRange(position{}, position{}, tt.text, false)
+			e.cursor = tt.cursor
+
+			e.Replace(tt.start, tt.end, tt.insert)
+			if got := e.Text(); got != tt.want {
+				t.Fatalf("Text() = %q, want %q", got, tt.want)
+			}
+			if e.cursor != tt.wantCursor {
+				t.Errorf("cursor = %+v, want %+v", e.cursor, tt.wantCursor)
+			}
+			if len(e.buf.undo) != 2 {
+				t.Fatalf("undo history has %d changes, want 2", len(e.buf.undo))
+			}
+
+			e.restore(e.buf.undoChange())
+			if got := e.Text(); got != tt.text {
+				t.Errorf("after undo Text() = %q, want %q", got, tt.text)
+			}
+		})
+	}
+}
+
+func TestReplaceUnchangedTextIsNoop(t *testing.T) {
+	test.NewTempApp(t)
+
+	e := newTextEditor()
+	e.SetText("same")
+	e.Replace(0, 4, "same")
+	if len(e.buf.undo) != 0 {
+		t.Errorf("undo history has %d changes, want 0", len(e.buf.undo))
+	}
+}
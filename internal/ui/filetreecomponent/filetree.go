@@ -16,6 +16,7 @@ type FiletreeComponent struct {
 	fileList   *widget.List
 	files      []fyne.URI
 	currentDir fyne.ListableURI
+	filter     func(fyne.URI) bool
 
 	OnSelectFile func(fyne.URI)
 	OnDeleteFile func(fyne.URI, int)
@@ -104,6 +105,10 @@ func (ftc *FiletreeComponent) SetDirectory(dir fyne.ListableURI) {
 	ftc.currentDir = dir
 }
 
+func (ftc *FiletreeComponent) SetFilter(filter func(fyne.URI) bool) {
+	ftc.filter = filter
+}
+
 func (ftc *FiletreeComponent) Refresh() {
 	if ftc.currentDir == nil {
 		app.ShowErrorNotification("File Tree Error", "Workspace directory not set.", nil)
@@ -119,7 +124,10 @@ func (ftc *FiletreeComponent) Refresh() {
 	}
 
 	for _, f := range items {
-		if strings.HasSuffix(strings.ToLower(f.Name()), ".md") {
+		if !strings.HasSuffix(strings.ToLower(f.Name()), ".md") {
+			continue
+		}
+		if ftc.filter == nil || ftc.filter(f) {
 			ftc.files = append(ftc.files, f)
 		}
 	}
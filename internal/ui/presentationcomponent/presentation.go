@@ -0,0 +1,188 @@
+package presentationcomponent
+
+import (
+	"fmt"
+	"markdown-editor/internal/diagram"
+	"markdown-editor/internal/slides"
+	"markdown-editor/internal/ui/previewcomponent"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	slideScale  float32 = 1.8
+	noNotesText         = "*No speaker notes for this slide.*"
+)
+
+type slideTheme struct {
+	fyne.Theme
+}
+
+func (t *slideTheme) Size(name fyne.ThemeSizeName) float32 {
+	return t.Theme.Size(name) * slideScale
+}
+
+type PresentationComponent struct {
+	deck    []slides.Slide
+	current int
+	closed  bool
+	stop    chan struct{}
+
+	window      fyne.Window
+	notesWindow fyne.Window
+	slide       *previewcomponent.PreviewComponent
+	slideView   *container.ThemeOverride
+	notes       *previewcomponent.PreviewComponent
+	counter     *widget.Label
+	position    *widget.Label
+	upNext      *widget.Label
+	elapsed     *widget.Label
+
+	OnClosed func()
+}
+
+func NewPresentationComponent(deck []slides.Slide, baseDir string, diagrams diagram.Renderer) *PresentationComponent {
+	p := &PresentationComponent{
+		deck:     deck,
+		stop:     make(chan struct{}),
+		slide:    newPreview(baseDir, diagrams),
+		notes:    newPreview(baseDir, diagrams),
+		counter:  widget.NewLabel(""),
+		position: widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		upNext:   widget.NewLabel(""),
+		elapsed:  widget.NewLabelWithStyle("00:00", fyne.TextAlignTrailing, fyne.TextStyle{Monospace: true}),
+	}
+	p.upNext.Truncation = fyne.TextTruncateEllipsis
+	p.slideView = container.NewThemeOverride(container.NewPadded(p.slide.View()), &slideTheme{Theme: theme.Current()})
+
+	a := fyne.CurrentApp()
+	p.window = a.NewWindow("Presentation")
+	p.window.SetContent(container.NewBorder(nil, container.NewHBox(layout.NewSpacer(), p.counter), nil, nil, p.slideView))
+
+	p.notesWindow = a.NewWindow("Speaker Notes")
+	p.notesWindow.SetContent(container.NewBorder(
+		container.NewVBox(container.NewHBox(p.position, layout.NewSpacer(), p.elapsed), p.upNext, widget.NewSeparator()),
+		container.NewGridWithColumns(3,
+			widget.NewButtonWithIcon("Previous", theme.NavigateBackIcon(), p.Previous),
+			widget.NewButtonWithIcon("Next", theme.NavigateNextIcon(), p.Next),
+			widget.NewButtonWithIcon("End Show", theme.CancelIcon(), p.Close),
+		),
+		nil, nil,
+		p.notes.View(),
+	))
+	p.notesWindow.Resize(fyne.NewSize(520, 480))
+
+	for _, w := range []fyne.Window{p.window, p.notesWindow} {
+		w.Canvas().SetOnTypedKey(p.typedKey)
+		w.SetOnClosed(p.Close)
+	}
+	return p
+}
+
+func newPreview(baseDir string, diagrams diagram.Renderer) *previewcomponent.PreviewComponent {
+	preview := previewcomponent.NewPreviewComponent()
+	preview.SetBaseDir(baseDir)
+	preview.SetDiagramRenderer(diagrams)
+	return preview
+}
+
+func (p *PresentationComponent) Show(start int) {
+	p.Go(start)
+	p.notesWindow.Show()
+	p.window.SetFullScreen(true)
+	p.window.Show()
+	p.window.RequestFocus()
+	go p.tick(time.Now())
+}
+
+func (p *PresentationComponent) Close() {
+	if p.closed {
+		return
+	}
+	p.closed = true
+	close(p.stop)
+	p.notesWindow.Close()
+	p.window.Close()
+	if p.OnClosed != nil {
+		p.OnClosed()
+	}
+}
+
+func (p *PresentationComponent) Next() {
+	p.Go(p.current + 1)
+}
+
+func (p *PresentationComponent) Previous() {
+	p.Go(p.current - 1)
+}
+
+func (p *PresentationComponent) Go(i int) {
+	if p.closed || len(p.deck) == 0 {
+		return
+	}
+	p.current = max(0, min(i, len(p.deck)-1))
+	slide := p.deck[p.current]
+
+	p.slide.Update(slide.Content)
+	if scroll, ok := p.slide.View().(*container.Scroll); ok {
+		scroll.ScrollToTop()
+	}
+	p.slideView.Refresh()
+	p.window.Canvas().Unfocus()
+
+	notes := slide.Notes
+	if notes == "" {
+		notes = noNotesText
+	}
+	p.notes.Update(notes)
+	p.notesWindow.Canvas().Unfocus()
+
+	p.counter.SetText(fmt.Sprintf("%d / %d", p.current+1, len(p.deck)))
+	p.position.SetText(fmt.Sprintf("Slide %d of %d", p.current+1, len(p.deck)))
+	if p.current+1 < len(p.deck) {
+		title := p.deck[p.current+1].Title()
+		if title == "" {
+			title = fmt.Sprintf("Slide %d", p.current+2)
+		}
+		p.upNext.SetText("Next: " + title)
+	} else {
+		p.upNext.SetText("Last slide")
+	}
+}
+
+func (p *PresentationComponent) typedKey(ev *fyne.KeyEvent) {
+	switch ev.Name {
+	case fyne.KeyRight, fyne.KeyDown, fyne.KeyPageDown, fyne.KeySpace, fyne.KeyReturn, fyne.KeyEnter:
+		p.Next()
+	case fyne.KeyLeft, fyne.KeyUp, fyne.KeyPageUp, fyne.KeyBackspace:
+		p.Previous()
+	case fyne.KeyHome:
+		p.Go(0)
+	case fyne.KeyEnd:
+		p.Go(len(p.deck) - 1)
+	case fyne.KeyEscape:
+		p.Close()
+	}
+}
+
+func (p *PresentationComponent) tick(started time.Time) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case now := <-ticker.C:
+			elapsed := now.Sub(started).Round(time.Second)
+			text := fmt.Sprintf("%02d:%02d", int(elapsed.Minutes()), int(elapsed.Seconds())%60)
+			fyne.Do(func() {
+				p.elapsed.SetText(text)
+			})
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package previewcomponent
+
+import (
+	"image/color"
+	"markdown-editor/internal/markdown"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const calloutTintAlpha = 0x1a
+
+type calloutStyle struct {
+	color fyne.ThemeColorName
+	icon  fyne.Resource
+}
+
+var calloutStyles = map[string]calloutStyle{
+	"note":      {color: theme.ColorNamePrimary, icon: theme.InfoIcon()},
+	"tip":       {color: theme.ColorNameSuccess, icon: theme.ConfirmIcon()},
+	"important": {color: theme.ColorNameHyperlink, icon: theme.QuestionIcon()},
+	"warning":   {color: theme.ColorNameWarning, icon: theme.WarningIcon()},
+	"caution":   {color: theme.ColorNameError, icon: theme.ErrorIcon()},
+}
+
+func (r *renderer) callout(n *markdown.Callout) fyne.CanvasObject {
+	style := calloutStyles[n.Category()]
+	label := widget.NewRichText(&widget.TextSegment{
+		Style: inlineStyle{bold: true, color: style.color}.richTextStyle(),
+		Text:  n.DisplayTitle(),
+	})
+	label.Wrapping = fyne.TextWrapWord
+	icon := widget.NewIcon(theme.NewColoredResource(style.icon, style.color))
+	header := container.NewBorder(nil, nil, icon, nil, label)
+
+	accent := theme.Color(style.color)
+	tint := color.NRGBAModel.Convert(accent).(color.NRGBA)
+	tint.A = calloutTintAlpha
+	bg := canvas.NewRectangle(tint)
+	bar := canvas.NewRectangle(accent)
+	bar.SetMinSize(fyne.NewSize(quoteBarWidth, 0))
+
+	body := container.NewVBox(append([]fyne.CanvasObject{header}, r.renderChildren(n)...)...)
+	return container.NewStack(bg, container.NewBorder(nil, nil, bar, nil, body))
+}
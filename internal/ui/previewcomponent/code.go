@@ -0,0 +1,66 @@
+package previewcomponent
+
+import (
+	"log"
+	"markdown-editor/internal/highlight"
+	"markdown-editor/internal/markdown"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/yuin/goldmark/ast"
+)
+
+func (r *renderer) codeBlock(n ast.Node) fyne.CanvasObject {
+	code := markdown.BlockText(n, r.source)
+	lang, info := "", ""
+	if fenced, ok := n.(*ast.FencedCodeBlock); ok && fenced.Info != nil {
+		info = string(fenced.Info.Segment.Value(r.source))
+		lang = highlight.Language(info)
+	}
+
+	rt := widget.NewRichText(codeSegments(lang, code)...)
+	rt.Scroll = container.ScrollHorizontalOnly
+
+	copyButton := widget.NewButtonWithIcon("", theme.ContentCopyIcon(), func() {
+		fyne.CurrentApp().Clipboard().SetContent(code)
+	})
+	copyButton.Importance = widget.LowImportance
+
+	label := widget.NewLabel(lang)
+	label.TextStyle = fyne.TextStyle{Italic: true}
+	header := container.NewHBox(layout.NewSpacer(), label, copyButton)
+
+	bg := canvas.NewRectangle(theme.Color(theme.ColorNameInputBackground))
+	view := container.NewStack(bg, container.NewBorder(header, nil, nil, nil, rt))
+	if fields := strings.Fields(info); len(fields) > 0 && r.pc.diagrams != nil && r.pc.diagrams.Supports(fields[0]) {
+		return r.diagram(strings.ToLower(fields[0]), code, view)
+	}
+	return view
+}
+
+func codeSegments(lang, code string) []widget.RichTextSegment {
+	tokens, err := highlight.Tokenize(lang, code)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		tokens = []highlight.Token{{Kind: highlight.KindPlain, Text: code}}
+	}
+
+	segments := make([]widget.RichTextSegment, 0, len(tokens))
+	for _, token := range tokens {
+		style := widget.RichTextStyleCodeInline
+		style.ColorName = token.Kind.ColorName()
+		style.TextStyle = token.Kind.TextStyle()
+		style.TextStyle.Monospace = true
+		segments = append(segments, &widget.TextSegment{Style: style, Text: token.Text})
+	}
+	if len(segments) > 0 {
+		last := segments[len(segments)-1].(*widget.TextSegment)
+		last.Style.Inline = false
+	}
+	return segments
+}
@@ -0,0 +1,63 @@
+package previewcomponent
+
+import (
+	"context"
+	"image"
+	"log"
+	"markdown-editor/internal/diagram"
+	"markdown-editor/internal/imaging"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+func (r *renderer) diagram(lang, code string, source fyne.CanvasObject) fyne.CanvasObject {
+	holder := container.NewStack(container.NewVBox(source, diagramCaption("Rendering diagram…", theme.ColorNamePlaceHolder)))
+	renderer := r.pc.diagrams
+
+	go func() {
+		img, err := renderDiagram(renderer, lang, code)
+		fyne.Do(func() {
+			if err != nil {
+				log.Printf("Error: Failed to render %s diagram: %v", lang, err)
+				holder.Objects = []fyne.CanvasObject{container.NewVBox(source, diagramCaption(err.Error(), theme.ColorNameError))}
+			} else {
+				holder.Objects = []fyne.CanvasObject{diagramImage(lang, img)}
+			}
+			holder.Refresh()
+		})
+	}()
+	return holder
+}
+
+func renderDiagram(renderer diagram.Renderer, lang, code string) (image.Image, error) {
+	data, err := renderer.Render(context.Background(), lang, []byte(code))
+	if err != nil {
+		return nil, err
+	}
+	return imaging.Decode("diagram"+diagram.Extension(data), data)
+}
+
+func diagramImage(lang string, img image.Image) fyne.CanvasObject {
+	scaled := imaging.Scale(img, maxImageWidth)
+	picture := canvas.NewImageFromImage(scaled)
+	picture.FillMode = canvas.ImageFillContain
+	picture.ScaleMode = canvas.ImageScaleSmooth
+	bounds := scaled.Bounds()
+	picture.SetMinSize(fyne.NewSize(float32(bounds.Dx()), float32(bounds.Dy())))
+
+	view := newImageView(picture, func() { showPicture(img, lang) })
+	return container.NewHBox(view)
+}
+
+func diagramCaption(text string, color fyne.ThemeColorName) fyne.CanvasObject {
+	caption := widget.NewRichText(&widget.TextSegment{
+		Style: inlineStyle{italic: true, size: theme.SizeNameCaptionText, color: color}.richTextStyle(),
+		Text:  text,
+	})
+	caption.Wrapping = fyne.TextWrapWord
+	return caption
+}
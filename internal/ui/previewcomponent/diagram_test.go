@@ -0,0 +1,101 @@
+package previewcomponent
+
+import (
+	"errors"
+	"markdown-editor/internal/diagram"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+func findObjects[T fyne.CanvasObject](obj fyne.CanvasObject) []T {
+	var found []T
+	if match, ok := obj.(T); ok {
+		found = append(found, match)
+	}
+	switch o := obj.(type) {
+	case *fyne.Container:
+		for _, child := range o.Objects {
+			found = append(found, findObjects[T](child)...)
+		}
+	case *imageView:
+		found = append(found, findObjects[T](o.content)...)
+	}
+	return found
+}
+
+func waitFor(t *testing.T, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the diagram to render")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDiagramFences(t *testing.T) {
+	test.NewTempApp(t)
+
+	tests := []struct {
+		name      string
+		fence     string
+		err       error
+		wantImage bool
+		wantCalls int
+	}{
+		{name: "mermaid", fence: "mermaid", wantImage: true, wantCalls: 1},
+		{name: "dot with attributes", fence: "dot {.wide}", wantImage: true, wantCalls: 1},
+		{name: "unsupported language stays code", fence: "go"},
+		{name: "render error keeps the code", fence: "mermaid", err: errors.New("bad diagram"), wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := diagram.NewStubRenderer("mermaid", "dot")
+			stub.Err = tt.err
+			pc := NewPreviewComponent()
+			pc.SetDiagramRenderer(stub)
+			pc.Update("```" + tt.fence + "\ngraph TD; A-->B\n```\n")
+			if len(pc.blocks) != 1 {
+				t.Fatalf("rendered %d blocks, want 1", len(pc.blocks))
+			}
+			block := pc.blocks[0].object
+
+			waitFor(t, func() bool { return stub.Calls() == tt.wantCalls })
+			waitFor(t, func() bool {
+				if tt.wantCalls == 0 {
+					return true
+				}
+				for _, rt := range findObjects[*widget.RichText](block) {
+					if segment, ok := rt.Segments[0].(*widget.TextSegment); ok && segment.Text == "Rendering diagram…" {
+						return false
+					}
+				}
+				return true
+			})
+
+			images := findObjects[*canvas.Image](block)
+			code := findObjects[*widget.RichText](block)
+			if tt.wantImage {
+				if len(images) != 1 || len(code) != 0 {
+					t.Errorf("got %d images and %d text blocks, want the diagram image only", len(images), len(code))
+				}
+				return
+			}
+			if len(images) != 0 || len(code) == 0 {
+				t.Errorf("got %d images and %d text blocks, want the code block", len(images), len(code))
+			}
+			if tt.err != nil {
+				last := code[len(code)-1].Segments[0].(*widget.TextSegment)
+				if last.Text != tt.err.Error() {
+					t.Errorf("caption = %q, want %q", last.Text, tt.err.Error())
+				}
+			}
+		})
+	}
+}
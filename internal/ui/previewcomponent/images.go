@@ -0,0 +1,114 @@
+package previewcomponent
+
+import (
+	"image"
+	"log"
+	"markdown-editor/internal/markdown"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/yuin/goldmark/ast"
+)
+
+const (
+	maxImageWidth    = 640
+	maxWindowSize    = 1024
+	placeholderWidth = 240
+)
+
+type imageView struct {
+	widget.BaseWidget
+	content  fyne.CanvasObject
+	OnTapped func()
+}
+
+func newImageView(content fyne.CanvasObject, onTapped func()) *imageView {
+	v := &imageView{content: content, OnTapped: onTapped}
+	v.ExtendBaseWidget(v)
+	return v
+}
+
+func (v *imageView) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(v.content)
+}
+
+func (v *imageView) Tapped(*fyne.PointEvent) {
+	if v.OnTapped != nil {
+		v.OnTapped()
+	}
+}
+
+func (r *renderer) image(n *ast.Image) fyne.CanvasObject {
+	alt := markdown.PlainText(n, r.source)
+	path, ok := markdown.ResolveLink(r.pc.baseDir, string(n.Destination))
+	if !ok {
+		return imagePlaceholder(alt, string(n.Destination))
+	}
+
+	img, err := r.pc.images.Scaled(path, maxImageWidth)
+	if err != nil {
+		log.Printf("Error: Failed to load preview image: %v", err)
+		return imagePlaceholder(alt, string(n.Destination))
+	}
+
+	picture := canvas.NewImageFromImage(img)
+	picture.FillMode = canvas.ImageFillContain
+	picture.ScaleMode = canvas.ImageScaleSmooth
+	bounds := img.Bounds()
+	picture.SetMinSize(fyne.NewSize(float32(bounds.Dx()), float32(bounds.Dy())))
+
+	title := alt
+	if title == "" {
+		title = filepath.Base(path)
+	}
+	view := newImageView(picture, func() { r.pc.showImage(path, title) })
+	return container.NewHBox(view)
+}
+
+func imagePlaceholder(alt, dest string) fyne.CanvasObject {
+	text := alt
+	if text == "" {
+		text = dest
+	}
+	label := widget.NewLabel(text)
+	label.TextStyle = fyne.TextStyle{Italic: true}
+	label.Wrapping = fyne.TextWrapWord
+
+	bg := canvas.NewRectangle(theme.Color(theme.ColorNameInputBackground))
+	bg.StrokeColor = theme.Color(theme.ColorNameSeparator)
+	bg.StrokeWidth = 1
+	bg.SetMinSize(fyne.NewSize(placeholderWidth, 0))
+
+	icon := widget.NewIcon(theme.BrokenImageIcon())
+	return container.NewHBox(container.NewStack(bg, container.NewBorder(nil, nil, icon, nil, label)))
+}
+
+func (pc *PreviewComponent) showImage(path, title string) {
+	img, err := pc.images.Load(path)
+	if err != nil {
+		log.Printf("Error: Failed to load image for viewing: %v", err)
+		return
+	}
+	showPicture(img, title)
+}
+
+func showPicture(img image.Image, title string) {
+	picture := canvas.NewImageFromImage(img)
+	picture.FillMode = canvas.ImageFillOriginal
+
+	w := fyne.CurrentApp().NewWindow(title)
+	w.SetContent(container.NewScroll(picture))
+	w.Resize(windowSize(img.Bounds()))
+	w.Show()
+}
+
+func windowSize(bounds image.Rectangle) fyne.Size {
+	return fyne.NewSize(
+		float32(min(bounds.Dx(), maxWindowSize)),
+		float32(min(bounds.Dy(), maxWindowSize)),
+	)
+}
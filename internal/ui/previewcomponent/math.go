@@ -0,0 +1,165 @@
+package previewcomponent
+
+import (
+	"log"
+	"markdown-editor/internal/markdown"
+	"markdown-editor/internal/mathtex"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	mathOversample   = 2
+	displayMathScale = 1.2
+)
+
+type inlineFormula struct {
+	widget.BaseWidget
+	image   *canvas.Image
+	size    fyne.Size
+	offset  float32
+	minSize fyne.Size
+}
+
+func newInlineFormula(image *canvas.Image, size fyne.Size, offset float32, minSize fyne.Size) *inlineFormula {
+	f := &inlineFormula{image: image, size: size, offset: offset, minSize: minSize}
+	f.ExtendBaseWidget(f)
+	return f
+}
+
+func (f *inlineFormula) CreateRenderer() fyne.WidgetRenderer {
+	return &inlineFormulaRenderer{formula: f}
+}
+
+type inlineFormulaRenderer struct {
+	formula *inlineFormula
+}
+
+func (r *inlineFormulaRenderer) Layout(fyne.Size) {
+	r.formula.image.Move(fyne.NewPos(0, r.formula.offset))
+	r.formula.image.Resize(r.formula.size)
+}
+
+func (r *inlineFormulaRenderer) MinSize() fyne.Size {
+	return r.formula.minSize
+}
+
+func (r *inlineFormulaRenderer) Refresh() {
+	canvas.Refresh(r.formula.image)
+}
+
+func (r *inlineFormulaRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.formula.image}
+}
+
+func (r *inlineFormulaRenderer) Destroy() {}
+
+type mathSegment struct {
+	formula string
+	visual  func() fyne.CanvasObject
+}
+
+func (s *mathSegment) Inline() bool {
+	return true
+}
+
+func (s *mathSegment) Textual() string {
+	return "$" + s.formula + "$"
+}
+
+func (s *mathSegment) Update(fyne.CanvasObject) {}
+
+func (s *mathSegment) Visual() fyne.CanvasObject {
+	return s.visual()
+}
+
+func (s *mathSegment) Select(fyne.Position, fyne.Position) {}
+
+func (s *mathSegment) SelectedText() string {
+	return ""
+}
+
+func (s *mathSegment) Unselect() {}
+
+func (r *renderer) renderFormula(formula string, display bool, size fyne.ThemeSizeName) (*canvas.Image, *mathtex.Formula, error) {
+	if size == "" {
+		size = theme.SizeNameText
+	}
+	scale := float32(mathOversample)
+	if display {
+		scale *= displayMathScale
+	}
+	rendered, err := r.pc.math.Render(formula, mathtex.Options{
+		Size:    float64(theme.Size(size) * scale),
+		Color:   theme.Color(theme.ColorNameForeground),
+		Display: display,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	image := canvas.NewImageFromImage(rendered.Image)
+	image.FillMode = canvas.ImageFillStretch
+	image.ScaleMode = canvas.ImageScaleSmooth
+	bounds := rendered.Image.Bounds()
+	image.SetMinSize(fyne.NewSize(float32(bounds.Dx())/mathOversample, float32(bounds.Dy())/mathOversample))
+	return image, rendered, nil
+}
+
+func (r *renderer) mathBlock(formula string) fyne.CanvasObject {
+	image, _, err := r.renderFormula(formula, true, "")
+	if err != nil {
+		log.Printf("Error: Failed to render formula: %v", err)
+		return mathError(formula, err)
+	}
+	return container.NewHScroll(container.NewHBox(layout.NewSpacer(), image, layout.NewSpacer()))
+}
+
+func mathError(formula string, err error) fyne.CanvasObject {
+	source := widget.NewRichText(&widget.TextSegment{
+		Style: inlineStyle{mono: true, color: theme.ColorNameError}.richTextStyle(),
+		Text:  formula,
+	})
+	source.Wrapping = fyne.TextWrapWord
+	message := widget.NewRichText(&widget.TextSegment{
+		Style: inlineStyle{italic: true, size: theme.SizeNameCaptionText, color: theme.ColorNameError}.richTextStyle(),
+		Text:  err.Error(),
+	})
+	message.Wrapping = fyne.TextWrapWord
+
+	icon := widget.NewIcon(theme.NewErrorThemedResource(theme.ErrorIcon()))
+	bg := canvas.NewRectangle(theme.Color(theme.ColorNameInputBackground))
+	return container.NewStack(bg, container.NewBorder(nil, nil, container.NewVBox(icon), nil, container.NewVBox(source, message)))
+}
+
+func (r *renderer) appendMath(segments *[]widget.RichTextSegment, n *markdown.MathInline, st inlineStyle) {
+	formula := markdown.Formula(n, r.source)
+	image, rendered, err := r.renderFormula(formula, false, st.size)
+	if err != nil {
+		log.Printf("Error: Failed to render formula: %v", err)
+		failed := st
+		failed.mono = true
+		failed.color = theme.ColorNameError
+		appendText(segments, "$"+formula+"$", failed)
+		return
+	}
+
+	textSize := theme.Size(theme.SizeNameText)
+	if st.size != "" {
+		textSize = theme.Size(st.size)
+	}
+	line, baseline := fyne.CurrentApp().Driver().RenderedTextSize("M", textSize, fyne.TextStyle{}, nil)
+	size := image.MinSize()
+	offset := baseline - float32(rendered.Baseline)/mathOversample
+	*segments = append(*segments, &mathSegment{
+		formula: formula,
+		visual: func() fyne.CanvasObject {
+			return newInlineFormula(image, size, offset, fyne.NewSize(size.Width, line.Height))
+		},
+	})
+}
@@ -1,30 +1,190 @@
 package previewcomponent
 
 import (
+	"log"
+	"markdown-editor/internal/diagram"
+	"markdown-editor/internal/fileservice"
+	"markdown-editor/internal/frontmatter"
+	"markdown-editor/internal/imaging"
+	"markdown-editor/internal/markdown"
+	"markdown-editor/internal/mathtex"
+	"markdown-editor/internal/tasks"
+	"net/url"
+	"regexp"
+	"strings"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/widget"
 )
 
+var taskMarkRegex = regexp.MustCompile(`(?m)^(\s*(?:[-*+]|\d+[.)])\s+\[)[xX](\])`)
+
+type taskCheck struct {
+	rel   int
+	check *widget.Check
+}
+
+type block struct {
+	key     string
+	line    int
+	object  fyne.CanvasObject
+	checks  []taskCheck
+	anchors map[string]fyne.CanvasObject
+}
+
 type PreviewComponent struct {
-	preview   *widget.RichText
+	content   *fyne.Container
 	container *container.Scroll
+	blocks    []*block
+	text      string
+	baseDir   string
+	images    *imaging.Loader
+	math      *mathtex.Renderer
+	diagrams  diagram.Renderer
+
+	OnTaskToggled func(line int, done bool)
 }
 
 func NewPreviewComponent() *PreviewComponent {
-	preview := widget.NewRichTextFromMarkdown("")
-	preview.Wrapping = fyne.TextWrapWord
-	return &PreviewComponent{
-		preview:   preview,
-		container: container.NewScroll(preview),
+	content := container.NewVBox()
+	pc := &PreviewComponent{
+		content:   content,
+		container: container.NewScroll(content),
+		images:    imaging.NewLoader(fileservice.New()),
+		math:      mathtex.NewRenderer(),
+	}
+	if app := fyne.CurrentApp(); app != nil {
+		app.Settings().AddListener(func(fyne.Settings) {
+			fyne.Do(pc.rerender)
+		})
+	}
+	return pc
+}
+
+func (pc *PreviewComponent) SetOnTaskToggled(fn func(line int, done bool)) {
+	pc.OnTaskToggled = fn
+}
+
+func (pc *PreviewComponent) SetDiagramRenderer(renderer diagram.Renderer) {
+	pc.diagrams = renderer
+	pc.blocks = nil
+}
+
+func (pc *PreviewComponent) SetBaseDir(dir string) {
+	if dir == pc.baseDir {
+		return
 	}
+	pc.baseDir = dir
+	pc.blocks = nil
 }
 
 func (pc *PreviewComponent) Update(text string) {
-	pc.preview.ParseMarkdown(text)
-	pc.preview.Refresh()
+	pc.text = text
+	doc := frontmatter.Split(text)
+	source := []byte(doc.Body)
+	root := markdown.Parse(source)
+	r := newRenderer(pc, source, doc.BodyLine)
+	bodyLines := strings.Split(doc.Body, "\n")
+
+	reusable := make(map[string][]*block, len(pc.blocks))
+	for _, b := range pc.blocks {
+		reusable[b.key] = append(reusable[b.key], b)
+	}
+
+	blocks := make([]*block, 0, root.ChildCount())
+	for n := root.FirstChild(); n != nil; n = n.NextSibling() {
+		key, line := r.blockKey(n)
+		if candidates := reusable[key]; len(candidates) > 0 {
+			b := candidates[0]
+			reusable[key] = candidates[1:]
+			b.line = line
+			refreshChecks(b, bodyLines, doc.BodyLine)
+			blocks = append(blocks, b)
+			continue
+		}
+
+		b := &block{key: key, line: line}
+		r.block = b
+		b.object = r.renderBlock(n)
+		r.anchor(b.object)
+		blocks = append(blocks, b)
+	}
+
+	changed := len(blocks) != len(pc.blocks)
+	for i := 0; !changed && i < len(blocks); i++ {
+		changed = blocks[i] != pc.blocks[i]
+	}
+	pc.blocks = blocks
+	if !changed {
+		return
+	}
+
+	objects := make([]fyne.CanvasObject, 0, len(blocks))
+	for _, b := range blocks {
+		objects = append(objects, b.object)
+	}
+	pc.content.Objects = objects
+	pc.content.Refresh()
 }
 
 func (pc *PreviewComponent) View() fyne.CanvasObject {
 	return pc.container
 }
+
+func (pc *PreviewComponent) scrollToAnchor(name string) {
+	target := pc.findAnchor(name)
+	if target == nil {
+		log.Printf("Error: Preview anchor not found: #%s", name)
+		return
+	}
+	pc.container.Refresh()
+	driver := fyne.CurrentApp().Driver()
+	y := driver.AbsolutePositionForObject(target).Y - driver.AbsolutePositionForObject(pc.content).Y
+	pc.container.ScrollToOffset(fyne.NewPos(pc.container.Offset.X, y))
+}
+
+func (pc *PreviewComponent) findAnchor(name string) fyne.CanvasObject {
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	for _, b := range pc.blocks {
+		if obj, ok := b.anchors[name]; ok {
+			return obj
+		}
+	}
+	for _, b := range pc.blocks {
+		for anchor, obj := range b.anchors {
+			if strings.EqualFold(anchor, name) {
+				return obj
+			}
+		}
+	}
+	return nil
+}
+
+func (pc *PreviewComponent) rerender() {
+	pc.blocks = nil
+	pc.Update(pc.text)
+}
+
+func refreshChecks(b *block, bodyLines []string, lineOffset int) {
+	for _, tc := range b.checks {
+		i := b.line + tc.rel - lineOffset
+		if i < 0 || i >= len(bodyLines) {
+			continue
+		}
+		task, ok := tasks.ParseLine(bodyLines[i])
+		if !ok || tc.check.Checked == task.Done {
+			continue
+		}
+		onChanged := tc.check.OnChanged
+		tc.check.OnChanged = nil
+		tc.check.SetChecked(task.Done)
+		tc.check.OnChanged = onChanged
+	}
+}
+
+func normalizeTaskMarks(source string) string {
+	return taskMarkRegex.ReplaceAllString(source, "${1} ${2}")
+}
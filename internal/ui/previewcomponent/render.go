@@ -0,0 +1,519 @@
+package previewcomponent
+
+import (
+	"fmt"
+	"image/color"
+	"markdown-editor/internal/markdown"
+	"net/url"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+)
+
+const (
+	blockIndentWidth float32 = 24
+	quoteBarWidth    float32 = 4
+	strikeMark               = '\u0336'
+)
+
+type inlineStyle struct {
+	bold   bool
+	italic bool
+	mono   bool
+	strike bool
+	size   fyne.ThemeSizeName
+	color  fyne.ThemeColorName
+	align  fyne.TextAlign
+}
+
+func (st inlineStyle) richTextStyle() widget.RichTextStyle {
+	return widget.RichTextStyle{
+		Alignment: st.align,
+		ColorName: st.color,
+		Inline:    true,
+		SizeName:  st.size,
+		TextStyle: fyne.TextStyle{Bold: st.bold, Italic: st.italic, Monospace: st.mono},
+	}
+}
+
+type renderer struct {
+	pc         *PreviewComponent
+	source     []byte
+	lines      *markdown.Lines
+	lineOffset int
+	block      *block
+	pending    []string
+}
+
+func newRenderer(pc *PreviewComponent, source []byte, lineOffset int) *renderer {
+	return &renderer{
+		pc:         pc,
+		source:     source,
+		lines:      markdown.NewLines(source),
+		lineOffset: lineOffset,
+	}
+}
+
+func (r *renderer) lineOf(offset int) int {
+	return r.lines.LineOf(offset) + r.lineOffset
+}
+
+func (r *renderer) blockKey(n ast.Node) (string, int) {
+	start, end, ok := markdown.Span(n, r.source)
+	if !ok {
+		return fmt.Sprintf("%s@%p", n.Kind(), n), r.lineOffset
+	}
+
+	var key strings.Builder
+	key.WriteString(n.Kind().String())
+	switch node := n.(type) {
+	case *ast.Heading:
+		fmt.Fprintf(&key, ":%d#%s", node.Level, markdown.HeadingID(node))
+	case *markdown.Callout:
+		key.WriteString(":" + node.Variant + ":" + node.Title)
+	case *ast.FencedCodeBlock:
+		if node.Info != nil {
+			key.WriteString(":" + string(node.Info.Segment.Value(r.source)))
+		}
+	}
+	_ = ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if link, ok := child.(*extast.FootnoteLink); ok && entering {
+			fmt.Fprintf(&key, ":fn%d.%d", link.Index, link.RefIndex)
+		}
+		if fn, ok := child.(*extast.Footnote); ok && entering {
+			fmt.Fprintf(&key, ":fd%d", fn.Index)
+		}
+		return ast.WalkContinue, nil
+	})
+	key.WriteString("\n")
+	key.WriteString(normalizeTaskMarks(string(r.source[start:end])))
+	return key.String(), r.lineOf(start)
+}
+
+func (r *renderer) renderBlock(n ast.Node) fyne.CanvasObject {
+	switch node := n.(type) {
+	case *ast.Heading:
+		return r.heading(node)
+	case *ast.Paragraph, *ast.TextBlock:
+		return r.paragraph(n, inlineStyle{})
+	case *ast.ThematicBreak:
+		return widget.NewSeparator()
+	case *ast.CodeBlock, *ast.FencedCodeBlock:
+		return r.codeBlock(n)
+	case *ast.Blockquote:
+		return r.blockquote(node)
+	case *markdown.Callout:
+		return r.callout(node)
+	case *ast.List:
+		return r.list(node)
+	case *ast.HTMLBlock:
+		return r.htmlBlock(node)
+	case *extast.Table:
+		return r.table(node)
+	case *extast.DefinitionList:
+		return r.definitionList(node)
+	case *extast.FootnoteList:
+		return r.footnotes(node)
+	case *markdown.MathBlock:
+		return r.mathBlock(markdown.Formula(node, r.source))
+	default:
+		return container.NewVBox(r.renderChildren(n)...)
+	}
+}
+
+func (r *renderer) renderChildren(n ast.Node) []fyne.CanvasObject {
+	objects := make([]fyne.CanvasObject, 0, n.ChildCount())
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		objects = append(objects, r.renderBlock(child))
+	}
+	return objects
+}
+
+func (r *renderer) heading(h *ast.Heading) fyne.CanvasObject {
+	st := inlineStyle{bold: true, size: theme.SizeNameText}
+	switch h.Level {
+	case 1:
+		st.size = theme.SizeNameHeadingText
+	case 2:
+		st.size = theme.SizeNameSubHeadingText
+	}
+	if id := markdown.HeadingID(h); id != "" {
+		r.pending = append(r.pending, id)
+	}
+	return r.paragraph(h, st)
+}
+
+func (r *renderer) paragraph(n ast.Node, st inlineStyle) fyne.CanvasObject {
+	if !hasBlockInline(n) {
+		rt := wrappedText(r.inlineSegments(n, st))
+		r.anchor(rt)
+		return rt
+	}
+
+	box := container.NewVBox()
+	var segments []widget.RichTextSegment
+	flush := func() {
+		if !blankSegments(segments) {
+			rt := wrappedText(trimLeading(segments))
+			r.anchor(rt)
+			box.Add(rt)
+		}
+		segments = nil
+	}
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		switch node := child.(type) {
+		case *ast.Image:
+			flush()
+			box.Add(r.image(node))
+			continue
+		case *markdown.MathInline:
+			if node.Display {
+				flush()
+				box.Add(r.mathBlock(markdown.Formula(node, r.source)))
+				continue
+			}
+		}
+		r.appendInline(&segments, child, st)
+	}
+	flush()
+	r.anchor(box)
+	return box
+}
+
+func hasBlockInline(n ast.Node) bool {
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		switch node := child.(type) {
+		case *ast.Image:
+			return true
+		case *markdown.MathInline:
+			if node.Display {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func wrappedText(segments []widget.RichTextSegment) *widget.RichText {
+	rt := widget.NewRichText(segments...)
+	rt.Wrapping = fyne.TextWrapWord
+	return rt
+}
+
+func (r *renderer) blockquote(n *ast.Blockquote) fyne.CanvasObject {
+	bar := canvas.NewRectangle(theme.Color(theme.ColorNamePrimary))
+	bar.SetMinSize(fyne.NewSize(quoteBarWidth, 0))
+	return container.NewBorder(nil, nil, bar, nil, container.NewVBox(r.renderChildren(n)...))
+}
+
+func (r *renderer) list(list *ast.List) fyne.CanvasObject {
+	items := container.NewVBox()
+	number := list.Start
+	for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+		marker := r.taskCheck(item)
+		if marker == nil {
+			text := "•"
+			if list.IsOrdered() {
+				text = fmt.Sprintf("%d%c", number, list.Marker)
+			}
+			marker = widget.NewRichText(&widget.TextSegment{Style: widget.RichTextStyleInline, Text: text})
+		}
+		number++
+
+		body := container.NewVBox(r.renderChildren(item)...)
+		items.Add(container.NewBorder(nil, nil, container.NewVBox(marker), nil, body))
+	}
+	return items
+}
+
+func (r *renderer) taskCheck(item ast.Node) fyne.CanvasObject {
+	first := item.FirstChild()
+	if first == nil {
+		return nil
+	}
+	box, ok := first.FirstChild().(*extast.TaskCheckBox)
+	if !ok || first.Lines().Len() == 0 {
+		return nil
+	}
+
+	b := r.block
+	rel := r.lineOf(first.Lines().At(0).Start) - b.line
+	check := widget.NewCheck("", nil)
+	check.SetChecked(box.IsChecked)
+	check.OnChanged = func(checked bool) {
+		if r.pc.OnTaskToggled != nil {
+			r.pc.OnTaskToggled(b.line+rel, checked)
+		}
+	}
+	b.checks = append(b.checks, taskCheck{rel: rel, check: check})
+	return check
+}
+
+func (r *renderer) htmlBlock(n *ast.HTMLBlock) fyne.CanvasObject {
+	st := inlineStyle{mono: true, color: theme.ColorNameDisabled}
+	seg := &widget.TextSegment{Style: st.richTextStyle(), Text: markdown.BlockText(n, r.source)}
+	seg.Style.Inline = false
+	rt := widget.NewRichText(seg)
+	rt.Wrapping = fyne.TextWrapWord
+	return rt
+}
+
+func (r *renderer) table(t *extast.Table) fyne.CanvasObject {
+	columns := len(t.Alignments)
+	var cells []fyne.CanvasObject
+	for row := t.FirstChild(); row != nil; row = row.NextSibling() {
+		header := row.Kind() == extast.KindTableHeader
+		col := 0
+		for cell := row.FirstChild(); cell != nil && col < columns; cell = cell.NextSibling() {
+			st := inlineStyle{bold: header, align: tableAlignment(t.Alignments[col])}
+			cells = append(cells, r.tableCell(r.inlineSegments(cell, st), header))
+			col++
+		}
+		for ; col < columns; col++ {
+			cells = append(cells, r.tableCell(nil, header))
+		}
+	}
+
+	grid := container.New(&tableLayout{columns: columns}, cells...)
+	border := canvas.NewRectangle(theme.Color(theme.ColorNameSeparator))
+	view := container.NewHScroll(container.NewStack(border, grid))
+	r.anchor(view)
+	return view
+}
+
+func (r *renderer) tableCell(segments []widget.RichTextSegment, header bool) fyne.CanvasObject {
+	bgName := theme.ColorNameBackground
+	if header {
+		bgName = theme.ColorNameHeaderBackground
+	}
+	return container.NewStack(canvas.NewRectangle(theme.Color(bgName)), widget.NewRichText(segments...))
+}
+
+func tableAlignment(a extast.Alignment) fyne.TextAlign {
+	switch a {
+	case extast.AlignCenter:
+		return fyne.TextAlignCenter
+	case extast.AlignRight:
+		return fyne.TextAlignTrailing
+	default:
+		return fyne.TextAlignLeading
+	}
+}
+
+func (r *renderer) definitionList(n *extast.DefinitionList) fyne.CanvasObject {
+	box := container.NewVBox()
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		switch child.(type) {
+		case *extast.DefinitionTerm:
+			box.Add(r.paragraph(child, inlineStyle{bold: true}))
+		case *extast.DefinitionDescription:
+			box.Add(indented(container.NewVBox(r.renderChildren(child)...)))
+		}
+	}
+	return box
+}
+
+func (r *renderer) footnotes(n *extast.FootnoteList) fyne.CanvasObject {
+	box := container.NewVBox(widget.NewSeparator())
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		fn, ok := child.(*extast.Footnote)
+		if !ok {
+			continue
+		}
+		label := widget.NewRichText(&widget.TextSegment{
+			Style: inlineStyle{size: theme.SizeNameCaptionText}.richTextStyle(),
+			Text:  fmt.Sprintf("%d.", fn.Index),
+		})
+		row := container.NewBorder(nil, nil, container.NewVBox(label), nil, container.NewVBox(r.renderChildren(fn)...))
+		r.addAnchor(footnoteAnchor(fn.Index), row)
+		box.Add(row)
+	}
+	return box
+}
+
+func indented(obj fyne.CanvasObject) fyne.CanvasObject {
+	spacer := canvas.NewRectangle(color.Transparent)
+	spacer.SetMinSize(fyne.NewSize(blockIndentWidth, 0))
+	return container.NewBorder(nil, nil, spacer, nil, obj)
+}
+
+func (r *renderer) inlineSegments(n ast.Node, st inlineStyle) []widget.RichTextSegment {
+	var segments []widget.RichTextSegment
+	r.appendInlines(&segments, n, st)
+	return trimLeading(segments)
+}
+
+func trimLeading(segments []widget.RichTextSegment) []widget.RichTextSegment {
+	if len(segments) > 0 {
+		if first, ok := segments[0].(*widget.TextSegment); ok {
+			first.Text = strings.TrimLeft(first.Text, " ")
+		}
+	}
+	return segments
+}
+
+func blankSegments(segments []widget.RichTextSegment) bool {
+	for _, seg := range segments {
+		text, ok := seg.(*widget.TextSegment)
+		if !ok || strings.TrimSpace(text.Text) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *renderer) appendInlines(segments *[]widget.RichTextSegment, parent ast.Node, st inlineStyle) {
+	for child := parent.FirstChild(); child != nil; child = child.NextSibling() {
+		r.appendInline(segments, child, st)
+	}
+}
+
+func (r *renderer) appendInline(segments *[]widget.RichTextSegment, child ast.Node, st inlineStyle) {
+	switch node := child.(type) {
+	case *ast.Text:
+		text := string(node.Segment.Value(r.source))
+		if node.SoftLineBreak() {
+			text += " "
+		}
+		seg := appendText(segments, text, st)
+		if node.HardLineBreak() && seg != nil {
+			seg.Style.Inline = false
+		}
+	case *ast.String:
+		appendText(segments, string(node.Value), st)
+	case *ast.CodeSpan:
+		code := st
+		code.mono = true
+		appendText(segments, markdown.PlainText(node, r.source), code)
+	case *ast.Emphasis:
+		emphasis := st
+		if node.Level >= 2 {
+			emphasis.bold = true
+		} else {
+			emphasis.italic = true
+		}
+		r.appendInlines(segments, node, emphasis)
+	case *extast.Strikethrough:
+		strike := st
+		strike.strike = true
+		r.appendInlines(segments, node, strike)
+	case *ast.Link:
+		r.appendLink(segments, string(node.Destination), markdown.PlainText(node, r.source), st)
+	case *ast.AutoLink:
+		dest := string(node.URL(r.source))
+		if node.AutoLinkType == ast.AutoLinkEmail && !strings.HasPrefix(dest, "mailto:") {
+			dest = "mailto:" + dest
+		}
+		r.appendLink(segments, dest, string(node.Label(r.source)), st)
+	case *ast.Image:
+		alt := st
+		alt.italic = true
+		alt.color = theme.ColorNamePlaceHolder
+		appendText(segments, "["+markdown.PlainText(node, r.source)+"]", alt)
+	case *markdown.MathInline:
+		r.appendMath(segments, node, st)
+	case *extast.FootnoteLink:
+		r.pending = append(r.pending, footnoteRefAnchor(node.Index, node.RefIndex))
+		target := footnoteAnchor(node.Index)
+		*segments = append(*segments, &widget.HyperlinkSegment{
+			Alignment: st.align,
+			Text:      fmt.Sprintf("[%d]", node.Index),
+			OnTapped:  func() { r.pc.scrollToAnchor(target) },
+		})
+	case *extast.FootnoteBacklink:
+		text := " ↩"
+		if node.RefCount > 1 {
+			text = fmt.Sprintf(" ↩%d", node.RefIndex+1)
+		}
+		target := footnoteRefAnchor(node.Index, node.RefIndex)
+		*segments = append(*segments, &widget.HyperlinkSegment{
+			Alignment: st.align,
+			Text:      text,
+			OnTapped:  func() { r.pc.scrollToAnchor(target) },
+		})
+	case *extast.TaskCheckBox, *ast.RawHTML:
+	default:
+		r.appendInlines(segments, node, st)
+	}
+}
+
+func appendText(segments *[]widget.RichTextSegment, text string, st inlineStyle) *widget.TextSegment {
+	if text == "" {
+		return nil
+	}
+	if st.strike {
+		if st.color == "" {
+			st.color = theme.ColorNameDisabled
+		}
+		text = strikeThrough(text)
+	}
+	seg := &widget.TextSegment{Style: st.richTextStyle(), Text: text}
+	*segments = append(*segments, seg)
+	return seg
+}
+
+func (r *renderer) appendLink(segments *[]widget.RichTextSegment, dest, text string, st inlineStyle) {
+	if text == "" {
+		text = dest
+	}
+	if name, ok := strings.CutPrefix(dest, "#"); ok {
+		*segments = append(*segments, &widget.HyperlinkSegment{
+			Alignment: st.align,
+			Text:      text,
+			OnTapped:  func() { r.pc.scrollToAnchor(name) },
+		})
+		return
+	}
+	link, err := url.Parse(dest)
+	if err != nil || link.Scheme == "" {
+		linkStyle := st
+		linkStyle.color = theme.ColorNameHyperlink
+		appendText(segments, text, linkStyle)
+		return
+	}
+	*segments = append(*segments, &widget.HyperlinkSegment{Alignment: st.align, Text: text, URL: link})
+}
+
+func (r *renderer) anchor(obj fyne.CanvasObject) {
+	for _, name := range r.pending {
+		r.addAnchor(name, obj)
+	}
+	r.pending = nil
+}
+
+func (r *renderer) addAnchor(name string, obj fyne.CanvasObject) {
+	if r.block == nil {
+		return
+	}
+	if r.block.anchors == nil {
+		r.block.anchors = make(map[string]fyne.CanvasObject)
+	}
+	if _, ok := r.block.anchors[name]; !ok {
+		r.block.anchors[name] = obj
+	}
+}
+
+func footnoteAnchor(index int) string {
+	return fmt.Sprintf("fn:%d", index)
+}
+
+func footnoteRefAnchor(index, ref int) string {
+	return fmt.Sprintf("fnref:%d:%d", index, ref)
+}
+
+func strikeThrough(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		b.WriteRune(r)
+		if r != ' ' {
+			b.WriteRune(strikeMark)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package previewcomponent
+
+import (
+	"fyne.io/fyne/v2"
+)
+
+const tableCellGap float32 = 1
+
+type tableLayout struct {
+	columns int
+}
+
+func (l *tableLayout) sizes(objects []fyne.CanvasObject) ([]float32, []float32) {
+	if l.columns <= 0 {
+		return nil, nil
+	}
+
+	rows := (len(objects) + l.columns - 1) / l.columns
+	widths := make([]float32, l.columns)
+	heights := make([]float32, rows)
+	for i, obj := range objects {
+		if !obj.Visible() {
+			continue
+		}
+		min := obj.MinSize()
+		col, row := i%l.columns, i/l.columns
+		widths[col] = max(widths[col], min.Width)
+		heights[row] = max(heights[row], min.Height)
+	}
+	return widths, heights
+}
+
+func (l *tableLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	widths, heights := l.sizes(objects)
+	size := fyne.NewSize(tableCellGap*float32(len(widths)+1), tableCellGap*float32(len(heights)+1))
+	for _, w := range widths {
+		size.Width += w
+	}
+	for _, h := range heights {
+		size.Height += h
+	}
+	return size
+}
+
+func (l *tableLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	widths, heights := l.sizes(objects)
+	if len(widths) == 0 {
+		return
+	}
+
+	if extra := size.Width - l.MinSize(objects).Width; extra > 0 {
+		share := extra / float32(len(widths))
+		for i := range widths {
+			widths[i] += share
+		}
+	}
+
+	y := tableCellGap
+	for row, height := range heights {
+		x := tableCellGap
+		for col, width := range widths {
+			i := row*l.columns + col
+			if i >= len(objects) {
+				return
+			}
+			objects[i].Move(fyne.NewPos(x, y))
+			objects[i].Resize(fyne.NewSize(width, height))
+			x += width + tableCellGap
+		}
+		y += height + tableCellGap
+	}
+}
@@ -0,0 +1,235 @@
+package propertiescomponent
+
+import (
+	"markdown-editor/internal/frontmatter"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+type customField struct {
+	key      *widget.Entry
+	value    *widget.Entry
+	previous any
+	editable bool
+	row      fyne.CanvasObject
+}
+
+type PropertiesComponent struct {
+	format    *widget.Label
+	title     *widget.Entry
+	tags      *widget.Entry
+	date      *widget.Entry
+	fields    []*customField
+	fieldsBox *fyne.Container
+	previous  map[string]any
+	displayed map[string]string
+	order     []string
+
+	OnApply  func([]frontmatter.Property)
+	OnReload func()
+
+	widget fyne.CanvasObject
+}
+
+func NewPropertiesComponent(onApply func([]frontmatter.Property), onReload func()) *PropertiesComponent {
+	pc := &PropertiesComponent{
+		format:    widget.NewLabel(""),
+		title:     widget.NewEntry(),
+		tags:      widget.NewEntry(),
+		date:      widget.NewEntry(),
+		fieldsBox: container.NewVBox(),
+		previous:  make(map[string]any),
+		displayed: make(map[string]string),
+		OnApply:   onApply,
+		OnReload:  onReload,
+	}
+	pc.tags.SetPlaceHolder("tag-one, tag-two")
+	pc.date.SetPlaceHolder(frontmatter.DateLayout)
+
+	form := widget.NewForm(
+		widget.NewFormItem("Title", pc.title),
+		widget.NewFormItem("Tags", pc.tags),
+		widget.NewFormItem("Date", pc.date),
+	)
+
+	pc.widget = container.NewBorder(
+		container.NewHBox(
+			widget.NewButton("Apply", pc.apply),
+			widget.NewButton("Reload", func() {
+				if pc.OnReload != nil {
+					pc.OnReload()
+				}
+			}),
+			pc.format,
+		),
+		nil, nil, nil,
+		container.NewVScroll(container.NewVBox(
+			form,
+			widget.NewSeparator(),
+			widget.NewLabel("Custom fields"),
+			pc.fieldsBox,
+			widget.NewButtonWithIcon("Add Field", theme.ContentAddIcon(), func() {
+				pc.addField("", "", true)
+			}),
+		)),
+	)
+
+	pc.SetDocument(nil)
+	return pc
+}
+
+func (pc *PropertiesComponent) SetDocument(doc *frontmatter.Document) {
+	pc.previous = make(map[string]any)
+	pc.displayed = make(map[string]string)
+	pc.order = nil
+	pc.fields = nil
+	pc.fieldsBox.RemoveAll()
+	pc.title.SetText("")
+	pc.tags.SetText("")
+	pc.date.SetText("")
+
+	if doc == nil {
+		pc.format.SetText("No document")
+		return
+	}
+	if doc.Format == frontmatter.FormatNone {
+		pc.format.SetText("No frontmatter (YAML will be added)")
+	} else {
+		pc.format.SetText(doc.Format.String() + " frontmatter")
+	}
+
+	for _, p := range doc.Properties {
+		text := frontmatter.FormatValue(p.Value)
+		if p.Key == frontmatter.KeyTags {
+			text = strings.Join(frontmatter.Tags(p.Value), ", ")
+		}
+		pc.previous[p.Key] = p.Value
+		pc.displayed[p.Key] = text
+		pc.order = append(pc.order, p.Key)
+
+		switch p.Key {
+		case frontmatter.KeyTitle:
+			pc.title.SetText(text)
+		case frontmatter.KeyTags:
+			pc.tags.SetText(text)
+		case frontmatter.KeyDate:
+			pc.date.SetText(text)
+		default:
+			field := pc.addField(p.Key, text, frontmatter.IsEditable(p.Value))
+			field.previous = p.Value
+		}
+	}
+}
+
+func (pc *PropertiesComponent) View() fyne.CanvasObject {
+	return pc.widget
+}
+
+func (pc *PropertiesComponent) addField(key, value string, editable bool) *customField {
+	field := &customField{
+		key:      widget.NewEntry(),
+		value:    widget.NewEntry(),
+		editable: editable,
+	}
+	field.key.SetPlaceHolder("key")
+	field.key.SetText(key)
+	field.value.SetText(value)
+	if !editable {
+		field.key.Disable()
+		field.value.Disable()
+	}
+
+	removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+	removeBtn.Importance = widget.LowImportance
+	field.row = container.NewBorder(nil, nil, nil, removeBtn,
+		container.NewGridWithColumns(2, field.key, field.value))
+	removeBtn.OnTapped = func() {
+		pc.removeField(field)
+	}
+
+	pc.fields = append(pc.fields, field)
+	pc.fieldsBox.Add(field.row)
+	return field
+}
+
+func (pc *PropertiesComponent) removeField(field *customField) {
+	for i, f := range pc.fields {
+		if f == field {
+			pc.fields = append(pc.fields[:i], pc.fields[i+1:]...)
+			break
+		}
+	}
+	pc.fieldsBox.Remove(field.row)
+}
+
+func (pc *PropertiesComponent) apply() {
+	if pc.OnApply == nil {
+		return
+	}
+
+	var props []frontmatter.Property
+	add := func(key, text string, parse func(string) any) {
+		if displayed, known := pc.displayed[key]; known && text == displayed {
+			props = append(props, frontmatter.Property{Key: key, Value: pc.previous[key]})
+			return
+		}
+		if strings.TrimSpace(text) == "" {
+			return
+		}
+		props = append(props, frontmatter.Property{Key: key, Value: parse(text)})
+	}
+	parseWithPrevious := func(key string) func(string) any {
+		return func(text string) any {
+			return frontmatter.ParseValue(text, pc.previous[key])
+		}
+	}
+
+	add(frontmatter.KeyTitle, pc.title.Text, parseWithPrevious(frontmatter.KeyTitle))
+	add(frontmatter.KeyTags, pc.tags.Text, func(text string) any {
+		return frontmatter.SplitList(text)
+	})
+	add(frontmatter.KeyDate, pc.date.Text, func(text string) any {
+		if t, ok := frontmatter.ParseDate(strings.TrimSpace(text)); ok {
+			return t
+		}
+		return strings.TrimSpace(text)
+	})
+
+	for _, field := range pc.fields {
+		key := strings.TrimSpace(field.key.Text)
+		if key == "" {
+			continue
+		}
+		if !field.editable {
+			props = append(props, frontmatter.Property{Key: key, Value: field.previous})
+			continue
+		}
+		add(key, field.value.Text, func(text string) any {
+			return frontmatter.ParseValue(text, field.previous)
+		})
+	}
+
+	pc.OnApply(pc.ordered(props))
+}
+
+func (pc *PropertiesComponent) ordered(props []frontmatter.Property) []frontmatter.Property {
+	position := make(map[string]int, len(pc.order))
+	for i, key := range pc.order {
+		position[key] = i
+	}
+	rank := func(key string) int {
+		if i, ok := position[key]; ok {
+			return i
+		}
+		return len(pc.order)
+	}
+	sort.SliceStable(props, func(i, j int) bool {
+		return rank(props[i].Key) < rank(props[j].Key)
+	})
+	return props
+}
@@ -0,0 +1,75 @@
+package propertiescomponent
+
+import (
+	"markdown-editor/internal/frontmatter"
+	"reflect"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+)
+
+func TestApply(t *testing.T) {
+	test.NewTempApp(t)
+
+	content := "---\ntitle: Note\nsummary:\ndraft: ~\naliases: []\nrating: 3\n---\nbody\n"
+	tests := []struct {
+		name string
+		edit func(pc *PropertiesComponent)
+		want []frontmatter.Property
+	}{
+		{
+			name: "untouched empty values are kept",
+			edit: func(pc *PropertiesComponent) {},
+			want: []frontmatter.Property{
+				{Key: "title", Value: "Note"},
+				{Key: "summary", Value: nil},
+				{Key: "draft", Value: nil},
+				{Key: "aliases", Value: []any{}},
+				{Key: "rating", Value: 3},
+			},
+		},
+		{
+			name: "cleared values are dropped",
+			edit: func(pc *PropertiesComponent) {
+				pc.title.SetText("")
+				pc.fields[3].value.SetText(" ")
+			},
+			want: []frontmatter.Property{
+				{Key: "summary", Value: nil},
+				{Key: "draft", Value: nil},
+				{Key: "aliases", Value: []any{}},
+			},
+		},
+		{
+			name: "removed fields are dropped and edits parsed",
+			edit: func(pc *PropertiesComponent) {
+				pc.removeField(pc.fields[0])
+				pc.fields[0].value.SetText("yes")
+				pc.fields[2].value.SetText("4")
+			},
+			want: []frontmatter.Property{
+				{Key: "title", Value: "Note"},
+				{Key: "draft", Value: "yes"},
+				{Key: "aliases", Value: []any{}},
+				{Key: "rating", Value: 4},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []frontmatter.Property
+			pc := NewPropertiesComponent(func(props []frontmatter.Property) { got = props }, nil)
+			doc, err := frontmatter.Parse(content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pc.SetDocument(doc)
+			tt.edit(pc)
+			pc.apply()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("apply() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package tagcomponent
+
+import (
+	"fmt"
+	"log"
+	"markdown-editor/internal/index"
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+type TagComponent struct {
+	tagList  *widget.List
+	tags     []index.TagCount
+	selected map[string]bool
+	status   *widget.Label
+
+	OnFilterChanged func([]string)
+	OnRenameTag     func(string)
+
+	widget fyne.CanvasObject
+}
+
+func NewTagComponent(onFilterChanged func([]string), onRename func(string)) *TagComponent {
+	tc := &TagComponent{
+		selected:        make(map[string]bool),
+		status:          widget.NewLabel(""),
+		OnFilterChanged: onFilterChanged,
+		OnRenameTag:     onRename,
+	}
+
+	tc.tagList = widget.NewList(
+		func() int { return len(tc.tags) },
+		func() fyne.CanvasObject {
+			renameBtn := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), nil)
+			renameBtn.Importance = widget.LowImportance
+			return container.NewBorder(
+				nil,
+				nil,
+				nil,
+				renameBtn,
+				widget.NewCheck("template", nil))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			container, ok := item.(*fyne.Container)
+			if !ok {
+				log.Printf("Error: Failed to cast item to container for tag ID %d", id)
+				return
+			}
+			check, ok := container.Objects[0].(*widget.Check)
+			if !ok {
+				log.Printf("Error: Failed to cast object to check for tag ID %d", id)
+				return
+			}
+			btn, ok := container.Objects[1].(*widget.Button)
+			if !ok {
+				log.Printf("Error: Failed to cast object to button for tag ID %d", id)
+				return
+			}
+
+			tag := tc.tags[id]
+			check.OnChanged = nil
+			check.SetText(fmt.Sprintf("#%s (%d)", tag.Name, tag.Count))
+			check.SetChecked(tc.selected[tag.Name])
+			check.OnChanged = func(checked bool) {
+				tc.setSelected(tag.Name, checked)
+			}
+			btn.OnTapped = func() {
+				if tc.OnRenameTag != nil {
+					tc.OnRenameTag(tag.Name)
+				}
+			}
+		},
+	)
+
+	tc.widget = container.NewBorder(
+		container.NewHBox(
+			widget.NewButton("Clear Filter", tc.ClearSelection),
+			tc.status,
+		),
+		nil, nil, nil,
+		tc.tagList,
+	)
+	tc.updateStatus()
+
+	return tc
+}
+
+func (tc *TagComponent) SetTags(tags []index.TagCount) {
+	tc.tags = tags
+
+	available := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		available[tag.Name] = true
+	}
+	pruned := false
+	for name := range tc.selected {
+		if !available[name] {
+			delete(tc.selected, name)
+			pruned = true
+		}
+	}
+
+	tc.tagList.Refresh()
+	tc.updateStatus()
+	if pruned {
+		tc.notifyFilterChanged()
+	}
+}
+
+func (tc *TagComponent) SelectedTags() []string {
+	tags := make([]string, 0, len(tc.selected))
+	for name := range tc.selected {
+		tags = append(tags, name)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func (tc *TagComponent) ClearSelection() {
+	if len(tc.selected) == 0 {
+		return
+	}
+	tc.selected = make(map[string]bool)
+	tc.tagList.Refresh()
+	tc.updateStatus()
+	tc.notifyFilterChanged()
+}
+
+func (tc *TagComponent) View() fyne.CanvasObject {
+	return tc.widget
+}
+
+func (tc *TagComponent) setSelected(name string, selected bool) {
+	if selected {
+		tc.selected[name] = true
+	} else {
+		delete(tc.selected, name)
+	}
+	tc.updateStatus()
+	tc.notifyFilterChanged()
+}
+
+func (tc *TagComponent) updateStatus() {
+	if len(tc.selected) == 0 {
+		tc.status.SetText(fmt.Sprintf("%d tags", len(tc.tags)))
+		return
+	}
+	tc.status.SetText(fmt.Sprintf("Filtering by %d of %d tags", len(tc.selected), len(tc.tags)))
+}
+
+func (tc *TagComponent) notifyFilterChanged() {
+	if tc.OnFilterChanged != nil {
+		tc.OnFilterChanged(tc.SelectedTags())
+	}
+}
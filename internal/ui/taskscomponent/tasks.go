@@ -0,0 +1,194 @@
+package taskscomponent
+
+import (
+	"fmt"
+	"log"
+	"markdown-editor/internal/index"
+	"markdown-editor/internal/tasks"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	statusOpen = "Open"
+	statusDone = "Done"
+	statusAll  = "All"
+
+	dueAny     = "Any due date"
+	dueSet     = "With due date"
+	dueOverdue = "Overdue"
+	dueToday   = "Due today"
+)
+
+type TasksComponent struct {
+	taskList *widget.List
+	all      []index.TaskItem
+	visible  []index.TaskItem
+	root     string
+
+	search *widget.Entry
+	status *widget.Select
+	due    *widget.Select
+	count  *widget.Label
+
+	OnToggle func(index.TaskItem, bool)
+	OnOpen   func(index.TaskItem)
+
+	widget fyne.CanvasObject
+}
+
+func NewTasksComponent(onToggle func(index.TaskItem, bool), onOpen func(index.TaskItem)) *TasksComponent {
+	tc := &TasksComponent{
+		search:   widget.NewEntry(),
+		count:    widget.NewLabel(""),
+		OnToggle: onToggle,
+		OnOpen:   onOpen,
+	}
+	tc.search.SetPlaceHolder("Filter tasks...")
+	tc.search.OnChanged = func(string) { tc.applyFilter() }
+	tc.status = widget.NewSelect([]string{statusOpen, statusDone, statusAll}, func(string) { tc.applyFilter() })
+	tc.due = widget.NewSelect([]string{dueAny, dueSet, dueOverdue, dueToday}, func(string) { tc.applyFilter() })
+
+	tc.taskList = widget.NewList(
+		func() int { return len(tc.visible) },
+		func() fyne.CanvasObject {
+			detail := widget.NewLabel("template")
+			detail.TextStyle = fyne.TextStyle{Italic: true}
+			detail.Truncation = fyne.TextTruncateEllipsis
+			return container.NewVBox(widget.NewCheck("template", nil), detail)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			container, ok := item.(*fyne.Container)
+			if !ok {
+				log.Printf("Error: Failed to cast item to container for task ID %d", id)
+				return
+			}
+			check, ok := container.Objects[0].(*widget.Check)
+			if !ok {
+				log.Printf("Error: Failed to cast object to check for task ID %d", id)
+				return
+			}
+			detail, ok := container.Objects[1].(*widget.Label)
+			if !ok {
+				log.Printf("Error: Failed to cast object to label for task ID %d", id)
+				return
+			}
+
+			task := tc.visible[id]
+			check.OnChanged = nil
+			check.SetText(task.Title())
+			check.SetChecked(task.Done)
+			check.OnChanged = func(checked bool) {
+				if tc.OnToggle != nil {
+					tc.OnToggle(task, checked)
+				}
+			}
+			detail.SetText(tc.describe(task))
+		},
+	)
+	tc.taskList.OnSelected = func(id widget.ListItemID) {
+		if id >= 0 && id < len(tc.visible) && tc.OnOpen != nil {
+			tc.OnOpen(tc.visible[id])
+		}
+		tc.taskList.UnselectAll()
+	}
+
+	tc.status.SetSelected(statusOpen)
+	tc.due.SetSelected(dueAny)
+
+	tc.widget = container.NewBorder(
+		container.NewVBox(
+			tc.search,
+			container.NewGridWithColumns(2, tc.status, tc.due),
+			tc.count,
+		),
+		nil, nil, nil,
+		tc.taskList,
+	)
+	return tc
+}
+
+func (tc *TasksComponent) SetRoot(root string) {
+	tc.root = root
+}
+
+func (tc *TasksComponent) SetTasks(items []index.TaskItem) {
+	tc.all = items
+	tc.applyFilter()
+}
+
+func (tc *TasksComponent) View() fyne.CanvasObject {
+	return tc.widget
+}
+
+func (tc *TasksComponent) applyFilter() {
+	if tc.taskList == nil {
+		return
+	}
+
+	query := strings.ToLower(strings.TrimSpace(tc.search.Text))
+	today := time.Now()
+	tc.visible = tc.visible[:0]
+	for _, item := range tc.all {
+		switch tc.status.Selected {
+		case statusOpen:
+			if item.Done {
+				continue
+			}
+		case statusDone:
+			if !item.Done {
+				continue
+			}
+		}
+
+		switch tc.due.Selected {
+		case dueSet:
+			if !item.HasDue() {
+				continue
+			}
+		case dueOverdue:
+			if !item.HasDue() || item.Done || !item.Due.Before(startOfDay(today)) {
+				continue
+			}
+		case dueToday:
+			if !item.HasDue() || !startOfDay(item.Due).Equal(startOfDay(today)) {
+				continue
+			}
+		}
+
+		if query != "" && !strings.Contains(strings.ToLower(item.Text+" "+item.Heading+" "+item.Path), query) {
+			continue
+		}
+		tc.visible = append(tc.visible, item)
+	}
+
+	tc.count.SetText(fmt.Sprintf("%d of %d tasks", len(tc.visible), len(tc.all)))
+	tc.taskList.Refresh()
+}
+
+func (tc *TasksComponent) describe(item index.TaskItem) string {
+	name := filepath.Base(item.Path)
+	if tc.root != "" {
+		if rel, err := filepath.Rel(tc.root, item.Path); err == nil {
+			name = filepath.ToSlash(rel)
+		}
+	}
+
+	parts := []string{fmt.Sprintf("%s:%d", name, item.Line+1)}
+	if item.Heading != "" {
+		parts = append(parts, item.Heading)
+	}
+	if item.HasDue() {
+		parts = append(parts, "due "+item.Due.Format(tasks.DueLayout))
+	}
+	return strings.Join(parts, " · ")
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}